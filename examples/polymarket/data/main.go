@@ -110,8 +110,8 @@ func main() {
 	values, err := client.GetPortfolioValue(ctx, userAddress)
 	if err != nil {
 		fmt.Printf("获取持仓价值失败: %v\n", err)
-	} else if len(values) > 0 {
-		fmt.Printf("持仓总价值: $%.2f\n", values[0].Value)
+	} else if value, ok := common.First(values); ok {
+		fmt.Printf("持仓总价值: $%.2f\n", value.Value)
 	}
 
 	// 7. 获取全局 Open Interest
@@ -119,8 +119,8 @@ func main() {
 	oi, err := client.GetOpenInterest(ctx)
 	if err != nil {
 		fmt.Printf("获取 OI 失败: %v\n", err)
-	} else if len(oi) > 0 {
-		fmt.Printf("全局 Open Interest: $%.2f\n", oi[0].Value)
+	} else if total, ok := common.First(oi); ok {
+		fmt.Printf("全局 Open Interest: $%.2f\n", total.Value)
 	}
 
 	// 8. 获取事件实时交易量
@@ -170,9 +170,9 @@ func main() {
 	// 11. 获取交易者排行榜
 	fmt.Println("\n11. 获取交易者排行榜 (按 PnL)")
 	leaderboard, err := client.GetLeaderboard(ctx, &common.LeaderboardParams{
-		Category:   "OVERALL",
-		TimePeriod: "DAY",
-		OrderBy:    "PNL",
+		Category:   common.CategoryOverall,
+		TimePeriod: common.TimePeriodDay,
+		OrderBy:    common.OrderByPnL,
 		Limit:      5,
 	})
 	if err != nil {
@@ -109,14 +109,10 @@ func main() {
 		fmt.Printf("  活跃: %v, 已结算: %v\n", market.Active, market.Closed)
 
 		// 解析价格
-		prices, _ := common.ParseOutcomePrices(market.OutcomePrices)
-		outcomes, _ := common.ParseOutcomes(market.Outcomes)
-		if len(prices) > 0 && len(outcomes) > 0 {
+		if tokens, err := market.OutcomeTokens(); err == nil {
 			fmt.Printf("  价格:\n")
-			for i, o := range outcomes {
-				if i < len(prices) {
-					fmt.Printf("    %s: %.4f\n", o, prices[i])
-				}
+			for _, t := range tokens {
+				fmt.Printf("    %s: %.4f\n", t.Outcome, t.Price)
 			}
 		}
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/gamma"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/updown"
 )
 
 var proxyString = "127.0.0.1:7897"
@@ -48,17 +49,17 @@ func testListEventsWithTagID(ctx context.Context, client *gamma.Client, period,
 		return
 	}
 
-	fmt.Printf("查询参数: tag_id=%d, closed=false, limit=100\n", tagID)
+	fmt.Printf("查询参数: tag_id=%d, closed=false, limit=100 (自动翻页)\n", tagID)
 	fmt.Printf("筛选条件: slug包含 %q\n\n", symbol)
 
 	closed := false
-	events, err := client.ListEvents(ctx, &common.EventQueryParams{
+	events, err := client.ListAllEvents(ctx, &common.EventQueryParams{
 		MarketQueryParams: common.MarketQueryParams{
 			TagID:  tagID,
 			Closed: &closed,
 			Limit:  100,
 		},
-	})
+	}, 0)
 	if err != nil {
 		fmt.Printf("错误: %v\n", err)
 		return
@@ -131,7 +132,7 @@ func testListEventsWithTagID(ctx context.Context, client *gamma.Client, period,
 				fmt.Sscanf(strings.TrimSpace(priceList[0]), "%f", &upPrice)
 				fmt.Sscanf(strings.TrimSpace(priceList[1]), "%f", &downPrice)
 				sum := upPrice + downPrice
-				fmt.Printf("    价格之和: %.4f (套利空间: %.2f%%)\n", sum, (1-sum)*100)
+				fmt.Printf("    价格之和: %.4f (套利空间: %.2f%%)\n", sum, common.ArbMarginPct(upPrice, downPrice))
 			}
 		}
 		fmt.Println()
@@ -213,12 +214,12 @@ func testDailySlugFormat(ctx context.Context, client *gamma.Client) {
 	// 尝试不同的 slug 格式
 	slugFormats := []string{
 		// bitcoin 格式
-		fmt.Sprintf("bitcoin-up-or-down-on-january-%d", now.Day()),
-		fmt.Sprintf("bitcoin-up-or-down-on-january-%d", now.Day()-1),
+		updown.Slug("btc", updown.PeriodDaily, now),
+		updown.Slug("btc", updown.PeriodDaily, now.AddDate(0, 0, -1)),
 		// btc 格式
-		fmt.Sprintf("btc-up-or-down-on-january-%d", now.Day()),
+		fmt.Sprintf("btc-up-or-down-on-%s-%d", strings.ToLower(now.Month().String()), now.Day()),
 		// ethereum 格式
-		fmt.Sprintf("ethereum-up-or-down-on-january-%d", now.Day()),
+		updown.Slug("eth", updown.PeriodDaily, now),
 	}
 
 	for _, slug := range slugFormats {
@@ -297,7 +298,7 @@ func testFindCurrent15mMarket(ctx context.Context, client *gamma.Client) {
 		fmt.Printf("=== %s 15m 市场 ===\n", strings.ToUpper(symbol))
 		for _, offset := range offsets {
 			ts := timestamp + int64(offset)
-			slug := fmt.Sprintf("%s-updown-15m-%d", symbol, ts)
+			slug := updown.Slug(symbol, updown.Period15m, time.Unix(ts, 0))
 			periodStart := time.Unix(ts, 0).UTC()
 
 			e, err := client.GetEventBySlug(ctx, slug)
@@ -306,13 +307,8 @@ func testFindCurrent15mMarket(ctx context.Context, client *gamma.Client) {
 			}
 
 			// 计算是否为当前活跃市场
-			endTime, _ := time.Parse(time.RFC3339, e.EndDate)
-			isActive := now.Before(endTime) && !e.Closed
-
 			status := "已过期"
-			if isActive {
-				status = "活跃中"
-				remaining := time.Until(endTime)
+			if isActive, remaining := common.IsActiveRound(e, now); isActive {
 				status = fmt.Sprintf("活跃中 (剩余 %v)", remaining.Round(time.Second))
 			}
 
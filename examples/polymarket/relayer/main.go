@@ -125,6 +125,7 @@ func main() {
 		fmt.Printf("创建 Relayer 失败: %v\n", err)
 		os.Exit(1)
 	}
+	defer client.Close()
 
 	// 1. 显示地址信息
 	fmt.Println("\n1. 地址信息")
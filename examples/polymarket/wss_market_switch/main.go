@@ -6,13 +6,13 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/gamma"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/updown"
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/wss"
 )
 
@@ -23,94 +23,9 @@ var (
 	symbol      = "btc"  // btc, eth, sol, xrp
 	period      = "15m"  // 15m, 1h, 4h
 	preSubSec   = 30     // 提前多少秒预订阅下一轮
+	startGrace  = updown.DefaultStartGracePeriod // 当前轮次开始超过该时长则直接订阅下一轮
 )
 
-var symbolFullName = map[string]string{
-	"btc": "bitcoin", "eth": "ethereum", "sol": "solana", "xrp": "xrp",
-}
-
-// ==================== OrderBook ====================
-
-type OrderBook struct {
-	mu      sync.RWMutex
-	AssetID string
-	Side    string // "UP" or "DOWN"
-	Bids    map[string]string
-	Asks    map[string]string
-}
-
-func NewOrderBook(assetID, side string) *OrderBook {
-	return &OrderBook{
-		AssetID: assetID,
-		Side:    side,
-		Bids:    make(map[string]string),
-		Asks:    make(map[string]string),
-	}
-}
-
-func (ob *OrderBook) ApplySnapshot(snapshot *common.OrderBookSnapshot) {
-	ob.mu.Lock()
-	defer ob.mu.Unlock()
-	ob.Bids = make(map[string]string)
-	ob.Asks = make(map[string]string)
-	for _, bid := range snapshot.Bids {
-		if bid.Size != "0" {
-			ob.Bids[bid.Price] = bid.Size
-		}
-	}
-	for _, ask := range snapshot.Asks {
-		if ask.Size != "0" {
-			ob.Asks[ask.Price] = ask.Size
-		}
-	}
-}
-
-func (ob *OrderBook) ApplyPriceChange(event *common.PriceChangeEvent) {
-	ob.mu.Lock()
-	defer ob.mu.Unlock()
-	if event.Side == "BUY" {
-		if event.Size == "0" {
-			delete(ob.Bids, event.Price)
-		} else {
-			ob.Bids[event.Price] = event.Size
-		}
-	} else {
-		if event.Size == "0" {
-			delete(ob.Asks, event.Price)
-		} else {
-			ob.Asks[event.Price] = event.Size
-		}
-	}
-}
-
-func (ob *OrderBook) GetBestBid() (price float64, size float64) {
-	ob.mu.RLock()
-	defer ob.mu.RUnlock()
-	for p, s := range ob.Bids {
-		if pf, _ := strconv.ParseFloat(p, 64); pf > price {
-			price = pf
-			size, _ = strconv.ParseFloat(s, 64)
-		}
-	}
-	return
-}
-
-func (ob *OrderBook) GetBestAsk() (price float64, size float64) {
-	ob.mu.RLock()
-	defer ob.mu.RUnlock()
-	var best float64 = 999
-	for p, s := range ob.Asks {
-		if pf, _ := strconv.ParseFloat(p, 64); pf < best {
-			best = pf
-			size, _ = strconv.ParseFloat(s, 64)
-		}
-	}
-	if best == 999 {
-		return 0, 0
-	}
-	return best, size
-}
-
 // ==================== Round ====================
 
 type Round struct {
@@ -131,9 +46,13 @@ type MarketSwitcher struct {
 
 	current   *Round
 	next      *Round
-	upBook    *OrderBook
-	downBook  *OrderBook
+	upBook    *wss.LocalBook
+	downBook  *wss.LocalBook
+	lastPrice map[string]float64 // assetID -> 最新成交价（单边订单簿时的展示兜底）
 	stopChan  chan struct{}
+
+	loopCancel context.CancelFunc // 停止当前连接的消息处理循环
+	loopDone   chan struct{}      // 当前消息处理循环退出后关闭
 }
 
 func NewMarketSwitcher() *MarketSwitcher {
@@ -144,6 +63,7 @@ func NewMarketSwitcher() *MarketSwitcher {
 		}),
 		wssClient: wss.NewClient(wss.ClientConfig{ProxyString: proxyString}),
 		stopChan:  make(chan struct{}),
+		lastPrice: make(map[string]float64),
 	}
 }
 
@@ -154,76 +74,28 @@ func (m *MarketSwitcher) Stop() {
 	}
 }
 
-// getSlug 根据时间戳生成 slug
-func getSlug(timestamp int64) string {
-	if period == "daily" {
-		t := time.Unix(timestamp, 0).UTC()
-		return fmt.Sprintf("%s-up-or-down-on-%s-%d", symbolFullName[symbol], strings.ToLower(t.Month().String()), t.Day())
-	}
-	return fmt.Sprintf("%s-updown-%s-%d", symbol, period, timestamp)
-}
-
 // getPeriodDuration 获取周期时长
 func getPeriodDuration() time.Duration {
-	switch period {
-	case "15m":
-		return 15 * time.Minute
-	case "1h":
-		return time.Hour
-	case "4h":
-		return 4 * time.Hour
-	case "daily":
-		return 24 * time.Hour
-	}
-	return 15 * time.Minute
-}
-
-// calcCurrentRoundStart 计算当前轮次开始时间
-func calcCurrentRoundStart() time.Time {
-	now := time.Now().UTC()
-	duration := getPeriodDuration()
-
-	if period == "daily" {
-		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	}
-
-	periodSec := int(duration.Seconds())
-	currentSec := now.Hour()*3600 + now.Minute()*60 + now.Second()
-	alignedSec := (currentSec / periodSec) * periodSec
-	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, alignedSec, 0, time.UTC)
-}
-
-// parseTokenIDs 解析 token IDs
-func parseTokenIDs(s string) []string {
-	s = strings.Trim(s, "[]")
-	parts := strings.Split(s, ",")
-	var ids []string
-	for _, p := range parts {
-		if id := strings.Trim(strings.TrimSpace(p), "\""); id != "" {
-			ids = append(ids, id)
-		}
-	}
-	return ids
+	return common.PeriodDuration(period)
 }
 
 // fetchRound 获取指定时间戳的轮次信息
 func (m *MarketSwitcher) fetchRound(ctx context.Context, startTime time.Time) (*Round, error) {
-	slug := getSlug(startTime.Unix())
+	slug := updown.Slug(symbol, updown.Period(period), startTime)
 	event, err := m.gammaClient.GetEventBySlug(ctx, slug)
 	if err != nil {
 		return nil, fmt.Errorf("获取市场失败 [%s]: %w", slug, err)
 	}
 
-	if len(event.Markets) == 0 {
-		return nil, fmt.Errorf("市场无数据: %s", slug)
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("市场数据不完整: %w", err)
 	}
-
-	ids := parseTokenIDs(event.Markets[0].ClobTokenIds)
-	if len(ids) < 2 {
-		return nil, fmt.Errorf("token IDs 不足: %s", slug)
+	if err := event.Markets[0].Validate(); err != nil {
+		return nil, fmt.Errorf("市场数据不完整: %w", err)
 	}
 
-	endTime, _ := time.Parse(time.RFC3339, event.EndDate)
+	ids, _ := common.ParseTokenIDs(event.Markets[0].ClobTokenIds)
+	endTime, _ := common.ParseDate(event.EndDate)
 
 	return &Round{
 		Slug:        slug,
@@ -236,10 +108,9 @@ func (m *MarketSwitcher) fetchRound(ctx context.Context, startTime time.Time) (*
 
 // subscribe 订阅当前轮次
 func (m *MarketSwitcher) subscribe(ctx context.Context) error {
-	m.upBook = NewOrderBook(m.current.UpTokenID, "UP")
-	m.downBook = NewOrderBook(m.current.DownTokenID, "DOWN")
-
 	m.conn = m.wssClient.CreateMarketConnection([]string{m.current.UpTokenID, m.current.DownTokenID})
+	m.upBook = m.conn.TrackBook(m.current.UpTokenID)
+	m.downBook = m.conn.TrackBook(m.current.DownTokenID)
 
 	m.conn.OnConnected(func() {
 		fmt.Println("[WSS] 已连接")
@@ -268,7 +139,9 @@ func (m *MarketSwitcher) preSubscribeNext(ctx context.Context) error {
 
 	m.next = round
 
-	// 订阅下一轮的 token
+	// 订阅下一轮的 token，并提前维护其本地订单簿
+	m.conn.TrackBook(round.UpTokenID)
+	m.conn.TrackBook(round.DownTokenID)
 	if err := m.conn.Subscribe([]string{round.UpTokenID, round.DownTokenID}); err != nil {
 		return fmt.Errorf("订阅下一轮失败: %w", err)
 	}
@@ -285,56 +158,60 @@ func (m *MarketSwitcher) switchToNext() {
 
 	// 取消旧订阅
 	m.conn.Unsubscribe([]string{m.current.UpTokenID, m.current.DownTokenID})
+	m.conn.UntrackBook(m.current.UpTokenID)
+	m.conn.UntrackBook(m.current.DownTokenID)
+	delete(m.lastPrice, m.current.UpTokenID)
+	delete(m.lastPrice, m.current.DownTokenID)
 
 	// 切换
 	m.current = m.next
 	m.next = nil
 
-	// 重置订单簿
-	m.upBook = NewOrderBook(m.current.UpTokenID, "UP")
-	m.downBook = NewOrderBook(m.current.DownTokenID, "DOWN")
+	// 切换到预订阅期间已维护的订单簿
+	m.upBook = m.conn.TrackBook(m.current.UpTokenID)
+	m.downBook = m.conn.TrackBook(m.current.DownTokenID)
 
 	fmt.Printf("\n[切换] %s\n", m.current.Slug)
 }
 
-// handleBook 处理订单簿快照
+// handleBook 处理订单簿快照（本地订单簿由连接自动维护）
 func (m *MarketSwitcher) handleBook(snapshot *common.OrderBookSnapshot) {
-	if snapshot.AssetID == m.current.UpTokenID {
-		m.upBook.ApplySnapshot(snapshot)
-	} else if snapshot.AssetID == m.current.DownTokenID {
-		m.downBook.ApplySnapshot(snapshot)
-	} else if m.next != nil {
-		// 可能是下一轮的数据，忽略或处理
-		return
+	if snapshot.AssetID != m.current.UpTokenID && snapshot.AssetID != m.current.DownTokenID {
+		return // 下一轮的数据
 	}
 	m.display()
 }
 
 // handlePriceChange 处理价格变化
 func (m *MarketSwitcher) handlePriceChange(event *common.PriceChangeEvent) {
-	if event.AssetID == m.current.UpTokenID {
-		m.upBook.ApplyPriceChange(event)
-	} else if event.AssetID == m.current.DownTokenID {
-		m.downBook.ApplyPriceChange(event)
-	} else {
+	if event.AssetID != m.current.UpTokenID && event.AssetID != m.current.DownTokenID {
+		return
+	}
+	m.display()
+}
+
+// handleLastTrade 记录最新成交价
+func (m *MarketSwitcher) handleLastTrade(trade *common.LastTradePrice) {
+	if trade.AssetID != m.current.UpTokenID && trade.AssetID != m.current.DownTokenID {
 		return
 	}
+	if price, err := strconv.ParseFloat(trade.Price, 64); err == nil {
+		m.lastPrice[trade.AssetID] = price
+	}
 	m.display()
 }
 
-// display 显示订单簿
+// display 显示订单簿（单边订单簿时以中间价/最新成交价兜底展示）
 func (m *MarketSwitcher) display() {
-	upBid, upBidAmt := m.upBook.GetBestBid()
-	upAsk, upAskAmt := m.upBook.GetBestAsk()
-	downBid, downBidAmt := m.downBook.GetBestBid()
-	downAsk, downAskAmt := m.downBook.GetBestAsk()
+	up := wss.ViewBook(m.upBook, m.lastPrice[m.current.UpTokenID])
+	down := wss.ViewBook(m.downBook, m.lastPrice[m.current.DownTokenID])
 
-	if upAsk == 0 || downAsk == 0 {
+	if !up.HasPrice() || !down.HasPrice() {
 		return
 	}
 
-	sum := upAsk + downAsk
-	spread := (1 - sum) * 100
+	sum := up.Price + down.Price
+	margin := common.ArbMarginPct(up.Ask, down.Ask)
 
 	remaining := time.Until(m.current.EndTime)
 	var status string
@@ -344,20 +221,20 @@ func (m *MarketSwitcher) display() {
 		status = "已结束"
 	}
 
-	fmt.Printf("[%s] UP bid=%.2f(%.0f) ask=%.2f(%.0f) | DOWN bid=%.2f(%.0f) ask=%.2f(%.0f) | Sum=%.4f Spread=%.2f%% | %s\n",
-		m.current.Slug, upBid, upBidAmt, upAsk, upAskAmt, downBid, downBidAmt, downAsk, downAskAmt, sum, spread, status)
+	fmt.Printf("[%s] UP bid=%.2f(%.0f) ask=%.2f(%.0f) %s | DOWN bid=%.2f(%.0f) ask=%.2f(%.0f) %s | Sum(%s/%s)=%.4f Margin=%.2f%% | %s\n",
+		m.current.Slug, up.Bid, up.BidSize, up.Ask, up.AskSize, up.State, down.Bid, down.BidSize, down.Ask, down.AskSize, down.State,
+		up.Source, down.Source, sum, margin, status)
 }
 
 // Run 运行主循环
 func (m *MarketSwitcher) Run(ctx context.Context) error {
 	// 1. 计算当前轮次
-	startTime := calcCurrentRoundStart()
-	elapsed := time.Since(startTime)
+	now := time.Now()
+	startTime := updown.SubscribeRoundStart(updown.Period(period), now, startGrace)
 
-	// 如果当前轮次已开始超过10秒，跳到下一轮
-	if elapsed > 10*time.Second {
-		fmt.Printf("当前轮次已开始 %v，跳到下一轮\n", elapsed.Round(time.Second))
-		startTime = startTime.Add(getPeriodDuration())
+	// 当前轮次已开始超过宽限期（或即将结束），跳到下一轮
+	if current := updown.RoundStart(updown.Period(period), now); !startTime.Equal(current) {
+		fmt.Printf("当前轮次已开始 %v，跳到下一轮\n", now.Sub(current).Round(time.Second))
 	}
 
 	// 2. 获取轮次信息
@@ -387,7 +264,8 @@ func (m *MarketSwitcher) Run(ctx context.Context) error {
 	}
 
 	// 5. 启动消息处理
-	go m.messageLoop(ctx)
+	m.startLoop(ctx)
+	defer m.stopLoop()
 
 	// 6. 主循环：检测轮次切换
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -418,14 +296,16 @@ func (m *MarketSwitcher) Run(ctx context.Context) error {
 						time.Sleep(time.Second)
 						continue
 					}
-					m.current = round
+					// 先停止旧连接的消息循环，再替换连接
+					m.stopLoop()
 					m.conn.Close()
+					m.current = round
 					if err := m.subscribe(ctx); err != nil {
 						fmt.Printf("重新订阅失败: %v\n", err)
 						time.Sleep(time.Second)
 						continue
 					}
-					go m.messageLoop(ctx)
+					m.startLoop(ctx)
 				}
 			}
 
@@ -437,14 +317,46 @@ func (m *MarketSwitcher) Run(ctx context.Context) error {
 	}
 }
 
-// messageLoop 消息处理循环
-func (m *MarketSwitcher) messageLoop(ctx context.Context) {
+// startLoop 为当前连接启动消息处理循环
+func (m *MarketSwitcher) startLoop(ctx context.Context) {
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.loopCancel, m.loopDone = cancel, done
+	go func(conn *wss.Connection) {
+		defer close(done)
+		m.messageLoop(loopCtx, conn)
+	}(m.conn)
+}
+
+// stopLoop 停止消息处理循环并等待其退出
+func (m *MarketSwitcher) stopLoop() {
+	if m.loopCancel == nil {
+		return
+	}
+	m.loopCancel()
+	<-m.loopDone
+	m.loopCancel, m.loopDone = nil, nil
+}
+
+// messageLoop 消息处理循环，连接关闭（事件通道关闭）时退出
+func (m *MarketSwitcher) messageLoop(ctx context.Context, conn *wss.Connection) {
 	for {
 		select {
-		case book := <-m.conn.BookCh():
+		case book, ok := <-conn.BookCh():
+			if !ok {
+				return
+			}
 			m.handleBook(book)
-		case event := <-m.conn.PriceChangeCh():
+		case event, ok := <-conn.PriceChangeCh():
+			if !ok {
+				return
+			}
 			m.handlePriceChange(event)
+		case trade, ok := <-conn.LastTradePriceCh():
+			if !ok {
+				return
+			}
+			m.handleLastTrade(trade)
 		case <-ctx.Done():
 			return
 		case <-m.stopChan:
@@ -2,6 +2,10 @@ package bridge
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
@@ -10,6 +14,16 @@ import (
 const (
 	// BaseURL Bridge API 基础地址
 	BaseURL = "https://bridge.polymarket.com"
+
+	// DepositPollInterval WaitForDeposit 默认轮询间隔
+	DepositPollInterval = 10 * time.Second
+)
+
+var (
+	// ErrAssetNotSupported 链/代币不在支持的充值资产列表中
+	ErrAssetNotSupported = errors.New("bridge: asset not supported")
+	// ErrBelowMinimumDeposit 充值金额低于该链/代币的最小充值金额
+	ErrBelowMinimumDeposit = errors.New("bridge: amount below minimum deposit")
 )
 
 // ClientConfig Bridge 客户端配置
@@ -17,11 +31,20 @@ type ClientConfig struct {
 	BaseURL     string
 	Timeout     time.Duration
 	ProxyString string
+
+	// 充值手续费估算模型（API 不提供报价，按 固定费用 + 比例费用 估算），均为 0 时视为无手续费
+	DepositFeeUsd float64 // 每笔固定手续费(USD)
+	DepositFeeBps float64 // 比例手续费(基点，1bps = 0.01%)
+
+	DepositPollInterval time.Duration // WaitForDeposit 轮询间隔（默认 DepositPollInterval）
 }
 
 // Client Bridge API 客户端
 type Client struct {
-	client *common.HTTPClient
+	client              *common.HTTPClient
+	depositFeeUsd       float64
+	depositFeeBps       float64
+	depositPollInterval time.Duration
 }
 
 // NewClient 创建 Bridge 客户端
@@ -32,6 +55,9 @@ func NewClient(cfg ClientConfig) *Client {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
+	if cfg.DepositPollInterval <= 0 {
+		cfg.DepositPollInterval = DepositPollInterval
+	}
 
 	return &Client{
 		client: common.NewHTTPClient(common.HTTPClientConfig{
@@ -39,9 +65,17 @@ func NewClient(cfg ClientConfig) *Client {
 			Timeout:     cfg.Timeout,
 			ProxyString: cfg.ProxyString,
 		}),
+		depositFeeUsd:       cfg.DepositFeeUsd,
+		depositFeeBps:       cfg.DepositFeeBps,
+		depositPollInterval: cfg.DepositPollInterval,
 	}
 }
 
+// Close 释放空闲 HTTP 连接，客户端不再使用时调用，可重复调用
+func (c *Client) Close() {
+	c.client.Close()
+}
+
 // GetSupportedAssets 获取支持的资产列表
 // 返回所有支持跨链充值的链和代币信息
 func (c *Client) GetSupportedAssets(ctx context.Context) ([]SupportedAsset, error) {
@@ -52,6 +86,53 @@ func (c *Client) GetSupportedAssets(ctx context.Context) ([]SupportedAsset, erro
 	return resp.SupportedAssets, nil
 }
 
+// EstimateDeposit 校验充值金额是否达到该链/代币的最小充值金额，并估算扣除手续费后的到账金额
+// chainID 为 SupportedAsset.ChainID，tokenSymbol 不区分大小写
+// 低于最小金额时同时返回估算结果（MeetsMinimum=false）和包含最小金额的 ErrBelowMinimumDeposit
+func (c *Client) EstimateDeposit(ctx context.Context, chainID, tokenSymbol string, amountUsd float64) (*DepositEstimate, error) {
+	if amountUsd <= 0 {
+		return nil, fmt.Errorf("invalid deposit amount: %v", amountUsd)
+	}
+	assets, err := c.GetSupportedAssets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get supported assets: %w", err)
+	}
+
+	asset, ok := findAsset(assets, chainID, tokenSymbol)
+	if !ok {
+		return nil, fmt.Errorf("%w: chain %s token %s", ErrAssetNotSupported, chainID, tokenSymbol)
+	}
+	return estimateDeposit(asset, amountUsd, c.depositFeeUsd, c.depositFeeBps)
+}
+
+// findAsset 按链 ID 和代币符号查找支持的资产
+func findAsset(assets []SupportedAsset, chainID, tokenSymbol string) (SupportedAsset, bool) {
+	for _, a := range assets {
+		if a.ChainID == chainID && strings.EqualFold(a.Token.Symbol, tokenSymbol) {
+			return a, true
+		}
+	}
+	return SupportedAsset{}, false
+}
+
+// estimateDeposit 计算手续费和到账金额，金额等于最小值时视为满足
+func estimateDeposit(asset SupportedAsset, amountUsd, feeUsd, feeBps float64) (*DepositEstimate, error) {
+	fee := feeUsd + amountUsd*feeBps/10000
+	est := &DepositEstimate{
+		Asset:        asset,
+		AmountUsd:    amountUsd,
+		MinUsd:       asset.MinCheckoutUsd,
+		FeeUsd:       fee,
+		NetUsd:       max(amountUsd-fee, 0),
+		MeetsMinimum: amountUsd >= asset.MinCheckoutUsd,
+	}
+	if !est.MeetsMinimum {
+		return est, fmt.Errorf("%w: %s on %s requires at least $%.2f, got $%.2f",
+			ErrBelowMinimumDeposit, asset.Token.Symbol, asset.ChainName, asset.MinCheckoutUsd, amountUsd)
+	}
+	return est, nil
+}
+
 // CreateDepositAddresses 创建充值地址
 // address: Polymarket 钱包地址 (通常是 Safe 地址)
 // 返回 EVM、Solana、Bitcoin 三种类型的充值地址
@@ -63,3 +144,122 @@ func (c *Client) CreateDepositAddresses(ctx context.Context, address string) (*D
 	}
 	return &resp, nil
 }
+
+// ListDeposits 获取钱包（通常是 Safe 地址）所有充值地址上的充值记录
+func (c *Client) ListDeposits(ctx context.Context, safeAddress string) ([]DepositRecord, error) {
+	addrs, err := c.depositAddresses(ctx, safeAddress)
+	if err != nil {
+		return nil, err
+	}
+	return c.listDeposits(ctx, addrs)
+}
+
+// ListAddressDeposits 获取单个充值地址（CreateDepositAddresses 返回的 EVM/SVM/BTC 地址）上的充值记录
+func (c *Client) ListAddressDeposits(ctx context.Context, depositAddress string) ([]DepositRecord, error) {
+	if depositAddress == "" {
+		return nil, fmt.Errorf("deposit address is required")
+	}
+	var resp DepositStatusResponse
+	if err := c.client.GetJSON(ctx, "/status/"+url.PathEscape(depositAddress), nil, &resp); err != nil {
+		return nil, fmt.Errorf("get deposit status: %w", err)
+	}
+	return resp.Transactions, nil
+}
+
+// GetDepositStatus 获取钱包（通常是 Safe 地址）所有充值地址上的充值，按处理中/已到账/失败分类
+func (c *Client) GetDepositStatus(ctx context.Context, safeAddress string) (*DepositStatus, error) {
+	addrs, err := c.depositAddresses(ctx, safeAddress)
+	if err != nil {
+		return nil, err
+	}
+	return c.depositStatus(ctx, addrs)
+}
+
+// WaitForDeposit 轮询直到出现 since 之后创建且已到账的充值并返回该记录；
+// 充值地址只在开始时获取一次，轮询中的查询失败视为暂时错误继续轮询；
+// 期间出现失败的充值时返回错误，ctx 结束时返回 ctx 错误（附带最近一次查询错误）
+func (c *Client) WaitForDeposit(ctx context.Context, safeAddress string, since time.Time) (*DepositRecord, error) {
+	addrs, err := c.depositAddresses(ctx, safeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(c.depositPollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		status, err := c.depositStatus(ctx, addrs)
+		switch {
+		case err == nil:
+			lastErr = nil
+			for i := range status.Completed {
+				if r := &status.Completed[i]; !r.CreatedAt().Before(since) {
+					return r, nil
+				}
+			}
+			for i := range status.Failed {
+				if r := &status.Failed[i]; !r.CreatedAt().Before(since) {
+					return r, fmt.Errorf("deposit %s failed", r.TxHash)
+				}
+			}
+		case ctx.Err() == nil:
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("wait for deposit: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, fmt.Errorf("wait for deposit: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// depositAddresses 获取钱包的充值地址
+func (c *Client) depositAddresses(ctx context.Context, safeAddress string) (DepositAddresses, error) {
+	if safeAddress == "" {
+		return DepositAddresses{}, fmt.Errorf("safe address is required")
+	}
+	deposit, err := c.CreateDepositAddresses(ctx, safeAddress)
+	if err != nil {
+		return DepositAddresses{}, fmt.Errorf("get deposit addresses: %w", err)
+	}
+	return deposit.Address, nil
+}
+
+// listDeposits 获取各充值地址上的充值记录，跳过空地址
+func (c *Client) listDeposits(ctx context.Context, addrs DepositAddresses) ([]DepositRecord, error) {
+	var records []DepositRecord
+	for _, addr := range []string{addrs.EVM, addrs.SVM, addrs.BTC} {
+		if addr == "" {
+			continue
+		}
+		list, err := c.ListAddressDeposits(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, list...)
+	}
+	return records, nil
+}
+
+// depositStatus 按处理中/已到账/失败分类充值地址上的充值
+func (c *Client) depositStatus(ctx context.Context, addrs DepositAddresses) (*DepositStatus, error) {
+	records, err := c.listDeposits(ctx, addrs)
+	if err != nil {
+		return nil, err
+	}
+	status := &DepositStatus{Addresses: addrs}
+	for _, r := range records {
+		switch {
+		case r.Status == DepositStatusCompleted:
+			status.Completed = append(status.Completed, r)
+		case r.Status == DepositStatusFailed:
+			status.Failed = append(status.Failed, r)
+		default:
+			status.Pending = append(status.Pending, r)
+		}
+	}
+	return status, nil
+}
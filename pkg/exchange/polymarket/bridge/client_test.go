@@ -0,0 +1,359 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient 创建指向 httptest 服务器的客户端
+func newTestClient(t *testing.T, handler http.Handler, cfg ClientConfig) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	c := NewClient(cfg)
+	t.Cleanup(c.Close)
+	return c
+}
+
+// writeJSON 以 JSON 写出响应
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// assetsHandler 返回固定支持资产列表的 /supported-assets 服务
+func assetsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /supported-assets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, SupportedAssetsResponse{SupportedAssets: []SupportedAsset{
+			{ChainID: "1", ChainName: "Ethereum", Token: Token{Symbol: "USDC", Decimals: 6}, MinCheckoutUsd: 10},
+			{ChainID: "8453", ChainName: "Base", Token: Token{Symbol: "USDC", Decimals: 6}, MinCheckoutUsd: 2},
+			{ChainID: "1151111081099710", ChainName: "Solana", Token: Token{Symbol: "SOL", Decimals: 9}, MinCheckoutUsd: 5},
+		}})
+	})
+	return mux
+}
+
+func TestEstimateDeposit(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       ClientConfig
+		chainID   string
+		symbol    string
+		amount    float64
+		wantErr   error
+		wantChain string
+		wantMin   float64
+		wantFee   float64
+		wantNet   float64
+		wantMeets bool
+	}{
+		{name: "no fee", chainID: "8453", symbol: "USDC", amount: 50, wantChain: "Base", wantMin: 2, wantNet: 50, wantMeets: true},
+		{name: "symbol case insensitive", chainID: "1", symbol: "usdc", amount: 10, wantChain: "Ethereum", wantMin: 10, wantNet: 10, wantMeets: true},
+		{
+			name: "fixed and bps fee", cfg: ClientConfig{DepositFeeUsd: 1, DepositFeeBps: 50}, chainID: "1", symbol: "USDC", amount: 100,
+			wantChain: "Ethereum", wantMin: 10, wantFee: 1.5, wantNet: 98.5, wantMeets: true,
+		},
+		{
+			name: "fee exceeds amount", cfg: ClientConfig{DepositFeeUsd: 5}, chainID: "8453", symbol: "USDC", amount: 3,
+			wantChain: "Base", wantMin: 2, wantFee: 5, wantNet: 0, wantMeets: true,
+		},
+		{
+			name: "below minimum", chainID: "1", symbol: "USDC", amount: 9.99, wantErr: ErrBelowMinimumDeposit,
+			wantChain: "Ethereum", wantMin: 10, wantNet: 9.99,
+		},
+		{name: "unknown chain", chainID: "137", symbol: "USDC", amount: 50, wantErr: ErrAssetNotSupported},
+		{name: "unknown token on chain", chainID: "8453", symbol: "SOL", amount: 50, wantErr: ErrAssetNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, assetsHandler(), tt.cfg)
+			est, err := c.EstimateDeposit(t.Context(), tt.chainID, tt.symbol, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantChain == "" {
+				if est != nil {
+					t.Errorf("estimate = %+v, want nil", est)
+				}
+				return
+			}
+			// 低于最小金额时同时返回估算结果
+			if est == nil {
+				t.Fatal("estimate = nil")
+			}
+			if est.Asset.ChainName != tt.wantChain || est.AmountUsd != tt.amount || est.MinUsd != tt.wantMin || est.MeetsMinimum != tt.wantMeets {
+				t.Errorf("estimate = %+v", est)
+			}
+			if math.Abs(est.FeeUsd-tt.wantFee) > 1e-9 || math.Abs(est.NetUsd-tt.wantNet) > 1e-9 {
+				t.Errorf("fee/net = %v/%v, want %v/%v", est.FeeUsd, est.NetUsd, tt.wantFee, tt.wantNet)
+			}
+		})
+	}
+}
+
+func TestEstimateDepositErrors(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /supported-assets", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+	})
+	c := newTestClient(t, mux, ClientConfig{})
+
+	for _, amount := range []float64{0, -5} {
+		if _, err := c.EstimateDeposit(t.Context(), "1", "USDC", amount); err == nil {
+			t.Errorf("amount %v: want error", amount)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("requests = %d, want no request for invalid amount", requests)
+	}
+
+	_, err := c.EstimateDeposit(t.Context(), "1", "USDC", 50)
+	if err == nil || errors.Is(err, ErrAssetNotSupported) {
+		t.Errorf("supported assets failure: err = %v", err)
+	}
+}
+
+// depositServer 充值测试服务：/deposit 返回固定的 EVM/SVM 地址（BTC 为空），/status 按地址返回 records
+type depositServer struct {
+	records  map[string][]DepositRecord
+	statuses []string // 请求过的充值地址
+	deposits int      // /deposit 请求次数
+}
+
+func (s *depositServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /deposit", func(w http.ResponseWriter, r *http.Request) {
+		var req DepositRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Address != "0xsafe" {
+			t.Errorf("deposit request = %+v, %v", req, err)
+		}
+		s.deposits++
+		writeJSON(w, DepositResponse{Address: DepositAddresses{EVM: "0xevm", SVM: "solAddr"}})
+	})
+	mux.HandleFunc("GET /status/{address}", func(w http.ResponseWriter, r *http.Request) {
+		addr := r.PathValue("address")
+		s.statuses = append(s.statuses, addr)
+		writeJSON(w, DepositStatusResponse{Transactions: s.records[addr]})
+	})
+	return mux
+}
+
+func TestListDepositsAndStatus(t *testing.T) {
+	srv := &depositServer{records: map[string][]DepositRecord{
+		"0xevm": {
+			{TxHash: "0x1", Status: DepositStatusCompleted},
+			{TxHash: "0x2", Status: DepositStatusProcessing},
+			{TxHash: "0x3", Status: DepositStatusFailed},
+		},
+		"solAddr": {
+			{TxHash: "s1", Status: DepositStatusDetected},
+			{TxHash: "s2", Status: DepositStatusCompleted},
+		},
+	}}
+	c := newTestClient(t, srv.handler(t), ClientConfig{})
+
+	records, err := c.ListAddressDeposits(t.Context(), "0xevm")
+	if err != nil || len(records) != 3 || records[1].TxHash != "0x2" || !records[1].IsPending() || records[0].IsPending() {
+		t.Errorf("ListAddressDeposits = %+v, %v", records, err)
+	}
+	if records, err := c.ListAddressDeposits(t.Context(), "0xnone"); err != nil || len(records) != 0 {
+		t.Errorf("ListAddressDeposits without records = %+v, %v", records, err)
+	}
+	if _, err := c.ListAddressDeposits(t.Context(), ""); err == nil {
+		t.Error("empty deposit address should fail")
+	}
+
+	// 按钱包地址汇总所有充值地址上的记录
+	records, err = c.ListDeposits(t.Context(), "0xsafe")
+	if err != nil || len(records) != 5 || records[0].TxHash != "0x1" || records[4].TxHash != "s2" {
+		t.Errorf("ListDeposits = %+v, %v", records, err)
+	}
+	if _, err := c.ListDeposits(t.Context(), ""); err == nil {
+		t.Error("empty safe address should fail")
+	}
+
+	srv.statuses = nil
+	status, err := c.GetDepositStatus(t.Context(), "0xsafe")
+	if err != nil {
+		t.Fatalf("GetDepositStatus: %v", err)
+	}
+	hashes := func(records []DepositRecord) []string {
+		var out []string
+		for _, r := range records {
+			out = append(out, r.TxHash)
+		}
+		return out
+	}
+	if got := hashes(status.Completed); len(got) != 2 || got[0] != "0x1" || got[1] != "s2" {
+		t.Errorf("completed = %v", got)
+	}
+	if got := hashes(status.Pending); len(got) != 2 || got[0] != "0x2" || got[1] != "s1" {
+		t.Errorf("pending = %v", got)
+	}
+	if got := hashes(status.Failed); len(got) != 1 || got[0] != "0x3" {
+		t.Errorf("failed = %v", got)
+	}
+	// 空的 BTC 地址不查询
+	if status.Addresses.EVM != "0xevm" || len(srv.statuses) != 2 {
+		t.Errorf("addresses = %+v, status requests = %v", status.Addresses, srv.statuses)
+	}
+}
+
+func TestGetDepositStatusError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /deposit", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, DepositResponse{Address: DepositAddresses{EVM: "0xevm"}})
+	})
+	mux.HandleFunc("GET /status/{address}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"bad address"}`, http.StatusBadRequest)
+	})
+	c := newTestClient(t, mux, ClientConfig{})
+	if status, err := c.GetDepositStatus(t.Context(), "0xsafe"); err == nil {
+		t.Errorf("GetDepositStatus = %+v, want error", status)
+	}
+}
+
+func TestWaitForDeposit(t *testing.T) {
+	since := time.UnixMilli(1700000000000)
+	before, after := since.Add(-time.Minute).UnixMilli(), since.Add(time.Minute).UnixMilli()
+	tests := []struct {
+		name     string
+		records  []DepositRecord
+		wantHash string
+		wantErr  bool
+		timeout  bool
+	}{
+		{
+			name: "completed",
+			records: []DepositRecord{
+				{TxHash: "old", Status: DepositStatusCompleted, CreatedTimeMs: before},
+				{TxHash: "new", Status: DepositStatusCompleted, CreatedTimeMs: after},
+			},
+			wantHash: "new",
+		},
+		{
+			name:     "completed exactly at since",
+			records:  []DepositRecord{{TxHash: "edge", Status: DepositStatusCompleted, CreatedTimeMs: since.UnixMilli()}},
+			wantHash: "edge",
+		},
+		{
+			name: "failed",
+			records: []DepositRecord{
+				{TxHash: "old", Status: DepositStatusCompleted, CreatedTimeMs: before},
+				{TxHash: "bad", Status: DepositStatusFailed, CreatedTimeMs: after},
+			},
+			wantHash: "bad", wantErr: true,
+		},
+		{
+			name: "pending until timeout",
+			records: []DepositRecord{
+				{TxHash: "old", Status: DepositStatusCompleted, CreatedTimeMs: before},
+				{TxHash: "oldFail", Status: DepositStatusFailed, CreatedTimeMs: before},
+				{TxHash: "wip", Status: DepositStatusSubmitted, CreatedTimeMs: after},
+			},
+			wantErr: true, timeout: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &depositServer{records: map[string][]DepositRecord{"0xevm": tt.records}}
+			c := newTestClient(t, srv.handler(t), ClientConfig{DepositPollInterval: 20 * time.Millisecond})
+			ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+			defer cancel()
+
+			record, err := c.WaitForDeposit(ctx, "0xsafe", since)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			// 充值地址只获取一次
+			if srv.deposits != 1 {
+				t.Errorf("deposit address requests = %d, want 1", srv.deposits)
+			}
+			if tt.timeout {
+				if !errors.Is(err, context.DeadlineExceeded) || record != nil {
+					t.Errorf("timeout: record = %+v, err = %v", record, err)
+				}
+				if len(srv.statuses) < 4 {
+					t.Errorf("status requests = %d, want repeated polling", len(srv.statuses))
+				}
+				return
+			}
+			if record == nil || record.TxHash != tt.wantHash {
+				t.Errorf("record = %+v, want %s", record, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestWaitForDepositPolling(t *testing.T) {
+	since := time.UnixMilli(1700000000000)
+	createdAt := since.Add(time.Minute).UnixMilli()
+	var deposits, polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /deposit", func(w http.ResponseWriter, r *http.Request) {
+		deposits++
+		writeJSON(w, DepositResponse{Address: DepositAddresses{EVM: "0xevm"}})
+	})
+	// 第 1、3 次查询失败，第 2 次处理中，第 4 次到账
+	mux.HandleFunc("GET /status/{address}", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		switch polls {
+		case 1, 3:
+			http.Error(w, `{"error":"upstream unavailable"}`, http.StatusBadRequest)
+		case 2:
+			writeJSON(w, DepositStatusResponse{Transactions: []DepositRecord{{TxHash: "0x1", Status: DepositStatusProcessing, CreatedTimeMs: createdAt}}})
+		default:
+			writeJSON(w, DepositStatusResponse{Transactions: []DepositRecord{{TxHash: "0x1", Status: DepositStatusCompleted, CreatedTimeMs: createdAt}}})
+		}
+	})
+	c := newTestClient(t, mux, ClientConfig{DepositPollInterval: time.Millisecond})
+
+	record, err := c.WaitForDeposit(t.Context(), "0xsafe", since)
+	if err != nil || record == nil || record.TxHash != "0x1" || record.Status != DepositStatusCompleted {
+		t.Fatalf("WaitForDeposit = %+v, %v", record, err)
+	}
+	if deposits != 1 || polls != 4 {
+		t.Errorf("deposit requests = %d, polls = %d, want 1 and 4", deposits, polls)
+	}
+
+	// 查询持续失败时 ctx 结束返回 ctx 错误，并附带最近一次错误
+	polls = 0
+	mux2 := http.NewServeMux()
+	mux2.HandleFunc("POST /deposit", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, DepositResponse{Address: DepositAddresses{EVM: "0xevm"}})
+	})
+	mux2.HandleFunc("GET /status/{address}", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		http.Error(w, `{"error":"upstream unavailable"}`, http.StatusBadRequest)
+	})
+	c = newTestClient(t, mux2, ClientConfig{DepositPollInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(t.Context(), 35*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForDeposit(ctx, "0xsafe", since); !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Errorf("err = %v, want deadline with last error", err)
+	}
+	if polls < 2 {
+		t.Errorf("polls = %d, want polling through errors", polls)
+	}
+
+	// 获取充值地址失败时直接返回
+	mux3 := http.NewServeMux()
+	mux3.HandleFunc("POST /deposit", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid address"}`, http.StatusBadRequest)
+	})
+	c = newTestClient(t, mux3, ClientConfig{DepositPollInterval: time.Millisecond})
+	if _, err := c.WaitForDeposit(t.Context(), "0xsafe", since); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("deposit address failure: err = %v", err)
+	}
+}
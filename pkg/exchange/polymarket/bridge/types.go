@@ -1,5 +1,7 @@
 package bridge
 
+import "time"
+
 // SupportedAsset 支持的资产
 type SupportedAsset struct {
 	ChainID       string `json:"chainId"`       // 链 ID
@@ -8,6 +10,16 @@ type SupportedAsset struct {
 	MinCheckoutUsd float64 `json:"minCheckoutUsd"` // 最小充值金额(USD)
 }
 
+// DepositEstimate 充值金额校验及到账估算
+type DepositEstimate struct {
+	Asset        SupportedAsset
+	AmountUsd    float64 // 计划充值金额(USD)
+	MinUsd       float64 // 该链/代币的最小充值金额(USD)
+	FeeUsd       float64 // 估算手续费(USD)
+	NetUsd       float64 // 估算到账金额(USD)，不低于 0
+	MeetsMinimum bool    // AmountUsd >= MinUsd
+}
+
 // Token 代币信息
 type Token struct {
 	Name     string `json:"name"`     // 代币名称
@@ -38,3 +50,48 @@ type DepositResponse struct {
 type DepositRequest struct {
 	Address string `json:"address"` // Polymarket 钱包地址
 }
+
+// 充值状态
+const (
+	DepositStatusDetected          = "DEPOSIT_DETECTED"    // 已检测到源链充值
+	DepositStatusProcessing        = "PROCESSING"          // 跨链处理中
+	DepositStatusOriginTxConfirmed = "ORIGIN_TX_CONFIRMED" // 源链交易已确认
+	DepositStatusSubmitted         = "SUBMITTED"           // 目标链交易已提交
+	DepositStatusCompleted         = "COMPLETED"           // 已到账
+	DepositStatusFailed            = "FAILED"              // 失败
+)
+
+// DepositRecord 充值记录
+type DepositRecord struct {
+	FromChainID        string `json:"fromChainId"`        // 源链 ID
+	FromTokenAddress   string `json:"fromTokenAddress"`   // 源链代币地址
+	FromAmountBaseUnit string `json:"fromAmountBaseUnit"` // 充值数量（源代币最小单位）
+	ToChainID          string `json:"toChainId"`          // 目标链 ID (Polygon)
+	ToTokenAddress     string `json:"toTokenAddress"`     // 目标代币地址
+	Status             string `json:"status"`             // 充值状态
+	TxHash             string `json:"txHash"`             // 交易哈希
+	CreatedTimeMs      int64  `json:"createdTimeMs"`      // 创建时间（毫秒）
+}
+
+// IsPending 是否仍在处理中（未到账也未失败）
+func (r *DepositRecord) IsPending() bool {
+	return r.Status != DepositStatusCompleted && r.Status != DepositStatusFailed
+}
+
+// CreatedAt 创建时间
+func (r *DepositRecord) CreatedAt() time.Time {
+	return time.UnixMilli(r.CreatedTimeMs)
+}
+
+// DepositStatusResponse 充值状态响应
+type DepositStatusResponse struct {
+	Transactions []DepositRecord `json:"transactions"`
+}
+
+// DepositStatus 钱包的充值汇总（覆盖 EVM/SVM/BTC 三个充值地址）
+type DepositStatus struct {
+	Addresses DepositAddresses
+	Pending   []DepositRecord
+	Completed []DepositRecord
+	Failed    []DepositRecord
+}
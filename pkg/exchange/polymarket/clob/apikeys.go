@@ -0,0 +1,118 @@
+package clob
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 批量派生凭证的默认参数（L1 认证接口限流较严，默认保守）
+const (
+	DefaultApiKeyConcurrency  = 4
+	DefaultApiKeyRPS          = 2.0
+	DefaultApiKeyMaxRetries   = 5
+	DefaultApiKeyRetryBackoff = time.Second
+)
+
+// ApiKeyFunc 获取单个账户的 API 凭证（如 (*Client).CreateOrDeriveApiKey）
+// 速率限制作用于经 ctx 发出的 Client 请求，须使用传入的 ctx 调用 Client
+type ApiKeyFunc func(ctx context.Context) (*ApiKeyCreds, error)
+
+// ApiKeySchedulerConfig 凭证派生调度配置，零值字段使用默认值
+type ApiKeySchedulerConfig struct {
+	Concurrency       int           // 最大并发数
+	RequestsPerSecond float64       // 所有账户共享的每秒 HTTP 请求上限（每个请求及其重试均计入）
+	MaxRetries        int           // 遇到 429 时的最大重试次数，< 0 表示不重试
+	RetryBackoff      time.Duration // 429 重试的初始退避，每次翻倍，不超过 MaxRetryAfter
+}
+
+// ApiKeyResult 单个账户的凭证派生结果，Index 对应输入顺序
+type ApiKeyResult struct {
+	Index int
+	Creds *ApiKeyCreds
+	Err   error
+}
+
+// ApiKeyScheduler 批量账户凭证派生调度器：限制并发和速率，并在 429 时退避重试
+// 适用于一次性为大量账户创建/派生 API Key，避免触发 L1 认证限流
+type ApiKeyScheduler struct {
+	cfg ApiKeySchedulerConfig
+}
+
+// NewApiKeyScheduler 创建凭证派生调度器
+func NewApiKeyScheduler(cfg ApiKeySchedulerConfig) *ApiKeyScheduler {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultApiKeyConcurrency
+	}
+	if cfg.RequestsPerSecond <= 0 {
+		cfg.RequestsPerSecond = DefaultApiKeyRPS
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultApiKeyMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultApiKeyRetryBackoff
+	}
+	return &ApiKeyScheduler{cfg: cfg}
+}
+
+// DeriveClients 为每个客户端调用 CreateOrDeriveApiKey，结果与 clients 顺序一致
+// 不会自动设置到客户端上，调用方按需调用 SetApiCreds
+func (s *ApiKeyScheduler) DeriveClients(ctx context.Context, clients []*Client) []ApiKeyResult {
+	fns := make([]ApiKeyFunc, len(clients))
+	for i, c := range clients {
+		fns[i] = c.CreateOrDeriveApiKey
+	}
+	return s.Run(ctx, fns)
+}
+
+// Run 按并发和速率限制执行 fns，429 错误按指数退避重试，其他错误直接记录在对应结果中
+// 客户端不会自行重试 429，由调度器统一退避；ctx 取消后未开始的任务以 ctx 错误结束
+func (s *ApiKeyScheduler) Run(ctx context.Context, fns []ApiKeyFunc) []ApiKeyResult {
+	results := make([]ApiKeyResult, len(fns))
+	ctx = withSharedLimiter(ctx, newLimiter(s.cfg.RequestsPerSecond, 1))
+	sem := make(chan struct{}, s.cfg.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		results[i].Index = i
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, fn ApiKeyFunc) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Creds, results[i].Err = s.derive(ctx, fn)
+		}(i, fn)
+	}
+	wg.Wait()
+	return results
+}
+
+// derive 执行单个账户的凭证派生，429 时退避重试
+func (s *ApiKeyScheduler) derive(ctx context.Context, fn ApiKeyFunc) (*ApiKeyCreds, error) {
+	backoff := s.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		creds, err := fn(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		if !IsRateLimited(err) || attempt >= s.cfg.MaxRetries {
+			return nil, err
+		}
+		if err := sleepCtx(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff = min(backoff*2, MaxRetryAfter)
+	}
+}
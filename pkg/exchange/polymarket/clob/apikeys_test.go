@@ -0,0 +1,232 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// errRateLimited 模拟 L1 认证接口的 429 响应
+var errRateLimited = &APIError{StatusCode: http.StatusTooManyRequests, ErrorMsg: "too many requests"}
+
+// scriptedKey 前 limited 次调用返回 429，之后返回 ApiKey 为 key 的凭证，记录调用时间
+type scriptedKey struct {
+	key     string
+	limited int
+
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (s *scriptedKey) fn(ctx context.Context) (*ApiKeyCreds, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, time.Now())
+	if len(s.calls) <= s.limited {
+		return nil, errRateLimited
+	}
+	return &ApiKeyCreds{ApiKey: s.key}, nil
+}
+
+func (s *scriptedKey) Calls() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.calls...)
+}
+
+func TestApiKeySchedulerRetryCooldown(t *testing.T) {
+	backoff := 20 * time.Millisecond
+	s := NewApiKeyScheduler(ApiKeySchedulerConfig{RequestsPerSecond: 1000, MaxRetries: 3, RetryBackoff: backoff})
+
+	recovers := &scriptedKey{key: "a", limited: 2}
+	exhausted := &scriptedKey{key: "b", limited: 10}
+	failing := 0
+	results := s.Run(t.Context(), []ApiKeyFunc{
+		recovers.fn,
+		exhausted.fn,
+		func(ctx context.Context) (*ApiKeyCreds, error) {
+			failing++
+			return nil, errors.New("invalid signature")
+		},
+	})
+
+	if results[0].Err != nil || results[0].Creds.ApiKey != "a" {
+		t.Errorf("results[0] = %+v, want creds after retries", results[0])
+	}
+	// 429 退避每次翻倍：两次冷却间隔分别不少于 backoff、2*backoff
+	calls := recovers.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("recovering calls = %d, want 3", len(calls))
+	}
+	if d := calls[1].Sub(calls[0]); d < backoff {
+		t.Errorf("first cooldown = %v, want >= %v", d, backoff)
+	}
+	if d := calls[2].Sub(calls[1]); d < 2*backoff {
+		t.Errorf("second cooldown = %v, want >= %v", d, 2*backoff)
+	}
+
+	// 超过 MaxRetries 后返回最后一次 429
+	if !IsRateLimited(results[1].Err) || len(exhausted.Calls()) != 4 {
+		t.Errorf("exhausted: err = %v, calls = %d, want 429 after 4 calls", results[1].Err, len(exhausted.Calls()))
+	}
+	// 非 429 错误不重试
+	if results[2].Err == nil || failing != 1 {
+		t.Errorf("non-rate-limit error: err = %v, calls = %d", results[2].Err, failing)
+	}
+
+	// MaxRetries < 0 时不重试
+	noRetry := &scriptedKey{key: "c", limited: 1}
+	results = NewApiKeyScheduler(ApiKeySchedulerConfig{RequestsPerSecond: 1000, MaxRetries: -1}).Run(t.Context(), []ApiKeyFunc{noRetry.fn})
+	if !IsRateLimited(results[0].Err) || len(noRetry.Calls()) != 1 {
+		t.Errorf("no retry: err = %v, calls = %d", results[0].Err, len(noRetry.Calls()))
+	}
+}
+
+func TestApiKeySchedulerConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	fns := make([]ApiKeyFunc, 8)
+	for i := range fns {
+		fns[i] = func(ctx context.Context) (*ApiKeyCreds, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return &ApiKeyCreds{ApiKey: strconv.Itoa(i)}, nil
+		}
+	}
+	results := NewApiKeyScheduler(ApiKeySchedulerConfig{Concurrency: 2, RequestsPerSecond: 1000}).Run(t.Context(), fns)
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", p)
+	}
+	for i, r := range results {
+		if r.Index != i || r.Err != nil || r.Creds.ApiKey != strconv.Itoa(i) {
+			t.Errorf("results[%d] = %+v, want creds %d in input order", i, r, i)
+		}
+	}
+}
+
+// apiKeyServer 记录 /auth/api-key 请求时间的测试服务，前 limited 次请求返回 429
+type apiKeyServer struct {
+	limited int
+
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (s *apiKeyServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth/api-key", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.calls = append(s.calls, time.Now())
+		n := len(s.calls)
+		s.mu.Unlock()
+		if n <= s.limited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"too many requests"}`))
+			return
+		}
+		writeJSON(t, w, ApiKeyCreds{ApiKey: "created", Secret: "c2VjcmV0", Passphrase: "pass"})
+	})
+	return mux
+}
+
+func (s *apiKeyServer) Calls() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.calls...)
+}
+
+func TestApiKeySchedulerRateLimitsRequests(t *testing.T) {
+	// 速率上限按 HTTP 请求计算并由所有账户共享：20 次/秒时 5 个请求至少间隔 200ms
+	srv := &apiKeyServer{}
+	clients := make([]*Client, 5)
+	for i := range clients {
+		clients[i] = newTestClient(t, srv.handler(t))
+	}
+	results := NewApiKeyScheduler(ApiKeySchedulerConfig{Concurrency: 5, RequestsPerSecond: 20}).DeriveClients(t.Context(), clients)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+	calls := srv.Calls()
+	if len(calls) != 5 {
+		t.Fatalf("requests = %d, want 5", len(calls))
+	}
+	if span := calls[4].Sub(calls[0]); span < 150*time.Millisecond {
+		t.Errorf("5 requests at 20 rps spanned %v, want >= 200ms", span)
+	}
+
+	// 客户端默认会重试 429，经调度器发出的请求不在客户端内重试
+	srv = &apiKeyServer{limited: 2}
+	c := newTestClient(t, srv.handler(t))
+	results = NewApiKeyScheduler(ApiKeySchedulerConfig{RequestsPerSecond: 1000, MaxRetries: -1}).DeriveClients(t.Context(), []*Client{c})
+	if !IsRateLimited(results[0].Err) || len(srv.Calls()) != 1 {
+		t.Errorf("no scheduler retry: err = %v, requests = %d, want 429 after 1 request", results[0].Err, len(srv.Calls()))
+	}
+	if c.Retries() != 0 {
+		t.Errorf("client retries = %d, want 0", c.Retries())
+	}
+
+	// 调度器重试的每个请求同样计入速率
+	srv = &apiKeyServer{limited: 2}
+	c = newTestClient(t, srv.handler(t))
+	results = NewApiKeyScheduler(ApiKeySchedulerConfig{RequestsPerSecond: 20, RetryBackoff: time.Millisecond}).DeriveClients(t.Context(), []*Client{c})
+	calls = srv.Calls()
+	if results[0].Err != nil || len(calls) != 3 {
+		t.Fatalf("scheduler retry: err = %v, requests = %d, want success after 3 requests", results[0].Err, len(calls))
+	}
+	if span := calls[2].Sub(calls[0]); span < 80*time.Millisecond {
+		t.Errorf("3 requests at 20 rps spanned %v, want >= 100ms", span)
+	}
+}
+
+func TestApiKeySchedulerCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	called := &scriptedKey{}
+	results := NewApiKeyScheduler(ApiKeySchedulerConfig{}).Run(ctx, []ApiKeyFunc{called.fn, called.fn, called.fn})
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) || r.Index != i {
+			t.Errorf("results[%d] = %+v, want context.Canceled", i, r)
+		}
+	}
+	if n := len(called.Calls()); n != 0 {
+		t.Errorf("calls after cancel = %d, want 0", n)
+	}
+}
+
+func TestApiKeySchedulerDeriveClients(t *testing.T) {
+	var creates atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth/api-key", func(w http.ResponseWriter, r *http.Request) {
+		// 第一次调用被限流
+		if creates.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"too many requests"}`))
+			return
+		}
+		writeJSON(t, w, ApiKeyCreds{ApiKey: "created", Secret: "c2VjcmV0", Passphrase: "pass"})
+	})
+	clients := []*Client{newTestClient(t, mux), newTestClient(t, mux)}
+
+	results := NewApiKeyScheduler(ApiKeySchedulerConfig{Concurrency: 1, RequestsPerSecond: 1000, RetryBackoff: time.Millisecond}).DeriveClients(t.Context(), clients)
+	for i, r := range results {
+		if r.Err != nil || r.Creds == nil || r.Creds.ApiKey != "created" {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+	if n := creates.Load(); n != 3 {
+		t.Errorf("create calls = %d, want 3 (one 429 retry)", n)
+	}
+}
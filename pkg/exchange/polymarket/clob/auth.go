@@ -9,10 +9,10 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
-	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	polycommon "github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
 )
 
 // ClobAuthDomain EIP-712 域
@@ -53,9 +53,7 @@ func signClobAuth(privateKey *ecdsa.PrivateKey, chainID int64, address string, t
 		return "", fmt.Errorf("sign: %w", err)
 	}
 
-	if sig[64] < 27 {
-		sig[64] += 27
-	}
+	sig = polycommon.NormalizeSignatureV(sig, polycommon.VSchemeEthereum)
 
 	return "0x" + hex.EncodeToString(sig), nil
 }
@@ -68,10 +66,10 @@ type L1AuthHeaders struct {
 	Nonce     int64
 }
 
-// buildL1AuthHeaders 构建 L1 认证请求头
-func buildL1AuthHeaders(privateKey *ecdsa.PrivateKey, chainID int64, nonce int64) (*L1AuthHeaders, error) {
+// buildL1AuthHeaders 构建 L1 认证请求头，ts 为签名使用的 Unix 时间戳（秒）
+func buildL1AuthHeaders(privateKey *ecdsa.PrivateKey, chainID int64, nonce int64, ts int64) (*L1AuthHeaders, error) {
 	address := crypto.PubkeyToAddress(privateKey.PublicKey)
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	timestamp := fmt.Sprintf("%d", ts)
 
 	signature, err := signClobAuth(privateKey, chainID, address.Hex(), timestamp, nonce)
 	if err != nil {
@@ -95,9 +93,9 @@ type L2AuthHeaders struct {
 	Passphrase string
 }
 
-// buildL2AuthHeaders 构建 L2 认证请求头
-func buildL2AuthHeaders(address string, creds *ApiKeyCreds, method, path string, body []byte) (*L2AuthHeaders, error) {
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+// buildL2AuthHeaders 构建 L2 认证请求头，ts 为签名使用的 Unix 时间戳（秒）
+func buildL2AuthHeaders(address string, creds *ApiKeyCreds, method, path string, body []byte, ts int64) (*L2AuthHeaders, error) {
+	timestamp := fmt.Sprintf("%d", ts)
 	signature := buildClobHmacSignature(creds.Secret, timestamp, method, path, body)
 
 	return &L2AuthHeaders{
@@ -142,9 +140,9 @@ type BuilderAuthHeaders struct {
 	Signature  string
 }
 
-// buildBuilderAuthHeaders 构建 Builder 认证请求头
-func buildBuilderAuthHeaders(creds *ApiKeyCreds, method, path string, body []byte) (*BuilderAuthHeaders, error) {
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+// buildBuilderAuthHeaders 构建 Builder 认证请求头，ts 为签名使用的 Unix 时间戳（秒）
+func buildBuilderAuthHeaders(creds *ApiKeyCreds, method, path string, body []byte, ts int64) (*BuilderAuthHeaders, error) {
+	timestamp := fmt.Sprintf("%d", ts)
 
 	message := timestamp + method + path
 	if len(body) > 0 {
@@ -0,0 +1,55 @@
+package clob
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenCacheEntry token 级缓存条目
+type tokenCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time // 零值表示永不过期
+}
+
+// tokenCache 按 token ID 缓存 tick size / neg risk 等很少变化的市场参数
+type tokenCache struct {
+	ttl     time.Duration // <= 0 表示永不过期
+	entries sync.Map      // key: kind + ":" + tokenID
+}
+
+func (tc *tokenCache) get(kind, tokenID string) (interface{}, bool) {
+	v, ok := tc.entries.Load(kind + ":" + tokenID)
+	if !ok {
+		return nil, false
+	}
+	entry := v.(tokenCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		tc.entries.Delete(kind + ":" + tokenID)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (tc *tokenCache) set(kind, tokenID string, value interface{}) {
+	entry := tokenCacheEntry{value: value}
+	if tc.ttl > 0 {
+		entry.expiresAt = time.Now().Add(tc.ttl)
+	}
+	tc.entries.Store(kind+":"+tokenID, entry)
+}
+
+func (tc *tokenCache) invalidate(tokenID string) {
+	for _, kind := range []string{cacheTickSize, cacheNegRisk} {
+		tc.entries.Delete(kind + ":" + tokenID)
+	}
+}
+
+const (
+	cacheTickSize = "tick"
+	cacheNegRisk  = "negrisk"
+)
+
+// InvalidateTokenCache 清除指定 token 缓存的 tick size 和 neg risk（如收到 tick_size_change 推送或市场结算后）
+func (c *Client) InvalidateTokenCache(tokenID string) {
+	c.tokenCache.invalidate(tokenID)
+}
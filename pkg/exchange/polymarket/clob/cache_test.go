@@ -0,0 +1,75 @@
+package clob
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingMarketServer 记录 /tick-size 和 /neg-risk 请求次数的测试服务器
+func countingMarketServer(t *testing.T, requests *atomic.Int32) *http.ServeMux {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tick-size", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(t, w, TickSizeResponse{MinimumTickSize: 0.001})
+	})
+	mux.HandleFunc("GET /neg-risk", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(t, w, NegRiskResponse{NegRisk: true})
+	})
+	return mux
+}
+
+func TestTokenCacheHit(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, countingMarketServer(t, &requests))
+
+	for range 2 {
+		tick, err := c.GetTickSize(t.Context(), "123")
+		if err != nil || tick != TickSize0001 {
+			t.Fatalf("GetTickSize = %v, %v", tick, err)
+		}
+		negRisk, err := c.GetNegRisk(t.Context(), "123")
+		if err != nil || !negRisk {
+			t.Fatalf("GetNegRisk = %v, %v", negRisk, err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2 (second round from cache)", n)
+	}
+
+	// 其他 token 单独缓存
+	if _, err := c.GetTickSize(t.Context(), "456"); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests after new token = %d, want 3", n)
+	}
+
+	c.InvalidateTokenCache("123")
+	c.GetTickSize(t.Context(), "123")
+	c.GetNegRisk(t.Context(), "123")
+	if n := requests.Load(); n != 5 {
+		t.Errorf("requests after invalidate = %d, want 5", n)
+	}
+}
+
+func TestTokenCacheTTL(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, countingMarketServer(t, &requests), func(cfg *ClientConfig) {
+		cfg.TokenCacheTTL = 20 * time.Millisecond
+	})
+
+	c.GetTickSize(t.Context(), "123")
+	c.GetTickSize(t.Context(), "123")
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("requests before expiry = %d, want 1", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	c.GetTickSize(t.Context(), "123")
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests after expiry = %d, want 2", n)
+	}
+}
@@ -0,0 +1,87 @@
+package clob
+
+import (
+	"sort"
+	"time"
+)
+
+// Candle 价格 K 线（Polymarket 价格历史不含成交量）
+type Candle struct {
+	Start time.Time // 区间开始时间（按 bucket 对齐到 Unix 纪元，UTC）
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+	Count int // 区间内的价格点数，前向填充的空区间为 0
+}
+
+// Filled 是否为前向填充的空区间
+func (c Candle) Filled() bool { return c.Count == 0 }
+
+// ResampleOption 重采样选项
+type ResampleOption func(*resampleOptions)
+
+type resampleOptions struct {
+	fillForward bool
+}
+
+// FillForward 用上一根 K 线的收盘价填充没有价格点的区间（默认跳过空区间）
+func FillForward() ResampleOption {
+	return func(o *resampleOptions) { o.fillForward = true }
+}
+
+// ResampleOHLC 将 GetPriceHistory 返回的价格序列重采样为 bucket 周期的 OHLC K 线
+// 价格点按时间排序后归入 [Start, Start+bucket) 区间；bucket 不足 1 秒或没有价格点时返回 nil
+func ResampleOHLC(prices []MarketPrice, bucket time.Duration, opts ...ResampleOption) []Candle {
+	step := int64(bucket / time.Second)
+	if step <= 0 || len(prices) == 0 {
+		return nil
+	}
+	var o resampleOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sorted := append([]MarketPrice(nil), prices...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].T < sorted[j].T })
+
+	var candles []Candle
+	for _, p := range sorted {
+		start := floorDiv(p.T, step) * step
+		if n := len(candles); n > 0 {
+			last := &candles[n-1]
+			lastStart := last.Start.Unix()
+			if start == lastStart {
+				last.High = max(last.High, p.P)
+				last.Low = min(last.Low, p.P)
+				last.Close = p.P
+				last.Count++
+				continue
+			}
+			if o.fillForward {
+				prevClose := last.Close
+				for gap := lastStart + step; gap < start; gap += step {
+					candles = append(candles, Candle{
+						Start: time.Unix(gap, 0).UTC(),
+						Open:  prevClose, High: prevClose, Low: prevClose, Close: prevClose,
+					})
+				}
+			}
+		}
+		candles = append(candles, Candle{
+			Start: time.Unix(start, 0).UTC(),
+			Open:  p.P, High: p.P, Low: p.P, Close: p.P,
+			Count: 1,
+		})
+	}
+	return candles
+}
+
+// floorDiv 向下取整除法（兼容负时间戳）
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,73 @@
+package clob
+
+import (
+	"testing"
+	"time"
+)
+
+// candle 构造从 Unix 时间 start 开始的 K 线
+func candle(start int64, open, high, low, close float64, count int) Candle {
+	return Candle{Start: time.Unix(start, 0).UTC(), Open: open, High: high, Low: low, Close: close, Count: count}
+}
+
+func TestResampleOHLC(t *testing.T) {
+	// 乱序输入：60s 区间 [0,60) 有 3 个点，[60,120) 为空，[120,180) 有 2 个点
+	prices := []MarketPrice{
+		{T: 125, P: 0.55}, {T: 30, P: 0.48}, {T: 0, P: 0.50}, {T: 59, P: 0.46}, {T: 170, P: 0.52},
+	}
+	tests := []struct {
+		name   string
+		prices []MarketPrice
+		bucket time.Duration
+		opts   []ResampleOption
+		want   []Candle
+	}{
+		{
+			name: "gaps skipped", prices: prices, bucket: time.Minute,
+			want: []Candle{candle(0, 0.50, 0.50, 0.46, 0.46, 3), candle(120, 0.55, 0.55, 0.52, 0.52, 2)},
+		},
+		{
+			name: "gaps filled forward", prices: prices, bucket: time.Minute, opts: []ResampleOption{FillForward()},
+			want: []Candle{
+				candle(0, 0.50, 0.50, 0.46, 0.46, 3),
+				candle(60, 0.46, 0.46, 0.46, 0.46, 0),
+				candle(120, 0.55, 0.55, 0.52, 0.52, 2),
+			},
+		},
+		{
+			name: "single bucket", prices: prices, bucket: time.Hour,
+			want: []Candle{candle(0, 0.50, 0.55, 0.46, 0.52, 5)},
+		},
+		{
+			// 区间按纪元对齐，不按第一个点对齐
+			name: "epoch aligned", prices: []MarketPrice{{T: 90, P: 0.1}, {T: 150, P: 0.2}}, bucket: 2 * time.Minute,
+			want: []Candle{candle(0, 0.1, 0.1, 0.1, 0.1, 1), candle(120, 0.2, 0.2, 0.2, 0.2, 1)},
+		},
+		{
+			name: "negative timestamp", prices: []MarketPrice{{T: -1, P: 0.3}}, bucket: time.Minute,
+			want: []Candle{candle(-60, 0.3, 0.3, 0.3, 0.3, 1)},
+		},
+		{name: "empty", prices: nil, bucket: time.Minute},
+		{name: "sub-second bucket", prices: prices, bucket: time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := ResampleOHLC(tt.prices, tt.bucket, tt.opts...)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: candles = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: candle[%d] = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+			if got[i].Filled() != (tt.want[i].Count == 0) {
+				t.Errorf("%s: candle[%d].Filled() = %v", tt.name, i, got[i].Filled())
+			}
+		}
+	}
+
+	// 不修改输入顺序
+	if prices[0].T != 125 {
+		t.Error("ResampleOHLC reordered the input slice")
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"golang.org/x/time/rate"
 )
 
 // Client CLOB API 客户端
@@ -27,6 +31,13 @@ type Client struct {
 	orderBuilder  *OrderBuilder
 	apiCreds      *ApiKeyCreds
 	signatureType SignatureType
+	readLimiter   *rate.Limiter
+	writeLimiter  *rate.Limiter
+	maxRetries    int
+	retryBackoff  time.Duration
+	retries       atomic.Int64
+	tokenCache    *tokenCache
+	clock         clockSync
 }
 
 // ClientConfig CLOB 客户端配置
@@ -39,9 +50,35 @@ type ClientConfig struct {
 	ApiCreds      *ApiKeyCreds
 	ProxyString   string
 	Timeout       time.Duration
+	Transport     http.RoundTripper // 自定义传输层（可选）
+	Debug         bool              // 未设置 Logger 时将请求日志输出到 stderr
+	Logger        common.Logger     // 请求/重试日志（可选）
+	Hook          *common.HTTPHook  // 请求/响应钩子（可选）
+
+	// TransportOptions 连接池/TLS 参数（可选，设置 Transport 时忽略）；
+	// 多个客户端并发访问 CLOB 时可调大 MaxIdleConnsPerHost，限流额度不会因此提高
+	TransportOptions common.TransportOptions
+
+	// 客户端限流（可选，<= 0 表示不限流），读 (GET) 与写 (POST/DELETE) 分别计数
+	RequestsPerSecond      float64 // 读请求每秒上限
+	Burst                  int     // 读请求突发数 (默认 RequestsPerSecond 取整，至少 1)
+	WriteRequestsPerSecond float64 // 写请求每秒上限
+	WriteBurst             int     // 写请求突发数 (默认 WriteRequestsPerSecond 取整，至少 1)
+
+	// 失败重试：GET 在网络错误/429/5xx 时重试；POST/DELETE 在 429/5xx 或网络错误时重试，
+	// 下单 (/order、/orders) 仅在连接建立失败时重试，避免重复提交
+	MaxRetries   int           // 最大重试次数 (默认 2，负数表示不重试)
+	RetryBackoff time.Duration // 首次重试等待时间，之后每次翻倍 (默认 200ms)
+
+	// TokenCacheTTL tick size / neg risk 缓存有效期（<= 0 表示永不过期，可通过 InvalidateTokenCache 手动清除）
+	TokenCacheTTL time.Duration
+
+	// TimeSyncInterval 认证签名时间戳的服务器时间校准间隔：首次认证请求前自动调用 SyncTime，
+	// 之后每隔该时间重新校准 (默认 10 分钟，负数表示不自动校准，仍可手动调用 SyncTime)
+	TimeSyncInterval time.Duration
 }
 
-// NewClient 创建 CLOB 客户端
+// NewClient 创建 CLOB 客户端，PrivateKey 为空时仅可调用公开接口
 func NewClient(cfg ClientConfig) (*Client, error) {
 	if cfg.BaseURL == "" {
 		cfg.BaseURL = BaseURL
@@ -52,13 +89,27 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
-
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.PrivateKey, "0x"))
-	if err != nil {
-		return nil, fmt.Errorf("parse private key: %w", err)
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.TimeSyncInterval == 0 {
+		cfg.TimeSyncInterval = DefaultTimeSyncInterval
 	}
 
-	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	// 未配置私钥时仅可调用公开接口，认证和下单返回 ErrNoPrivateKey
+	var privateKey *ecdsa.PrivateKey
+	var address string
+	if cfg.PrivateKey != "" {
+		var err error
+		privateKey, err = crypto.HexToECDSA(strings.TrimPrefix(cfg.PrivateKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+		address = crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	}
 	funder := cfg.Funder
 	if funder == "" {
 		funder = address
@@ -70,9 +121,18 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		BaseURL:     baseURL,
 		Timeout:     cfg.Timeout,
 		ProxyString: cfg.ProxyString,
+		Transport:   cfg.Transport,
+		Debug:       cfg.Debug,
+		Logger:      cfg.Logger,
+		Hook:        cfg.Hook,
+
+		TransportOptions: cfg.TransportOptions,
 	})
 
-	orderBuilder := NewOrderBuilder(privateKey, cfg.ChainID, cfg.SignatureType, funder)
+	var orderBuilder *OrderBuilder
+	if privateKey != nil {
+		orderBuilder = NewOrderBuilder(privateKey, cfg.ChainID, cfg.SignatureType, funder)
+	}
 
 	// 使用默认 Builder 凭证
 	apiCreds := cfg.ApiCreds
@@ -94,10 +154,21 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		orderBuilder:  orderBuilder,
 		apiCreds:      apiCreds,
 		signatureType: cfg.SignatureType,
+		readLimiter:   newLimiter(cfg.RequestsPerSecond, cfg.Burst),
+		writeLimiter:  newLimiter(cfg.WriteRequestsPerSecond, cfg.WriteBurst),
+		maxRetries:    max(cfg.MaxRetries, 0),
+		retryBackoff:  cfg.RetryBackoff,
+		tokenCache:    &tokenCache{ttl: cfg.TokenCacheTTL},
+		clock:         clockSync{interval: cfg.TimeSyncInterval},
 	}, nil
 }
 
-// GetAddress 获取签名者地址
+// Close 释放空闲 HTTP 连接，客户端不再使用时调用，可重复调用
+func (c *Client) Close() {
+	c.httpClient.Close()
+}
+
+// GetAddress 获取签名者地址（未配置私钥时为空）
 func (c *Client) GetAddress() string { return c.address }
 
 // GetFunder 获取资金来源地址
@@ -126,26 +197,59 @@ func (c *Client) GetServerTime(ctx context.Context) (int64, error) {
 	return timestamp, nil
 }
 
-// GetTickSize 获取市场 tick size
+// GetTickSize 获取市场 tick size（按 token 缓存，见 ClientConfig.TokenCacheTTL）
 func (c *Client) GetTickSize(ctx context.Context, tokenID string) (TickSize, error) {
+	if v, ok := c.tokenCache.get(cacheTickSize, tokenID); ok {
+		return v.(TickSize), nil
+	}
 	var resp TickSizeResponse
 	if err := c.doGet(ctx, "/tick-size", url.Values{"token_id": {tokenID}}, &resp); err != nil {
 		return "", err
 	}
 	// 转换 float64 到 TickSize 字符串
-	tickSizeStr := strconv.FormatFloat(resp.MinimumTickSize, 'f', -1, 64)
-	return TickSize(tickSizeStr), nil
+	tickSize := TickSize(strconv.FormatFloat(resp.MinimumTickSize, 'f', -1, 64))
+	c.tokenCache.set(cacheTickSize, tokenID, tickSize)
+	return tickSize, nil
 }
 
-// GetNegRisk 获取市场 neg risk 状态
+// GetNegRisk 获取市场 neg risk 状态（按 token 缓存）
 func (c *Client) GetNegRisk(ctx context.Context, tokenID string) (bool, error) {
+	if v, ok := c.tokenCache.get(cacheNegRisk, tokenID); ok {
+		return v.(bool), nil
+	}
 	var resp NegRiskResponse
 	if err := c.doGet(ctx, "/neg-risk", url.Values{"token_id": {tokenID}}, &resp); err != nil {
 		return false, err
 	}
+	c.tokenCache.set(cacheNegRisk, tokenID, resp.NegRisk)
 	return resp.NegRisk, nil
 }
 
+// ResolveOrderOptions 从服务端解析 token 的 tick size 和 neg risk 状态，补全下单选项
+// neg risk 以服务端为准（决定签名使用的交易所合约）；tick size 未指定时使用市场最小 tick，
+// 指定的 tick 比市场最小 tick 更细时返回错误，避免价格按错误的精度舍入
+func (c *Client) ResolveOrderOptions(ctx context.Context, tokenID string, opts CreateOrderOptions) (CreateOrderOptions, error) {
+	minTick, err := c.GetTickSize(ctx, tokenID)
+	if err != nil {
+		return opts, fmt.Errorf("get tick size: %w", err)
+	}
+	negRisk, err := c.GetNegRisk(ctx, tokenID)
+	if err != nil {
+		return opts, fmt.Errorf("get neg risk: %w", err)
+	}
+
+	opts.NegRisk = negRisk
+	if opts.TickSize == "" {
+		opts.TickSize = minTick
+	} else if opts.TickSize.Float64() < minTick.Float64() {
+		return opts, fmt.Errorf("invalid tick size (%s), minimum for the market is %s", opts.TickSize, minTick)
+	}
+	if _, ok := roundingConfigs[opts.TickSize]; !ok {
+		return opts, fmt.Errorf("unsupported tick size: %s", opts.TickSize)
+	}
+	return opts, nil
+}
+
 // GetFeeRateBps 获取市场费率
 func (c *Client) GetFeeRateBps(ctx context.Context, tokenID string) (float64, error) {
 	var resp FeeRateResponse
@@ -227,21 +331,30 @@ func (c *Client) GetMarkets(ctx context.Context, nextCursor string) (*MarketsRes
 
 // GetAllMarkets 获取所有市场 (自动分页)
 func (c *Client) GetAllMarkets(ctx context.Context) ([]Market, error) {
-	var results []Market
-	nextCursor := InitialCursor
+	return c.MarketsIterator().All(ctx)
+}
 
-	for nextCursor != EndCursor {
-		resp, err := c.GetMarkets(ctx, nextCursor)
+// GetMarketsSince 从持久化的游标继续拉取市场 (增量同步)
+// cursor 为空时从头开始；返回本次拉取的市场以及可持久化的续传游标。
+// 续传游标指向最后一页的起始位置（而非 EndCursor），下次调用会重新拉取最后一页以获取其后新增的市场，
+// 调用方应按 ConditionID 去重
+func (c *Client) GetMarketsSince(ctx context.Context, cursor string) ([]Market, string, error) {
+	if cursor == "" || cursor == EndCursor {
+		cursor = InitialCursor
+	}
+
+	var results []Market
+	for {
+		resp, err := c.GetMarkets(ctx, cursor)
 		if err != nil {
-			return nil, err
+			return results, cursor, err
 		}
 		results = append(results, resp.Data...)
-		nextCursor = resp.NextCursor
-		if nextCursor == "" {
-			break
+		if !resp.HasMore() {
+			return results, cursor, nil
 		}
+		cursor = resp.NextCursor
 	}
-	return results, nil
 }
 
 // GetMarket 获取单个市场
@@ -269,21 +382,7 @@ func (c *Client) GetSimplifiedMarkets(ctx context.Context, nextCursor string) (*
 
 // GetAllSimplifiedMarkets 获取所有简化市场 (自动分页)
 func (c *Client) GetAllSimplifiedMarkets(ctx context.Context) ([]SimplifiedMarket, error) {
-	var results []SimplifiedMarket
-	nextCursor := InitialCursor
-
-	for nextCursor != EndCursor {
-		resp, err := c.GetSimplifiedMarkets(ctx, nextCursor)
-		if err != nil {
-			return nil, err
-		}
-		results = append(results, resp.Data...)
-		nextCursor = resp.NextCursor
-		if nextCursor == "" {
-			break
-		}
-	}
-	return results, nil
+	return c.SimplifiedMarketsIterator().All(ctx)
 }
 
 // GetOrderBook 获取订单簿
@@ -295,6 +394,16 @@ func (c *Client) GetOrderBook(ctx context.Context, tokenID string) (*OrderBookSu
 	return &book, nil
 }
 
+// FetchBookSnapshot 获取订单簿并转换为 WebSocket 快照格式
+// 可直接作为 wss.NewBookCache 的 REST 回退函数
+func (c *Client) FetchBookSnapshot(ctx context.Context, tokenID string) (*common.OrderBookSnapshot, error) {
+	book, err := c.GetOrderBook(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	return book.ToSnapshot(), nil
+}
+
 // GetOrderBooks 批量获取订单簿
 func (c *Client) GetOrderBooks(ctx context.Context, tokenIDs []string) ([]OrderBookSummary, error) {
 	var resp []OrderBookSummary
@@ -305,6 +414,32 @@ func (c *Client) GetOrderBooks(ctx context.Context, tokenIDs []string) ([]OrderB
 	return resp, nil
 }
 
+// FetchBookSnapshots 批量获取订单簿并转换为 WebSocket 快照格式（供 wss.Connection.Bootstrap 使用）
+func (c *Client) FetchBookSnapshots(ctx context.Context, tokenIDs []string) ([]*common.OrderBookSnapshot, error) {
+	books, err := c.GetOrderBooks(ctx, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*common.OrderBookSnapshot, 0, len(books))
+	for i := range books {
+		snapshots = append(snapshots, books[i].ToSnapshot())
+	}
+	return snapshots, nil
+}
+
+// GetOrderBooksMap 批量获取订单簿，按 token ID 索引（服务端返回顺序不保证与请求一致）
+func (c *Client) GetOrderBooksMap(ctx context.Context, tokenIDs []string) (map[string]*OrderBookSummary, error) {
+	books, err := c.GetOrderBooks(ctx, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*OrderBookSummary, len(books))
+	for i := range books {
+		result[books[i].AssetID] = &books[i]
+	}
+	return result, nil
+}
+
 // GetPrice 获取价格
 func (c *Client) GetPrice(ctx context.Context, tokenID string, side Side) (string, error) {
 	var resp PriceResponse
@@ -383,6 +518,10 @@ func (c *Client) GetLastTradePrices(ctx context.Context, tokenIDs []string) ([]L
 
 // GetPriceHistory 获取价格历史
 func (c *Client) GetPriceHistory(ctx context.Context, params PriceHistoryParams) ([]MarketPrice, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid price history params: %w", err)
+	}
+
 	queryParams := url.Values{
 		"market":   {params.Market},
 		"interval": {string(params.Interval)},
@@ -410,7 +549,10 @@ func (c *Client) GetPriceHistory(ctx context.Context, params PriceHistoryParams)
 
 // CreateApiKey 创建 API Key
 func (c *Client) CreateApiKey(ctx context.Context, nonce int64) (*ApiKeyCreds, error) {
-	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce)
+	if c.privateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce, c.authTimestamp(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("build l1 auth headers: %w", err)
 	}
@@ -424,7 +566,10 @@ func (c *Client) CreateApiKey(ctx context.Context, nonce int64) (*ApiKeyCreds, e
 
 // DeriveApiKey 派生 API Key (使用 GET 请求)
 func (c *Client) DeriveApiKey(ctx context.Context, nonce int64) (*ApiKeyCreds, error) {
-	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce)
+	if c.privateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce, c.authTimestamp(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("build l1 auth headers: %w", err)
 	}
@@ -447,13 +592,20 @@ func (c *Client) CreateOrDeriveApiKey(ctx context.Context) (*ApiKeyCreds, error)
 	if err == nil && creds.ApiKey != "" {
 		return creds, nil
 	}
+	if IsRateLimited(err) {
+		// 被限流时派生同样会失败，直接返回以便调用方退避
+		return nil, err
+	}
 
 	return c.DeriveApiKey(ctx, nonce)
 }
 
 // DeleteApiKey 删除 API Key
 func (c *Client) DeleteApiKey(ctx context.Context, nonce int64) error {
-	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce)
+	if c.privateKey == nil {
+		return ErrNoPrivateKey
+	}
+	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce, c.authTimestamp(ctx))
 	if err != nil {
 		return fmt.Errorf("build l1 auth headers: %w", err)
 	}
@@ -462,7 +614,10 @@ func (c *Client) DeleteApiKey(ctx context.Context, nonce int64) error {
 
 // GetApiKeys 获取所有 API Keys
 func (c *Client) GetApiKeys(ctx context.Context, nonce int64) ([]string, error) {
-	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce)
+	if c.privateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	headers, err := buildL1AuthHeaders(c.privateKey, c.chainID, nonce, c.authTimestamp(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("build l1 auth headers: %w", err)
 	}
@@ -476,11 +631,29 @@ func (c *Client) GetApiKeys(ctx context.Context, nonce int64) ([]string, error)
 
 // CreateOrder 创建签名订单
 func (c *Client) CreateOrder(order UserOrder, opts CreateOrderOptions) (*SignedOrder, error) {
+	if c.orderBuilder == nil {
+		return nil, ErrNoPrivateKey
+	}
+	return c.orderBuilder.BuildOrder(order, opts)
+}
+
+// CreateGTDOrder 创建 GTD 限价单，expiresAt 须晚于当前时间 GTDMinBuffer，提交时使用 OrderTypeGTD
+func (c *Client) CreateGTDOrder(order UserOrder, expiresAt time.Time, opts CreateOrderOptions) (*SignedOrder, error) {
+	if c.orderBuilder == nil {
+		return nil, ErrNoPrivateKey
+	}
+	order.Expiration = expiresAt.Unix()
+	if order.Expiration == 0 {
+		return nil, fmt.Errorf("expiration is required for GTD order")
+	}
 	return c.orderBuilder.BuildOrder(order, opts)
 }
 
 // CreateMarketOrder 创建市价单
 func (c *Client) CreateMarketOrder(order UserMarketOrder, opts CreateOrderOptions) (*SignedOrder, error) {
+	if c.orderBuilder == nil {
+		return nil, ErrNoPrivateKey
+	}
 	return c.orderBuilder.BuildMarketOrder(order, opts)
 }
 
@@ -488,14 +661,43 @@ func (c *Client) CreateMarketOrder(order UserMarketOrder, opts CreateOrderOption
 
 // PostOrder 提交订单
 func (c *Client) PostOrder(ctx context.Context, order *SignedOrder, orderType OrderType) (*OrderResponse, error) {
+	return c.postOrder(ctx, order, orderType, false)
+}
+
+// PostOrderDeferred 提交延迟撮合订单 (deferExec=true)
+// 服务端接受订单后不立即撮合，而是延后执行，适用于 Polymarket 支持的批量下单流程
+func (c *Client) PostOrderDeferred(ctx context.Context, order *SignedOrder, orderType OrderType) (*OrderResponse, error) {
+	return c.postOrder(ctx, order, orderType, true)
+}
+
+// OrderFillTxs 获取下单即撮合时产生的链上交易哈希（未撮合时为空）
+// 可配合 relayer.Client.WaitForReceipts 等待成交上链
+func (c *Client) OrderFillTxs(resp *OrderResponse) []string {
+	if resp == nil {
+		return nil
+	}
+	hashes := make([]string, 0, len(resp.TransactionsHashes))
+	for _, hash := range resp.TransactionsHashes {
+		if hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+func (c *Client) postOrder(ctx context.Context, order *SignedOrder, orderType OrderType, deferExec bool) (*OrderResponse, error) {
 	if c.apiCreds == nil {
 		return nil, fmt.Errorf("API credentials not set")
 	}
+	if err := checkOrderExpiration(order, orderType); err != nil {
+		return nil, err
+	}
 
 	body := postOrderRequest{
 		Order:     order.toOrderPayload(),
 		Owner:     c.apiCreds.ApiKey,
 		OrderType: orderType,
+		DeferExec: deferExec,
 	}
 
 	var resp OrderResponse
@@ -513,12 +715,15 @@ func (c *Client) PostOrders(ctx context.Context, orders []PostOrdersArgs) ([]Ord
 
 	// 官方 SDK: 直接发送数组，不包装
 	var reqOrders []postOrderRequest
-	for _, o := range orders {
+	for i, o := range orders {
+		if err := checkOrderExpiration(&o.Order, o.OrderType); err != nil {
+			return nil, fmt.Errorf("order %d: %w", i, err)
+		}
 		reqOrders = append(reqOrders, postOrderRequest{
 			Order:     o.Order.toOrderPayload(),
 			Owner:     c.apiCreds.ApiKey,
 			OrderType: o.OrderType,
-			DeferExec: false,
+			DeferExec: o.DeferExec,
 		})
 	}
 
@@ -585,6 +790,125 @@ func (c *Client) CancelMarketOrders(ctx context.Context, params OrderMarketCance
 	return &resp, nil
 }
 
+// CancelAssetOrders 取消指定 token 的所有订单 (不限市场，分批取消)
+func (c *Client) CancelAssetOrders(ctx context.Context, assetID string) (*CancelOrdersResponse, error) {
+	if assetID == "" {
+		return nil, fmt.Errorf("asset id is required")
+	}
+
+	orders, err := c.GetOpenOrders(ctx, OpenOrderParams{AssetID: assetID})
+	if err != nil {
+		return nil, fmt.Errorf("get open orders: %w", err)
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+	return c.cancelInBatches(ctx, ids)
+}
+
+// GetOpenOrdersByTokens 获取多个 token 的未结订单，按 token ID 索引（没有挂单的 token 对应空切片）
+// 部分 token 查询失败时返回成功的结果，同时返回合并后的错误（每个错误包含对应 token ID）
+func (c *Client) GetOpenOrdersByTokens(ctx context.Context, tokenIDs []string) (map[string][]OpenOrder, error) {
+	result := make(map[string][]OpenOrder, len(tokenIDs))
+	var errs []error
+	for _, tokenID := range tokenIDs {
+		if _, ok := result[tokenID]; ok {
+			continue
+		}
+		orders, err := c.GetOpenOrders(ctx, OpenOrderParams{AssetID: tokenID})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token %s: %w", tokenID, err))
+			continue
+		}
+		if orders == nil {
+			orders = []OpenOrder{}
+		}
+		result[tokenID] = orders
+	}
+	return result, errors.Join(errs...)
+}
+
+// CancelOrdersByTokens 取消多个 token 的所有挂单，按 CancelBatchSize 分批调用 CancelOrders
+// 返回所有批次合并后的已取消和未取消订单；查询挂单部分失败时仍会取消已查到的订单，并返回查询错误
+func (c *Client) CancelOrdersByTokens(ctx context.Context, tokenIDs []string) (*CancelOrdersResponse, error) {
+	byToken, queryErr := c.GetOpenOrdersByTokens(ctx, tokenIDs)
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, tokenID := range tokenIDs {
+		for _, o := range byToken[tokenID] {
+			if !seen[o.ID] {
+				seen[o.ID] = true
+				ids = append(ids, o.ID)
+			}
+		}
+	}
+	result, err := c.cancelInBatches(ctx, ids)
+	if err != nil {
+		return result, errors.Join(err, queryErr)
+	}
+	if queryErr != nil {
+		return result, fmt.Errorf("get open orders: %w", queryErr)
+	}
+	return result, nil
+}
+
+// cancelInBatches 按 CancelBatchSize 分批取消订单并合并结果，某批失败时返回已完成批次的结果和错误
+func (c *Client) cancelInBatches(ctx context.Context, ids []string) (*CancelOrdersResponse, error) {
+	result := &CancelOrdersResponse{
+		Canceled:    []string{},
+		NotCanceled: map[string]any{},
+	}
+	for start := 0; start < len(ids); start += CancelBatchSize {
+		end := min(start+CancelBatchSize, len(ids))
+		resp, err := c.CancelOrders(ctx, ids[start:end])
+		if err != nil {
+			return result, fmt.Errorf("cancel orders: %w", err)
+		}
+		result.Canceled = append(result.Canceled, resp.Canceled...)
+		for id, reason := range resp.NotCanceled {
+			result.NotCanceled[id] = reason
+		}
+	}
+	return result, nil
+}
+
+// CancelAndWait 取消订单并轮询直到订单不再挂单（或超时）
+// 撤单响应返回后订单可能仍短暂留在撮合引擎中，立即重新下单可能冲突。
+// 查询返回 404 视为已撤销；网络错误、限流和 5xx 会继续轮询到超时，其余错误立即返回
+func (c *Client) CancelAndWait(ctx context.Context, orderID string, timeout time.Duration) (*CancelOrderResponse, error) {
+	resp, err := c.CancelOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(CancelPollInterval)
+	defer ticker.Stop()
+	for {
+		order, err := c.GetOrder(ctx, orderID)
+		switch {
+		case err == nil && !order.IsOpen(), IsNotFound(err):
+			// 撤单后订单可能直接查不到 (404)，同样视为已撤销
+			return resp, nil
+		case err != nil && !isTransient(err):
+			return resp, fmt.Errorf("wait for cancel [%s]: %w", orderID, err)
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return resp, fmt.Errorf("wait for cancel [%s]: %w", orderID, err)
+			}
+			return resp, fmt.Errorf("wait for cancel [%s]: order still %s", orderID, order.Status)
+		case <-ticker.C:
+		}
+	}
+}
+
 // GetOpenOrdersPaginated 获取未结订单 (分页)
 func (c *Client) GetOpenOrdersPaginated(ctx context.Context, params OpenOrderParams, nextCursor string) (*OpenOrdersResponse, error) {
 	if c.apiCreds == nil {
@@ -614,21 +938,7 @@ func (c *Client) GetOpenOrdersPaginated(ctx context.Context, params OpenOrderPar
 
 // GetOpenOrders 获取所有未结订单 (自动分页)
 func (c *Client) GetOpenOrders(ctx context.Context, params OpenOrderParams) ([]OpenOrder, error) {
-	var results []OpenOrder
-	nextCursor := InitialCursor
-
-	for nextCursor != EndCursor {
-		resp, err := c.GetOpenOrdersPaginated(ctx, params, nextCursor)
-		if err != nil {
-			return nil, err
-		}
-		results = append(results, resp.Data...)
-		nextCursor = resp.NextCursor
-		if nextCursor == "" {
-			break
-		}
-	}
-	return results, nil
+	return c.OpenOrdersIterator(params).All(ctx)
 }
 
 // GetOrder 获取单个订单
@@ -644,6 +954,35 @@ func (c *Client) GetOrder(ctx context.Context, orderID string) (*OpenOrder, erro
 	return &order, nil
 }
 
+// GetOrderTrades 获取订单关联的成交记录（按 associate_trades 逐个查询，保持原顺序）
+func (c *Client) GetOrderTrades(ctx context.Context, orderID string) ([]Trade, error) {
+	order, err := c.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	trades := make([]Trade, 0, len(order.AssociateTrades))
+	seen := make(map[string]bool, len(order.AssociateTrades))
+	for _, tradeID := range order.AssociateTrades {
+		if tradeID == "" || seen[tradeID] {
+			continue
+		}
+		seen[tradeID] = true
+
+		resp, err := c.GetTradesFirstPage(ctx, TradeParams{ID: tradeID, Market: order.Market})
+		if err != nil {
+			return nil, fmt.Errorf("get trade %s: %w", tradeID, err)
+		}
+		for _, t := range resp.Data {
+			if t.ID == tradeID {
+				trades = append(trades, t)
+				break
+			}
+		}
+	}
+	return trades, nil
+}
+
 // GetTradesPaginated 获取交易记录 (分页)
 func (c *Client) GetTradesPaginated(ctx context.Context, params TradeParams, nextCursor string) (*TradesResponse, error) {
 	if c.apiCreds == nil {
@@ -682,21 +1021,34 @@ func (c *Client) GetTradesPaginated(ctx context.Context, params TradeParams, nex
 
 // GetTrades 获取所有交易记录 (自动分页)
 func (c *Client) GetTrades(ctx context.Context, params TradeParams) ([]Trade, error) {
-	var results []Trade
-	nextCursor := InitialCursor
+	return c.TradesIterator(params).All(ctx)
+}
 
-	for nextCursor != EndCursor {
-		resp, err := c.GetTradesPaginated(ctx, params, nextCursor)
-		if err != nil {
-			return nil, err
-		}
-		results = append(results, resp.Data...)
-		nextCursor = resp.NextCursor
-		if nextCursor == "" {
-			break
-		}
+// FetchUserState 获取 since 之后的成交及当前未结订单，转换为 WebSocket 推送格式
+// 可直接作为 wss.Connection.SetReconciler 的参数，用于用户频道重连后补齐断线期间的成交
+func (c *Client) FetchUserState(ctx context.Context, since time.Time) ([]*common.TradeNotification, []*common.OrderUpdate, error) {
+	params := TradeParams{}
+	if !since.IsZero() {
+		params.After = strconv.FormatInt(since.Unix(), 10)
 	}
-	return results, nil
+	trades, err := c.GetTrades(ctx, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get trades: %w", err)
+	}
+	orders, err := c.GetOpenOrders(ctx, OpenOrderParams{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get open orders: %w", err)
+	}
+
+	notifications := make([]*common.TradeNotification, 0, len(trades))
+	for _, t := range trades {
+		notifications = append(notifications, t.ToNotification())
+	}
+	updates := make([]*common.OrderUpdate, 0, len(orders))
+	for _, o := range orders {
+		updates = append(updates, o.ToOrderUpdate())
+	}
+	return notifications, updates, nil
 }
 
 // GetTradesFirstPage 只获取第一页交易记录
@@ -722,6 +1074,53 @@ func (c *Client) GetBalanceAllowance(ctx context.Context, params BalanceAllowanc
 	return &resp, nil
 }
 
+// balanceAllowanceConcurrency 批量查询余额授权的最大并发数（服务端无批量接口）
+const balanceAllowanceConcurrency = 4
+
+// GetBalanceAllowances 批量获取多个 token 的余额和授权，按 token ID 索引
+// 服务端没有批量接口，内部以有限并发逐个调用 GetBalanceAllowance；重复的 token ID 只查询一次
+// 部分失败时返回成功的结果，同时返回合并后的错误（每个错误包含对应 token ID）
+func (c *Client) GetBalanceAllowances(ctx context.Context, assetType AssetType, tokenIDs []string) (map[string]*BalanceAllowanceResponse, error) {
+	if c.apiCreds == nil {
+		return nil, fmt.Errorf("API credentials not set")
+	}
+
+	unique := make([]string, 0, len(tokenIDs))
+	seen := make(map[string]bool, len(tokenIDs))
+	for _, id := range tokenIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	result := make(map[string]*BalanceAllowanceResponse, len(unique))
+	sem := make(chan struct{}, balanceAllowanceConcurrency)
+	for _, id := range unique {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(tokenID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := c.GetBalanceAllowance(ctx, BalanceAllowanceParams{AssetType: assetType, TokenID: tokenID})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("token %s: %w", tokenID, err))
+				return
+			}
+			result[tokenID] = resp
+		}(id)
+	}
+	wg.Wait()
+	return result, errors.Join(errs...)
+}
+
 // GetNotifications 获取通知
 func (c *Client) GetNotifications(ctx context.Context) ([]Notification, error) {
 	if c.apiCreds == nil {
@@ -800,41 +1199,90 @@ func (c *Client) UpdateBalanceAllowance(ctx context.Context, params BalanceAllow
 }
 
 // CreateAndPostOrder 创建并提交订单
+// 下单前通过 ResolveOrderOptions 补全 tick size 和 neg risk；
+// opts.AutoReduce 为 true 时，余额不足被拒后会按可负担的最大数量重试一次
 func (c *Client) CreateAndPostOrder(ctx context.Context, userOrder UserOrder, opts CreateOrderOptions, orderType OrderType) (*OrderResponse, error) {
+	opts, err := c.ResolveOrderOptions(ctx, userOrder.TokenID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("resolve order options: %w", err)
+	}
 	order, err := c.CreateOrder(userOrder, opts)
 	if err != nil {
 		return nil, fmt.Errorf("create order: %w", err)
 	}
-	return c.PostOrder(ctx, order, orderType)
+	resp, err := c.postOrder(ctx, order, orderType, opts.DeferExec)
+	if !opts.AutoReduce || !isInsufficientBalance(resp, err) {
+		return resp, err
+	}
+
+	size, balErr := c.affordableSize(ctx, userOrder, opts.TickSize)
+	if balErr != nil || size <= 0 || size >= userOrder.Size {
+		return resp, err
+	}
+	userOrder.Size = size
+	order, cerr := c.CreateOrder(userOrder, opts)
+	if cerr != nil {
+		return nil, fmt.Errorf("create reduced order: %w", cerr)
+	}
+	return c.postOrder(ctx, order, orderType, opts.DeferExec)
 }
 
-// CreateAndPostMarketOrder 创建并提交市价单
-func (c *Client) CreateAndPostMarketOrder(ctx context.Context, userMarketOrder UserMarketOrder, opts CreateOrderOptions, orderType OrderType) (*OrderResponse, error) {
-	order, err := c.CreateMarketOrder(userMarketOrder, opts)
+// affordableSize 按当前余额计算可下单的最大数量（买单按 USDC 余额/价格，卖单按 token 余额）
+func (c *Client) affordableSize(ctx context.Context, order UserOrder, tickSize TickSize) (float64, error) {
+	params := BalanceAllowanceParams{AssetType: AssetTypeCollateral}
+	if order.Side == SideSell {
+		params = BalanceAllowanceParams{AssetType: AssetTypeConditional, TokenID: order.TokenID}
+	}
+	resp, err := c.GetBalanceAllowance(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("create market order: %w", err)
+		return 0, err
 	}
-	return c.PostOrder(ctx, order, orderType)
+	raw, err := strconv.ParseFloat(resp.Balance, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse balance: %w", err)
+	}
+	balance := raw / 1e6
+
+	config, ok := roundingConfigs[tickSize]
+	if !ok {
+		config = roundingConfigs[TickSize001]
+	}
+	if order.Side == SideSell {
+		return roundDown(balance, config.Size), nil
+	}
+	if order.Price <= 0 {
+		return 0, fmt.Errorf("invalid price: %v", order.Price)
+	}
+	return roundDown(balance/order.Price, config.Size), nil
 }
 
-// CalculateMarketPrice 计算市价单价格
-func (c *Client) CalculateMarketPrice(ctx context.Context, tokenID string, side Side, amount float64, orderType OrderType) (float64, error) {
-	book, err := c.GetOrderBook(ctx, tokenID)
+// isInsufficientBalance 是否因余额/授权不足被拒
+func isInsufficientBalance(resp *OrderResponse, err error) bool {
 	if err != nil {
-		return 0, fmt.Errorf("get order book: %w", err)
+		return IsInsufficientBalance(err)
 	}
+	return resp != nil && !resp.Success && isInsufficientBalanceMsg(resp.ErrorMsg)
+}
 
-	if side == SideBuy {
-		if len(book.Asks) == 0 {
-			return 0, fmt.Errorf("no asks in orderbook")
-		}
-		return calculateBuyMarketPrice(book.Asks, amount, orderType)
+// CreateAndPostMarketOrder 创建并提交市价单，下单前通过 ResolveOrderOptions 补全 tick size 和 neg risk
+func (c *Client) CreateAndPostMarketOrder(ctx context.Context, userMarketOrder UserMarketOrder, opts CreateOrderOptions, orderType OrderType) (*OrderResponse, error) {
+	opts, err := c.ResolveOrderOptions(ctx, userMarketOrder.TokenID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("resolve order options: %w", err)
 	}
-
-	if len(book.Bids) == 0 {
-		return 0, fmt.Errorf("no bids in orderbook")
+	order, err := c.CreateMarketOrder(userMarketOrder, opts)
+	if err != nil {
+		return nil, fmt.Errorf("create market order: %w", err)
 	}
-	return calculateSellMarketPrice(book.Bids, amount, orderType)
+	return c.postOrder(ctx, order, orderType, opts.DeferExec)
+}
+
+// CalculateMarketPrice 计算市价单价格（与官方 SDK 一致）
+// 返回累计成交达到 amount 时所在档位的价格，即最差成交价，用作市价单的限价；
+// 不反映可成交数量，需要时使用 CalculateMarketPriceDetail 或 CalculateMarketFill
+func (c *Client) CalculateMarketPrice(ctx context.Context, tokenID string, side Side, amount float64, orderType OrderType) (float64, error) {
+	price, _, _, err := c.CalculateMarketPriceDetail(ctx, tokenID, side, amount, orderType)
+	return price, err
 }
 
 // ========== Rewards 方法 ==========
@@ -971,6 +1419,20 @@ func (c *Client) GetBuilderApiKeys(ctx context.Context) ([]BuilderApiKeyResponse
 	return resp, nil
 }
 
+// RevokeBuilderApiKey 撤销 Builder API Key
+// 撤销后该 key 仍会出现在 GetBuilderApiKeys 结果中，RevokedAt 为撤销时间
+func (c *Client) RevokeBuilderApiKey(ctx context.Context, key string) error {
+	if c.apiCreds == nil {
+		return fmt.Errorf("API credentials not set")
+	}
+	if key == "" {
+		return fmt.Errorf("builder api key is required")
+	}
+
+	body := map[string]string{"key": key}
+	return c.doDeleteWithL2Auth(ctx, "/auth/builder-api-key", body, nil)
+}
+
 // GetBuilderTrades 获取 Builder 交易
 func (c *Client) GetBuilderTrades(ctx context.Context, params TradeParams, nextCursor string, builderCreds *ApiKeyCreds) ([]BuilderTrade, string, int, int, error) {
 	if builderCreds == nil {
@@ -1004,54 +1466,6 @@ func (c *Client) GetBuilderTrades(ctx context.Context, params TradeParams, nextC
 	return resp.Data, resp.NextCursor, resp.Limit, resp.Count, nil
 }
 
-// ========== 辅助函数 ==========
-
-func calculateBuyMarketPrice(asks []OrderSummary, amountToMatch float64, orderType OrderType) (float64, error) {
-	if len(asks) == 0 {
-		return 0, fmt.Errorf("no match")
-	}
-
-	var sum float64
-	for i := len(asks) - 1; i >= 0; i-- {
-		p := asks[i]
-		price, _ := strconv.ParseFloat(p.Price, 64)
-		size, _ := strconv.ParseFloat(p.Size, 64)
-		sum += size * price
-		if sum >= amountToMatch {
-			return price, nil
-		}
-	}
-
-	if orderType == OrderTypeFOK {
-		return 0, fmt.Errorf("no match")
-	}
-	price, _ := strconv.ParseFloat(asks[0].Price, 64)
-	return price, nil
-}
-
-func calculateSellMarketPrice(bids []OrderSummary, amountToMatch float64, orderType OrderType) (float64, error) {
-	if len(bids) == 0 {
-		return 0, fmt.Errorf("no match")
-	}
-
-	var sum float64
-	for i := len(bids) - 1; i >= 0; i-- {
-		p := bids[i]
-		size, _ := strconv.ParseFloat(p.Size, 64)
-		sum += size
-		if sum >= amountToMatch {
-			price, _ := strconv.ParseFloat(p.Price, 64)
-			return price, nil
-		}
-	}
-
-	if orderType == OrderTypeFOK {
-		return 0, fmt.Errorf("no match")
-	}
-	price, _ := strconv.ParseFloat(bids[0].Price, 64)
-	return price, nil
-}
-
 // ========== HTTP 请求方法 ==========
 
 func (c *Client) doGet(ctx context.Context, path string, params url.Values, result interface{}) error {
@@ -1155,6 +1569,9 @@ func (c *Client) doDeleteWithL1Auth(ctx context.Context, path string, headers *L
 }
 
 func (c *Client) doPostWithL2Auth(ctx context.Context, path string, body interface{}, result interface{}) error {
+	if c.privateKey == nil {
+		return ErrNoPrivateKey
+	}
 	fullURL := c.baseURL + path
 
 	var bodyBytes []byte
@@ -1167,7 +1584,7 @@ func (c *Client) doPostWithL2Auth(ctx context.Context, path string, body interfa
 	}
 
 	// L2 认证使用 signer 的 EOA 地址，不是 funder
-	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "POST", path, bodyBytes)
+	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "POST", path, bodyBytes, c.authTimestamp(ctx))
 	if err != nil {
 		return fmt.Errorf("build l2 auth headers: %w", err)
 	}
@@ -1189,6 +1606,9 @@ func (c *Client) doPostWithL2Auth(ctx context.Context, path string, body interfa
 }
 
 func (c *Client) doGetWithL2Auth(ctx context.Context, path string, params url.Values, result interface{}) error {
+	if c.privateKey == nil {
+		return ErrNoPrivateKey
+	}
 	fullPath := path
 	if len(params) > 0 {
 		fullPath += "?" + params.Encode()
@@ -1196,7 +1616,7 @@ func (c *Client) doGetWithL2Auth(ctx context.Context, path string, params url.Va
 	fullURL := c.baseURL + fullPath
 
 	// L2 认证: 使用 signer 的 EOA 地址，签名时 path 不包含查询参数
-	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "GET", path, nil)
+	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "GET", path, nil, c.authTimestamp(ctx))
 	if err != nil {
 		return fmt.Errorf("build l2 auth headers: %w", err)
 	}
@@ -1217,6 +1637,9 @@ func (c *Client) doGetWithL2Auth(ctx context.Context, path string, params url.Va
 }
 
 func (c *Client) doDeleteWithL2Auth(ctx context.Context, path string, body interface{}, result interface{}) error {
+	if c.privateKey == nil {
+		return ErrNoPrivateKey
+	}
 	fullURL := c.baseURL + path
 
 	var bodyBytes []byte
@@ -1229,7 +1652,7 @@ func (c *Client) doDeleteWithL2Auth(ctx context.Context, path string, body inter
 	}
 
 	// L2 认证使用 signer 的 EOA 地址，不是 funder
-	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "DELETE", path, bodyBytes)
+	headers, err := buildL2AuthHeaders(c.address, c.apiCreds, "DELETE", path, bodyBytes, c.authTimestamp(ctx))
 	if err != nil {
 		return fmt.Errorf("build l2 auth headers: %w", err)
 	}
@@ -1257,7 +1680,7 @@ func (c *Client) doGetWithBuilderAuth(ctx context.Context, path string, params u
 	}
 	fullURL := c.baseURL + fullPath
 
-	headers, err := buildBuilderAuthHeaders(builderCreds, "GET", fullPath, nil)
+	headers, err := buildBuilderAuthHeaders(builderCreds, "GET", fullPath, nil, c.authTimestamp(ctx))
 	if err != nil {
 		return fmt.Errorf("build builder auth headers: %w", err)
 	}
@@ -1276,21 +1699,53 @@ func (c *Client) doGetWithBuilderAuth(ctx context.Context, path string, params u
 	return c.doRequest(req, result)
 }
 
+// doRequest 发送请求（每次发送前等待限流令牌），失败时按 retryable 规则以指数退避重试
+// 429 优先按 Retry-After 等待（不超过 MaxRetryAfter）
 func (c *Client) doRequest(req *http.Request, result interface{}) error {
-	httpClient := c.httpClient.Client
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("do request: %w", err)
-	}
-	defer resp.Body.Close()
+	var (
+		resp     *http.Response
+		respBody []byte
+		err      error
+		attempt  int
+	)
+	for {
+		if err := c.waitRate(req); err != nil {
+			return err
+		}
+		resp, respBody, err = c.send(req)
+		if attempt >= c.maxRetries || !retryable(req, resp, err) {
+			break
+		}
 
-	respBody, err := io.ReadAll(resp.Body)
+		wait := c.retryBackoff << attempt
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > MaxRetryAfter {
+				break
+			} else if retryAfter > 0 {
+				wait = retryAfter
+			}
+		}
+		attempt++
+		c.retries.Add(1)
+		c.httpClient.Logger().Warnf("retry %s %s (%d/%d) in %v: %s", req.Method, req.URL.Path, attempt, c.maxRetries, wait, retryReason(resp, err))
+		if err := sleepCtx(req.Context(), wait); err != nil {
+			return fmt.Errorf("retry backoff: %w", err)
+		}
+		if req, err = rewind(req); err != nil {
+			return err
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		if attempt > 0 {
+			return fmt.Errorf("%w (after %d retries)", err, attempt)
+		}
+		return err
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		apiErr := newAPIError(resp.StatusCode, respBody)
+		apiErr.Retries = attempt
+		return apiErr
 	}
 
 	if result != nil && len(respBody) > 0 {
@@ -1301,3 +1756,26 @@ func (c *Client) doRequest(req *http.Request, result interface{}) error {
 
 	return nil
 }
+
+// retryReason 重试原因（日志用）
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
+
+// send 发送请求并读取完整响应体
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.httpClient.Client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response: %w", err)
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,619 @@
+package clob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common/replay"
+)
+
+// testPrivateKey 测试用私钥（公开的示例密钥，不持有资产）
+const testPrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// newTestClient 创建指向 httptest 服务器的客户端：使用固定 L2 凭证，关闭自动校时，重试等待缩短到 1ms
+func newTestClient(t *testing.T, handler http.Handler, opts ...func(*ClientConfig)) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := ClientConfig{
+		BaseURL:          srv.URL,
+		PrivateKey:       testPrivateKey,
+		ApiCreds:         &ApiKeyCreds{ApiKey: "key", Secret: "c2VjcmV0", Passphrase: "pass"},
+		TimeSyncInterval: -1,
+		RetryBackoff:     time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// writeJSON 以 JSON 写出响应
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("encode response: %v", err)
+	}
+}
+
+// readJSON 解析请求体
+func readJSON(t *testing.T, r *http.Request, v any) {
+	t.Helper()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("decode body %s: %v", body, err)
+	}
+}
+
+func TestCancelAssetOrdersBatches(t *testing.T) {
+	const total = CancelBatchSize + 50
+	var (
+		mu      sync.Mutex
+		batches [][]string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /data/orders", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("asset_id"); got != "asset-1" {
+			t.Errorf("asset_id = %q, want asset-1", got)
+		}
+		// 分两页返回
+		start, end, next := 0, total/2, "page-2"
+		if r.URL.Query().Get("next_cursor") == "page-2" {
+			start, end, next = total/2, total, EndCursor
+		}
+		orders := make([]OpenOrder, 0, end-start)
+		for i := start; i < end; i++ {
+			orders = append(orders, OpenOrder{ID: fmt.Sprintf("order-%d", i), AssetID: "asset-1", Status: OrderStatusLive})
+		}
+		writeJSON(t, w, OpenOrdersResponse{Data: orders, NextCursor: next})
+	})
+	mux.HandleFunc("DELETE /orders", func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		readJSON(t, r, &ids)
+		mu.Lock()
+		batches = append(batches, ids)
+		mu.Unlock()
+		writeJSON(t, w, CancelOrdersResponse{Canceled: ids})
+	})
+	c := newTestClient(t, mux)
+
+	resp, err := c.CancelAssetOrders(t.Context(), "asset-1")
+	if err != nil {
+		t.Fatalf("CancelAssetOrders: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != CancelBatchSize || len(batches[1]) != total-CancelBatchSize {
+		t.Fatalf("batch sizes = %v, want [%d %d]", batchSizes(batches), CancelBatchSize, total-CancelBatchSize)
+	}
+	if len(resp.Canceled) != total {
+		t.Errorf("canceled = %d, want %d", len(resp.Canceled), total)
+	}
+}
+
+func TestCancelAssetOrdersRequiresAsset(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	if _, err := c.CancelAssetOrders(t.Context(), ""); err == nil {
+		t.Fatal("expected error for empty asset id")
+	}
+}
+
+func batchSizes(batches [][]string) []int {
+	sizes := make([]int, len(batches))
+	for i, b := range batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func TestPostOrderDeferExec(t *testing.T) {
+	var bodies []map[string]json.RawMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /order", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		readJSON(t, r, &body)
+		bodies = append(bodies, body)
+		writeJSON(t, w, OrderResponse{Success: true, OrderID: "0x1"})
+	})
+	c := newTestClient(t, mux)
+
+	order, err := c.CreateOrder(UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}, CreateOrderOptions{TickSize: TickSize001})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if _, err := c.PostOrder(t.Context(), order, OrderTypeGTC); err != nil {
+		t.Fatalf("PostOrder: %v", err)
+	}
+	if _, err := c.PostOrderDeferred(t.Context(), order, OrderTypeGTC); err != nil {
+		t.Fatalf("PostOrderDeferred: %v", err)
+	}
+
+	// deferExec 必须始终出现在请求体中
+	for i, want := range []string{"false", "true"} {
+		if got := string(bodies[i]["deferExec"]); got != want {
+			t.Errorf("request %d deferExec = %q, want %s", i, got, want)
+		}
+	}
+}
+
+func TestPostOrdersDeferExec(t *testing.T) {
+	var body []map[string]json.RawMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		readJSON(t, r, &body)
+		writeJSON(t, w, []OrderResponse{{Success: true}, {Success: true}})
+	})
+	c := newTestClient(t, mux)
+
+	order, err := c.CreateOrder(UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}, CreateOrderOptions{TickSize: TickSize001})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	_, err = c.PostOrders(t.Context(), []PostOrdersArgs{
+		{Order: *order, OrderType: OrderTypeGTC, DeferExec: true},
+		{Order: *order, OrderType: OrderTypeGTC},
+	})
+	if err != nil {
+		t.Fatalf("PostOrders: %v", err)
+	}
+	if len(body) != 2 || string(body[0]["deferExec"]) != "true" || string(body[1]["deferExec"]) != "false" {
+		t.Errorf("deferExec flags = %s, %s", body[0]["deferExec"], body[1]["deferExec"])
+	}
+}
+
+func TestRevokeBuilderApiKey(t *testing.T) {
+	revoked := map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /auth/builder-api-key", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("POLY_API_KEY") != "key" || r.Header.Get("POLY_SIGNATURE") == "" {
+			t.Errorf("missing L2 auth headers: %v", r.Header)
+		}
+		var body map[string]string
+		readJSON(t, r, &body)
+		revoked[body["key"]] = "2025-03-01T00:00:00Z"
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /auth/builder-api-key", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []BuilderApiKeyResponse{
+			{Key: "builder-1", CreatedAt: "2025-01-01T00:00:00Z", RevokedAt: revoked["builder-1"]},
+			{Key: "builder-2", CreatedAt: "2025-01-02T00:00:00Z", RevokedAt: revoked["builder-2"]},
+		})
+	})
+	c := newTestClient(t, mux)
+
+	if err := c.RevokeBuilderApiKey(t.Context(), "builder-1"); err != nil {
+		t.Fatalf("RevokeBuilderApiKey: %v", err)
+	}
+	keys, err := c.GetBuilderApiKeys(t.Context())
+	if err != nil {
+		t.Fatalf("GetBuilderApiKeys: %v", err)
+	}
+	if len(keys) != 2 || !keys[0].Revoked() || keys[1].Revoked() {
+		t.Errorf("keys = %+v, want builder-1 revoked only", keys)
+	}
+	if err := c.RevokeBuilderApiKey(t.Context(), ""); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+// marketPages 按游标分页的 /markets 测试服务器，pages[cursor] 为该页的 condition_id
+type marketPages struct {
+	mu    sync.Mutex
+	pages map[string][]string
+	next  map[string]string
+	seen  []string
+}
+
+func (p *marketPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cursor := r.URL.Query().Get("next_cursor")
+	p.mu.Lock()
+	p.seen = append(p.seen, cursor)
+	ids, ok := p.pages[cursor]
+	next := p.next[cursor]
+	p.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"bad cursor"}`, http.StatusBadRequest)
+		return
+	}
+	markets := make([]Market, len(ids))
+	for i, id := range ids {
+		markets[i] = Market{ConditionID: id}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MarketsResponse{Data: markets, NextCursor: next, Count: len(markets)})
+}
+
+func conditionIDs(markets []Market) []string {
+	ids := make([]string, len(markets))
+	for i, m := range markets {
+		ids[i] = m.ConditionID
+	}
+	return ids
+}
+
+func TestGetMarketsSinceResumesFromCursor(t *testing.T) {
+	pages := &marketPages{
+		pages: map[string][]string{InitialCursor: {"m1", "m2"}, "cur-2": {"m3", "m4"}, "cur-3": {"m5"}},
+		next:  map[string]string{InitialCursor: "cur-2", "cur-2": "cur-3", "cur-3": EndCursor},
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /markets", pages)
+	c := newTestClient(t, mux)
+
+	// 从中间游标续传：只拉取第 2、3 页，续传游标指向最后一页
+	markets, cursor, err := c.GetMarketsSince(t.Context(), "cur-2")
+	if err != nil {
+		t.Fatalf("GetMarketsSince: %v", err)
+	}
+	if got := fmt.Sprint(conditionIDs(markets)); got != "[m3 m4 m5]" {
+		t.Errorf("markets = %s, want [m3 m4 m5]", got)
+	}
+	if cursor != "cur-3" {
+		t.Errorf("cursor = %q, want cur-3", cursor)
+	}
+
+	// 最后一页新增市场后再次续传，只重拉最后一页
+	pages.mu.Lock()
+	pages.pages["cur-3"] = []string{"m5", "m6"}
+	pages.seen = nil
+	pages.mu.Unlock()
+	markets, cursor, err = c.GetMarketsSince(t.Context(), cursor)
+	if err != nil {
+		t.Fatalf("GetMarketsSince: %v", err)
+	}
+	if got := fmt.Sprint(conditionIDs(markets)); got != "[m5 m6]" {
+		t.Errorf("markets = %s, want [m5 m6]", got)
+	}
+	if cursor != "cur-3" || fmt.Sprint(pages.seen) != "[cur-3]" {
+		t.Errorf("cursor = %q, requested %v", cursor, pages.seen)
+	}
+}
+
+func TestGetMarketsSinceStartAndError(t *testing.T) {
+	pages := &marketPages{
+		pages: map[string][]string{InitialCursor: {"m1"}},
+		next:  map[string]string{InitialCursor: "cur-2"},
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /markets", pages)
+	c := newTestClient(t, mux)
+
+	// 空游标或 EndCursor 从头开始；中途失败时返回已拉取的市场和失败页的游标，便于重试
+	for _, start := range []string{"", EndCursor} {
+		markets, cursor, err := c.GetMarketsSince(t.Context(), start)
+		if err == nil {
+			t.Fatalf("start %q: expected error for unknown cursor", start)
+		}
+		if fmt.Sprint(conditionIDs(markets)) != "[m1]" || cursor != "cur-2" {
+			t.Errorf("start %q: markets = %v, cursor = %q", start, conditionIDs(markets), cursor)
+		}
+	}
+}
+
+func TestGetOrderBooksMapOutOfOrder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /books", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string][]string
+		readJSON(t, r, &body)
+		// 按请求的逆序返回
+		ids := body["token_ids"]
+		books := make([]OrderBookSummary, 0, len(ids))
+		for i := len(ids) - 1; i >= 0; i-- {
+			books = append(books, OrderBookSummary{AssetID: ids[i], Market: "market-" + ids[i]})
+		}
+		writeJSON(t, w, books)
+	})
+	c := newTestClient(t, mux)
+
+	books, err := c.GetOrderBooksMap(t.Context(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("GetOrderBooksMap: %v", err)
+	}
+	if len(books) != 3 {
+		t.Fatalf("books = %d, want 3", len(books))
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if book := books[id]; book == nil || book.AssetID != id || book.Market != "market-"+id {
+			t.Errorf("books[%s] = %+v", id, book)
+		}
+	}
+}
+
+func TestCancelAndWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		poll    func(n int, w http.ResponseWriter) // n 为第几次查询（从 1 开始）
+		wantErr bool
+		polls   int // 期望的查询次数，0 表示不检查
+	}{
+		{"disappears after polls", func(n int, w http.ResponseWriter) {
+			status := OrderStatusLive
+			if n > 2 {
+				status = OrderStatusCanceled
+			}
+			writeJSON(t, w, OpenOrder{ID: "o1", Status: status})
+		}, false, 3},
+		{"not found", func(n int, w http.ResponseWriter) {
+			if n == 1 {
+				writeJSON(t, w, OpenOrder{ID: "o1", Status: OrderStatusLive})
+				return
+			}
+			http.Error(w, `{"error":"order not found"}`, http.StatusNotFound)
+		}, false, 2},
+		{"unauthorized", func(n int, w http.ResponseWriter) {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		}, true, 1},
+		{"still live at timeout", func(n int, w http.ResponseWriter) {
+			writeJSON(t, w, OpenOrder{ID: "o1", Status: OrderStatusLive})
+		}, true, 0},
+		{"persistent server error", func(n int, w http.ResponseWriter) {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				polls int
+			)
+			mux := http.NewServeMux()
+			mux.HandleFunc("DELETE /order", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, CancelOrderResponse{OrderID: "o1", Status: "canceled"})
+			})
+			mux.HandleFunc("GET /data/order/o1", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				polls++
+				n := polls
+				mu.Unlock()
+				tt.poll(n, w)
+			})
+			c := newTestClient(t, mux, func(cfg *ClientConfig) { cfg.MaxRetries = -1 })
+
+			start := time.Now()
+			resp, err := c.CancelAndWait(t.Context(), "o1", time.Second/2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CancelAndWait err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resp == nil || resp.OrderID != "o1" {
+				t.Errorf("resp = %+v", resp)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.polls > 0 && polls != tt.polls {
+				t.Errorf("polls = %d, want %d", polls, tt.polls)
+			}
+			if tt.name == "unauthorized" && time.Since(start) > CancelPollInterval {
+				t.Errorf("permanent error took %v, want immediate return", time.Since(start))
+			}
+		})
+	}
+}
+
+// replayTokenID 录制 fixture 使用的 token（BTC $150k 2025 YES）
+const replayTokenID = "21742633143463906290569050155826241533067272736897614950488156847949938836455"
+
+// newReplayClient 创建回放 testdata/replay 的客户端（-tags record 时请求线上 API 并重新录制）
+func newReplayClient(t *testing.T) *Client {
+	t.Helper()
+	c, err := NewClient(ClientConfig{
+		PrivateKey:       testPrivateKey,
+		TimeSyncInterval: -1,
+		MaxRetries:       -1,
+		Transport:        replay.New("testdata/replay"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestGetOrderBookReplay(t *testing.T) {
+	c := newReplayClient(t)
+	book, err := c.GetOrderBook(t.Context(), replayTokenID)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if book.AssetID != replayTokenID || book.Market == "" || book.Hash == "" {
+		t.Errorf("book header = %+v", book)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		t.Fatalf("empty book: %d bids, %d asks", len(book.Bids), len(book.Asks))
+	}
+	switch TickSize(book.TickSize) {
+	case TickSize01, TickSize001, TickSize0001, TickSize00001:
+	default:
+		t.Errorf("tick size = %q", book.TickSize)
+	}
+
+	// 不存在的 token 返回 404
+	if _, err := c.GetOrderBook(t.Context(), "1"); !IsNotFound(err) {
+		t.Errorf("unknown token err = %v, want 404", err)
+	}
+}
+
+func TestGetOrderTrades(t *testing.T) {
+	trades := map[string]Trade{
+		"t1": {ID: "t1", Market: "m", Size: "10"},
+		"t2": {ID: "t2", Market: "m", Size: "5"},
+	}
+	var (
+		mu      sync.Mutex
+		queried []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /data/order/o1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, OpenOrder{ID: "o1", Market: "m", AssociateTrades: []string{"t2", "t1", "t2", ""}})
+	})
+	mux.HandleFunc("GET /data/trades", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if got := r.URL.Query().Get("market"); got != "m" {
+			t.Errorf("market = %q, want m", got)
+		}
+		mu.Lock()
+		queried = append(queried, id)
+		mu.Unlock()
+		// 响应中混入其他成交，只应取 id 匹配的一条
+		data := []Trade{{ID: "other", Market: "m"}}
+		if tr, ok := trades[id]; ok {
+			data = append(data, tr)
+		}
+		writeJSON(t, w, TradesResponse{Data: data, NextCursor: EndCursor})
+	})
+	c := newTestClient(t, mux)
+
+	got, err := c.GetOrderTrades(t.Context(), "o1")
+	if err != nil {
+		t.Fatalf("GetOrderTrades: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "t2" || got[1].ID != "t1" || got[1].Size != "10" {
+		t.Errorf("trades = %+v, want [t2 t1]", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queried) != 2 {
+		t.Errorf("queried = %v, want each trade once", queried)
+	}
+}
+
+func TestPublicClientWithoutPrivateKey(t *testing.T) {
+	authCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, OrderBookSummary{AssetID: r.URL.Query().Get("token_id")})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		authCalls++
+		http.NotFound(w, r)
+	})
+	c := newTestClient(t, mux, func(cfg *ClientConfig) { cfg.PrivateKey = "" })
+
+	if c.GetAddress() != "" {
+		t.Errorf("address = %q, want empty", c.GetAddress())
+	}
+	book, err := c.GetOrderBook(t.Context(), "tok")
+	if err != nil || book.AssetID != "tok" {
+		t.Fatalf("GetOrderBook = %+v, %v", book, err)
+	}
+
+	if _, err := c.GetOpenOrders(t.Context(), OpenOrderParams{}); !errors.Is(err, ErrNoPrivateKey) {
+		t.Errorf("GetOpenOrders err = %v, want ErrNoPrivateKey", err)
+	}
+	if _, err := c.DeriveApiKey(t.Context(), 0); !errors.Is(err, ErrNoPrivateKey) {
+		t.Errorf("DeriveApiKey err = %v, want ErrNoPrivateKey", err)
+	}
+	if _, err := c.CreateOrder(UserOrder{TokenID: "tok", Price: 0.5, Size: 10, Side: SideBuy}, CreateOrderOptions{TickSize: "0.01"}); !errors.Is(err, ErrNoPrivateKey) {
+		t.Errorf("CreateOrder err = %v, want ErrNoPrivateKey", err)
+	}
+	if authCalls != 0 {
+		t.Errorf("authenticated requests sent = %d, want 0", authCalls)
+	}
+}
+
+func TestGetBalanceAllowancesDedupAndPartialFailure(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /balance-allowance", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("asset_type") != string(AssetTypeConditional) {
+			t.Errorf("asset_type = %q", q.Get("asset_type"))
+		}
+		id := q.Get("token_id")
+		mu.Lock()
+		calls[id]++
+		mu.Unlock()
+		if id == "bad" {
+			http.Error(w, `{"error":"invalid token"}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(t, w, BalanceAllowanceResponse{Balance: "balance-" + id, Allowance: "1"})
+	})
+	c := newTestClient(t, mux)
+
+	got, err := c.GetBalanceAllowances(t.Context(), AssetTypeConditional, []string{"a", "b", "a", "bad", "b"})
+	if err == nil || !strings.Contains(err.Error(), "token bad") {
+		t.Errorf("err = %v, want error naming token bad", err)
+	}
+	if len(got) != 2 || got["a"].Balance != "balance-a" || got["b"].Balance != "balance-b" {
+		t.Errorf("result = %v, want entries for a and b", got)
+	}
+	if _, ok := got["bad"]; ok {
+		t.Error("failed token should not appear in result")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["a"] != 1 || calls["b"] != 1 || calls["bad"] != 1 {
+		t.Errorf("calls = %v, want each token queried once", calls)
+	}
+}
+
+func TestCancelOrdersByTokensBatchesAndMerges(t *testing.T) {
+	perToken := map[string]int{"t1": CancelBatchSize - 10, "t2": 30, "empty": 0}
+	var (
+		mu      sync.Mutex
+		batches [][]string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /data/orders", func(w http.ResponseWriter, r *http.Request) {
+		asset := r.URL.Query().Get("asset_id")
+		orders := []OpenOrder{}
+		for i := range perToken[asset] {
+			orders = append(orders, OpenOrder{ID: fmt.Sprintf("%s-%d", asset, i), AssetID: asset, Status: OrderStatusLive})
+		}
+		writeJSON(t, w, OpenOrdersResponse{Data: orders, NextCursor: EndCursor})
+	})
+	mux.HandleFunc("DELETE /orders", func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		readJSON(t, r, &ids)
+		mu.Lock()
+		batches = append(batches, ids)
+		mu.Unlock()
+		// 每批的第一个订单未能取消
+		writeJSON(t, w, CancelOrdersResponse{Canceled: ids[1:], NotCanceled: map[string]any{ids[0]: "already matched"}})
+	})
+	c := newTestClient(t, mux)
+
+	byToken, err := c.GetOpenOrdersByTokens(t.Context(), []string{"t1", "t2", "empty", "t1"})
+	if err != nil {
+		t.Fatalf("GetOpenOrdersByTokens: %v", err)
+	}
+	if len(byToken) != 3 || len(byToken["t1"]) != perToken["t1"] || len(byToken["t2"]) != 30 || byToken["empty"] == nil {
+		t.Errorf("byToken sizes = t1:%d t2:%d empty:%v", len(byToken["t1"]), len(byToken["t2"]), byToken["empty"])
+	}
+
+	resp, err := c.CancelOrdersByTokens(t.Context(), []string{"t1", "t2", "empty"})
+	if err != nil {
+		t.Fatalf("CancelOrdersByTokens: %v", err)
+	}
+	total := perToken["t1"] + perToken["t2"]
+	if len(batches) != 2 || len(batches[0]) != CancelBatchSize || len(batches[1]) != total-CancelBatchSize {
+		t.Fatalf("batch sizes = %v, want [%d %d]", batchSizes(batches), CancelBatchSize, total-CancelBatchSize)
+	}
+	if len(resp.Canceled) != total-2 || len(resp.NotCanceled) != 2 {
+		t.Errorf("canceled = %d, not canceled = %d, want %d and 2", len(resp.Canceled), len(resp.NotCanceled), total-2)
+	}
+	for _, b := range batches {
+		if resp.NotCanceled[b[0]] != "already matched" {
+			t.Errorf("not_canceled missing %s: %v", b[0], resp.NotCanceled)
+		}
+	}
+}
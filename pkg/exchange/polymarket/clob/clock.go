@@ -0,0 +1,76 @@
+package clob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeSyncInterval 服务器时间自动校准的默认间隔
+const DefaultTimeSyncInterval = 10 * time.Minute
+
+// timeSyncRetryDelay 自动校准失败后的重试间隔，避免每个请求都额外请求 /time
+const timeSyncRetryDelay = 30 * time.Second
+
+// clockSync 本地时钟与服务器时间的偏差，用于修正认证签名的时间戳
+type clockSync struct {
+	interval time.Duration // 自动校准间隔，< 0 表示不自动校准
+	offset   atomic.Int64  // 服务器时间 - 本地时间 (ns)
+	syncedAt atomic.Int64  // 上次校准的本地时间 (UnixNano)，0 表示未校准
+	failedAt atomic.Int64  // 上次自动校准失败的本地时间 (UnixNano)
+	mu       sync.Mutex    // 防止并发请求同时校准
+}
+
+// SyncTime 调用 GetServerTime 校准本地时钟，返回服务器时间相对本地时间的偏差
+// 之后的 L1/L2/Builder 认证签名均使用校准后的时间戳
+func (c *Client) SyncTime(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	serverTime, err := c.GetServerTime(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get server time: %w", err)
+	}
+	end := time.Now()
+
+	// 以请求往返的中点作为服务器时间对应的本地时刻；服务器时间精度为秒，取该秒的中点
+	local := start.Add(end.Sub(start) / 2)
+	offset := time.Unix(serverTime, 0).Add(time.Second / 2).Sub(local)
+	c.clock.offset.Store(int64(offset))
+	c.clock.syncedAt.Store(end.UnixNano())
+	c.clock.failedAt.Store(0)
+	return offset, nil
+}
+
+// ClockOffset 当前使用的服务器时间偏差（未校准时为 0）
+func (c *Client) ClockOffset() time.Duration {
+	return time.Duration(c.clock.offset.Load())
+}
+
+// now 校准后的当前时间
+func (c *Client) now() time.Time {
+	return time.Now().Add(c.ClockOffset())
+}
+
+// authTimestamp 认证签名使用的 Unix 时间戳（秒），按需自动校准
+// 校准失败时沿用上次的偏差（或本地时间），不影响请求本身
+func (c *Client) authTimestamp(ctx context.Context) int64 {
+	if c.clock.interval >= 0 && c.timeSyncDue() && c.clock.mu.TryLock() {
+		if c.timeSyncDue() {
+			if _, err := c.SyncTime(ctx); err != nil {
+				c.clock.failedAt.Store(time.Now().UnixNano())
+			}
+		}
+		c.clock.mu.Unlock()
+	}
+	return c.now().Unix()
+}
+
+// timeSyncDue 是否需要重新校准
+func (c *Client) timeSyncDue() bool {
+	if failedAt := c.clock.failedAt.Load(); failedAt != 0 && time.Since(time.Unix(0, failedAt)) < timeSyncRetryDelay {
+		return false
+	}
+	syncedAt := c.clock.syncedAt.Load()
+	return syncedAt == 0 || time.Since(time.Unix(0, syncedAt)) >= c.clock.interval
+}
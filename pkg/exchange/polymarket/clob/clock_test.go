@@ -0,0 +1,191 @@
+package clob
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// timeServer 测试用 CLOB 服务：/time 返回本地时间加 offset（fail 为 true 时返回 500），
+// 认证接口记录请求头中的签名时间戳
+type timeServer struct {
+	offset    time.Duration
+	fail      atomic.Bool
+	timeCalls atomic.Int32
+
+	mu         sync.Mutex
+	timestamps map[string]string // 认证头名称 -> 最近一次的时间戳
+}
+
+func (s *timeServer) handler(t *testing.T) http.Handler {
+	s.timestamps = make(map[string]string)
+	record := func(header string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			s.timestamps[header] = r.Header.Get(header)
+			s.mu.Unlock()
+			switch r.URL.Path {
+			case "/data/orders":
+				writeJSON(t, w, OpenOrdersResponse{NextCursor: EndCursor})
+			case "/builder/trades":
+				writeJSON(t, w, map[string]any{"data": []any{}, "next_cursor": EndCursor})
+			default:
+				writeJSON(t, w, ApiKeyCreds{ApiKey: "key"})
+			}
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /time", func(w http.ResponseWriter, r *http.Request) {
+		s.timeCalls.Add(1)
+		if s.fail.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(t, w, time.Now().Add(s.offset).Unix())
+	})
+	mux.HandleFunc("GET /auth/derive-api-key", record("POLY_TIMESTAMP"))
+	mux.HandleFunc("GET /data/orders", record("POLY_TIMESTAMP"))
+	mux.HandleFunc("GET /builder/trades", record("POLY_BUILDER_TIMESTAMP"))
+	return mux
+}
+
+// timestamp 最近一次记录的时间戳
+func (s *timeServer) timestamp(t *testing.T, header string) time.Time {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, err := strconv.ParseInt(s.timestamps[header], 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q: %v", header, s.timestamps[header], err)
+	}
+	return time.Unix(ts, 0)
+}
+
+// assertNear 断言 got 与 want 相差不超过 2 秒（服务器时间精度为秒）
+func assertNear(t *testing.T, what string, got, want time.Time) {
+	t.Helper()
+	if d := got.Sub(want); d < -2*time.Second || d > 2*time.Second {
+		t.Errorf("%s = %v, want about %v (diff %v)", what, got, want, d)
+	}
+}
+
+func TestSyncTime(t *testing.T) {
+	for _, offset := range []time.Duration{0, 90 * time.Second, -45 * time.Minute} {
+		srv := &timeServer{offset: offset}
+		c := newTestClient(t, srv.handler(t))
+
+		got, err := c.SyncTime(t.Context())
+		if err != nil {
+			t.Fatalf("offset %v: SyncTime: %v", offset, err)
+		}
+		// 服务器时间精度为秒，校准误差不超过 1 秒
+		if d := got - offset; d < -time.Second || d > time.Second {
+			t.Errorf("SyncTime = %v, want about %v", got, offset)
+		}
+		if c.ClockOffset() != got {
+			t.Errorf("ClockOffset = %v, want %v", c.ClockOffset(), got)
+		}
+		assertNear(t, "now", c.now(), time.Now().Add(offset))
+		if n := srv.timeCalls.Load(); n != 1 {
+			t.Errorf("/time calls = %d, want 1", n)
+		}
+	}
+
+	// 响应无法解析时返回错误且不修改偏差
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /time", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`"soon"`)) })
+	c := newTestClient(t, mux)
+	if _, err := c.SyncTime(t.Context()); err == nil {
+		t.Error("SyncTime with malformed /time should fail")
+	}
+	if c.ClockOffset() != 0 {
+		t.Errorf("offset after malformed response = %v, want 0", c.ClockOffset())
+	}
+}
+
+func TestAuthHeadersUseServerTimeOffset(t *testing.T) {
+	srv := &timeServer{offset: time.Hour}
+	c := newTestClient(t, srv.handler(t), func(cfg *ClientConfig) { cfg.TimeSyncInterval = 0 })
+
+	// L1：首次认证请求前自动校准
+	if _, err := c.DeriveApiKey(t.Context(), 0); err != nil {
+		t.Fatalf("DeriveApiKey: %v", err)
+	}
+	assertNear(t, "L1 timestamp", srv.timestamp(t, "POLY_TIMESTAMP"), time.Now().Add(time.Hour))
+
+	// L2
+	if _, err := c.GetOpenOrders(t.Context(), OpenOrderParams{}); err != nil {
+		t.Fatalf("GetOpenOrders: %v", err)
+	}
+	assertNear(t, "L2 timestamp", srv.timestamp(t, "POLY_TIMESTAMP"), time.Now().Add(time.Hour))
+
+	// Builder
+	builder := &ApiKeyCreds{ApiKey: "builder", Secret: "c2VjcmV0", Passphrase: "pass"}
+	if _, _, _, _, err := c.GetBuilderTrades(t.Context(), TradeParams{}, "", builder); err != nil {
+		t.Fatalf("GetBuilderTrades: %v", err)
+	}
+	assertNear(t, "builder timestamp", srv.timestamp(t, "POLY_BUILDER_TIMESTAMP"), time.Now().Add(time.Hour))
+
+	// 校准间隔内不重复请求 /time
+	if n := srv.timeCalls.Load(); n != 1 {
+		t.Errorf("/time calls = %d, want 1", n)
+	}
+}
+
+func TestFailedTimeSyncKeepsOffset(t *testing.T) {
+	srv := &timeServer{offset: -time.Hour}
+	c := newTestClient(t, srv.handler(t), func(cfg *ClientConfig) {
+		cfg.TimeSyncInterval = time.Hour
+		cfg.MaxRetries = -1
+	})
+	if _, err := c.SyncTime(t.Context()); err != nil {
+		t.Fatalf("SyncTime: %v", err)
+	}
+	before := c.ClockOffset()
+
+	srv.fail.Store(true)
+	if _, err := c.SyncTime(t.Context()); err == nil {
+		t.Fatal("SyncTime should fail when /time errors")
+	}
+	if got := c.ClockOffset(); got != before {
+		t.Errorf("offset after failed sync = %v, want %v", got, before)
+	}
+
+	// 到期自动校准失败时请求照常发出，仍使用上次的偏差
+	c.clock.syncedAt.Store(0)
+	calls := srv.timeCalls.Load()
+	if _, err := c.GetOpenOrders(t.Context(), OpenOrderParams{}); err != nil {
+		t.Fatalf("GetOpenOrders: %v", err)
+	}
+	if srv.timeCalls.Load() != calls+1 {
+		t.Errorf("/time calls = %d, want one auto sync attempt", srv.timeCalls.Load()-calls)
+	}
+	assertNear(t, "L2 timestamp", srv.timestamp(t, "POLY_TIMESTAMP"), time.Now().Add(-time.Hour))
+
+	// 失败后在重试间隔内不再请求 /time
+	if _, err := c.GetOpenOrders(t.Context(), OpenOrderParams{}); err != nil {
+		t.Fatalf("GetOpenOrders: %v", err)
+	}
+	if srv.timeCalls.Load() != calls+1 {
+		t.Errorf("/time retried within %v", timeSyncRetryDelay)
+	}
+}
+
+func TestNegativeTimeSyncIntervalDisablesSync(t *testing.T) {
+	srv := &timeServer{offset: time.Hour}
+	c := newTestClient(t, srv.handler(t), func(cfg *ClientConfig) { cfg.TimeSyncInterval = -1 })
+
+	if _, err := c.GetOpenOrders(t.Context(), OpenOrderParams{}); err != nil {
+		t.Fatalf("GetOpenOrders: %v", err)
+	}
+	if n := srv.timeCalls.Load(); n != 0 {
+		t.Errorf("/time calls = %d, want 0", n)
+	}
+	assertNear(t, "L2 timestamp", srv.timestamp(t, "POLY_TIMESTAMP"), time.Now())
+	if c.ClockOffset() != 0 {
+		t.Errorf("offset = %v, want 0", c.ClockOffset())
+	}
+}
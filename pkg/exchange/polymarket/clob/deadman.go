@@ -0,0 +1,128 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDeadMansTimeout 死手开关默认心跳超时
+const DefaultDeadMansTimeout = 30 * time.Second
+
+// ErrHeartbeatMissed 超时未收到心跳
+var ErrHeartbeatMissed = errors.New("dead man's switch: heartbeat missed")
+
+// CancelAllFunc 撤销全部挂单（如 (*Client).CancelAll）
+type CancelAllFunc func(ctx context.Context) (*CancelOrdersResponse, error)
+
+// DeadMansSwitchConfig 死手开关配置
+type DeadMansSwitchConfig struct {
+	Timeout       time.Duration                                             // 心跳超时：超过该时间未调用 Reset 即撤单 (默认 30s)
+	CheckInterval time.Duration                                             // 检查间隔 (默认 Timeout/4，最短 100ms)
+	HealthCheck   func(ctx context.Context) error                           // 额外健康检查（可选），返回错误即撤单
+	OnTrigger     func(reason error, resp *CancelOrdersResponse, err error) // 触发撤单后的回调（可选），err 为撤单错误
+	Now           func() time.Time                                          // 时钟 (默认 time.Now)
+}
+
+// DeadMansSwitch 死手开关：进程在 Timeout 内未调用 Reset（或健康检查失败）时撤销全部挂单
+// 用于行情/WebSocket 断开后保护挂单不被以过期价格成交；触发后不再重复撤单，直到下一次 Reset
+// 撤单失败时保持未触发状态，下一次检查时重试
+type DeadMansSwitch struct {
+	cancelAll CancelAllFunc
+	config    DeadMansSwitchConfig
+
+	mu        sync.Mutex
+	lastBeat  time.Time
+	triggered bool
+}
+
+// NewDeadMansSwitch 创建死手开关，创建时即视为收到一次心跳
+func NewDeadMansSwitch(cancelAll CancelAllFunc, cfg DeadMansSwitchConfig) *DeadMansSwitch {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultDeadMansTimeout
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = max(cfg.Timeout/4, 100*time.Millisecond)
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &DeadMansSwitch{
+		cancelAll: cancelAll,
+		config:    cfg,
+		lastBeat:  cfg.Now(),
+	}
+}
+
+// Reset 心跳：记录存活时间并重新启用开关
+func (d *DeadMansSwitch) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastBeat = d.config.Now()
+	d.triggered = false
+}
+
+// Triggered 自上次 Reset 以来是否已撤单
+func (d *DeadMansSwitch) Triggered() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.triggered
+}
+
+// Check 执行一次检查：心跳超时或健康检查失败时撤销全部挂单
+// 返回本次是否触发撤单，以及撤单错误
+func (d *DeadMansSwitch) Check(ctx context.Context) (bool, error) {
+	d.mu.Lock()
+	if d.triggered {
+		d.mu.Unlock()
+		return false, nil
+	}
+	lastBeat := d.lastBeat
+	d.mu.Unlock()
+
+	var reason error
+	if elapsed := d.config.Now().Sub(lastBeat); elapsed > d.config.Timeout {
+		reason = fmt.Errorf("%w: last heartbeat %v ago", ErrHeartbeatMissed, elapsed.Truncate(time.Millisecond))
+	} else if d.config.HealthCheck != nil {
+		if err := d.config.HealthCheck(ctx); err != nil {
+			reason = fmt.Errorf("health check: %w", err)
+		}
+	}
+	if reason == nil {
+		return false, nil
+	}
+
+	resp, err := d.cancelAll(ctx)
+	if err != nil {
+		err = fmt.Errorf("cancel all: %w", err)
+	} else {
+		d.mu.Lock()
+		// 撤单期间收到心跳则不标记，避免吞掉新的 Reset
+		if !d.lastBeat.After(lastBeat) {
+			d.triggered = true
+		}
+		d.mu.Unlock()
+	}
+	if d.config.OnTrigger != nil {
+		d.config.OnTrigger(reason, resp, err)
+	}
+	return true, err
+}
+
+// Run 按 CheckInterval 定期检查，直到 ctx 取消（取消时不会撤单）
+// 启动时视为收到一次心跳
+func (d *DeadMansSwitch) Run(ctx context.Context) {
+	d.Reset()
+	ticker := time.NewTicker(d.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的测试时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeCancelAll 记录撤单次数，fail 不为空时返回该错误
+type fakeCancelAll struct {
+	calls int
+	fail  error
+}
+
+func (f *fakeCancelAll) cancel(ctx context.Context) (*CancelOrdersResponse, error) {
+	f.calls++
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	return &CancelOrdersResponse{Canceled: []string{"o1"}}, nil
+}
+
+func TestDeadMansSwitchFiresOnceUntilReset(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cancel := &fakeCancelAll{}
+	var reasons []error
+	d := NewDeadMansSwitch(cancel.cancel, DeadMansSwitchConfig{
+		Timeout:   10 * time.Second,
+		Now:       clock.Now,
+		OnTrigger: func(reason error, resp *CancelOrdersResponse, err error) { reasons = append(reasons, reason) },
+	})
+
+	// 未超时
+	clock.Advance(10 * time.Second)
+	if fired, err := d.Check(t.Context()); fired || err != nil || cancel.calls != 0 {
+		t.Fatalf("within timeout: fired = %v, err = %v, calls = %d", fired, err, cancel.calls)
+	}
+
+	// 超时撤单一次
+	clock.Advance(time.Second)
+	if fired, err := d.Check(t.Context()); !fired || err != nil {
+		t.Fatalf("missed heartbeat: fired = %v, err = %v", fired, err)
+	}
+	if cancel.calls != 1 || !d.Triggered() {
+		t.Fatalf("calls = %d, triggered = %v", cancel.calls, d.Triggered())
+	}
+	if len(reasons) != 1 || !errors.Is(reasons[0], ErrHeartbeatMissed) {
+		t.Errorf("reasons = %v, want ErrHeartbeatMissed", reasons)
+	}
+
+	// 触发后保持不再撤单，直到 Reset
+	clock.Advance(time.Minute)
+	if fired, _ := d.Check(t.Context()); fired || cancel.calls != 1 {
+		t.Errorf("after trigger: fired = %v, calls = %d", fired, cancel.calls)
+	}
+
+	d.Reset()
+	if d.Triggered() {
+		t.Error("Reset should re-arm the switch")
+	}
+	clock.Advance(5 * time.Second)
+	if fired, _ := d.Check(t.Context()); fired {
+		t.Error("fired right after Reset")
+	}
+	clock.Advance(6 * time.Second)
+	if fired, _ := d.Check(t.Context()); !fired || cancel.calls != 2 {
+		t.Errorf("after re-arm: fired = %v, calls = %d, want second cancel", fired, cancel.calls)
+	}
+}
+
+func TestDeadMansSwitchRetriesFailedCancel(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cancel := &fakeCancelAll{fail: errors.New("network down")}
+	d := NewDeadMansSwitch(cancel.cancel, DeadMansSwitchConfig{Timeout: time.Second, Now: clock.Now})
+
+	clock.Advance(2 * time.Second)
+	if fired, err := d.Check(t.Context()); !fired || err == nil {
+		t.Fatalf("failed cancel: fired = %v, err = %v", fired, err)
+	}
+	if d.Triggered() {
+		t.Error("failed cancel should leave the switch armed")
+	}
+
+	// 下一次检查重试，成功后不再撤单
+	cancel.fail = nil
+	if fired, err := d.Check(t.Context()); !fired || err != nil {
+		t.Fatalf("retry: fired = %v, err = %v", fired, err)
+	}
+	if fired, _ := d.Check(t.Context()); fired || cancel.calls != 2 {
+		t.Errorf("after successful retry: fired = %v, calls = %d, want 2", fired, cancel.calls)
+	}
+}
+
+func TestDeadMansSwitchHealthCheck(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cancel := &fakeCancelAll{}
+	healthy := true
+	d := NewDeadMansSwitch(cancel.cancel, DeadMansSwitchConfig{
+		Timeout: time.Minute,
+		Now:     clock.Now,
+		HealthCheck: func(ctx context.Context) error {
+			if healthy {
+				return nil
+			}
+			return errors.New("feed stale")
+		},
+	})
+
+	if fired, _ := d.Check(t.Context()); fired {
+		t.Fatal("fired while healthy")
+	}
+	healthy = false
+	if fired, err := d.Check(t.Context()); !fired || err != nil || cancel.calls != 1 {
+		t.Errorf("unhealthy: fired = %v, err = %v, calls = %d", fired, err, cancel.calls)
+	}
+}
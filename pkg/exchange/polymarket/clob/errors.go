@@ -0,0 +1,83 @@
+package clob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrNoPrivateKey 客户端未配置私钥，无法调用认证接口或签名订单
+var ErrNoPrivateKey = errors.New("clob: private key not configured")
+
+// APIError CLOB API 错误响应
+type APIError struct {
+	StatusCode int
+	ErrorMsg   string // 响应体中的 error 字段
+	Code       string // 响应体中的 code 字段（若有）
+	Raw        []byte
+	Retries    int // 返回该错误前的重试次数
+}
+
+// Error 实现 error 接口，格式与原 "HTTP %d: %s" 保持一致
+func (e *APIError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, string(e.Raw))
+}
+
+// newAPIError 从错误响应解析 APIError（响应体非 JSON 时 ErrorMsg 为原始内容）
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Raw: body}
+	var parsed struct {
+		Error   string      `json:"error"`
+		Message string      `json:"message"`
+		Code    interface{} `json:"code"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.ErrorMsg = parsed.Error
+		if apiErr.ErrorMsg == "" {
+			apiErr.ErrorMsg = parsed.Message
+		}
+		if parsed.Code != nil {
+			apiErr.Code = fmt.Sprint(parsed.Code)
+		}
+	} else {
+		apiErr.ErrorMsg = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// IsRateLimited 是否为限流错误 (HTTP 429)
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsNotFound 是否为资源不存在错误 (HTTP 404)
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// isTransient 是否为可稍后重试的错误：网络错误、限流或服务端错误（其余 4xx 重试也不会成功）
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+// IsInsufficientBalance 是否为余额/授权不足错误
+func IsInsufficientBalance(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return isInsufficientBalanceMsg(apiErr.ErrorMsg)
+}
+
+func isInsufficientBalanceMsg(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "not enough balance") || strings.Contains(msg, "insufficient balance")
+}
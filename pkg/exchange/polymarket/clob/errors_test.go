@@ -0,0 +1,91 @@
+package clob
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantMsg  string
+		wantCode string
+	}{
+		{"error field", 400, `{"error":"invalid signature"}`, "invalid signature", ""},
+		{"message field", 400, `{"message":"bad request"}`, "bad request", ""},
+		{"numeric code", 400, `{"error":"x","code":1001}`, "x", "1001"},
+		{"string code", 400, `{"error":"x","code":"INVALID_ORDER"}`, "x", "INVALID_ORDER"},
+		{"plain text", 502, "bad gateway\n", "bad gateway", ""},
+	}
+	for _, tt := range tests {
+		err := newAPIError(tt.status, []byte(tt.body))
+		if err.StatusCode != tt.status || err.ErrorMsg != tt.wantMsg || err.Code != tt.wantCode {
+			t.Errorf("%s: got %+v, want msg %q code %q", tt.name, err, tt.wantMsg, tt.wantCode)
+		}
+		if want := fmt.Sprintf("HTTP %d: %s", tt.status, tt.body); err.Error() != want {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, err.Error(), want)
+		}
+	}
+}
+
+func TestAPIErrorFromJSONBody(t *testing.T) {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tick-size", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid token id"}`))
+	})
+	mux.HandleFunc("POST /order", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"not enough balance / allowance"}`))
+	})
+	c := newTestClient(t, mux)
+
+	_, err := c.GetTickSize(t.Context(), "123")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v (%T), want *APIError", err, err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorMsg != "invalid token id" || apiErr.Retries != 0 {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if IsRateLimited(err) || IsInsufficientBalance(err) || IsNotFound(err) {
+		t.Errorf("predicates matched a plain 400: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 (4xx not retried)", n)
+	}
+
+	order, err := c.CreateOrder(UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}, CreateOrderOptions{TickSize: TickSize001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PostOrder(t.Context(), order, OrderTypeGTC); !IsInsufficientBalance(err) {
+		t.Errorf("PostOrder err = %v, want insufficient balance", err)
+	}
+}
+
+func TestAPIErrorRateLimited(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"Too Many Requests"}`))
+	}))
+
+	_, err := c.GetMidpoint(t.Context(), "123")
+	if !IsRateLimited(err) {
+		t.Fatalf("err = %v, want rate limited", err)
+	}
+	var apiErr *APIError
+	errors.As(err, &apiErr)
+	if apiErr.Retries != 2 || calls.Load() != 3 {
+		t.Errorf("retries = %d, requests = %d, want 2 retries and 3 requests", apiErr.Retries, calls.Load())
+	}
+}
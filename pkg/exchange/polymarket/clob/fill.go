@@ -0,0 +1,100 @@
+package clob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MarketFill 按当前订单簿模拟市价单的成交结果
+type MarketFill struct {
+	WorstPrice   float64 // 最差成交价（吃到的最后一档价格，可作为市价单限价）
+	AvgPrice     float64 // 成交均价（按成交量加权）
+	FilledAmount float64 // 已成交金额，单位与请求的 amount 一致（买单为 USDC，卖单为份额）
+	FilledSize   float64 // 已成交份额
+	FilledValue  float64 // 已成交 USDC 金额
+	Complete     bool    // 订单簿深度是否足以完全成交
+}
+
+// CalculateMarketFill 按当前订单簿计算市价单的最差价、均价和可成交量
+// 买单 amount 为 USDC 金额，卖单 amount 为份额；FOK 订单深度不足时返回错误（同时返回部分成交结果），FAK 允许部分成交
+func (c *Client) CalculateMarketFill(ctx context.Context, tokenID string, side Side, amount float64, orderType OrderType) (*MarketFill, error) {
+	book, err := c.GetOrderBook(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("get order book: %w", err)
+	}
+
+	levels := book.Bids
+	if side == SideBuy {
+		levels = book.Asks
+	}
+	fill := simulateMarketFill(levels, side, amount)
+	if fill.FilledSize == 0 {
+		return fill, fmt.Errorf("no match")
+	}
+	if !fill.Complete && orderType == OrderTypeFOK {
+		return fill, fmt.Errorf("no match: insufficient depth, filled %v of %v", fill.FilledAmount, amount)
+	}
+	return fill, nil
+}
+
+// CalculateMarketPriceDetail 计算市价单价格及可成交份额
+// price 为最差成交价（深度不足时为订单簿最后一档价格）；complete 表示深度是否足以完全成交；
+// FOK 订单深度不足时返回错误，FAK 订单返回部分成交信息
+func (c *Client) CalculateMarketPriceDetail(ctx context.Context, tokenID string, side Side, amount float64, orderType OrderType) (price, fillableSize float64, complete bool, err error) {
+	fill, err := c.CalculateMarketFill(ctx, tokenID, side, amount, orderType)
+	if fill == nil {
+		return 0, 0, false, err
+	}
+	if err != nil {
+		return 0, fill.FilledSize, false, err
+	}
+	return fill.WorstPrice, fill.FilledSize, fill.Complete, nil
+}
+
+// simulateMarketFill 从最优价开始逐档吃单，直到成交 amount
+func simulateMarketFill(levels []OrderSummary, side Side, amount float64) *MarketFill {
+	type level struct{ price, size float64 }
+	sorted := make([]level, 0, len(levels))
+	for _, l := range levels {
+		price, size := parseFloat(l.Price), parseFloat(l.Size)
+		if price > 0 && size > 0 {
+			sorted = append(sorted, level{price, size})
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if side == SideBuy {
+			return sorted[i].price < sorted[j].price
+		}
+		return sorted[i].price > sorted[j].price
+	})
+
+	fill := &MarketFill{}
+	remaining := amount
+	for _, l := range sorted {
+		if remaining <= 1e-9 {
+			break
+		}
+		size := l.size
+		if side == SideBuy {
+			size = min(size, remaining/l.price)
+			remaining -= size * l.price
+		} else {
+			size = min(size, remaining)
+			remaining -= size
+		}
+		fill.FilledSize += size
+		fill.FilledValue += size * l.price
+		fill.WorstPrice = l.price
+	}
+
+	if fill.FilledSize > 0 {
+		fill.AvgPrice = fill.FilledValue / fill.FilledSize
+	}
+	fill.FilledAmount = fill.FilledSize
+	if side == SideBuy {
+		fill.FilledAmount = fill.FilledValue
+	}
+	fill.Complete = remaining <= 1e-9
+	return fill
+}
@@ -0,0 +1,99 @@
+package clob
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+// bookServer 返回固定订单簿的测试服务器，档位按服务端顺序（买卖盘均从差到优）给出
+func bookServer(t *testing.T) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, OrderBookSummary{
+			AssetID: r.URL.Query().Get("token_id"),
+			Bids:    []OrderSummary{{Price: "0.40", Size: "10"}, {Price: "0.45", Size: "100"}, {Price: "0.48", Size: "100"}},
+			Asks:    []OrderSummary{{Price: "0.55", Size: "50"}, {Price: "0.52", Size: "100"}, {Price: "0.50", Size: "100"}},
+		})
+	})
+	return newTestClient(t, mux)
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestCalculateMarketFill(t *testing.T) {
+	c := bookServer(t)
+	tests := []struct {
+		name                  string
+		side                  Side
+		amount                float64
+		orderType             OrderType
+		worst, avg, filledAmt float64
+		complete, wantErr     bool
+	}{
+		// 100@0.50 = 50 USDC，剩余 30 USDC 在 0.52 买入 57.69 份
+		{"buy across two levels", SideBuy, 80, OrderTypeFOK, 0.52, 80 / (100 + 30/0.52), 80, true, false},
+		{"buy within best level", SideBuy, 25, OrderTypeFOK, 0.50, 0.50, 25, true, false},
+		// 整个卖盘只有 129.5 USDC
+		{"buy beyond depth FOK", SideBuy, 200, OrderTypeFOK, 0.55, 129.5 / 250, 129.5, false, true},
+		{"buy beyond depth FAK", SideBuy, 200, OrderTypeFAK, 0.55, 129.5 / 250, 129.5, false, false},
+		{"sell across two levels", SideSell, 150, OrderTypeFOK, 0.45, 70.5 / 150, 150, true, false},
+		{"sell beyond depth FAK", SideSell, 300, OrderTypeFAK, 0.40, 97.0 / 210, 210, false, false},
+	}
+	for _, tt := range tests {
+		fill, err := c.CalculateMarketFill(t.Context(), "123", tt.side, tt.amount, tt.orderType)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if fill == nil {
+			t.Errorf("%s: nil fill", tt.name)
+			continue
+		}
+		if !approx(fill.WorstPrice, tt.worst) || !approx(fill.AvgPrice, tt.avg) || !approx(fill.FilledAmount, tt.filledAmt) || fill.Complete != tt.complete {
+			t.Errorf("%s: fill = %+v, want worst %v avg %v filled %v complete %v", tt.name, fill, tt.worst, tt.avg, tt.filledAmt, tt.complete)
+		}
+	}
+}
+
+func TestCalculateMarketPriceDetail(t *testing.T) {
+	c := bookServer(t)
+
+	// 完全成交：价格为最差档位
+	price, size, complete, err := c.CalculateMarketPriceDetail(t.Context(), "123", SideBuy, 80, OrderTypeFOK)
+	if err != nil || price != 0.52 || !complete || !approx(size, 100+30/0.52) {
+		t.Errorf("complete fill = %v, %v, %v, %v", price, size, complete, err)
+	}
+
+	// FAK 深度不足：返回可成交份额，不报错
+	price, size, complete, err = c.CalculateMarketPriceDetail(t.Context(), "123", SideSell, 300, OrderTypeFAK)
+	if err != nil || price != 0.40 || complete || !approx(size, 210) {
+		t.Errorf("partial FAK fill = %v, %v, %v, %v", price, size, complete, err)
+	}
+
+	// FOK 深度不足：报错，但仍返回可成交份额
+	price, size, complete, err = c.CalculateMarketPriceDetail(t.Context(), "123", SideSell, 300, OrderTypeFOK)
+	if err == nil || price != 0 || complete || !approx(size, 210) {
+		t.Errorf("FOK beyond depth = %v, %v, %v, %v", price, size, complete, err)
+	}
+
+	// 兼容方法只返回价格
+	if p, err := c.CalculateMarketPrice(t.Context(), "123", SideBuy, 25, OrderTypeFOK); err != nil || p != 0.50 {
+		t.Errorf("CalculateMarketPrice = %v, %v", p, err)
+	}
+}
+
+func TestCalculateMarketPriceDetailEmptyBook(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, OrderBookSummary{AssetID: "123"})
+	})
+	c := newTestClient(t, mux)
+
+	for _, orderType := range []OrderType{OrderTypeFOK, OrderTypeFAK} {
+		price, size, complete, err := c.CalculateMarketPriceDetail(t.Context(), "123", SideBuy, 10, orderType)
+		if err == nil || price != 0 || size != 0 || complete {
+			t.Errorf("%s no fill = %v, %v, %v, %v", orderType, price, size, complete, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package clob
+
+import (
+	"context"
+	"fmt"
+)
+
+// GuardReason 对冲下单预检拦截原因
+type GuardReason string
+
+const (
+	GuardReasonNotComplementary GuardReason = "NOT_COMPLEMENTARY" // 两腿不是同一 condition 的两个不同结果
+	GuardReasonCrossesBook      GuardReason = "CROSSES_BOOK"      // 挂单价会立即与对手盘成交
+	GuardReasonSpreadTooNarrow  GuardReason = "SPREAD_TOO_NARROW" // 挂单价距对手盘不足 MinSpreadTicks
+)
+
+// GuardError 对冲下单预检失败的结构化原因
+type GuardError struct {
+	Reason       GuardReason
+	TokenID      string
+	Price        float64 // 计划挂单价
+	BestOpposite float64 // 对手盘最优价（买单为最优卖价，卖单为最优买价）
+	TickSize     float64
+	Detail       string
+}
+
+// Error 实现 error 接口
+func (e *GuardError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("hedge guard %s: %s", e.Reason, e.Detail)
+	}
+	return fmt.Sprintf("hedge guard %s: token %s price %.4f vs opposite %.4f (tick %v)", e.Reason, e.TokenID, e.Price, e.BestOpposite, e.TickSize)
+}
+
+// HedgeLeg 对冲组合中的一条腿
+type HedgeLeg struct {
+	TokenID string
+	Side    Side
+	Price   float64
+}
+
+// HedgeGuardOptions 对冲下单预检选项
+type HedgeGuardOptions struct {
+	MinSpreadTicks int  // 挂单价与对手盘最优价之间至少保留的 tick 数（0 表示仅要求不穿价）
+	AllowTaking    bool // 允许立即吃单（跳过穿价和价差检查）
+}
+
+// CheckHedgePair 下单前检查对冲组合：两腿须为 conditionID 下的两个不同结果，
+// 且（未允许吃单时）每条腿的挂单价不会与当前对手盘成交并距其至少 MinSpreadTicks 个 tick
+// 两腿的订单簿通过一次 /books 请求获取，保证基于同一时刻的快照；应在提交前立即调用
+// 拦截时返回 *GuardError
+func (c *Client) CheckHedgePair(ctx context.Context, conditionID string, a, b HedgeLeg, opts HedgeGuardOptions) error {
+	if a.TokenID == b.TokenID {
+		return &GuardError{Reason: GuardReasonNotComplementary, TokenID: a.TokenID, Detail: "both legs use the same token"}
+	}
+	market, err := c.GetMarket(ctx, conditionID)
+	if err != nil {
+		return fmt.Errorf("get market: %w", err)
+	}
+	tokens := make(map[string]bool, len(market.Tokens))
+	for _, t := range market.Tokens {
+		tokens[t.TokenID] = true
+	}
+	for _, leg := range []HedgeLeg{a, b} {
+		if !tokens[leg.TokenID] {
+			return &GuardError{Reason: GuardReasonNotComplementary, TokenID: leg.TokenID, Detail: fmt.Sprintf("token %s not in condition %s", leg.TokenID, conditionID)}
+		}
+	}
+
+	if opts.AllowTaking {
+		return nil
+	}
+	books, err := c.GetOrderBooksMap(ctx, []string{a.TokenID, b.TokenID})
+	if err != nil {
+		return fmt.Errorf("get order books: %w", err)
+	}
+	for _, leg := range []HedgeLeg{a, b} {
+		book := books[leg.TokenID]
+		if book == nil {
+			return fmt.Errorf("order book not found: %s", leg.TokenID)
+		}
+		if err := checkPassiveLeg(book, leg, opts.MinSpreadTicks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPassiveLeg 检查挂单不会穿价且与对手盘最优价保持 minSpreadTicks 个 tick 的距离
+func checkPassiveLeg(book *OrderBookSummary, leg HedgeLeg, minSpreadTicks int) error {
+	levels := book.Asks
+	if leg.Side == SideSell {
+		levels = book.Bids
+	}
+	// 以极小数量模拟吃单，首档成交价即对手盘最优价
+	fill := simulateMarketFill(levels, leg.Side, 1e-6)
+	if fill.FilledSize == 0 {
+		return nil // 对手盘为空，不会成交
+	}
+	best := fill.WorstPrice
+	tick := parseFloat(book.TickSize)
+	if tick <= 0 {
+		tick = 0.01
+	}
+
+	// gap 为挂单价到对手盘最优价的距离，<= 0 表示会立即成交
+	gap := best - leg.Price
+	if leg.Side == SideSell {
+		gap = leg.Price - best
+	}
+	const eps = 1e-9
+	guardErr := &GuardError{TokenID: leg.TokenID, Price: leg.Price, BestOpposite: best, TickSize: tick}
+	switch {
+	case gap <= eps:
+		guardErr.Reason = GuardReasonCrossesBook
+		return guardErr
+	case gap < float64(minSpreadTicks)*tick-eps:
+		guardErr.Reason = GuardReasonSpreadTooNarrow
+		return guardErr
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package clob
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// newGuardClient 创建 condition "cond" 下有 yes/no 两个结果的测试客户端，返回 /books 请求计数
+func newGuardClient(t *testing.T, books map[string]OrderBookSummary) (*Client, *atomic.Int32) {
+	t.Helper()
+	var bookCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /markets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "cond" {
+			http.Error(w, `{"error":"market not found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(t, w, Market{ConditionID: "cond", Tokens: []MarketToken{{Outcome: "Yes", TokenID: "yes"}, {Outcome: "No", TokenID: "no"}}})
+	})
+	mux.HandleFunc("POST /books", func(w http.ResponseWriter, r *http.Request) {
+		bookCalls.Add(1)
+		var body map[string][]string
+		readJSON(t, r, &body)
+		var out []OrderBookSummary
+		for _, id := range body["token_ids"] {
+			book := books[id]
+			book.AssetID = id
+			out = append(out, book)
+		}
+		writeJSON(t, w, out)
+	})
+	return newTestClient(t, mux, func(cfg *ClientConfig) { cfg.MaxRetries = -1 }), &bookCalls
+}
+
+func TestCheckHedgePair(t *testing.T) {
+	books := map[string]OrderBookSummary{
+		"yes": {TickSize: "0.01", Bids: []OrderSummary{{"0.48", "100"}}, Asks: []OrderSummary{{"0.60", "10"}, {"0.52", "100"}}},
+		"no":  {TickSize: "0.01", Bids: []OrderSummary{{"0.46", "100"}, {"0.47", "0"}}, Asks: []OrderSummary{{"0.50", "100"}}},
+	}
+	tests := []struct {
+		name       string
+		a, b       HedgeLeg
+		opts       HedgeGuardOptions
+		wantReason GuardReason // 空表示放行
+		wantToken  string
+		wantBest   float64
+	}{
+		{name: "safe book", a: HedgeLeg{"yes", SideBuy, 0.48}, b: HedgeLeg{"no", SideBuy, 0.46}, opts: HedgeGuardOptions{MinSpreadTicks: 2}},
+		{name: "exactly min spread", a: HedgeLeg{"yes", SideBuy, 0.50}, b: HedgeLeg{"no", SideBuy, 0.48}, opts: HedgeGuardOptions{MinSpreadTicks: 2}},
+		{name: "buy at best ask crosses", a: HedgeLeg{"yes", SideBuy, 0.52}, b: HedgeLeg{"no", SideBuy, 0.46},
+			wantReason: GuardReasonCrossesBook, wantToken: "yes", wantBest: 0.52},
+		{name: "buy through ask crosses", a: HedgeLeg{"yes", SideBuy, 0.48}, b: HedgeLeg{"no", SideBuy, 0.55},
+			wantReason: GuardReasonCrossesBook, wantToken: "no", wantBest: 0.50},
+		{name: "sell at best bid crosses", a: HedgeLeg{"yes", SideBuy, 0.48}, b: HedgeLeg{"no", SideSell, 0.46},
+			wantReason: GuardReasonCrossesBook, wantToken: "no", wantBest: 0.46},
+		{name: "inside spread but too narrow", a: HedgeLeg{"yes", SideBuy, 0.51}, b: HedgeLeg{"no", SideBuy, 0.46}, opts: HedgeGuardOptions{MinSpreadTicks: 2},
+			wantReason: GuardReasonSpreadTooNarrow, wantToken: "yes", wantBest: 0.52},
+		{name: "passive sell", a: HedgeLeg{"yes", SideSell, 0.55}, b: HedgeLeg{"no", SideSell, 0.49}, opts: HedgeGuardOptions{MinSpreadTicks: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newGuardClient(t, books)
+			err := c.CheckHedgePair(t.Context(), "cond", tt.a, tt.b, tt.opts)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("CheckHedgePair = %v, want pass", err)
+				}
+				return
+			}
+			var guardErr *GuardError
+			if !errors.As(err, &guardErr) {
+				t.Fatalf("err = %v, want *GuardError", err)
+			}
+			if guardErr.Reason != tt.wantReason || guardErr.TokenID != tt.wantToken || guardErr.BestOpposite != tt.wantBest || guardErr.TickSize != 0.01 {
+				t.Errorf("guard error = %+v, want %s on %s (best %v)", guardErr, tt.wantReason, tt.wantToken, tt.wantBest)
+			}
+		})
+	}
+}
+
+func TestCheckHedgePairRejectsNonComplementary(t *testing.T) {
+	c, bookCalls := newGuardClient(t, nil)
+	for _, tt := range []struct {
+		name string
+		a, b HedgeLeg
+	}{
+		{"same token", HedgeLeg{"yes", SideBuy, 0.4}, HedgeLeg{"yes", SideBuy, 0.5}},
+		{"foreign token", HedgeLeg{"yes", SideBuy, 0.4}, HedgeLeg{"other", SideBuy, 0.5}},
+	} {
+		var guardErr *GuardError
+		if err := c.CheckHedgePair(t.Context(), "cond", tt.a, tt.b, HedgeGuardOptions{}); !errors.As(err, &guardErr) || guardErr.Reason != GuardReasonNotComplementary {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, GuardReasonNotComplementary)
+		}
+	}
+	if n := bookCalls.Load(); n != 0 {
+		t.Errorf("/books calls = %d, want 0", n)
+	}
+
+	// 市场不存在时返回普通错误而不是 GuardError
+	var guardErr *GuardError
+	if err := c.CheckHedgePair(t.Context(), "missing", HedgeLeg{"yes", SideBuy, 0.4}, HedgeLeg{"no", SideBuy, 0.5}, HedgeGuardOptions{}); err == nil || errors.As(err, &guardErr) {
+		t.Errorf("missing market: err = %v", err)
+	}
+}
+
+func TestCheckHedgePairAllowTakingAndEmptyBook(t *testing.T) {
+	books := map[string]OrderBookSummary{
+		"yes": {Asks: []OrderSummary{{"0.40", "100"}}},
+		"no":  {}, // 对手盘为空
+	}
+	c, bookCalls := newGuardClient(t, books)
+
+	// AllowTaking 跳过订单簿检查
+	if err := c.CheckHedgePair(t.Context(), "cond", HedgeLeg{"yes", SideBuy, 0.45}, HedgeLeg{"no", SideBuy, 0.50}, HedgeGuardOptions{AllowTaking: true, MinSpreadTicks: 5}); err != nil {
+		t.Fatalf("AllowTaking: %v", err)
+	}
+	if n := bookCalls.Load(); n != 0 {
+		t.Errorf("/books calls with AllowTaking = %d, want 0", n)
+	}
+
+	// 缺少 tick_size 时按 0.01 计算；空对手盘不会成交
+	if err := c.CheckHedgePair(t.Context(), "cond", HedgeLeg{"yes", SideBuy, 0.38}, HedgeLeg{"no", SideBuy, 0.60}, HedgeGuardOptions{MinSpreadTicks: 2}); err != nil {
+		t.Errorf("safe with empty book: %v", err)
+	}
+	var guardErr *GuardError
+	err := c.CheckHedgePair(t.Context(), "cond", HedgeLeg{"yes", SideBuy, 0.39}, HedgeLeg{"no", SideBuy, 0.60}, HedgeGuardOptions{MinSpreadTicks: 2})
+	if !errors.As(err, &guardErr) || guardErr.Reason != GuardReasonSpreadTooNarrow || guardErr.TickSize != 0.01 {
+		t.Errorf("default tick: err = %v", err)
+	}
+	// 两腿通过一次 /books 请求获取
+	if n := bookCalls.Load(); n != 2 {
+		t.Errorf("/books calls = %d, want 2 (one per check)", n)
+	}
+}
@@ -0,0 +1,341 @@
+package clob
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// Position 单个 token 的库存
+type Position struct {
+	TokenID       string
+	Size          float64 // 净持仓 (负数表示空头)
+	AvgCost       float64 // 平均成本
+	RealizedPnl   float64 // 已实现盈亏
+	MarkPrice     float64 // 标记价格
+	UnrealizedPnl float64 // 未实现盈亏 (按标记价格)
+	BuyVolume     float64 // 累计买入数量
+	SellVolume    float64 // 累计卖出数量
+}
+
+const (
+	// inventorySeenLimit 已结束成交 ID 的去重记录上限，超出后淘汰最早的记录
+	inventorySeenLimit = 10000
+	// inventoryPendingLimit 待定成交上限，超出后最早的待定成交按已确认处理（不再可撤销）
+	inventoryPendingLimit = 1000
+)
+
+// InventoryTracker 做市库存跟踪器
+// 消费用户频道的成交推送，实时维护每个 token 的净持仓、平均成本和盈亏（平均成本法）。
+// 未确认 (CONFIRMED) 的成交保留在待定列表中，之后变为 FAILED 时会从持仓中撤销
+type InventoryTracker struct {
+	owner string
+
+	mu        sync.RWMutex
+	positions map[string]*Position // 已确认成交 + 待定成交
+	base      map[string]*Position // 仅已确认成交
+	pending   []*inventoryTrade    // 按到达顺序排列的待定成交
+	orders    map[string]bool      // TrackOrder 登记的订单
+	done      map[string]bool      // 已确认或已失败的成交 ID
+	doneOrder []string
+}
+
+// inventoryTrade 一笔成交推送中属于自己的成交部分
+type inventoryTrade struct {
+	id        string
+	fills     []inventoryFill
+	confirmed bool
+}
+
+type inventoryFill struct {
+	tokenID     string
+	side        Side
+	price, size float64
+}
+
+// NewInventoryTracker 创建库存跟踪器
+// owner 为 API Key（成交推送中的 owner 字段），用于识别作为 maker 成交时属于自己的订单；
+// 为空时只计入通过 TrackOrder 登记的 maker 订单
+func NewInventoryTracker(owner string) *InventoryTracker {
+	return &InventoryTracker{
+		owner:     owner,
+		positions: make(map[string]*Position),
+		base:      make(map[string]*Position),
+		orders:    make(map[string]bool),
+		done:      make(map[string]bool),
+	}
+}
+
+// TrackOrder 登记自己的订单 ID，作为 maker 成交时据此识别属于自己的部分
+func (t *InventoryTracker) TrackOrder(orderID string) {
+	if orderID == "" {
+		return
+	}
+	t.mu.Lock()
+	t.orders[orderID] = true
+	t.mu.Unlock()
+}
+
+// UntrackOrder 取消登记订单（订单结束后调用，避免登记表无限增长）
+func (t *InventoryTracker) UntrackOrder(orderID string) {
+	t.mu.Lock()
+	delete(t.orders, orderID)
+	t.mu.Unlock()
+}
+
+// ApplyTrade 处理成交推送
+// 同一成交的后续状态更新不会重复计入；成交变为 FAILED 时撤销之前计入的持仓，CONFIRMED 后不再变化
+func (t *InventoryTracker) ApplyTrade(trade *common.TradeNotification) {
+	if trade == nil {
+		return
+	}
+	tradeID := trade.ID
+	if tradeID == "" {
+		tradeID = trade.TradeID
+	}
+	status := strings.ToUpper(trade.Status)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tradeID == "" {
+		// 无法去重或撤销，直接按已确认处理
+		if status != "FAILED" {
+			t.appendTrade(&inventoryTrade{fills: t.ownFills(trade), confirmed: true})
+		}
+		return
+	}
+	if t.done[tradeID] {
+		return
+	}
+
+	switch status {
+	case "FAILED":
+		t.removeTrade(tradeID)
+		t.markDone(tradeID)
+		return
+	case "CONFIRMED":
+		if pt := t.pendingTrade(tradeID); pt != nil {
+			pt.confirmed = true
+			t.compact()
+			return
+		}
+	default:
+		if t.pendingTrade(tradeID) != nil {
+			return
+		}
+	}
+	t.appendTrade(&inventoryTrade{id: tradeID, fills: t.ownFills(trade), confirmed: status == "CONFIRMED"})
+}
+
+// ApplyFill 直接记录一笔已确认的成交
+func (t *InventoryTracker) ApplyFill(tokenID string, side Side, price, size float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.appendTrade(&inventoryTrade{fills: []inventoryFill{{tokenID, side, price, size}}, confirmed: true})
+}
+
+// Mark 更新标记价格（用于计算未实现盈亏）
+func (t *InventoryTracker) Mark(tokenID string, price float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.position(tokenID).MarkPrice = price
+}
+
+// Position 获取单个 token 的库存快照
+func (t *InventoryTracker) Position(tokenID string) (Position, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	p, ok := t.positions[tokenID]
+	if !ok {
+		return Position{TokenID: tokenID}, false
+	}
+	return p.snapshot(), true
+}
+
+// Snapshot 获取所有 token 的库存快照
+func (t *InventoryTracker) Snapshot() map[string]Position {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := make(map[string]Position, len(t.positions))
+	for id, p := range t.positions {
+		result[id] = p.snapshot()
+	}
+	return result
+}
+
+func (t *InventoryTracker) position(tokenID string) *Position {
+	return getPosition(t.positions, tokenID)
+}
+
+func getPosition(positions map[string]*Position, tokenID string) *Position {
+	p, ok := positions[tokenID]
+	if !ok {
+		p = &Position{TokenID: tokenID}
+		positions[tokenID] = p
+	}
+	return p
+}
+
+// ownFills 提取成交推送中属于自己的部分：作为 taker 时为整笔成交，
+// 作为 maker 时为 owner 匹配或已登记的 maker 订单
+func (t *InventoryTracker) ownFills(trade *common.TradeNotification) []inventoryFill {
+	if !strings.EqualFold(trade.TraderSide, "MAKER") {
+		return []inventoryFill{{trade.AssetID, Side(strings.ToUpper(trade.Side)), parseFloat(trade.Price), parseFloat(trade.Size)}}
+	}
+	var fills []inventoryFill
+	for _, m := range trade.MakerOrders {
+		if !t.orders[m.OrderID] && (t.owner == "" || m.Owner != t.owner) {
+			continue
+		}
+		fills = append(fills, inventoryFill{m.AssetID, Side(strings.ToUpper(m.Side)), parseFloat(m.Price), parseFloat(m.MatchedAmount)})
+	}
+	return fills
+}
+
+// appendTrade 计入一笔成交，已确认的成交会合并进 base
+func (t *InventoryTracker) appendTrade(trade *inventoryTrade) {
+	for _, f := range trade.fills {
+		applyFill(t.position(f.tokenID), f)
+	}
+	t.pending = append(t.pending, trade)
+	t.compact()
+}
+
+func (t *InventoryTracker) pendingTrade(tradeID string) *inventoryTrade {
+	for _, pt := range t.pending {
+		if pt.id == tradeID {
+			return pt
+		}
+	}
+	return nil
+}
+
+// compact 将待定列表开头已确认的成交合并进 base
+// 平均成本法与顺序相关，只能按到达顺序合并，之后的 FAILED 撤销才能精确重放
+func (t *InventoryTracker) compact() {
+	n := 0
+	for _, pt := range t.pending {
+		if !pt.confirmed && len(t.pending)-n <= inventoryPendingLimit {
+			break
+		}
+		for _, f := range pt.fills {
+			applyFill(getPosition(t.base, f.tokenID), f)
+		}
+		if pt.id != "" {
+			t.markDone(pt.id)
+		}
+		n++
+	}
+	t.pending = t.pending[n:]
+}
+
+// removeTrade 撤销待定成交，并从 base 重放其余待定成交重建受影响 token 的持仓
+func (t *InventoryTracker) removeTrade(tradeID string) {
+	idx := -1
+	for i, pt := range t.pending {
+		if pt.id == tradeID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	removed := t.pending[idx]
+	t.pending = append(t.pending[:idx], t.pending[idx+1:]...)
+
+	for _, f := range removed.fills {
+		p := &Position{TokenID: f.tokenID}
+		if b, ok := t.base[f.tokenID]; ok {
+			*p = *b
+		}
+		if old, ok := t.positions[f.tokenID]; ok {
+			p.MarkPrice = old.MarkPrice
+		}
+		for _, pt := range t.pending {
+			for _, pf := range pt.fills {
+				if pf.tokenID == f.tokenID {
+					applyFill(p, pf)
+				}
+			}
+		}
+		t.positions[f.tokenID] = p
+	}
+	t.compact()
+}
+
+// markDone 记录已结束的成交 ID，超过上限时淘汰最早的记录
+func (t *InventoryTracker) markDone(tradeID string) {
+	if t.done[tradeID] {
+		return
+	}
+	t.done[tradeID] = true
+	t.doneOrder = append(t.doneOrder, tradeID)
+	if len(t.doneOrder) > inventorySeenLimit {
+		delete(t.done, t.doneOrder[0])
+		t.doneOrder = t.doneOrder[1:]
+	}
+}
+
+// applyFill 按平均成本法更新持仓
+func applyFill(p *Position, f inventoryFill) {
+	tokenID, side, price, size := f.tokenID, f.side, f.price, f.size
+	if tokenID == "" || size <= 0 {
+		return
+	}
+
+	qty := size
+	if side == SideSell {
+		qty = -size
+		p.SellVolume += size
+	} else {
+		p.BuyVolume += size
+	}
+
+	// 同向加仓：更新平均成本
+	if p.Size == 0 || (p.Size > 0) == (qty > 0) {
+		total := p.Size + qty
+		p.AvgCost = (p.AvgCost*abs(p.Size) + price*abs(qty)) / abs(total)
+		p.Size = total
+		return
+	}
+
+	// 反向减仓：实现盈亏，超出部分按成交价反向开仓
+	closed := min(abs(qty), abs(p.Size))
+	if p.Size > 0 {
+		p.RealizedPnl += (price - p.AvgCost) * closed
+	} else {
+		p.RealizedPnl += (p.AvgCost - price) * closed
+	}
+	p.Size += qty
+	switch {
+	case abs(p.Size) < 1e-9:
+		p.Size = 0
+		p.AvgCost = 0
+	case (p.Size > 0) == (qty > 0):
+		p.AvgCost = price
+	}
+}
+
+func (p *Position) snapshot() Position {
+	s := *p
+	if s.MarkPrice > 0 {
+		s.UnrealizedPnl = (s.MarkPrice - s.AvgCost) * s.Size
+	}
+	return s
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,140 @@
+package clob
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// takerTrade 构造作为 taker 的成交推送
+func takerTrade(id, status, asset string, side Side, price, size float64) *common.TradeNotification {
+	return &common.TradeNotification{
+		ID: id, Status: status, AssetID: asset, Side: string(side), TraderSide: "TAKER",
+		Price: fmt.Sprint(price), Size: fmt.Sprint(size),
+	}
+}
+
+// checkPosition 校验持仓快照
+func checkPosition(t *testing.T, inv *InventoryTracker, token string, size, avgCost, realized float64) {
+	t.Helper()
+	p, _ := inv.Position(token)
+	if math.Abs(p.Size-size) > 1e-9 || math.Abs(p.AvgCost-avgCost) > 1e-9 || math.Abs(p.RealizedPnl-realized) > 1e-9 {
+		t.Errorf("%s: size=%v avg=%v realized=%v, want size=%v avg=%v realized=%v", token, p.Size, p.AvgCost, p.RealizedPnl, size, avgCost, realized)
+	}
+}
+
+func TestInventoryCostBasis(t *testing.T) {
+	inv := NewInventoryTracker("key")
+	steps := []struct {
+		side                     Side
+		price, size              float64
+		wantSize, wantAvg, wantR float64
+	}{
+		{SideBuy, 0.40, 10, 10, 0.40, 0},
+		{SideBuy, 0.60, 10, 20, 0.50, 0},
+		{SideSell, 0.70, 5, 15, 0.50, 1.0},
+		// 卖出超过持仓：平掉多头后按成交价反向开空
+		{SideSell, 0.30, 20, -5, 0.30, -2.0},
+		{SideBuy, 0.25, 5, 0, 0, -1.75},
+	}
+	for i, s := range steps {
+		inv.ApplyTrade(takerTrade(fmt.Sprintf("t%d", i), "CONFIRMED", "yes", s.side, s.price, s.size))
+		checkPosition(t, inv, "yes", s.wantSize, s.wantAvg, s.wantR)
+	}
+
+	inv.ApplyFill("no", SideSell, 0.30, 5)
+	inv.Mark("no", 0.20)
+	p, ok := inv.Position("no")
+	if !ok || math.Abs(p.UnrealizedPnl-0.5) > 1e-9 {
+		t.Errorf("no position = %+v, want unrealized 0.5", p)
+	}
+	snap := inv.Snapshot()
+	if len(snap) != 2 || snap["yes"].BuyVolume != 25 || snap["yes"].SellVolume != 25 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestInventoryStatusUpdatesCountedOnce(t *testing.T) {
+	inv := NewInventoryTracker("key")
+	for _, status := range []string{"MATCHED", "MINED", "RETRYING", "MINED", "CONFIRMED", "CONFIRMED"} {
+		inv.ApplyTrade(takerTrade("t1", status, "yes", SideBuy, 0.5, 10))
+	}
+	checkPosition(t, inv, "yes", 10, 0.5, 0)
+}
+
+func TestInventoryFailedTradeReversed(t *testing.T) {
+	inv := NewInventoryTracker("key")
+	inv.ApplyTrade(takerTrade("t1", "CONFIRMED", "yes", SideBuy, 0.40, 10))
+	inv.ApplyTrade(takerTrade("t2", "MATCHED", "yes", SideBuy, 0.60, 10))
+	inv.ApplyTrade(takerTrade("t3", "MATCHED", "yes", SideSell, 0.70, 5))
+	checkPosition(t, inv, "yes", 15, 0.50, 1.0)
+	inv.Mark("yes", 0.55)
+
+	// t2 上链失败：撤销 t2 并按顺序重放 t3
+	inv.ApplyTrade(takerTrade("t2", "FAILED", "yes", SideBuy, 0.60, 10))
+	checkPosition(t, inv, "yes", 5, 0.40, 1.5)
+	if p, _ := inv.Position("yes"); p.MarkPrice != 0.55 {
+		t.Errorf("mark price lost after reversal: %v", p.MarkPrice)
+	}
+
+	// 失败后的重复推送不再计入，已确认的成交不可撤销
+	inv.ApplyTrade(takerTrade("t2", "MATCHED", "yes", SideBuy, 0.60, 10))
+	inv.ApplyTrade(takerTrade("t3", "CONFIRMED", "yes", SideSell, 0.70, 5))
+	inv.ApplyTrade(takerTrade("t3", "FAILED", "yes", SideSell, 0.70, 5))
+	checkPosition(t, inv, "yes", 5, 0.40, 1.5)
+	if len(inv.pending) != 0 {
+		t.Errorf("pending = %d, want 0", len(inv.pending))
+	}
+
+	// 首次推送即为 FAILED 时不计入
+	inv.ApplyTrade(takerTrade("t4", "FAILED", "yes", SideBuy, 0.5, 100))
+	checkPosition(t, inv, "yes", 5, 0.40, 1.5)
+}
+
+func TestInventoryMakerOwnership(t *testing.T) {
+	makerTrade := func(id string) *common.TradeNotification {
+		return &common.TradeNotification{
+			ID: id, Status: "MATCHED", TraderSide: "MAKER", AssetID: "yes", Side: "BUY", Price: "0.5", Size: "30",
+			MakerOrders: []common.MakerOrder{
+				{OrderID: "mine", Owner: "key", AssetID: "yes", Side: "SELL", Price: "0.5", MatchedAmount: "10"},
+				{OrderID: "other", Owner: "someone", AssetID: "yes", Side: "SELL", Price: "0.5", MatchedAmount: "20"},
+			},
+		}
+	}
+
+	byOwner := NewInventoryTracker("key")
+	byOwner.ApplyTrade(makerTrade("t1"))
+	checkPosition(t, byOwner, "yes", -10, 0.5, 0)
+
+	// 未设置 owner 时不计入未登记的 maker 订单
+	anonymous := NewInventoryTracker("")
+	anonymous.ApplyTrade(makerTrade("t1"))
+	if _, ok := anonymous.Position("yes"); ok {
+		t.Error("untracked maker orders credited without owner")
+	}
+	anonymous.TrackOrder("mine")
+	anonymous.ApplyTrade(makerTrade("t2"))
+	checkPosition(t, anonymous, "yes", -10, 0.5, 0)
+	anonymous.UntrackOrder("mine")
+	anonymous.ApplyTrade(makerTrade("t3"))
+	checkPosition(t, anonymous, "yes", -10, 0.5, 0)
+}
+
+func TestInventoryBounded(t *testing.T) {
+	inv := NewInventoryTracker("key")
+	for i := range inventorySeenLimit + 10 {
+		inv.ApplyTrade(takerTrade(fmt.Sprintf("c%d", i), "CONFIRMED", "yes", SideBuy, 0.5, 1))
+	}
+	if len(inv.done) != inventorySeenLimit || len(inv.doneOrder) != inventorySeenLimit {
+		t.Errorf("done = %d/%d, want %d", len(inv.done), len(inv.doneOrder), inventorySeenLimit)
+	}
+	for i := range inventoryPendingLimit + 5 {
+		inv.ApplyTrade(takerTrade(fmt.Sprintf("p%d", i), "MATCHED", "yes", SideBuy, 0.5, 1))
+	}
+	if len(inv.pending) != inventoryPendingLimit {
+		t.Errorf("pending = %d, want %d", len(inv.pending), inventoryPendingLimit)
+	}
+	checkPosition(t, inv, "yes", inventorySeenLimit+10+inventoryPendingLimit+5, 0.5, 0)
+}
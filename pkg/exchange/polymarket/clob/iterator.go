@@ -0,0 +1,109 @@
+package clob
+
+import "context"
+
+// PageFetcher 按游标获取一页数据，返回数据和下一页游标
+type PageFetcher[T any] func(ctx context.Context, cursor string) ([]T, string, error)
+
+// Iterator 分页迭代器：按需逐页拉取，遍历到 EndCursor 为止，可随时中断
+//
+//	it := client.MarketsIterator()
+//	for {
+//		market, ok, err := it.Next(ctx)
+//		if err != nil || !ok {
+//			break
+//		}
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch  PageFetcher[T]
+	cursor string
+	page   []T
+	done   bool
+}
+
+// NewIterator 创建分页迭代器，从 InitialCursor 开始
+func NewIterator[T any](fetch PageFetcher[T]) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, cursor: InitialCursor}
+}
+
+// Next 返回下一条数据，没有更多数据时 ok 为 false
+// 拉取失败时返回错误，游标不前进，可再次调用 Next 重试
+func (it *Iterator[T]) Next(ctx context.Context) (item T, ok bool, err error) {
+	for len(it.page) == 0 {
+		if it.done {
+			return item, false, nil
+		}
+		page, next, err := it.fetch(ctx, it.cursor)
+		if err != nil {
+			return item, false, err
+		}
+		it.page = page
+		it.cursor = next
+		it.done = next == "" || next == EndCursor
+	}
+	item, it.page = it.page[0], it.page[1:]
+	return item, true, nil
+}
+
+// Cursor 下一页的游标
+func (it *Iterator[T]) Cursor() string { return it.cursor }
+
+// All 读取剩余全部数据
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var results []T
+	for {
+		item, ok, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return results, nil
+		}
+		results = append(results, item)
+	}
+}
+
+// MarketsIterator 市场迭代器
+func (c *Client) MarketsIterator() *Iterator[Market] {
+	return NewIterator(func(ctx context.Context, cursor string) ([]Market, string, error) {
+		resp, err := c.GetMarkets(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.NextCursor, nil
+	})
+}
+
+// SimplifiedMarketsIterator 简化市场迭代器
+func (c *Client) SimplifiedMarketsIterator() *Iterator[SimplifiedMarket] {
+	return NewIterator(func(ctx context.Context, cursor string) ([]SimplifiedMarket, string, error) {
+		resp, err := c.GetSimplifiedMarkets(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.NextCursor, nil
+	})
+}
+
+// OpenOrdersIterator 未结订单迭代器
+func (c *Client) OpenOrdersIterator(params OpenOrderParams) *Iterator[OpenOrder] {
+	return NewIterator(func(ctx context.Context, cursor string) ([]OpenOrder, string, error) {
+		resp, err := c.GetOpenOrdersPaginated(ctx, params, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.NextCursor, nil
+	})
+}
+
+// TradesIterator 成交记录迭代器
+func (c *Client) TradesIterator(params TradeParams) *Iterator[Trade] {
+	return NewIterator(func(ctx context.Context, cursor string) ([]Trade, string, error) {
+		resp, err := c.GetTradesPaginated(ctx, params, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp.Data, resp.NextCursor, nil
+	})
+}
@@ -0,0 +1,104 @@
+package clob
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// pagedMarketsServer 三页市场数据：MA== -> c1 -> c2 -> EndCursor，记录每次请求的游标
+func pagedMarketsServer(t *testing.T) (*Client, func() []string) {
+	t.Helper()
+	pages := map[string]struct {
+		ids  []string
+		next string
+	}{
+		InitialCursor: {[]string{"m1", "m2"}, "c1"},
+		"c1":          {[]string{"m3", "m4"}, "c2"},
+		"c2":          {[]string{"m5"}, EndCursor},
+	}
+	var (
+		mu      sync.Mutex
+		cursors []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /markets", func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("next_cursor")
+		mu.Lock()
+		cursors = append(cursors, cursor)
+		mu.Unlock()
+		page, ok := pages[cursor]
+		if !ok {
+			http.Error(w, `{"error":"bad cursor"}`, http.StatusBadRequest)
+			return
+		}
+		resp := MarketsResponse{NextCursor: page.next, Count: len(page.ids)}
+		for _, id := range page.ids {
+			resp.Data = append(resp.Data, Market{ConditionID: id})
+		}
+		writeJSON(t, w, resp)
+	})
+	return newTestClient(t, mux), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), cursors...)
+	}
+}
+
+func TestIteratorYieldsAllPages(t *testing.T) {
+	c, cursors := pagedMarketsServer(t)
+	it := c.MarketsIterator()
+
+	var ids []string
+	for {
+		m, ok, err := it.Next(t.Context())
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if !ok {
+			break
+		}
+		ids = append(ids, m.ConditionID)
+	}
+	if fmt.Sprint(ids) != "[m1 m2 m3 m4 m5]" {
+		t.Errorf("ids = %v", ids)
+	}
+	if got := cursors(); fmt.Sprint(got) != fmt.Sprint([]string{InitialCursor, "c1", "c2"}) {
+		t.Errorf("cursors = %v", got)
+	}
+
+	// 结束后不再请求
+	if _, ok, err := it.Next(t.Context()); ok || err != nil {
+		t.Errorf("Next after end = %v, %v", ok, err)
+	}
+	if n := len(cursors()); n != 3 {
+		t.Errorf("requests after end = %d, want 3", n)
+	}
+}
+
+func TestIteratorLazyFetch(t *testing.T) {
+	c, cursors := pagedMarketsServer(t)
+	it := c.MarketsIterator()
+
+	// 只读第一页的数据时不拉取后续页
+	for range 2 {
+		if _, ok, err := it.Next(t.Context()); !ok || err != nil {
+			t.Fatalf("Next = %v, %v", ok, err)
+		}
+	}
+	if got := cursors(); len(got) != 1 {
+		t.Errorf("cursors after first page = %v", got)
+	}
+	if it.Cursor() != "c1" {
+		t.Errorf("cursor = %s, want c1", it.Cursor())
+	}
+
+	rest, err := it.All(t.Context())
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(rest) != 3 || rest[0].ConditionID != "m3" {
+		t.Errorf("rest = %+v", rest)
+	}
+}
@@ -64,6 +64,10 @@ func (b *OrderBuilder) GetFunder() string {
 
 // BuildOrder 构建并签名订单
 func (b *OrderBuilder) BuildOrder(order UserOrder, opts CreateOrderOptions) (*SignedOrder, error) {
+	if err := validatePrice(order.Price, opts.TickSize); err != nil {
+		return nil, err
+	}
+
 	makerAmount, takerAmount := calculateOrderAmounts(order.Side, order.Size, order.Price, opts.TickSize)
 
 	salt := generateSalt()
@@ -71,12 +75,15 @@ func (b *OrderBuilder) BuildOrder(order UserOrder, opts CreateOrderOptions) (*Si
 	// expiration: 只有 GTD 订单需要设置，其他订单类型必须为 "0"
 	// 官方 SDK: if (!expiration) expiration = '0'
 	expiration := order.Expiration
+	if err := validateExpiration(expiration, time.Now()); err != nil {
+		return nil, err
+	}
 
 	nonce := order.Nonce
 
-	taker := order.Taker
-	if taker == "" {
-		taker = common.Address{}.Hex()
+	taker, err := normalizeTaker(order.Taker)
+	if err != nil {
+		return nil, err
 	}
 
 	sideInt := 0
@@ -124,9 +131,9 @@ func (b *OrderBuilder) BuildMarketOrder(order UserMarketOrder, opts CreateOrderO
 
 	salt := generateSalt()
 
-	taker := order.Taker
-	if taker == "" {
-		taker = common.Address{}.Hex()
+	taker, err := normalizeTaker(order.Taker)
+	if err != nil {
+		return nil, err
 	}
 
 	sideInt := 0
@@ -175,9 +182,7 @@ func (b *OrderBuilder) signOrder(order *SignedOrder, exchange string) (string, e
 		return "", fmt.Errorf("sign: %w", err)
 	}
 
-	if sig[64] < 27 {
-		sig[64] += 27
-	}
+	sig = polycommon.NormalizeSignatureV(sig, polycommon.VSchemeEthereum)
 
 	return "0x" + hex.EncodeToString(sig), nil
 }
@@ -250,6 +255,45 @@ func buildOrderStructHash(order *SignedOrder) []byte {
 	)
 }
 
+// validateExpiration 校验 GTD 订单过期时间（0 表示非 GTD 订单）
+// 服务端要求过期时间至少晚于当前时间 GTDMinBuffer
+func validateExpiration(expiration int64, now time.Time) error {
+	if expiration == 0 {
+		return nil
+	}
+	if expiration < 0 {
+		return fmt.Errorf("invalid expiration (%d)", expiration)
+	}
+	if minExpiration := now.Add(GTDMinBuffer).Unix(); expiration <= minExpiration {
+		return fmt.Errorf("invalid expiration (%d), must be later than now + %v (%d)", expiration, GTDMinBuffer, minExpiration)
+	}
+	return nil
+}
+
+// checkOrderExpiration 校验订单类型与过期时间匹配：GTD 必须设置过期时间，其他类型必须为 0
+func checkOrderExpiration(order *SignedOrder, orderType OrderType) error {
+	unset := order.Expiration == "" || order.Expiration == "0"
+	if orderType == OrderTypeGTD && unset {
+		return fmt.Errorf("GTD order requires expiration")
+	}
+	if orderType != OrderTypeGTD && !unset {
+		return fmt.Errorf("%s order must not set expiration (%s)", orderType, order.Expiration)
+	}
+	return nil
+}
+
+// validatePrice 校验价格在 [tick, 1-tick] 范围内
+func validatePrice(price float64, tickSize TickSize) error {
+	tick := tickSize.Float64()
+	if tick <= 0 {
+		tick = TickSize001.Float64()
+	}
+	if price < tick-1e-9 || price > 1-tick+1e-9 {
+		return fmt.Errorf("invalid price (%v), min: %v - max: %v", price, tick, 1-tick)
+	}
+	return nil
+}
+
 // RoundConfig 舍入配置
 type RoundConfig struct {
 	Price  int
@@ -271,33 +315,14 @@ func calculateOrderAmounts(side Side, size, price float64, tickSize TickSize) (*
 		config = roundingConfigs[TickSize001]
 	}
 
-	rawPrice := roundNormal(price, config.Price)
+	rawPrice := ratRoundHalfUp(ratFromFloat(price), config.Price)
+	rawSize := ratRoundDown(ratFromFloat(size), config.Size)
+	rawAmount := roundAmount(new(big.Rat).Mul(rawSize, rawPrice), config.Amount)
 
 	if side == SideBuy {
-		rawTakerAmt := roundDown(size, config.Size)
-		rawMakerAmt := rawTakerAmt * rawPrice
-
-		if decimalPlaces(rawMakerAmt) > config.Amount {
-			rawMakerAmt = roundUp(rawMakerAmt, config.Amount+4)
-			if decimalPlaces(rawMakerAmt) > config.Amount {
-				rawMakerAmt = roundDown(rawMakerAmt, config.Amount)
-			}
-		}
-
-		return toUnits(rawMakerAmt), toUnits(rawTakerAmt)
+		return ratToUnits(rawAmount), ratToUnits(rawSize)
 	}
-
-	rawMakerAmt := roundDown(size, config.Size)
-	rawTakerAmt := rawMakerAmt * rawPrice
-
-	if decimalPlaces(rawTakerAmt) > config.Amount {
-		rawTakerAmt = roundUp(rawTakerAmt, config.Amount+4)
-		if decimalPlaces(rawTakerAmt) > config.Amount {
-			rawTakerAmt = roundDown(rawTakerAmt, config.Amount)
-		}
-	}
-
-	return toUnits(rawMakerAmt), toUnits(rawTakerAmt)
+	return ratToUnits(rawSize), ratToUnits(rawAmount)
 }
 
 // calculateMarketOrderAmounts 计算市价单金额
@@ -307,86 +332,120 @@ func calculateMarketOrderAmounts(side Side, amount, price float64, tickSize Tick
 		config = roundingConfigs[TickSize001]
 	}
 
-	rawPrice := roundDown(price, config.Price)
+	rawPrice := ratRoundDown(ratFromFloat(price), config.Price)
+	rawMakerAmt := ratRoundDown(ratFromFloat(amount), config.Size)
 
+	var rawTakerAmt *big.Rat
 	if side == SideBuy {
-		rawMakerAmt := roundDown(amount, config.Size)
-		rawTakerAmt := rawMakerAmt / rawPrice
-
-		if decimalPlaces(rawTakerAmt) > config.Amount {
-			rawTakerAmt = roundUp(rawTakerAmt, config.Amount+4)
-			if decimalPlaces(rawTakerAmt) > config.Amount {
-				rawTakerAmt = roundDown(rawTakerAmt, config.Amount)
-			}
+		if rawPrice.Sign() == 0 {
+			return ratToUnits(rawMakerAmt), new(big.Int)
 		}
-
-		return toUnits(rawMakerAmt), toUnits(rawTakerAmt)
+		rawTakerAmt = roundAmount(new(big.Rat).Quo(rawMakerAmt, rawPrice), config.Amount)
+	} else {
+		rawTakerAmt = roundAmount(new(big.Rat).Mul(rawMakerAmt, rawPrice), config.Amount)
 	}
+	return ratToUnits(rawMakerAmt), ratToUnits(rawTakerAmt)
+}
 
-	rawMakerAmt := roundDown(amount, config.Size)
-	rawTakerAmt := rawMakerAmt * rawPrice
+// roundAmount 金额精度处理（与官方 SDK 一致）：小数位超过 decimals 时先向上取整到 decimals+4 位，仍超出则向下取整到 decimals 位
+func roundAmount(value *big.Rat, decimals int) *big.Rat {
+	if !hasMoreDecimals(value, decimals) {
+		return value
+	}
+	value = ratRoundUp(value, decimals+4)
+	if hasMoreDecimals(value, decimals) {
+		value = ratRoundDown(value, decimals)
+	}
+	return value
+}
 
-	if decimalPlaces(rawTakerAmt) > config.Amount {
-		rawTakerAmt = roundUp(rawTakerAmt, config.Amount+4)
-		if decimalPlaces(rawTakerAmt) > config.Amount {
-			rawTakerAmt = roundDown(rawTakerAmt, config.Amount)
-		}
+// ratFromFloat 按最短十进制表示将 float64 转为精确有理数（0.333 -> 333/1000）
+func ratFromFloat(value float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
 	}
+	return r
+}
+
+// ratToUnits 转换为链上单位 (6 位小数)，超出部分截断
+func ratToUnits(value *big.Rat) *big.Int {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10Int(polycommon.USDCDecimals)))
+	return new(big.Int).Quo(scaled.Num(), scaled.Denom())
+}
 
-	return toUnits(rawMakerAmt), toUnits(rawTakerAmt)
+// ratRoundDown 向下取整到 decimals 位小数 (ROUND_DOWN)
+func ratRoundDown(value *big.Rat, decimals int) *big.Rat {
+	return ratRound(value, decimals, func(num, den *big.Int) *big.Int {
+		return new(big.Int).Quo(num, den)
+	})
 }
 
-// toUnits 转换为链上单位
-func toUnits(value float64) *big.Int {
-	valueStr := fmt.Sprintf("%.6f", value)
-	parts := strings.Split(valueStr, ".")
-	intPart := parts[0]
-	decPart := "000000"
-	if len(parts) > 1 {
-		decPart = parts[1]
-		for len(decPart) < 6 {
-			decPart += "0"
+// ratRoundUp 向上取整到 decimals 位小数 (ROUND_UP)
+func ratRoundUp(value *big.Rat, decimals int) *big.Rat {
+	return ratRound(value, decimals, func(num, den *big.Int) *big.Int {
+		q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+		if m.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
 		}
-		if len(decPart) > 6 {
-			decPart = decPart[:6]
+		return q
+	})
+}
+
+// ratRoundHalfUp 四舍五入到 decimals 位小数 (ROUND_HALF_UP)
+func ratRoundHalfUp(value *big.Rat, decimals int) *big.Rat {
+	return ratRound(value, decimals, func(num, den *big.Int) *big.Int {
+		q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+		if new(big.Int).Lsh(m, 1).Cmp(den) >= 0 {
+			q.Add(q, big.NewInt(1))
 		}
-	}
-	result := new(big.Int)
-	result.SetString(intPart+decPart, 10)
-	return result
+		return q
+	})
 }
 
-func roundNormal(value float64, decimals int) float64 {
-	multiplier := pow10(decimals)
-	return float64(int(value*multiplier+0.5)) / multiplier
+// ratRound 将 value*10^decimals 按 round 取整后还原（value 为非负数）
+func ratRound(value *big.Rat, decimals int, round func(num, den *big.Int) *big.Int) *big.Rat {
+	scale := pow10Int(decimals)
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(scale))
+	return new(big.Rat).SetFrac(round(scaled.Num(), scaled.Denom()), scale)
 }
 
-func roundDown(value float64, decimals int) float64 {
-	multiplier := pow10(decimals)
-	return float64(int(value*multiplier)) / multiplier
+// hasMoreDecimals 小数位数是否超过 decimals
+func hasMoreDecimals(value *big.Rat, decimals int) bool {
+	return !new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10Int(decimals))).IsInt()
+}
+
+func pow10Int(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
 }
 
-func roundUp(value float64, decimals int) float64 {
-	multiplier := pow10(decimals)
-	return float64(int(value*multiplier)+1) / multiplier
+// roundDown 向下取整到 decimals 位小数
+func roundDown(value float64, decimals int) float64 {
+	f, _ := ratRoundDown(ratFromFloat(value), decimals).Float64()
+	return f
 }
 
-func pow10(n int) float64 {
-	result := 1.0
-	for i := 0; i < n; i++ {
-		result *= 10
+// PrivateOrder 将订单设置为私有订单：只能与指定的 taker 地址成交，其他用户无法吃单
+func PrivateOrder(order UserOrder, taker string) (UserOrder, error) {
+	if taker == "" || !common.IsHexAddress(taker) {
+		return order, fmt.Errorf("invalid taker address: %q", taker)
 	}
-	return result
+	if common.HexToAddress(taker) == (common.Address{}) {
+		return order, fmt.Errorf("private order taker must not be the zero address")
+	}
+	order.Taker = common.HexToAddress(taker).Hex()
+	return order, nil
 }
 
-func decimalPlaces(value float64) int {
-	str := fmt.Sprintf("%.10f", value)
-	parts := strings.Split(str, ".")
-	if len(parts) < 2 {
-		return 0
+// normalizeTaker 校验 taker 地址，空值表示公开订单 (零地址)
+func normalizeTaker(taker string) (string, error) {
+	if taker == "" {
+		return common.Address{}.Hex(), nil
+	}
+	if !common.IsHexAddress(taker) {
+		return "", fmt.Errorf("invalid taker address: %q", taker)
 	}
-	dec := strings.TrimRight(parts[1], "0")
-	return len(dec)
+	return common.HexToAddress(taker).Hex(), nil
 }
 
 func generateSalt() string {
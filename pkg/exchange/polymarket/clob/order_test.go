@@ -0,0 +1,377 @@
+package clob
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPrivateOrder(t *testing.T) {
+	base := UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}
+	tests := []struct {
+		name    string
+		taker   string
+		want    string
+		wantErr bool
+	}{
+		{"checksummed", "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", false},
+		{"lowercase normalized", "0x2791bca1f2de4661ed88a30c99a7a9449aa84174", "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", false},
+		{"empty", "", "", true},
+		{"zero address", "0x0000000000000000000000000000000000000000", "", true},
+		{"too short", "0x2791bca1f2de", "", true},
+		{"not hex", "0xZZ91bca1f2de4661ed88a30c99a7a9449aa84174", "", true},
+	}
+	for _, tt := range tests {
+		got, err := PrivateOrder(base, tt.taker)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: PrivateOrder err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Taker != tt.want {
+			t.Errorf("%s: taker = %s, want %s", tt.name, got.Taker, tt.want)
+		}
+		if tt.wantErr && got.Taker != "" {
+			t.Errorf("%s: taker set on error: %s", tt.name, got.Taker)
+		}
+	}
+}
+
+func TestCreateOrderTaker(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	opts := CreateOrderOptions{TickSize: TickSize001}
+	base := UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}
+
+	public, err := c.CreateOrder(base, opts)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if public.Taker != "0x0000000000000000000000000000000000000000" {
+		t.Errorf("public order taker = %s, want zero address", public.Taker)
+	}
+
+	private, err := PrivateOrder(base, "0x2791bca1f2de4661ed88a30c99a7a9449aa84174")
+	if err != nil {
+		t.Fatalf("PrivateOrder: %v", err)
+	}
+	signed, err := c.CreateOrder(private, opts)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if signed.Taker != "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174" {
+		t.Errorf("signed taker = %s", signed.Taker)
+	}
+
+	base.Taker = "not-an-address"
+	if _, err := c.CreateOrder(base, opts); err == nil {
+		t.Error("expected error for invalid taker")
+	}
+}
+
+func TestValidateExpiration(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name       string
+		expiration int64
+		wantErr    bool
+	}{
+		{"not GTD", 0, false},
+		{"negative", -1, true},
+		{"past", now.Add(-time.Minute).Unix(), true},
+		{"now", now.Unix(), true},
+		{"within buffer", now.Add(GTDMinBuffer - time.Second).Unix(), true},
+		{"at buffer", now.Add(GTDMinBuffer).Unix(), true},
+		{"after buffer", now.Add(GTDMinBuffer + time.Second).Unix(), false},
+		{"far future", now.Add(24 * time.Hour).Unix(), false},
+	}
+	for _, tt := range tests {
+		if err := validateExpiration(tt.expiration, now); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateExpiration(%d) err = %v, wantErr %v", tt.name, tt.expiration, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCreateGTDOrder(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	opts := CreateOrderOptions{TickSize: TickSize001}
+	base := UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}
+
+	expiresAt := time.Now().Add(time.Hour)
+	signed, err := c.CreateGTDOrder(base, expiresAt, opts)
+	if err != nil {
+		t.Fatalf("CreateGTDOrder: %v", err)
+	}
+	if want := fmt.Sprint(expiresAt.Unix()); signed.Expiration != want {
+		t.Errorf("expiration = %s, want %s", signed.Expiration, want)
+	}
+	if err := checkOrderExpiration(signed, OrderTypeGTD); err != nil {
+		t.Errorf("GTD order rejected: %v", err)
+	}
+	if err := checkOrderExpiration(signed, OrderTypeGTC); err == nil {
+		t.Error("expected error posting GTD order as GTC")
+	}
+
+	if _, err := c.CreateGTDOrder(base, time.Now().Add(-time.Minute), opts); err == nil {
+		t.Error("expected error for past expiration")
+	}
+	if _, err := c.CreateGTDOrder(base, time.Now().Add(GTDMinBuffer/2), opts); err == nil {
+		t.Error("expected error for expiration within buffer")
+	}
+
+	gtc, err := c.CreateOrder(base, opts)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if err := checkOrderExpiration(gtc, OrderTypeGTD); err == nil {
+		t.Error("expected error posting order without expiration as GTD")
+	}
+
+	// 市价单始终不设置过期时间
+	market, err := c.CreateMarketOrder(UserMarketOrder{TokenID: "123", Amount: 10, Side: SideBuy, Price: 0.5}, opts)
+	if err != nil {
+		t.Fatalf("CreateMarketOrder: %v", err)
+	}
+	if market.Expiration != "0" {
+		t.Errorf("market order expiration = %s, want 0", market.Expiration)
+	}
+	if err := checkOrderExpiration(market, OrderTypeFOK); err != nil {
+		t.Errorf("market order rejected: %v", err)
+	}
+}
+
+func TestCalculateOrderAmounts(t *testing.T) {
+	// 期望值按官方 TypeScript SDK 的舍入规则得出：价格 ROUND_HALF_UP，数量 ROUND_DOWN
+	tests := []struct {
+		side         Side
+		size, price  float64
+		tick         TickSize
+		maker, taker string
+	}{
+		{SideBuy, 21.04, 0.5, TickSize01, "10520000", "21040000"},
+		{SideSell, 21.04, 0.5, TickSize01, "21040000", "10520000"},
+		{SideBuy, 21.04, 0.56, TickSize001, "11782400", "21040000"},
+		{SideBuy, 10, 0.125, TickSize001, "1300000", "10000000"},
+		{SideBuy, 10.129, 0.5, TickSize001, "5060000", "10120000"},
+		{SideBuy, 100, 0.333, TickSize0001, "33300000", "100000000"},
+		{SideBuy, 21.04, 0.333, TickSize0001, "7006320", "21040000"},
+		{SideSell, 0.07, 0.333, TickSize0001, "70000", "23310"},
+		{SideSell, 21.04, 0.0056, TickSize00001, "21040000", "117824"},
+		{SideBuy, 123.45, 0.0057, TickSize00001, "703665", "123450000"},
+	}
+	for _, tt := range tests {
+		maker, taker := calculateOrderAmounts(tt.side, tt.size, tt.price, tt.tick)
+		if maker.String() != tt.maker || taker.String() != tt.taker {
+			t.Errorf("%s %v@%v tick %s: amounts = %s/%s, want %s/%s", tt.side, tt.size, tt.price, tt.tick, maker, taker, tt.maker, tt.taker)
+		}
+	}
+}
+
+func TestCalculateMarketOrderAmounts(t *testing.T) {
+	// 市价单价格 ROUND_DOWN；除不尽时先向上取整到 Amount+4 位，再向下取整到 Amount 位
+	tests := []struct {
+		side          Side
+		amount, price float64
+		tick          TickSize
+		maker, taker  string
+	}{
+		{SideBuy, 100, 0.333, TickSize0001, "100000000", "300300300"},
+		{SideBuy, 10, 0.3, TickSize01, "10000000", "33333000"},
+		{SideBuy, 5, 0.07, TickSize001, "5000000", "71428500"},
+		{SideBuy, 10, 0.5678, TickSize001, "10000000", "17857100"},
+		{SideSell, 21.04, 0.56, TickSize001, "21040000", "11782400"},
+		{SideSell, 21.047, 0.5, TickSize001, "21040000", "10520000"},
+	}
+	for _, tt := range tests {
+		maker, taker := calculateMarketOrderAmounts(tt.side, tt.amount, tt.price, tt.tick)
+		if maker.String() != tt.maker || taker.String() != tt.taker {
+			t.Errorf("%s %v@%v tick %s: amounts = %s/%s, want %s/%s", tt.side, tt.amount, tt.price, tt.tick, maker, taker, tt.maker, tt.taker)
+		}
+	}
+}
+
+// resolveServer 返回固定 tick size 和 neg risk 的测试服务器，posted 非空时接受并记录提交的订单
+func resolveServer(t *testing.T, tick float64, negRisk bool, posted *[]postOrderRequest) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tick-size", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, TickSizeResponse{MinimumTickSize: tick})
+	})
+	mux.HandleFunc("GET /neg-risk", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, NegRiskResponse{NegRisk: negRisk})
+	})
+	if posted != nil {
+		mux.HandleFunc("POST /order", func(w http.ResponseWriter, r *http.Request) {
+			var req postOrderRequest
+			readJSON(t, r, &req)
+			*posted = append(*posted, req)
+			writeJSON(t, w, OrderResponse{Success: true, OrderID: "0x1"})
+		})
+	}
+	return mux
+}
+
+// recoverOrderSigner 按指定交易所合约恢复订单签名者地址
+func recoverOrderSigner(t *testing.T, p orderPayload, negRisk bool) string {
+	t.Helper()
+	order := &SignedOrder{
+		Salt: p.Salt.String(), Maker: p.Maker, Signer: p.Signer, Taker: p.Taker, TokenID: p.TokenID,
+		MakerAmount: p.MakerAmount, TakerAmount: p.TakerAmount, Expiration: p.Expiration,
+		Nonce: p.Nonce, FeeRateBps: p.FeeRateBps, SignatureType: p.SignatureType,
+	}
+	if p.Side == "SELL" {
+		order.Side = 1
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(GetOrderHash(order, ChainIDPolygon, negRisk), "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(p.Signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		t.Fatalf("bad signature %s: %v", p.Signature, err)
+	}
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub).Hex()
+}
+
+func TestCreateAndPostOrderResolvesOptions(t *testing.T) {
+	var posted []postOrderRequest
+	c := newTestClient(t, resolveServer(t, 0.001, true, &posted))
+
+	// 调用方未指定 tick size 且误传 NegRisk=false：以服务端的 0.001 tick 和 neg risk 交易所为准
+	order := UserOrder{TokenID: "123", Price: 0.125, Size: 10, Side: SideBuy}
+	if _, err := c.CreateAndPostOrder(t.Context(), order, CreateOrderOptions{}, OrderTypeGTC); err != nil {
+		t.Fatalf("CreateAndPostOrder: %v", err)
+	}
+	if len(posted) != 1 {
+		t.Fatalf("posted %d orders, want 1", len(posted))
+	}
+	p := posted[0].Order
+	if p.MakerAmount != "1250000" || p.TakerAmount != "10000000" {
+		t.Errorf("amounts = %s/%s, want 1250000/10000000 (price 0.125 on 0.001 grid)", p.MakerAmount, p.TakerAmount)
+	}
+	if got := recoverOrderSigner(t, p, true); got != c.GetAddress() {
+		t.Errorf("order not signed for neg risk exchange: recovered %s, want %s", got, c.GetAddress())
+	}
+	if got := recoverOrderSigner(t, p, false); got == c.GetAddress() {
+		t.Error("order signed for standard exchange")
+	}
+}
+
+func TestCreateAndPostOrderRejectsFinerTick(t *testing.T) {
+	var posted []postOrderRequest
+	c := newTestClient(t, resolveServer(t, 0.01, false, &posted))
+
+	order := UserOrder{TokenID: "123", Price: 0.125, Size: 10, Side: SideBuy}
+	if _, err := c.CreateAndPostOrder(t.Context(), order, CreateOrderOptions{TickSize: TickSize0001}, OrderTypeGTC); err == nil {
+		t.Fatal("expected error for tick finer than market minimum")
+	}
+	// 价格超出 [tick, 1-tick] 时在签名前被拒
+	order.Price = 0.995
+	if _, err := c.CreateAndPostOrder(t.Context(), order, CreateOrderOptions{}, OrderTypeGTC); err == nil {
+		t.Fatal("expected error for price above 1-tick")
+	}
+	if len(posted) != 0 {
+		t.Errorf("posted %d orders, want none", len(posted))
+	}
+}
+
+// autoReduceServer 余额不足时拒单的测试服务器：下单数量超过 balance 可负担的数量时返回 rejection
+func autoReduceServer(t *testing.T, balance string, reject func(w http.ResponseWriter), posted *[]orderPayload) *http.ServeMux {
+	mux := resolveServer(t, 0.01, false, nil)
+	mux.HandleFunc("GET /balance-allowance", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, BalanceAllowanceResponse{Balance: balance, Allowance: balance})
+	})
+	mux.HandleFunc("POST /order", func(w http.ResponseWriter, r *http.Request) {
+		var req postOrderRequest
+		readJSON(t, r, &req)
+		*posted = append(*posted, req.Order)
+		if len(*posted) == 1 {
+			reject(w)
+			return
+		}
+		writeJSON(t, w, OrderResponse{Success: true, OrderID: "0x2"})
+	})
+	return mux
+}
+
+func TestCreateAndPostOrderAutoReduce(t *testing.T) {
+	httpReject := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"not enough balance / allowance"}`))
+	}
+	bodyReject := func(w http.ResponseWriter) {
+		writeJSON(t, w, OrderResponse{Success: false, ErrorMsg: "not enough balance / allowance"})
+	}
+	otherReject := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid signature"}`))
+	}
+
+	tests := []struct {
+		name       string
+		side       Side
+		balance    string
+		reject     func(w http.ResponseWriter)
+		autoReduce bool
+		wantSizes  []string // 每次提交的 token 数量（买单 takerAmount，卖单 makerAmount）
+		wantErr    bool
+	}{
+		// 30.123456 USDC / 0.5 = 60.246912 → 向下取整到 60.24
+		{"buy http rejection", SideBuy, "30123456", httpReject, true, []string{"100000000", "60240000"}, false},
+		{"buy body rejection", SideBuy, "30123456", bodyReject, true, []string{"100000000", "60240000"}, false},
+		// 卖单按 token 余额：42.5
+		{"sell", SideSell, "42500000", httpReject, true, []string{"100000000", "42500000"}, false},
+		{"disabled", SideBuy, "30123456", httpReject, false, []string{"100000000"}, true},
+		{"other rejection", SideBuy, "30123456", otherReject, true, []string{"100000000"}, true},
+		{"zero balance", SideBuy, "0", httpReject, true, []string{"100000000"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted []orderPayload
+			c := newTestClient(t, autoReduceServer(t, tt.balance, tt.reject, &posted))
+			order := UserOrder{TokenID: "123", Price: 0.5, Size: 100, Side: tt.side}
+			resp, err := c.CreateAndPostOrder(t.Context(), order, CreateOrderOptions{AutoReduce: tt.autoReduce}, OrderTypeGTC)
+			failed := err != nil || resp == nil || !resp.Success
+			if failed != tt.wantErr {
+				t.Fatalf("resp = %+v, err = %v, wantErr %v", resp, err, tt.wantErr)
+			}
+			var sizes []string
+			for _, p := range posted {
+				if tt.side == SideBuy {
+					sizes = append(sizes, p.TakerAmount)
+				} else {
+					sizes = append(sizes, p.MakerAmount)
+				}
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantSizes) {
+				t.Errorf("posted sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+		})
+	}
+}
+
+func TestOrderFillTxs(t *testing.T) {
+	var resp OrderResponse
+	body := `{"success":true,"orderID":"0x1","status":"matched","transactionsHashes":["0xaaa","","0xbbb"]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, http.NotFoundHandler())
+	if got := c.OrderFillTxs(&resp); fmt.Sprint(got) != "[0xaaa 0xbbb]" {
+		t.Errorf("OrderFillTxs = %v, want [0xaaa 0xbbb]", got)
+	}
+	if got := c.OrderFillTxs(&OrderResponse{Success: true, Status: "live"}); len(got) != 0 {
+		t.Errorf("unmatched order txs = %v, want none", got)
+	}
+	if got := c.OrderFillTxs(nil); got != nil {
+		t.Errorf("nil response txs = %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultFillPollInterval WaitForFill 默认轮询间隔
+const DefaultFillPollInterval = 500 * time.Millisecond
+
+var (
+	// ErrFillTimeout 等待成交超时
+	ErrFillTimeout = errors.New("wait for fill: timeout")
+	// ErrOrderClosed 订单已结束（取消或未成交）但未满足成交条件
+	ErrOrderClosed = errors.New("wait for fill: order closed")
+)
+
+// WaitForFillOptions 等待成交选项，零值字段使用默认值
+type WaitForFillOptions struct {
+	Interval time.Duration // 轮询间隔（默认 DefaultFillPollInterval）
+	Timeout  time.Duration // 最长等待时间（<= 0 表示仅受 ctx 控制）
+	Full     bool          // 要求完全成交（默认只要有部分成交即返回）
+}
+
+// FillResult 单个订单的等待结果，Order 为最后一次查询到的订单状态（可能为 nil）
+type FillResult struct {
+	OrderID string
+	Order   *OpenOrder
+	Err     error
+}
+
+// orderFetcher 获取单个订单（如 (*Client).GetOrder）
+type orderFetcher func(ctx context.Context, orderID string) (*OpenOrder, error)
+
+// WaitForFill 轮询 GetOrder 直到订单成交，替代固定 sleep 后查询的写法
+// 满足条件（SizeMatched > 0，opts.Full 时要求全部成交）立即返回订单；
+// 订单已取消/未成交时返回 ErrOrderClosed，超时返回 ErrFillTimeout，ctx 取消返回 ctx 错误，
+// 这些情况下同时返回最后一次查询到的订单
+func (c *Client) WaitForFill(ctx context.Context, orderID string, opts WaitForFillOptions) (*OpenOrder, error) {
+	return waitForFill(ctx, c.GetOrder, orderID, opts)
+}
+
+// WaitForFills 并发等待多个订单成交，结果与 orderIDs 顺序一致
+func (c *Client) WaitForFills(ctx context.Context, orderIDs []string, opts WaitForFillOptions) []FillResult {
+	results := make([]FillResult, len(orderIDs))
+	var wg sync.WaitGroup
+	for i, id := range orderIDs {
+		results[i].OrderID = id
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			results[i].Order, results[i].Err = c.WaitForFill(ctx, id, opts)
+		}(i, id)
+	}
+	wg.Wait()
+	return results
+}
+
+// waitForFill WaitForFill 的实现，fetch 可替换以便脱离 HTTP 使用
+func waitForFill(ctx context.Context, fetch orderFetcher, orderID string, opts WaitForFillOptions) (*OpenOrder, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultFillPollInterval
+	}
+	parent := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var last *OpenOrder
+	for {
+		order, err := fetch(ctx, orderID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, waitErr(parent, ctx)
+			}
+			return last, fmt.Errorf("get order: %w", err)
+		}
+		last = order
+
+		matched := parseFloat(order.SizeMatched)
+		if matched > 0 && (!opts.Full || matched >= parseFloat(order.OriginalSize)-1e-9) {
+			return order, nil
+		}
+		if order.Status == OrderStatusCanceled || order.Status == OrderStatusUnmatched || order.Status == OrderStatusMatched {
+			return order, fmt.Errorf("%w: %s status %s, matched %s of %s", ErrOrderClosed, orderID, order.Status, order.SizeMatched, order.OriginalSize)
+		}
+
+		if sleepCtx(ctx, interval) != nil {
+			return last, waitErr(parent, ctx)
+		}
+	}
+}
+
+// waitErr ctx 结束时的错误：调用方 ctx 结束返回其错误，否则为 opts.Timeout 到期，返回 ErrFillTimeout
+func waitErr(parent, ctx context.Context) error {
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ErrFillTimeout
+	}
+	return nil
+}
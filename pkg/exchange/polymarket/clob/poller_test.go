@@ -0,0 +1,132 @@
+package clob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// orderScript 测试用订单服务：第 n 次查询返回 states[min(n, len-1)]
+type orderScript struct {
+	mu     sync.Mutex
+	states map[string][]OpenOrder
+	polls  map[string]int
+}
+
+func (s *orderScript) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	states, ok := s.states[id]
+	n := s.polls[id]
+	s.polls[id]++
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	order := states[min(n, len(states)-1)]
+	order.ID = id
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// Polls 订单 id 已被查询的次数
+func (s *orderScript) Polls(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.polls[id]
+}
+
+// newOrderScriptClient 创建按 states 返回订单状态的客户端（不重试）
+func newOrderScriptClient(t *testing.T, states map[string][]OpenOrder) (*Client, *orderScript) {
+	t.Helper()
+	script := &orderScript{states: states, polls: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.Handle("GET /data/order/{id}", script)
+	return newTestClient(t, mux, func(cfg *ClientConfig) { cfg.MaxRetries = -1 }), script
+}
+
+// live 未结束的订单，原始数量 10
+func live(matched string) OpenOrder {
+	return OpenOrder{Status: OrderStatusLive, OriginalSize: "10", SizeMatched: matched}
+}
+
+func TestWaitForFillOutcomes(t *testing.T) {
+	c, script := newOrderScriptClient(t, map[string][]OpenOrder{
+		"fill":     {live("0"), live("0"), live("4")},
+		"partial":  {live("4"), live("4"), live("10")},
+		"canceled": {live("0"), {Status: OrderStatusCanceled, OriginalSize: "10", SizeMatched: "0"}},
+		"pending":  {live("0")},
+	})
+	opts := WaitForFillOptions{Interval: time.Millisecond}
+
+	// 第三次查询出现成交后立即返回
+	order, err := c.WaitForFill(t.Context(), "fill", opts)
+	if err != nil || order.SizeMatched != "4" {
+		t.Fatalf("fill: order = %+v, err = %v", order, err)
+	}
+	if n := script.Polls("fill"); n != 3 {
+		t.Errorf("fill polls = %d, want 3", n)
+	}
+
+	// Full：部分成交继续等待，全部成交才返回
+	full := opts
+	full.Full = true
+	order, err = c.WaitForFill(t.Context(), "partial", full)
+	if err != nil || order.SizeMatched != "10" || script.Polls("partial") != 3 {
+		t.Errorf("partial: order = %+v, err = %v, polls = %d", order, err, script.Polls("partial"))
+	}
+
+	// 取消且未成交
+	order, err = c.WaitForFill(t.Context(), "canceled", opts)
+	if !errors.Is(err, ErrOrderClosed) || order == nil || order.Status != OrderStatusCanceled {
+		t.Errorf("canceled: order = %+v, err = %v, want ErrOrderClosed", order, err)
+	}
+
+	// opts.Timeout 到期返回 ErrFillTimeout 和最后一次查询的订单
+	timeout := opts
+	timeout.Timeout = 20 * time.Millisecond
+	order, err = c.WaitForFill(t.Context(), "pending", timeout)
+	if !errors.Is(err, ErrFillTimeout) || order == nil || order.Status != OrderStatusLive {
+		t.Errorf("timeout: order = %+v, err = %v, want ErrFillTimeout", order, err)
+	}
+
+	// 调用方 ctx 结束返回 ctx 错误
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForFill(ctx, "pending", opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ctx deadline: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// 查询失败直接返回
+	if _, err := c.WaitForFill(t.Context(), "missing", opts); err == nil || errors.Is(err, ErrFillTimeout) {
+		t.Errorf("missing order: err = %v, want get order error", err)
+	}
+}
+
+func TestWaitForFills(t *testing.T) {
+	c, _ := newOrderScriptClient(t, map[string][]OpenOrder{
+		"a": {live("0"), live("2")},
+		"b": {{Status: OrderStatusCanceled, OriginalSize: "10", SizeMatched: "0"}},
+		"c": {live("10")},
+	})
+	results := c.WaitForFills(t.Context(), []string{"a", "b", "c"}, WaitForFillOptions{Interval: time.Millisecond, Timeout: time.Second})
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3", len(results))
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if results[i].OrderID != id || results[i].Order == nil || results[i].Order.ID != id {
+			t.Errorf("results[%d] = %+v, want order %s", i, results[i], id)
+		}
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("filled errors = %v, %v", results[0].Err, results[2].Err)
+	}
+	if !errors.Is(results[1].Err, ErrOrderClosed) {
+		t.Errorf("canceled err = %v, want ErrOrderClosed", results[1].Err)
+	}
+}
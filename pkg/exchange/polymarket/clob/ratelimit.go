@@ -0,0 +1,139 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// MaxRetryAfter 收到 429 时按 Retry-After 等待的最长时间，超过则不再重试直接返回错误
+const MaxRetryAfter = 10 * time.Second
+
+// newLimiter 创建令牌桶限流器，rps <= 0 表示不限流
+func newLimiter(rps float64, burst int) *rate.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = max(1, int(rps))
+	}
+	return rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// limiter 按请求方法选择限流桶：GET 使用读限流，其他 (POST/DELETE) 使用写限流
+func (c *Client) limiter(method string) *rate.Limiter {
+	if method == http.MethodGet {
+		return c.readLimiter
+	}
+	return c.writeLimiter
+}
+
+// sharedLimiterKey 多个客户端共享的限流器在 context 中的 key
+type sharedLimiterKey struct{}
+
+// withSharedLimiter 为 ctx 附加共享限流器：经该 ctx 发出的每个请求（含客户端内重试）都需获取其令牌，
+// 且 429 不在客户端内重试，交由调用方（如 ApiKeyScheduler）退避
+func withSharedLimiter(ctx context.Context, limiter *rate.Limiter) context.Context {
+	return context.WithValue(ctx, sharedLimiterKey{}, limiter)
+}
+
+// sharedLimiter 获取 ctx 中的共享限流器，未设置时返回 nil
+func sharedLimiter(ctx context.Context) *rate.Limiter {
+	limiter, _ := ctx.Value(sharedLimiterKey{}).(*rate.Limiter)
+	return limiter
+}
+
+// waitRate 等待客户端及 ctx 共享限流器的令牌，ctx 取消时返回错误
+func (c *Client) waitRate(req *http.Request) error {
+	for _, limiter := range []*rate.Limiter{c.limiter(req.Method), sharedLimiter(req.Context())} {
+		if limiter == nil {
+			continue
+		}
+		if err := limiter.Wait(req.Context()); err != nil {
+			return fmt.Errorf("rate limit wait: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），无效时返回 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// Retries 获取客户端累计的请求重试次数
+func (c *Client) Retries() int64 {
+	return c.retries.Load()
+}
+
+// retryable 判断请求失败后是否可以重试
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if req.Method == http.MethodGet {
+			return true
+		}
+		if isOrderSubmit(req) {
+			// 下单仅在连接未建立时重试：请求一旦发出，服务端可能已处理
+			var opErr *net.OpError
+			return errors.As(err, &opErr) && opErr.Op == "dial"
+		}
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return sharedLimiter(req.Context()) == nil
+	case resp.StatusCode >= 500:
+		return !isOrderSubmit(req)
+	}
+	return false
+}
+
+// isOrderSubmit 是否为下单请求
+func isOrderSubmit(req *http.Request) bool {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	return req.Method == http.MethodPost && (strings.HasSuffix(path, "/order") || strings.HasSuffix(path, "/orders"))
+}
+
+// rewind 复制请求用于重试（重建请求体）
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rebuild body: %w", err)
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// sleepCtx 等待 d，ctx 取消时提前返回错误
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
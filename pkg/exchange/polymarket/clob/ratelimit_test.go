@@ -0,0 +1,200 @@
+package clob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacing(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler(), func(cfg *ClientConfig) {
+		cfg.RequestsPerSecond = 10
+		cfg.Burst = 2
+		cfg.WriteRequestsPerSecond = 2
+	})
+
+	// 以固定时间点驱动令牌桶（假时钟）：突发用完后按 1/rps 间隔放行
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	read := c.limiter(http.MethodGet)
+	var delays []time.Duration
+	for range 5 {
+		delays = append(delays, read.ReserveN(now, 1).DelayFrom(now))
+	}
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("read request %d delay = %v, want %v", i, delays[i], want[i])
+		}
+	}
+
+	// 写请求使用独立的桶：读桶耗尽不影响写，突发默认取整为 2
+	write := c.limiter(http.MethodPost)
+	if write == read || c.limiter(http.MethodDelete) != write {
+		t.Fatal("POST/DELETE must share a write bucket separate from GET")
+	}
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond} {
+		if got := write.ReserveN(now, 1).DelayFrom(now); got != want {
+			t.Errorf("write request %d delay = %v, want %v", i, got, want)
+		}
+	}
+
+	if unlimited := newTestClient(t, http.NotFoundHandler()); unlimited.limiter(http.MethodGet) != nil {
+		t.Error("limiter configured without RequestsPerSecond")
+	}
+}
+
+func TestLimiterWaitsAndHonorsContext(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(t, w, map[string]string{"mid": "0.5"})
+	}), func(cfg *ClientConfig) {
+		cfg.RequestsPerSecond = 20
+		cfg.Burst = 1
+	})
+
+	start := time.Now()
+	for range 4 {
+		if _, err := c.GetMidpoint(t.Context(), "123"); err != nil {
+			t.Fatalf("GetMidpoint: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests at 20 rps took %v, want >= 150ms", elapsed)
+	}
+
+	// 等待令牌时 ctx 到期：不发出请求
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	c.GetMidpoint(t.Context(), "123") // 消耗令牌
+	before := calls.Load()
+	if _, err := c.GetMidpoint(ctx, "123"); err == nil {
+		t.Fatal("expected rate limit wait to fail on context deadline")
+	}
+	if calls.Load() != before {
+		t.Error("request sent after context expired")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if got := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); got < 59*time.Minute {
+		t.Errorf("parseRetryAfter(date) = %v, want ~1h", got)
+	}
+
+	// Retry-After 超过 MaxRetryAfter 时不再等待重试，直接返回 429
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	_, err := c.GetMidpoint(t.Context(), "123")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsRateLimited(err) || calls.Load() != 1 {
+		t.Errorf("err = %v, requests = %d, want one 429 without retry", err, calls.Load())
+	}
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	// flaky 前 failures 次返回 503，之后正常响应
+	flaky := func(failures int32, calls *atomic.Int32, ok func(w http.ResponseWriter)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= failures {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			ok(w)
+		}
+	}
+
+	var gets atomic.Int32
+	c := newTestClient(t, flaky(2, &gets, func(w http.ResponseWriter) { writeJSON(t, w, MidpointResponse{Mid: "0.5"}) }))
+	mid, err := c.GetMidpoint(t.Context(), "123")
+	if err != nil || mid != "0.5" {
+		t.Fatalf("GetMidpoint = %q, %v", mid, err)
+	}
+	if gets.Load() != 3 || c.Retries() != 2 {
+		t.Errorf("requests = %d, retries = %d, want 3 and 2", gets.Load(), c.Retries())
+	}
+
+	// 超过 MaxRetries 时返回最后一次的错误
+	gets.Store(0)
+	c = newTestClient(t, flaky(5, &gets, nil), func(cfg *ClientConfig) { cfg.MaxRetries = 1 })
+	if _, err := c.GetMidpoint(t.Context(), "123"); err == nil || gets.Load() != 2 {
+		t.Errorf("err = %v, requests = %d, want 503 after 2 requests", err, gets.Load())
+	}
+
+	// 下单收到 5xx 时服务端可能已处理，不重试
+	var posts atomic.Int32
+	c = newTestClient(t, flaky(1, &posts, func(w http.ResponseWriter) { writeJSON(t, w, OrderResponse{Success: true}) }))
+	order, err := c.CreateOrder(UserOrder{TokenID: "123", Price: 0.5, Size: 10, Side: SideBuy}, CreateOrderOptions{TickSize: TickSize001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PostOrder(t.Context(), order, OrderTypeGTC); err == nil || posts.Load() != 1 {
+		t.Errorf("PostOrder err = %v, requests = %d, want 503 without retry", err, posts.Load())
+	}
+
+	// 其他写请求在 5xx 时重试
+	var cancels atomic.Int32
+	c = newTestClient(t, flaky(1, &cancels, func(w http.ResponseWriter) { writeJSON(t, w, CancelOrdersResponse{}) }))
+	if _, err := c.CancelAll(t.Context()); err != nil || cancels.Load() != 2 {
+		t.Errorf("CancelAll err = %v, requests = %d, want success after 2 requests", err, cancels.Load())
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	get, _ := http.NewRequest(http.MethodGet, "http://clob/book", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://clob/order", nil)
+	batch, _ := http.NewRequest(http.MethodPost, "http://clob/orders/", nil)
+	del, _ := http.NewRequest(http.MethodDelete, "http://clob/order", nil)
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	dialErr := fmt.Errorf("do request: %w", &net.OpError{Op: "dial", Err: errors.New("refused")})
+	readErr := fmt.Errorf("do request: %w", &net.OpError{Op: "read", Err: errors.New("reset")})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"get 503", get, status(503), nil, true},
+		{"get 429", get, status(429), nil, true},
+		{"get 404", get, status(404), nil, false},
+		{"get network", get, nil, readErr, true},
+		{"order 503", post, status(503), nil, false},
+		{"order 429", post, status(429), nil, true},
+		{"order dial", post, nil, dialErr, true},
+		{"order read", post, nil, readErr, false},
+		{"batch order 502", batch, status(502), nil, false},
+		{"delete 500", del, status(500), nil, true},
+		{"delete 400", del, status(400), nil, false},
+		{"delete network", del, nil, readErr, true},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.req, tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
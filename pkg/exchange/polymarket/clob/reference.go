@@ -0,0 +1,213 @@
+package clob
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// ReferenceHalfLife ReferencePrice 中最新成交价权重减半所需的时间
+const ReferenceHalfLife = 5 * time.Minute
+
+// BestBid 最优买价，无买单时返回 0
+func (b *OrderBookSummary) BestBid() float64 {
+	var best float64
+	for _, lvl := range b.Bids {
+		if p := parseFloat(lvl.Price); p > best && parseFloat(lvl.Size) > 0 {
+			best = p
+		}
+	}
+	return best
+}
+
+// BestAsk 最优卖价，无卖单时返回 0
+func (b *OrderBookSummary) BestAsk() float64 {
+	var best float64
+	for _, lvl := range b.Asks {
+		if p := parseFloat(lvl.Price); p > 0 && (best == 0 || p < best) && parseFloat(lvl.Size) > 0 {
+			best = p
+		}
+	}
+	return best
+}
+
+// ToSnapshot 转换为 WebSocket 订单簿快照格式
+func (b *OrderBookSummary) ToSnapshot() *common.OrderBookSnapshot {
+	snapshot := &common.OrderBookSnapshot{
+		AssetID:   b.AssetID,
+		Market:    b.Market,
+		Timestamp: b.Timestamp,
+		Hash:      b.Hash,
+		Bids:      make([]common.OrderBookLevel, len(b.Bids)),
+		Asks:      make([]common.OrderBookLevel, len(b.Asks)),
+	}
+	for i, lvl := range b.Bids {
+		snapshot.Bids[i] = common.OrderBookLevel{Price: lvl.Price, Size: lvl.Size}
+	}
+	for i, lvl := range b.Asks {
+		snapshot.Asks[i] = common.OrderBookLevel{Price: lvl.Price, Size: lvl.Size}
+	}
+	return snapshot
+}
+
+// ReferencePrice 获取 token 的参考价（中间价与最新成交价按成交新鲜度加权，见 BlendReferencePrice）
+// 成交时间取自市场成交事件 (/live-activity/events)，与订单簿快照时间比较，均为服务端时间；
+// 订单簿单边或为空且没有成交事件时回退到 /last-trade-price，两者都不可用时返回错误
+func (c *Client) ReferencePrice(ctx context.Context, tokenID string) (float64, error) {
+	book, err := c.GetOrderBook(ctx, tokenID)
+	if err != nil {
+		return 0, fmt.Errorf("get order book: %w", err)
+	}
+	bid, ask := book.BestBid(), book.BestAsk()
+
+	last, age := c.lastTradeEvent(ctx, tokenID, book)
+	if last <= 0 && (bid <= 0 || ask <= 0) {
+		trade, err := c.GetLastTradePrice(ctx, tokenID)
+		if err != nil {
+			return 0, fmt.Errorf("get last trade price: %w", err)
+		}
+		last, age = parseFloat(trade.Price), -1
+	}
+
+	if price, ok := BlendReferencePrice(bid, ask, last, age); ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no reference price for token %s", tokenID)
+}
+
+// BlendReferencePrice 按成交新鲜度混合中间价与最新成交价
+// 双边订单簿时返回 mid + w*(last' - mid)，last' 为限制在 [bid, ask] 内的成交价，
+// w = 0.5^(age/ReferenceHalfLife)：刚发生的成交权重为 1，每过一个半衰期减半；
+// 没有成交价或成交时间未知 (age < 0) 时 w = 0，即中间价。
+// 单边或空订单簿时返回成交价（不论新旧），两者都不可用时 ok 为 false
+func BlendReferencePrice(bid, ask, last float64, age time.Duration) (price float64, ok bool) {
+	if bid <= 0 || ask <= 0 {
+		return last, last > 0
+	}
+	mid := (bid + ask) / 2
+	if last <= 0 || age < 0 {
+		return mid, true
+	}
+	w := math.Pow(0.5, float64(age)/float64(ReferenceHalfLife))
+	return mid + w*(min(max(last, bid), ask)-mid), true
+}
+
+// lastTradeEvent 从市场成交事件中找出该 token 最近一笔成交的价格，以及距订单簿快照的时间
+// 没有可用事件时返回 0, -1（事件接口失败不影响参考价，按无成交处理）
+func (c *Client) lastTradeEvent(ctx context.Context, tokenID string, book *OrderBookSummary) (float64, time.Duration) {
+	if book.Market == "" {
+		return 0, -1
+	}
+	events, err := c.GetMarketTradesEvents(ctx, book.Market)
+	if err != nil {
+		return 0, -1
+	}
+	var (
+		price    float64
+		tradedAt time.Time
+	)
+	for _, event := range events {
+		at, ok := parseUnixTimestamp(event.Timestamp)
+		if event.Market.AssetID != tokenID || !ok || !at.After(tradedAt) {
+			continue
+		}
+		if p := parseFloat(event.Price); p > 0 {
+			price, tradedAt = p, at
+		}
+	}
+	if price == 0 {
+		return 0, -1
+	}
+	now, ok := parseUnixTimestamp(book.Timestamp)
+	if !ok {
+		now = time.Now()
+	}
+	return price, max(now.Sub(tradedAt), 0)
+}
+
+// parseUnixTimestamp 解析秒或毫秒时间戳
+func parseUnixTimestamp(s string) (time.Time, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	if n > 1e12 {
+		return time.UnixMilli(n), true
+	}
+	return time.Unix(n, 0), true
+}
+
+// SpreadSummary 单个 token 的盘口摘要，一次订单簿请求得出
+type SpreadSummary struct {
+	TokenID  string
+	BestBid  float64 // 无买单时为 0
+	BestAsk  float64 // 无卖单时为 0
+	Midpoint float64 // 双边有挂单时为 (BestBid+BestAsk)/2，否则为 0
+	Spread   float64 // 双边有挂单时为 BestAsk-BestBid，否则为 0
+	TickSize float64
+}
+
+// TwoSided 买卖双边是否均有挂单
+func (s SpreadSummary) TwoSided() bool { return s.BestBid > 0 && s.BestAsk > 0 }
+
+// SpreadSummary 从订单簿计算盘口摘要，TickSize 取订单簿自带的 tick_size
+func (b *OrderBookSummary) SpreadSummary() SpreadSummary {
+	s := SpreadSummary{
+		TokenID:  b.AssetID,
+		BestBid:  b.BestBid(),
+		BestAsk:  b.BestAsk(),
+		TickSize: parseFloat(b.TickSize),
+	}
+	if s.TwoSided() {
+		// 价格最多 4 位小数，保留 6 位以消除浮点误差
+		s.Midpoint = math.Round((s.BestBid+s.BestAsk)/2*1e6) / 1e6
+		s.Spread = math.Round((s.BestAsk-s.BestBid)*1e6) / 1e6
+	}
+	return s
+}
+
+// GetMarketSpreadSummary 获取 token 的最优买卖价、中间价、价差和 tick size
+// 只请求一次订单簿，替代分别调用 GetMidpoint、GetSpread、GetOrderBook；
+// 订单簿未返回 tick_size 时使用缓存的 GetTickSize
+func (c *Client) GetMarketSpreadSummary(ctx context.Context, tokenID string) (*SpreadSummary, error) {
+	book, err := c.GetOrderBook(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("get order book: %w", err)
+	}
+	return c.spreadSummary(ctx, tokenID, book)
+}
+
+// GetMarketSpreadSummaries 批量获取盘口摘要（一次 /books 请求），按 token ID 索引
+func (c *Client) GetMarketSpreadSummaries(ctx context.Context, tokenIDs []string) (map[string]*SpreadSummary, error) {
+	books, err := c.GetOrderBooksMap(ctx, tokenIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get order books: %w", err)
+	}
+	result := make(map[string]*SpreadSummary, len(books))
+	for tokenID, book := range books {
+		s, err := c.spreadSummary(ctx, tokenID, book)
+		if err != nil {
+			return nil, err
+		}
+		result[tokenID] = s
+	}
+	return result, nil
+}
+
+// spreadSummary 计算盘口摘要，必要时补全 tick size
+func (c *Client) spreadSummary(ctx context.Context, tokenID string, book *OrderBookSummary) (*SpreadSummary, error) {
+	s := book.SpreadSummary()
+	s.TokenID = tokenID
+	if s.TickSize <= 0 {
+		tick, err := c.GetTickSize(ctx, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("get tick size: %w", err)
+		}
+		s.TickSize = parseFloat(string(tick))
+	}
+	return &s, nil
+}
@@ -0,0 +1,195 @@
+package clob
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBlendReferencePrice(t *testing.T) {
+	h := ReferenceHalfLife
+	tests := []struct {
+		name           string
+		bid, ask, last float64
+		age            time.Duration
+		want           float64
+		wantOK         bool
+	}{
+		{"fresh trade", 0.40, 0.50, 0.48, 0, 0.48, true},
+		{"one half-life", 0.40, 0.50, 0.48, h, 0.465, true},
+		{"two half-lives", 0.40, 0.50, 0.48, 2 * h, 0.4575, true},
+		{"stale trade", 0.40, 0.50, 0.48, 100 * h, 0.45, true},
+		{"unknown age", 0.40, 0.50, 0.48, -1, 0.45, true},
+		{"no trade", 0.40, 0.50, 0, 0, 0.45, true},
+		{"trade above ask clamped", 0.40, 0.50, 0.70, 0, 0.50, true},
+		{"trade below bid clamped", 0.40, 0.50, 0.10, h, 0.425, true},
+		{"bid only", 0.40, 0, 0.38, 100 * h, 0.38, true},
+		{"ask only", 0, 0.50, 0.52, -1, 0.52, true},
+		{"empty book", 0, 0, 0.30, -1, 0.30, true},
+		{"nothing", 0, 0.50, 0, -1, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := BlendReferencePrice(tt.bid, tt.ask, tt.last, tt.age)
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: BlendReferencePrice = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// referenceServer 返回固定订单簿、市场成交事件和最新成交价的测试服务器，lastTrade 为空时 /last-trade-price 返回 404
+func referenceServer(t *testing.T, book OrderBookSummary, events []MarketTradeEvent, lastTrade string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, book)
+	})
+	mux.HandleFunc("GET /live-activity/events/{market}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("market") != book.Market {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(t, w, events)
+	})
+	mux.HandleFunc("GET /last-trade-price", func(w http.ResponseWriter, r *http.Request) {
+		if lastTrade == "" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(t, w, LastTradePrice{Price: lastTrade, Side: "BUY"})
+	})
+	return mux
+}
+
+func TestReferencePrice(t *testing.T) {
+	const bookTS = "1740830400000" // 2025-03-01 12:00:00 UTC (毫秒)
+	twoSided := OrderBookSummary{
+		Market: "0xc", AssetID: "yes", Timestamp: bookTS,
+		Bids: []OrderSummary{{Price: "0.40", Size: "10"}},
+		Asks: []OrderSummary{{Price: "0.50", Size: "10"}},
+	}
+	bidOnly := twoSided
+	bidOnly.Asks = nil
+	empty := twoSided
+	empty.Bids, empty.Asks = nil, nil
+
+	// trade 构造订单簿快照前 secondsBeforeBook 秒的成交事件（秒级时间戳）
+	trade := func(asset, price string, secondsBeforeBook int64) MarketTradeEvent {
+		e := MarketTradeEvent{Price: price, Timestamp: strconv.FormatInt(1740830400-secondsBeforeBook, 10)}
+		e.Market.AssetID = asset
+		return e
+	}
+	halfLife := int64(ReferenceHalfLife / time.Second)
+
+	tests := []struct {
+		name      string
+		book      OrderBookSummary
+		events    []MarketTradeEvent
+		lastTrade string
+		want      float64
+		wantErr   bool
+	}{
+		// 最近一笔 yes 成交在一个半衰期前：0.45 + 0.5*(0.48-0.45)
+		{"two-sided weighted", twoSided, []MarketTradeEvent{
+			trade("yes", "0.44", 3*halfLife),
+			trade("no", "0.60", 0),
+			trade("yes", "0.48", halfLife),
+		}, "", 0.465, false},
+		{"two-sided no events", twoSided, nil, "0.49", 0.45, false},
+		{"one-sided uses event", bidOnly, []MarketTradeEvent{trade("yes", "0.42", 100*halfLife)}, "", 0.42, false},
+		{"one-sided falls back to last trade", bidOnly, nil, "0.43", 0.43, false},
+		{"empty book uses last trade", empty, nil, "0.30", 0.30, false},
+		{"empty book no trade", empty, nil, "", 0, true},
+		{"empty book zero trade", empty, nil, "0", 0, true},
+	}
+	for _, tt := range tests {
+		c := newTestClient(t, referenceServer(t, tt.book, tt.events, tt.lastTrade))
+		got, err := c.ReferencePrice(t.Context(), "yes")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: ReferencePrice = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrderBookSummarySpreadSummary(t *testing.T) {
+	tests := []struct {
+		name                      string
+		bids, asks                []OrderSummary
+		wantBid, wantAsk, wantMid float64
+		wantSpread                float64
+		wantTwoSided              bool
+	}{
+		{"two sided", []OrderSummary{{"0.40", "10"}, {"0.45", "5"}}, []OrderSummary{{"0.52", "10"}, {"0.48", "3"}}, 0.45, 0.48, 0.465, 0.03, true},
+		{"zero size ignored", []OrderSummary{{"0.45", "0"}, {"0.44", "5"}}, []OrderSummary{{"0.46", "0"}, {"0.47", "1"}}, 0.44, 0.47, 0.455, 0.03, true},
+		{"bid only", []OrderSummary{{"0.45", "5"}}, nil, 0.45, 0, 0, 0, false},
+		{"ask only", nil, []OrderSummary{{"0.48", "5"}}, 0, 0.48, 0, 0, false},
+		{"empty", nil, nil, 0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		book := &OrderBookSummary{AssetID: "tok", Bids: tt.bids, Asks: tt.asks, TickSize: "0.01"}
+		s := book.SpreadSummary()
+		if s.TokenID != "tok" || s.BestBid != tt.wantBid || s.BestAsk != tt.wantAsk || s.Midpoint != tt.wantMid || s.Spread != tt.wantSpread || s.TickSize != 0.01 {
+			t.Errorf("%s: summary = %+v, want bid %v ask %v mid %v spread %v", tt.name, s, tt.wantBid, tt.wantAsk, tt.wantMid, tt.wantSpread)
+		}
+		if s.TwoSided() != tt.wantTwoSided {
+			t.Errorf("%s: TwoSided = %v, want %v", tt.name, s.TwoSided(), tt.wantTwoSided)
+		}
+	}
+}
+
+func TestGetMarketSpreadSummaryTickSizeFallback(t *testing.T) {
+	tickCalls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		book := OrderBookSummary{AssetID: r.URL.Query().Get("token_id"), Bids: []OrderSummary{{"0.45", "5"}}, Asks: []OrderSummary{{"0.47", "5"}}}
+		if book.AssetID == "with-tick" {
+			book.TickSize = "0.001"
+		}
+		writeJSON(t, w, book)
+	})
+	mux.HandleFunc("POST /books", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string][]string
+		readJSON(t, r, &body)
+		// 批量订单簿均不带 tick_size，其中一个 token 为空盘口
+		writeJSON(t, w, []OrderBookSummary{
+			{AssetID: "no-tick", Bids: []OrderSummary{{"0.45", "5"}}, Asks: []OrderSummary{{"0.47", "5"}}},
+			{AssetID: "empty"},
+		})
+	})
+	mux.HandleFunc("GET /tick-size", func(w http.ResponseWriter, r *http.Request) {
+		tickCalls++
+		writeJSON(t, w, TickSizeResponse{MinimumTickSize: 0.01})
+	})
+	c := newTestClient(t, mux)
+
+	s, err := c.GetMarketSpreadSummary(t.Context(), "with-tick")
+	if err != nil || s.TickSize != 0.001 || tickCalls != 0 {
+		t.Fatalf("with tick: summary = %+v, err = %v, tick calls = %d", s, err, tickCalls)
+	}
+
+	// 订单簿缺少 tick_size 时查询一次并缓存
+	for range 2 {
+		s, err = c.GetMarketSpreadSummary(t.Context(), "no-tick")
+		if err != nil || s.TickSize != 0.01 || s.Midpoint != 0.46 || s.Spread != 0.02 {
+			t.Fatalf("no tick: summary = %+v, err = %v", s, err)
+		}
+	}
+	if tickCalls != 1 {
+		t.Errorf("tick size calls = %d, want 1 (cached)", tickCalls)
+	}
+
+	summaries, err := c.GetMarketSpreadSummaries(t.Context(), []string{"no-tick", "empty"})
+	if err != nil {
+		t.Fatalf("GetMarketSpreadSummaries: %v", err)
+	}
+	if s := summaries["no-tick"]; s == nil || s.TickSize != 0.01 {
+		t.Errorf("summaries[no-tick] = %+v", s)
+	}
+	if s := summaries["empty"]; s == nil || s.TwoSided() || s.BestBid != 0 || s.BestAsk != 0 || s.Midpoint != 0 || s.TickSize != 0.01 {
+		t.Errorf("summaries[empty] = %+v, want empty book with fallback tick size", s)
+	}
+}
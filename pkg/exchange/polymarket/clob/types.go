@@ -2,6 +2,9 @@ package clob
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
 )
@@ -64,23 +67,54 @@ const (
 	TickSize00001 TickSize = "0.0001"
 )
 
+// Float64 tick size 数值
+func (t TickSize) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(t), 64)
+	return f
+}
+
 // PriceHistoryInterval 价格历史间隔
 type PriceHistoryInterval string
 
 const (
-	PriceHistoryMax     PriceHistoryInterval = "max"
-	PriceHistoryOneWeek PriceHistoryInterval = "1w"
-	PriceHistoryOneDay  PriceHistoryInterval = "1d"
-	PriceHistory6Hours  PriceHistoryInterval = "6h"
-	PriceHistoryOneHour PriceHistoryInterval = "1h"
+	PriceHistoryMax      PriceHistoryInterval = "max"
+	PriceHistoryOneMonth PriceHistoryInterval = "1m"
+	PriceHistoryOneWeek  PriceHistoryInterval = "1w"
+	PriceHistoryOneDay   PriceHistoryInterval = "1d"
+	PriceHistory6Hours   PriceHistoryInterval = "6h"
+	PriceHistoryOneHour  PriceHistoryInterval = "1h"
 )
 
+// priceHistoryIntervalMinutes 各间隔覆盖的分钟数 (max 不限)
+var priceHistoryIntervalMinutes = map[PriceHistoryInterval]int{
+	PriceHistoryOneMonth: 30 * 24 * 60,
+	PriceHistoryOneWeek:  7 * 24 * 60,
+	PriceHistoryOneDay:   24 * 60,
+	PriceHistory6Hours:   6 * 60,
+	PriceHistoryOneHour:  60,
+}
+
+// priceHistoryMinFidelity 各间隔允许的最小 fidelity (分钟)，更细的分辨率会被服务端拒绝
+var priceHistoryMinFidelity = map[PriceHistoryInterval]int{
+	PriceHistoryOneMonth: 10,
+	PriceHistoryOneWeek:  5,
+}
+
 // 分页常量
 const (
 	InitialCursor = "MA=="  // Base64("0")
 	EndCursor     = "LTE="  // Base64("-1")
 )
 
+// CancelBatchSize 单次批量取消的最大订单数
+const CancelBatchSize = 100
+
+// GTDMinBuffer GTD 订单过期时间相对当前时间的最小间隔（服务端存在 1 分钟安全阈值）
+const GTDMinBuffer = 60 * time.Second
+
+// CancelPollInterval CancelAndWait 轮询订单状态的间隔
+const CancelPollInterval = 200 * time.Millisecond
+
 // PaginationParams 分页查询参数
 type PaginationParams struct {
 	NextCursor string `url:"next_cursor,omitempty"`
@@ -247,6 +281,32 @@ type PriceHistoryParams struct {
 	Interval PriceHistoryInterval `url:"interval"`
 }
 
+// Validate 校验 interval 与 fidelity 组合
+// fidelity 为数据分辨率（分钟），需不低于该间隔允许的最小值且不超过间隔本身的时长
+func (p PriceHistoryParams) Validate() error {
+	if p.Market == "" {
+		return fmt.Errorf("market is required")
+	}
+	if p.Interval != "" && p.Interval != PriceHistoryMax {
+		if _, ok := priceHistoryIntervalMinutes[p.Interval]; !ok {
+			return fmt.Errorf("invalid interval: %s", p.Interval)
+		}
+	}
+	if p.Fidelity < 0 {
+		return fmt.Errorf("fidelity must be positive: %d", p.Fidelity)
+	}
+	if p.Fidelity == 0 {
+		return nil
+	}
+	if minFidelity := priceHistoryMinFidelity[p.Interval]; p.Fidelity < minFidelity {
+		return fmt.Errorf("fidelity %dm too fine for interval %s (minimum %dm)", p.Fidelity, p.Interval, minFidelity)
+	}
+	if span, ok := priceHistoryIntervalMinutes[p.Interval]; ok && p.Fidelity > span {
+		return fmt.Errorf("fidelity %dm exceeds interval %s (%dm)", p.Fidelity, p.Interval, span)
+	}
+	return nil
+}
+
 // MarketPrice 市场价格
 type MarketPrice struct {
 	T int64   `json:"t"`
@@ -308,7 +368,7 @@ type UserOrder struct {
 	FeeRateBps int     `json:"feeRateBps,omitempty"`
 	Nonce      int64   `json:"nonce,omitempty"`
 	Expiration int64   `json:"expiration,omitempty"`
-	Taker      string  `json:"taker,omitempty"`
+	Taker      string  `json:"taker,omitempty"` // 为空表示公开订单；指定地址则仅与该地址成交 (见 PrivateOrder)
 }
 
 // UserMarketOrder 用户市价单
@@ -325,8 +385,11 @@ type UserMarketOrder struct {
 
 // CreateOrderOptions 创建订单选项
 type CreateOrderOptions struct {
-	TickSize TickSize `json:"tickSize"`
-	NegRisk  bool     `json:"negRisk,omitempty"`
+	TickSize  TickSize `json:"tickSize"`
+	NegRisk   bool     `json:"negRisk,omitempty"`
+	DeferExec bool     `json:"deferExec,omitempty"` // 延迟撮合，仅对 CreateAndPost* 生效（见 PostOrderDeferred）
+	// AutoReduce 余额不足被拒时，按当前余额可负担的最大数量重试一次（仅对 CreateAndPostOrder 生效）
+	AutoReduce bool `json:"autoReduce,omitempty"`
 }
 
 // SignedOrder 签名订单
@@ -361,6 +424,7 @@ type OrderResponse struct {
 type PostOrdersArgs struct {
 	Order     SignedOrder `json:"order"`
 	OrderType OrderType   `json:"orderType"`
+	DeferExec bool        `json:"deferExec,omitempty"` // 延迟撮合
 }
 
 // CancelOrderResponse 取消单个订单响应
@@ -400,6 +464,40 @@ type OpenOrder struct {
 	OrderType       string   `json:"order_type"`
 }
 
+// ToOrderUpdate 转换为 WebSocket 订单更新格式 (Type 为 UPDATE)
+func (o OpenOrder) ToOrderUpdate() *common.OrderUpdate {
+	return &common.OrderUpdate{
+		ID:              o.ID,
+		Market:          o.Market,
+		AssetID:         o.AssetID,
+		EventType:       "order",
+		Type:            "UPDATE",
+		Side:            o.Side,
+		Price:           o.Price,
+		Size:            o.OriginalSize,
+		SizeMatched:     o.SizeMatched,
+		OriginalSize:    o.OriginalSize,
+		Outcome:         o.Outcome,
+		Owner:           o.Owner,
+		AssociateTrades: o.AssociateTrades,
+		Timestamp:       strconv.FormatInt(o.CreatedAt, 10),
+	}
+}
+
+// 订单状态
+const (
+	OrderStatusLive      = "LIVE"
+	OrderStatusMatched   = "MATCHED"
+	OrderStatusCanceled  = "CANCELED"
+	OrderStatusDelayed   = "DELAYED"
+	OrderStatusUnmatched = "UNMATCHED"
+)
+
+// IsOpen 订单是否仍在订单簿中
+func (o OpenOrder) IsOpen() bool {
+	return o.ID != "" && (o.Status == OrderStatusLive || o.Status == OrderStatusDelayed)
+}
+
 // OpenOrderParams 未结订单查询参数
 type OpenOrderParams struct {
 	ID      string `url:"id,omitempty" json:"id,omitempty"`
@@ -455,6 +553,41 @@ type Trade struct {
 	TraderSide      string       `json:"trader_side"`
 }
 
+// ToNotification 转换为 WebSocket 成交通知格式
+func (t Trade) ToNotification() *common.TradeNotification {
+	n := &common.TradeNotification{
+		ID:           t.ID,
+		EventType:    "trade",
+		Market:       t.Market,
+		AssetID:      t.AssetID,
+		TakerOrderID: t.TakerOrderID,
+		Side:         string(t.Side),
+		Price:        t.Price,
+		Size:         t.Size,
+		FeeRateBps:   t.FeeRateBps,
+		Status:       t.Status,
+		Outcome:      t.Outcome,
+		Owner:        t.Owner,
+		MatchTime:    t.MatchTime,
+		LastUpdate:   t.LastUpdate,
+		TradeID:      t.ID,
+		TraderSide:   t.TraderSide,
+		Type:         "TRADE",
+	}
+	for _, m := range t.MakerOrders {
+		n.MakerOrders = append(n.MakerOrders, common.MakerOrder{
+			AssetID:       m.AssetID,
+			MatchedAmount: m.MatchedAmount,
+			OrderID:       m.OrderID,
+			Outcome:       m.Outcome,
+			Owner:         m.Owner,
+			Price:         m.Price,
+			Side:          string(m.Side),
+		})
+	}
+	return n
+}
+
 // TradeParams 交易查询参数
 type TradeParams struct {
 	ID           string `url:"id,omitempty" json:"id,omitempty"`
@@ -615,6 +748,11 @@ type BuilderApiKeyResponse struct {
 	RevokedAt string `json:"revokedAt,omitempty"`
 }
 
+// Revoked 是否已撤销
+func (r BuilderApiKeyResponse) Revoked() bool {
+	return r.RevokedAt != ""
+}
+
 // BanStatus 封禁状态
 type BanStatus struct {
 	ClosedOnly bool `json:"closed_only"`
@@ -0,0 +1,67 @@
+package clob
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPriceHistoryParamsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval PriceHistoryInterval
+		fidelity int
+		wantErr  bool
+	}{
+		{"default fidelity", PriceHistoryOneDay, 0, false},
+		{"1h minute bars", PriceHistoryOneHour, 1, false},
+		{"1h full span", PriceHistoryOneHour, 60, false},
+		{"1d minute bars", PriceHistoryOneDay, 1, false},
+		{"6h hourly", PriceHistory6Hours, 60, false},
+		{"1w minimum", PriceHistoryOneWeek, 5, false},
+		{"1m minimum", PriceHistoryOneMonth, 10, false},
+		{"max daily", PriceHistoryMax, 1440, false},
+		{"empty interval", "", 5, false},
+		{"1w too fine", PriceHistoryOneWeek, 1, true},
+		{"1m too fine", PriceHistoryOneMonth, 5, true},
+		{"1h coarser than span", PriceHistoryOneHour, 61, true},
+		{"1d coarser than span", PriceHistoryOneDay, 1441, true},
+		{"negative", PriceHistoryOneDay, -1, true},
+		{"unknown interval", "2d", 5, true},
+	}
+	for _, tt := range tests {
+		p := PriceHistoryParams{Market: "123", Interval: tt.interval, Fidelity: tt.fidelity}
+		if err := p.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate(%s, %d) err = %v, wantErr %v", tt.name, tt.interval, tt.fidelity, err, tt.wantErr)
+		}
+	}
+	if err := (PriceHistoryParams{Interval: PriceHistoryOneDay}).Validate(); err == nil {
+		t.Error("expected error for missing market")
+	}
+}
+
+func TestGetPriceHistoryRejectsInvalidFidelity(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /prices-history", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.URL.Query().Get("fidelity"); got != "5" {
+			t.Errorf("fidelity = %q, want 5", got)
+		}
+		writeJSON(t, w, map[string][]MarketPrice{"history": {{T: 1, P: 0.5}}})
+	})
+	c := newTestClient(t, mux)
+
+	if _, err := c.GetPriceHistory(t.Context(), PriceHistoryParams{Market: "123", Interval: PriceHistoryOneWeek, Fidelity: 1}); err == nil {
+		t.Fatal("expected error for 1m fidelity on 1w interval")
+	}
+	if requests != 0 {
+		t.Fatalf("invalid params sent %d requests", requests)
+	}
+	history, err := c.GetPriceHistory(t.Context(), PriceHistoryParams{Market: "123", Interval: PriceHistoryOneWeek, Fidelity: 5})
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if requests != 1 || len(history) != 1 {
+		t.Errorf("requests = %d, history = %v", requests, history)
+	}
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ArbitrageSide 二元市场套利方向
+type ArbitrageSide string
+
+const (
+	ArbitrageBuyBoth  ArbitrageSide = "BUY_BOTH"  // 两边卖价之和 < 1：买入两个结果后合并赎回 1 USDC
+	ArbitrageSellBoth ArbitrageSide = "SELL_BOTH" // 两边买价之和 > 1：卖出两个结果（持有或拆分 1 USDC 得到）
+)
+
+// ArbitrageOptions 套利检测选项
+type ArbitrageOptions struct {
+	// FeeRateBps taker 费率（基点），按 Polymarket 公式每份收取 rate * min(p, 1-p)
+	FeeRateBps float64
+	// MinProfitPerShare 每份最低利润（USDC），低于该值的档位不计入（默认 0，即严格有利可图）
+	MinProfitPerShare float64
+	// SizeDecimals 份额精度，结果向下取整到该位数 (默认 2，与下单数量精度一致)
+	SizeDecimals int
+}
+
+// BinaryArbitrage 二元市场双边套利机会
+type BinaryArbitrage struct {
+	Side      ArbitrageSide
+	Size      float64 // 可无风险成交的份额（两边相同）
+	Value     float64 // BUY_BOTH 为含手续费的总成本，SELL_BOTH 为扣除手续费后的总收入
+	Fees      float64 // 两边手续费合计
+	Profit    float64 // 保证利润：BUY_BOTH 为 Size - Value，SELL_BOTH 为 Value - Size
+	UpPrice   float64 // Up 侧吃到的最差价格（可作为限价）
+	DownPrice float64 // Down 侧吃到的最差价格
+}
+
+// ProfitPercent 利润率（相对投入的百分比）
+func (a *BinaryArbitrage) ProfitPercent() float64 {
+	base := a.Value
+	if a.Side == ArbitrageSellBoth {
+		base = a.Size
+	}
+	if base <= 0 {
+		return 0
+	}
+	return a.Profit / base * 100
+}
+
+// DetectBinaryArbitrage 检测买入两个结果的套利：逐档吃两边卖单，
+// 只要每份 (upAsk + downAsk + 手续费) <= 1 - MinProfitPerShare 就继续累加，考虑全部深度而非仅最优档
+// 不存在套利（或可成交份额按精度取整后为 0）时返回 nil
+func DetectBinaryArbitrage(up, down *OrderBookSnapshot, opts ArbitrageOptions) *BinaryArbitrage {
+	if up == nil || down == nil {
+		return nil
+	}
+	upLevels := sortedLevels(up.Asks, true)
+	downLevels := sortedLevels(down.Asks, true)
+	return detectArbitrage(ArbitrageBuyBoth, upLevels, downLevels, opts)
+}
+
+// DetectBinarySellArbitrage 检测卖出两个结果的套利：逐档吃两边买单，
+// 只要每份 (upBid + downBid - 手续费) >= 1 + MinProfitPerShare 就继续累加
+// 卖出需要持有两边份额（或先用 1 USDC 拆分出一对）；不存在套利时返回 nil
+func DetectBinarySellArbitrage(up, down *OrderBookSnapshot, opts ArbitrageOptions) *BinaryArbitrage {
+	if up == nil || down == nil {
+		return nil
+	}
+	upLevels := sortedLevels(up.Bids, false)
+	downLevels := sortedLevels(down.Bids, false)
+	return detectArbitrage(ArbitrageSellBoth, upLevels, downLevels, opts)
+}
+
+// arbLevel 解析后的价格档位
+type arbLevel struct {
+	price float64
+	size  float64
+}
+
+// sortedLevels 解析并排序档位（ascending 为 true 时价格从低到高），忽略无效或数量为 0 的档位
+func sortedLevels(levels []OrderBookLevel, ascending bool) []arbLevel {
+	out := make([]arbLevel, 0, len(levels))
+	for _, lvl := range levels {
+		p, err1 := strconv.ParseFloat(lvl.Price, 64)
+		s, err2 := strconv.ParseFloat(lvl.Size, 64)
+		if err1 != nil || err2 != nil || p <= 0 || p >= 1 || s <= 0 {
+			continue
+		}
+		out = append(out, arbLevel{price: p, size: s})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ascending {
+			return out[i].price < out[j].price
+		}
+		return out[i].price > out[j].price
+	})
+	return out
+}
+
+// detectArbitrage 双指针同时遍历两边档位，累计每份仍有利可图的份额
+func detectArbitrage(side ArbitrageSide, upLevels, downLevels []arbLevel, opts ArbitrageOptions) *BinaryArbitrage {
+	decimals := opts.SizeDecimals
+	if decimals <= 0 {
+		decimals = 2
+	}
+	feeRate := opts.FeeRateBps / 10000
+	fee := func(p float64) float64 { return feeRate * min(p, 1-p) }
+	// profitable 每份利润为正（容忍浮点误差）且不低于 MinProfitPerShare
+	profitable := func(pu, pd float64) bool {
+		margin := 1 - (pu + pd + fee(pu) + fee(pd))
+		if side == ArbitrageSellBoth {
+			margin = pu + pd - fee(pu) - fee(pd) - 1
+		}
+		return margin > 1e-9 && margin >= opts.MinProfitPerShare-1e-9
+	}
+
+	// 第一遍：确定可成交份额
+	var size float64
+	i, j := 0, 0
+	upLeft, downLeft := 0.0, 0.0
+	for i < len(upLevels) && j < len(downLevels) {
+		if upLeft == 0 {
+			upLeft = upLevels[i].size
+		}
+		if downLeft == 0 {
+			downLeft = downLevels[j].size
+		}
+		if !profitable(upLevels[i].price, downLevels[j].price) {
+			break
+		}
+		q := min(upLeft, downLeft)
+		size += q
+		upLeft -= q
+		downLeft -= q
+		if upLeft == 0 {
+			i++
+		}
+		if downLeft == 0 {
+			j++
+		}
+	}
+
+	scale := math.Pow10(decimals)
+	size = math.Floor(size*scale+1e-9) / scale
+	if size <= 0 {
+		return nil
+	}
+
+	// 第二遍：按取整后的份额计算两边成交额和手续费
+	upValue, upFees, upWorst := fillLevels(upLevels, size, fee)
+	downValue, downFees, downWorst := fillLevels(downLevels, size, fee)
+	arb := &BinaryArbitrage{
+		Side:      side,
+		Size:      size,
+		Fees:      upFees + downFees,
+		UpPrice:   upWorst,
+		DownPrice: downWorst,
+	}
+	if side == ArbitrageBuyBoth {
+		arb.Value = upValue + downValue + arb.Fees
+		arb.Profit = size - arb.Value
+	} else {
+		arb.Value = upValue + downValue - arb.Fees
+		arb.Profit = arb.Value - size
+	}
+	return arb
+}
+
+// fillLevels 按顺序吃 size 份，返回成交额、手续费和最差价格
+func fillLevels(levels []arbLevel, size float64, fee func(float64) float64) (value, fees, worst float64) {
+	remaining := size
+	for _, lvl := range levels {
+		if remaining <= 1e-12 {
+			break
+		}
+		q := min(remaining, lvl.size)
+		value += q * lvl.price
+		fees += q * fee(lvl.price)
+		worst = lvl.price
+		remaining -= q
+	}
+	return value, fees, worst
+}
@@ -0,0 +1,129 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+// levels 由 (price, size) 字符串对构造档位
+func levels(pairs ...string) []OrderBookLevel {
+	out := make([]OrderBookLevel, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, OrderBookLevel{Price: pairs[i], Size: pairs[i+1]})
+	}
+	return out
+}
+
+func TestDetectBinaryArbitrage(t *testing.T) {
+	tests := []struct {
+		name      string
+		upAsks    []OrderBookLevel
+		downAsks  []OrderBookLevel
+		opts      ArbitrageOptions
+		wantNil   bool
+		wantSize  float64
+		wantValue float64
+		wantFees  float64
+		wantUp    float64
+		wantDown  float64
+	}{
+		{name: "no arbitrage", upAsks: levels("0.50", "100"), downAsks: levels("0.51", "100"), wantNil: true},
+		{name: "exactly one dollar", upAsks: levels("0.50", "100"), downAsks: levels("0.50", "100"), wantNil: true},
+		{name: "empty side", upAsks: levels("0.40", "100"), downAsks: nil, wantNil: true},
+		{
+			name:   "top level only",
+			upAsks: levels("0.45", "10", "0.60", "100"), downAsks: levels("0.50", "100"),
+			wantSize: 10, wantValue: 9.5, wantUp: 0.45, wantDown: 0.50,
+		},
+		{
+			// (0.45,0.50) 成交 50，(0.45,0.52) 成交 50，(0.56,0.52) 无利可图
+			name:   "at depth",
+			upAsks: levels("0.56", "100", "0.45", "100"), downAsks: levels("0.52", "100", "0.50", "50"),
+			wantSize: 100, wantValue: 96, wantUp: 0.45, wantDown: 0.52,
+		},
+		{
+			name:   "min profit per share stops at first level",
+			upAsks: levels("0.45", "100"), downAsks: levels("0.50", "50", "0.52", "100"),
+			opts:     ArbitrageOptions{MinProfitPerShare: 0.04},
+			wantSize: 50, wantValue: 47.5, wantUp: 0.45, wantDown: 0.50,
+		},
+		{
+			// 每份利润 0.02，手续费 0.01*(0.48+0.50)=0.0098
+			name:   "fee reduces profit",
+			upAsks: levels("0.48", "100"), downAsks: levels("0.50", "100"),
+			opts:     ArbitrageOptions{FeeRateBps: 100},
+			wantSize: 100, wantValue: 98.98, wantFees: 0.98, wantUp: 0.48, wantDown: 0.50,
+		},
+		{
+			// 手续费 0.03*(0.48+0.50)=0.0294 超过 0.02 的价差
+			name:   "fee removes arbitrage",
+			upAsks: levels("0.48", "100"), downAsks: levels("0.50", "100"),
+			opts:    ArbitrageOptions{FeeRateBps: 300},
+			wantNil: true,
+		},
+		{
+			name:   "size rounded down to default precision",
+			upAsks: levels("0.45", "10.456"), downAsks: levels("0.50", "20"),
+			wantSize: 10.45, wantValue: 9.9275, wantUp: 0.45, wantDown: 0.50,
+		},
+		{
+			name:   "size rounded down to custom precision",
+			upAsks: levels("0.45", "10.456"), downAsks: levels("0.50", "20"),
+			opts:     ArbitrageOptions{SizeDecimals: 1},
+			wantSize: 10.4, wantValue: 9.88, wantUp: 0.45, wantDown: 0.50,
+		},
+		{name: "size below precision", upAsks: levels("0.45", "0.004"), downAsks: levels("0.50", "20"), wantNil: true},
+		{
+			name:   "invalid levels ignored",
+			upAsks: levels("abc", "100", "0.45", "0", "1", "50", "0.40", "10"), downAsks: levels("0.50", "10"),
+			wantSize: 10, wantValue: 9, wantUp: 0.40, wantDown: 0.50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arb := DetectBinaryArbitrage(&OrderBookSnapshot{Asks: tt.upAsks}, &OrderBookSnapshot{Asks: tt.downAsks}, tt.opts)
+			if tt.wantNil {
+				if arb != nil {
+					t.Fatalf("arb = %+v, want nil", arb)
+				}
+				return
+			}
+			if arb == nil {
+				t.Fatal("arb = nil")
+			}
+			if arb.Side != ArbitrageBuyBoth || !approxEqual(arb.Size, tt.wantSize) || !approxEqual(arb.Value, tt.wantValue) || !approxEqual(arb.Fees, tt.wantFees) {
+				t.Errorf("arb = %+v, want size %v value %v fees %v", arb, tt.wantSize, tt.wantValue, tt.wantFees)
+			}
+			if !approxEqual(arb.Profit, tt.wantSize-tt.wantValue) {
+				t.Errorf("profit = %v, want %v", arb.Profit, tt.wantSize-tt.wantValue)
+			}
+			if arb.UpPrice != tt.wantUp || arb.DownPrice != tt.wantDown {
+				t.Errorf("worst prices = %v/%v, want %v/%v", arb.UpPrice, arb.DownPrice, tt.wantUp, tt.wantDown)
+			}
+		})
+	}
+
+	if DetectBinaryArbitrage(nil, &OrderBookSnapshot{}, ArbitrageOptions{}) != nil {
+		t.Error("nil book should yield nil")
+	}
+}
+
+func TestDetectBinarySellArbitrage(t *testing.T) {
+	up := &OrderBookSnapshot{Bids: levels("0.50", "100", "0.55", "20")}
+	down := &OrderBookSnapshot{Bids: levels("0.50", "30")}
+	arb := DetectBinarySellArbitrage(up, down, ArbitrageOptions{})
+	if arb == nil {
+		t.Fatal("arb = nil")
+	}
+	// 只有 (0.55,0.50) 一档有利：卖出 20 份收入 21
+	if arb.Side != ArbitrageSellBoth || !approxEqual(arb.Size, 20) || !approxEqual(arb.Value, 21) || !approxEqual(arb.Profit, 1) {
+		t.Errorf("arb = %+v", arb)
+	}
+	if got := arb.ProfitPercent(); math.Abs(got-5) > 1e-9 {
+		t.Errorf("ProfitPercent = %v, want 5", got)
+	}
+
+	if arb := DetectBinarySellArbitrage(&OrderBookSnapshot{Bids: levels("0.50", "10")}, &OrderBookSnapshot{Bids: levels("0.49", "10")}, ArbitrageOptions{}); arb != nil {
+		t.Errorf("no-arb sell = %+v, want nil", arb)
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// bookHashSummary 计算订单簿 hash 时序列化的摘要，字段顺序与服务端一致
+type bookHashSummary struct {
+	Market    string           `json:"market"`
+	AssetID   string           `json:"asset_id"`
+	Timestamp string           `json:"timestamp"`
+	Bids      []OrderBookLevel `json:"bids"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Hash      string           `json:"hash"`
+}
+
+// ComputeBookHash 按官方 SDK 算法计算订单簿 hash：对 hash 置空的摘要 JSON 做 SHA1（十六进制）
+// market/asset_id/timestamp 取自对应的 book 或 price_change 消息；价位按服务端顺序排列
+// （bids 价格升序，asks 价格降序），价格和数量使用服务端的字符串格式
+func ComputeBookHash(market, assetID, timestamp string, bids, asks []OrderBookLevel) string {
+	if bids == nil {
+		bids = []OrderBookLevel{}
+	}
+	if asks == nil {
+		asks = []OrderBookLevel{}
+	}
+	b, _ := json.Marshal(bookHashSummary{
+		Market:    market,
+		AssetID:   assetID,
+		Timestamp: timestamp,
+		Bids:      bids,
+		Asks:      asks,
+	})
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeHash 按官方算法重新计算快照的 hash
+func (s *OrderBookSnapshot) ComputeHash() string {
+	return ComputeBookHash(s.Market, s.AssetID, s.Timestamp, s.Bids, s.Asks)
+}
+
+// VerifyHash 校验快照内容与其 hash 字段是否一致，hash 为空时返回 true
+func (s *OrderBookSnapshot) VerifyHash() bool {
+	return s.Hash == "" || s.ComputeHash() == s.Hash
+}
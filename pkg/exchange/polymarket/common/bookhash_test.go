@@ -0,0 +1,53 @@
+package common
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestComputeBookHash(t *testing.T) {
+	bids := []OrderBookLevel{{Price: "0.47", Size: "50"}, {Price: "0.48", Size: "100.5"}}
+	asks := []OrderBookLevel{{Price: "0.52", Size: "20"}, {Price: "0.5", Size: "80"}}
+
+	// 官方 SDK：hash 置空后对摘要 JSON 做 SHA1，字段顺序 market/asset_id/timestamp/bids/asks/hash
+	want := sha1Hex(`{"market":"0xabc","asset_id":"123","timestamp":"1700000000000",` +
+		`"bids":[{"price":"0.47","size":"50"},{"price":"0.48","size":"100.5"}],` +
+		`"asks":[{"price":"0.52","size":"20"},{"price":"0.5","size":"80"}],"hash":""}`)
+	if got := ComputeBookHash("0xabc", "123", "1700000000000", bids, asks); got != want {
+		t.Errorf("ComputeBookHash = %s, want %s", got, want)
+	}
+
+	// 空的一侧序列化为 []
+	want = sha1Hex(`{"market":"0xabc","asset_id":"123","timestamp":"1","bids":[],"asks":[],"hash":""}`)
+	if got := ComputeBookHash("0xabc", "123", "1", nil, nil); got != want {
+		t.Errorf("ComputeBookHash(empty) = %s, want %s", got, want)
+	}
+}
+
+func TestOrderBookSnapshotVerifyHash(t *testing.T) {
+	snap := &OrderBookSnapshot{
+		Market:    "0xabc",
+		AssetID:   "123",
+		Timestamp: "1700000000000",
+		Bids:      []OrderBookLevel{{Price: "0.48", Size: "100"}},
+		Asks:      []OrderBookLevel{{Price: "0.5", Size: "80"}},
+	}
+	if !snap.VerifyHash() {
+		t.Error("empty hash should verify")
+	}
+	snap.Hash = snap.ComputeHash()
+	if !snap.VerifyHash() {
+		t.Error("computed hash should verify")
+	}
+	// hash 覆盖 timestamp，相同价位不同时间戳的快照 hash 不同
+	snap.Timestamp = "1700000000001"
+	if snap.VerifyHash() {
+		t.Error("hash should not verify after timestamp changed")
+	}
+}
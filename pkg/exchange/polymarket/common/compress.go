@@ -0,0 +1,64 @@
+package common
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipTransport 显式协商 gzip 并透明解压响应，仅包装默认传输层
+// 自定义 Transport（如 replay.Recorder）不包装：否则其收到的是压缩后的响应体，录制的数据无法直接阅读和回放
+type gzipTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 调用方自行设置了 Accept-Encoding 时不干预，由调用方负责解码
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || req.Method == http.MethodHead {
+		return resp, nil
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		if err == io.EOF {
+			// 空响应体
+			resp.Body = http.NoBody
+			return resp, nil
+		}
+		return nil, fmt.Errorf("gzip reader: %w", err)
+	}
+	resp.Body = &gzipBody{zr: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipBody 解压后的响应体，关闭时同时关闭底层连接
+type gzipBody struct {
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) { return b.zr.Read(p) }
+
+func (b *gzipBody) Close() error {
+	b.zr.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const gzipPayload = `{"data":"abcdefgh","count":3}`
+
+// gzipServer 客户端声明支持 gzip 时返回压缩响应，并记录每次请求的 Accept-Encoding
+func gzipServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu        sync.Mutex
+		encodings []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept-Encoding")
+		mu.Lock()
+		encodings = append(encodings, accept)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(accept, "gzip") {
+			w.Write([]byte(gzipPayload))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(gzipPayload))
+		zw.Close()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), encodings...)
+	}
+}
+
+// bodyRecorder 记录底层传输层返回的原始响应体（模拟 replay.Recorder）
+type bodyRecorder struct {
+	base   http.RoundTripper
+	bodies [][]byte
+}
+
+func (r *bodyRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.bodies = append(r.bodies, body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+type gzipResult struct {
+	Data  string `json:"data"`
+	Count int    `json:"count"`
+}
+
+func TestHTTPClientGzip(t *testing.T) {
+	srv, encodings := gzipServer(t)
+	c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL})
+	t.Cleanup(c.Close)
+
+	var result gzipResult
+	if err := c.GetJSON(t.Context(), "/", nil, &result); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if result.Data != "abcdefgh" || result.Count != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := encodings(); len(got) != 1 || got[0] != "gzip" {
+		t.Errorf("Accept-Encoding = %v, want gzip", got)
+	}
+}
+
+func TestHTTPClientDisableCompression(t *testing.T) {
+	srv, encodings := gzipServer(t)
+	c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, DisableCompression: true})
+	t.Cleanup(c.Close)
+
+	var result gzipResult
+	if err := c.GetJSON(t.Context(), "/", nil, &result); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if result.Count != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := encodings(); len(got) != 1 || got[0] != "" {
+		t.Errorf("Accept-Encoding = %v, want none", got)
+	}
+}
+
+func TestHTTPClientCustomTransportSeesPlainBody(t *testing.T) {
+	srv, _ := gzipServer(t)
+	rec := &bodyRecorder{base: http.DefaultTransport}
+	c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, Transport: rec})
+	t.Cleanup(c.Close)
+
+	var result gzipResult
+	if err := c.GetJSON(t.Context(), "/", nil, &result); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if result.Count != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	// 自定义传输层拿到的是解压后的数据，录制结果可直接回放
+	if len(rec.bodies) != 1 || string(rec.bodies[0]) != gzipPayload {
+		t.Errorf("recorded body = %q, want plain JSON", rec.bodies)
+	}
+}
@@ -25,6 +25,9 @@ const (
 
 	// Proxy Wallet Factory
 	ContractProxyWalletFactory = "0xaB45c5A4B0c941a2F231C04C3f49182e1A254052"
+
+	// Relay Hub (PROXY 钱包 GSN 中继)
+	ContractRelayHub = "0xD216153c06E857cD7f72665E0aF1d7D82172F494"
 )
 
 // 代币精度
@@ -41,6 +44,7 @@ const (
 	WssBaseURL        = "wss://ws-subscriptions-clob.polymarket.com"
 	RelayerURL        = "https://relayer-v2.polymarket.com/"
 	PolygonRPCDefault = "https://polygon-rpc.com"
+	UserPnLAPIBaseURL = "https://user-pnl-api.polymarket.com"
 )
 
 // Chain ID
@@ -51,6 +55,9 @@ const (
 // Safe 部署常量
 const (
 	SafeInitCodeHash = "0x2bce2127ff07fb632d16c8347c4ebf501f4841168bed00d9e6ef715ddb6fcecf"
+
+	// ProxyInitCodeHash ProxyWalletFactory 创建 PROXY 钱包的 init code hash
+	ProxyInitCodeHash = "0xd21df8dc65880a8606f09fe0ce3df9b8869287ab0b058be05aa9e8af6330a00b"
 )
 
 // CTF 操作常量
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -27,14 +28,65 @@ type HTTPClientConfig struct {
 	ProxyString string // 格式: host:port 或 host:port:user:pass 或 host:port:user:pass:socks5
 	Debug       bool
 	Retry       int
+	// RetryPredicate 判断失败请求是否重试，默认 DefaultRetryPredicate（非幂等的 POST 不重试）
+	RetryPredicate RetryPredicate
+	// Transport 自定义传输层（如 replay.Recorder/Replayer），设置后忽略 ProxyString
+	Transport http.RoundTripper
+	// DisableCompression 禁用 gzip 协商（默认发送 Accept-Encoding: gzip 并透明解压）
+	// 设置 Transport 时不做 gzip 处理，由自定义传输层自行协商
+	DisableCompression bool
+	// Logger 记录请求、状态码、耗时和重试（默认 Debug 时输出到 stderr，否则不输出）
+	Logger Logger
+	// Hook 请求/响应钩子（可选）
+	Hook *HTTPHook
+	// TransportOptions 连接池和 TLS 参数（设置 Transport 时忽略）
+	TransportOptions
+}
+
+// 默认连接池参数
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// TransportOptions 默认传输层的连接池和 TLS 参数，零值字段使用默认值
+// 调大 MaxIdleConnsPerHost/MaxConnsPerHost 可提升同一主机（如 CLOB）的并发吞吐，
+// 但不会提高服务端限流额度：Polymarket 按 IP/API Key 限流，并发过高只会更快触发 429，
+// 应同时配合客户端限流（如 clob.ClientConfig.RequestsPerSecond）使用
+type TransportOptions struct {
+	MaxIdleConns        int           // 所有主机的最大空闲连接数 (默认 100)
+	MaxIdleConnsPerHost int           // 每个主机的最大空闲连接数 (默认 10)
+	MaxConnsPerHost     int           // 每个主机的最大连接数，包括使用中的连接 (默认 0 不限制)
+	IdleConnTimeout     time.Duration // 空闲连接保持时间 (默认 90s)
+	TLSHandshakeTimeout time.Duration // TLS 握手超时 (默认 0 不限制，受请求超时约束)
+	DisableKeepAlives   bool          // 禁用连接复用，每个请求新建连接
+	// BaseTransport 自定义基础传输层（会被复制，不修改原对象），上述非零字段和 ProxyString 仍会应用
+	BaseTransport *http.Transport
+}
+
+// RetryPredicate 判断失败请求是否重试，status 为 0 表示网络错误
+type RetryPredicate func(method string, status int, body []byte) bool
+
+// DefaultRetryPredicate 仅对幂等方法的网络错误及 429/5xx 重试
+// POST 可能已被服务端处理（如下单），盲目重试会导致重复提交
+func DefaultRetryPredicate(method string, status int, body []byte) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return status == 0 || status == 429 || status >= 500
 }
 
 // HTTPClient HTTP 客户端
 type HTTPClient struct {
-	Client  *http.Client
-	BaseURL string
-	debug   bool
-	retry   int
+	Client      *http.Client
+	BaseURL     string
+	debug       bool
+	retry       int
+	shouldRetry RetryPredicate
+	logger      Logger
 }
 
 // NewHTTPClient 创建 HTTP 客户端
@@ -45,21 +97,23 @@ func NewHTTPClient(cfg HTTPClientConfig) *HTTPClient {
 	if cfg.Retry == 0 {
 		cfg.Retry = 2
 	}
-
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
+	if cfg.RetryPredicate == nil {
+		cfg.RetryPredicate = DefaultRetryPredicate
 	}
 
-	// 配置代理
-	if cfg.ProxyString != "" {
-		configureProxy(transport, cfg.ProxyString)
+	var transport http.RoundTripper = cfg.Transport
+	if transport == nil {
+		base := newTransport(cfg.ProxyString, cfg.TransportOptions)
+		if cfg.DisableCompression {
+			base.DisableCompression = true
+			transport = base
+		} else {
+			transport = &gzipTransport{base: base}
+		}
+	}
+	logger := resolveLogger(cfg.Logger, cfg.Debug)
+	if _, nop := logger.(NopLogger); !nop || cfg.Hook != nil {
+		transport = &observeTransport{base: transport, logger: logger, hook: cfg.Hook}
 	}
 
 	return &HTTPClient{
@@ -67,12 +121,65 @@ func NewHTTPClient(cfg HTTPClientConfig) *HTTPClient {
 			Transport: transport,
 			Timeout:   cfg.Timeout,
 		},
-		BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
-		debug:   cfg.Debug,
-		retry:   cfg.Retry,
+		BaseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+		debug:       cfg.Debug,
+		retry:       cfg.Retry,
+		shouldRetry: cfg.RetryPredicate,
+		logger:      logger,
 	}
 }
 
+// Logger 客户端使用的 Logger（未配置时为 NopLogger）
+func (c *HTTPClient) Logger() Logger { return c.logger }
+
+// Close 关闭传输层的空闲连接，可重复调用；关闭后仍可继续发起请求（会建立新连接）
+func (c *HTTPClient) Close() {
+	c.Client.CloseIdleConnections()
+}
+
+// newTransport 创建默认传输层
+func newTransport(proxyString string, opts TransportOptions) *http.Transport {
+	var transport *http.Transport
+	if opts.BaseTransport != nil {
+		transport = opts.BaseTransport.Clone()
+	} else {
+		transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        DefaultMaxIdleConns,
+			MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+			IdleConnTimeout:     DefaultIdleConnTimeout,
+		}
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	if opts.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+
+	// 配置代理
+	if proxyString != "" {
+		configureProxy(transport, proxyString)
+	}
+	return transport
+}
+
 // configureProxy 配置代理
 func configureProxy(transport *http.Transport, proxyString string) {
 	parts := strings.Split(proxyString, ":")
@@ -143,7 +250,8 @@ func (c *HTTPClient) Get(ctx context.Context, path string, params interface{}) (
 		resp, err := c.Client.Do(req)
 		if err != nil {
 			lastErr = err
-			if i < c.retry {
+			if i < c.retry && c.shouldRetry(http.MethodGet, 0, nil) {
+				c.logger.Warnf("retry GET %s (%d/%d): %v", path, i+1, c.retry, err)
 				time.Sleep(time.Duration(i+1) * 500 * time.Millisecond)
 				continue
 			}
@@ -159,9 +267,10 @@ func (c *HTTPClient) Get(ctx context.Context, path string, params interface{}) (
 
 		if resp.StatusCode >= 400 {
 			// 可重试的状态码
-			if resp.StatusCode == 429 || resp.StatusCode >= 500 {
+			if c.shouldRetry(http.MethodGet, resp.StatusCode, body) {
 				lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 				if i < c.retry {
+					c.logger.Warnf("retry GET %s (%d/%d): HTTP %d", path, i+1, c.retry, resp.StatusCode)
 					time.Sleep(time.Duration(i+1) * time.Second)
 					continue
 				}
@@ -181,24 +290,28 @@ func (c *HTTPClient) GetJSON(ctx context.Context, path string, params interface{
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(body, result)
+	return unmarshalBody(body, result)
 }
 
 // Post 发送 POST 请求
 func (c *HTTPClient) Post(ctx context.Context, path string, data interface{}) ([]byte, error) {
 	urlStr := c.BaseURL + path
 
-	var bodyReader io.Reader
+	var jsonData []byte
 	if data != nil {
-		jsonData, err := json.Marshal(data)
-		if err != nil {
+		var err error
+		if jsonData, err = json.Marshal(data); err != nil {
 			return nil, fmt.Errorf("marshal body: %w", err)
 		}
-		bodyReader = strings.NewReader(string(jsonData))
 	}
 
 	var lastErr error
 	for i := 0; i <= c.retry; i++ {
+		// 每次重试重新构造 body，避免复用已读取的 Reader
+		var bodyReader io.Reader
+		if jsonData != nil {
+			bodyReader = strings.NewReader(string(jsonData))
+		}
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlStr, bodyReader)
 		if err != nil {
 			return nil, fmt.Errorf("create request: %w", err)
@@ -210,7 +323,8 @@ func (c *HTTPClient) Post(ctx context.Context, path string, data interface{}) ([
 		resp, err := c.Client.Do(req)
 		if err != nil {
 			lastErr = err
-			if i < c.retry {
+			if i < c.retry && c.shouldRetry(http.MethodPost, 0, nil) {
+				c.logger.Warnf("retry POST %s (%d/%d): %v", path, i+1, c.retry, err)
 				time.Sleep(time.Duration(i+1) * 500 * time.Millisecond)
 				continue
 			}
@@ -221,13 +335,17 @@ func (c *HTTPClient) Post(ctx context.Context, path string, data interface{}) ([
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			lastErr = err
+			if !c.shouldRetry(http.MethodPost, 0, nil) {
+				return nil, fmt.Errorf("read body: %w", err)
+			}
 			continue
 		}
 
 		if resp.StatusCode >= 400 {
-			if resp.StatusCode == 429 || resp.StatusCode >= 500 {
+			if c.shouldRetry(http.MethodPost, resp.StatusCode, body) {
 				lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 				if i < c.retry {
+					c.logger.Warnf("retry POST %s (%d/%d): HTTP %d", path, i+1, c.retry, resp.StatusCode)
 					time.Sleep(time.Duration(i+1) * time.Second)
 					continue
 				}
@@ -247,6 +365,15 @@ func (c *HTTPClient) PostJSON(ctx context.Context, path string, data interface{}
 	if err != nil {
 		return err
 	}
+	return unmarshalBody(body, result)
+}
+
+// unmarshalBody 解析响应体，空响应体或 null 视为无数据（result 保持零值）
+func unmarshalBody(body []byte, result interface{}) error {
+	trimmed := strings.TrimSpace(string(body))
+	if trimmed == "" || trimmed == "null" {
+		return nil
+	}
 	return json.Unmarshal(body, result)
 }
 
@@ -255,16 +382,27 @@ func BuildQuery(params interface{}) string {
 	if params == nil {
 		return ""
 	}
+	if values, ok := params.(url.Values); ok {
+		return values.Encode()
+	}
 
 	values := url.Values{}
 	v := reflect.ValueOf(params)
 	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
 		v = v.Elem()
 	}
 	if v.Kind() != reflect.Struct {
 		return ""
 	}
+	addQueryValues(values, v)
+	return values.Encode()
+}
 
+// addQueryValues 按 url tag 将结构体字段写入 values，匿名嵌入的结构体（如 EventQueryParams 中的 MarketQueryParams）递归展开
+func addQueryValues(values url.Values, v reflect.Value) {
 	t := v.Type()
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
@@ -272,6 +410,10 @@ func BuildQuery(params interface{}) string {
 
 		// 获取 url tag
 		tag := fieldType.Tag.Get("url")
+		if fieldType.Anonymous && tag == "" && field.Kind() == reflect.Struct {
+			addQueryValues(values, field)
+			continue
+		}
 		if tag == "" || tag == "-" {
 			continue
 		}
@@ -318,8 +460,6 @@ func BuildQuery(params interface{}) string {
 			}
 		}
 	}
-
-	return values.Encode()
 }
 
 // ProxyConfig 代理配置（解析后）
@@ -397,3 +537,46 @@ func CreateProxyDialer(proxyString string) (proxy.Dialer, error) {
 	// HTTP 代理返回 nil，由调用方使用 GetProxyURL
 	return nil, nil
 }
+
+// ErrProxyAuthFailed SOCKS5 代理拒绝用户名/密码认证
+var ErrProxyAuthFailed = errors.New("proxy authentication failed")
+
+// ProxyDialContextFunc 支持 context 的拨号函数
+type ProxyDialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// CreateProxyDialContext 创建支持 context 的 SOCKS5 拨号函数（HTTP 代理返回 nil）
+// 认证失败时返回包装了 ErrProxyAuthFailed 的错误
+func CreateProxyDialContext(proxyString string) (ProxyDialContextFunc, error) {
+	cfg := ParseProxyString(proxyString)
+	if cfg == nil || !cfg.IsSocks() {
+		return nil, nil
+	}
+
+	dialer, err := CreateProxyDialer(proxyString)
+	if err != nil {
+		return nil, fmt.Errorf("create socks5 dialer: %w", err)
+	}
+	ctxDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("socks5 dialer does not support context")
+	}
+
+	proxyAddr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := ctxDialer.DialContext(ctx, network, addr)
+		if err != nil {
+			if isProxyAuthError(err) {
+				return nil, fmt.Errorf("socks5 proxy %s: %w: %v", proxyAddr, ErrProxyAuthFailed, err)
+			}
+			return nil, fmt.Errorf("socks5 proxy %s: %w", proxyAddr, err)
+		}
+		return conn, nil
+	}, nil
+}
+
+// isProxyAuthError 判断是否为 SOCKS5 认证失败（x/net/proxy 未导出对应错误类型）
+func isProxyAuthError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "authentication failed") ||
+		strings.Contains(msg, "no acceptable authentication methods")
+}
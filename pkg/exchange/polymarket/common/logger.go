@@ -0,0 +1,126 @@
+package common
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Logger 日志接口，可适配 log、zap、slog 等
+type Logger interface {
+	Debugf(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// NopLogger 不输出任何内容的 Logger（默认）
+type NopLogger struct{}
+
+func (NopLogger) Debugf(string, ...any) {}
+func (NopLogger) Warnf(string, ...any)  {}
+func (NopLogger) Errorf(string, ...any) {}
+
+// StdLogger 基于标准库 log.Logger 的 Logger，级别作为前缀输出
+type StdLogger struct {
+	*log.Logger
+}
+
+// NewStdLogger 创建输出到 stderr 的 StdLogger
+func NewStdLogger() *StdLogger {
+	return &StdLogger{Logger: log.New(os.Stderr, "[polymarket] ", log.LstdFlags|log.Lmicroseconds)}
+}
+
+func (l *StdLogger) Debugf(format string, args ...any) { l.Printf("DEBUG "+format, args...) }
+func (l *StdLogger) Warnf(format string, args ...any)  { l.Printf("WARN "+format, args...) }
+func (l *StdLogger) Errorf(format string, args ...any) { l.Printf("ERROR "+format, args...) }
+
+// HTTPHook HTTP 请求/响应钩子，字段均可选
+// 每次实际发出的请求（包括重试）各触发一次
+type HTTPHook struct {
+	OnRequest func(method, url string)
+	// OnResponse 响应体读取完毕或关闭时触发；status 为 0 表示网络错误，bytes 为（解压后的）响应体字节数
+	OnResponse func(status int, duration time.Duration, bytes int64)
+}
+
+// resolveLogger 按配置选择 Logger：显式设置优先，其次 debug 时输出到 stderr，否则不输出
+func resolveLogger(logger Logger, debug bool) Logger {
+	switch {
+	case logger != nil:
+		return logger
+	case debug:
+		return NewStdLogger()
+	default:
+		return NopLogger{}
+	}
+}
+
+// observeTransport 记录请求、状态码、耗时并调用 HTTPHook 的传输层
+type observeTransport struct {
+	base   http.RoundTripper
+	logger Logger
+	hook   *HTTPHook
+}
+
+func (t *observeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	method, url := req.Method, req.URL.String()
+	if t.hook != nil && t.hook.OnRequest != nil {
+		t.hook.OnRequest(method, url)
+	}
+	t.logger.Debugf("http request: %s %s", method, url)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		elapsed := time.Since(start)
+		t.logger.Warnf("http error: %s %s (%v): %v", method, url, elapsed, err)
+		t.observe(0, elapsed, 0)
+		return nil, err
+	}
+
+	status := resp.StatusCode
+	resp.Body = &observedBody{ReadCloser: resp.Body, done: func(n int64) {
+		elapsed := time.Since(start)
+		switch {
+		case status >= 500:
+			t.logger.Errorf("http response: %s %s -> %d (%v, %d bytes)", method, url, status, elapsed, n)
+		case status >= 400:
+			t.logger.Warnf("http response: %s %s -> %d (%v, %d bytes)", method, url, status, elapsed, n)
+		default:
+			t.logger.Debugf("http response: %s %s -> %d (%v, %d bytes)", method, url, status, elapsed, n)
+		}
+		t.observe(status, elapsed, n)
+	}}
+	return resp, nil
+}
+
+func (t *observeTransport) observe(status int, elapsed time.Duration, n int64) {
+	if t.hook != nil && t.hook.OnResponse != nil {
+		t.hook.OnResponse(status, elapsed, n)
+	}
+}
+
+// observedBody 统计读取的字节数，读到 EOF 或关闭时回调一次
+type observedBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.n) })
+	}
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
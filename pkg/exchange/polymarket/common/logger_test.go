@@ -0,0 +1,137 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordLogger 按级别记录日志
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) log(level, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Debugf(format string, args ...any) { l.log("DEBUG", format, args...) }
+func (l *recordLogger) Warnf(format string, args ...any)  { l.log("WARN", format, args...) }
+func (l *recordLogger) Errorf(format string, args ...any) { l.log("ERROR", format, args...) }
+
+// find 返回以 prefix 开头的日志行
+func (l *recordLogger) find(prefix string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []string
+	for _, line := range l.lines {
+		if strings.HasPrefix(line, prefix) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+type hookResponse struct {
+	status   int
+	duration time.Duration
+	bytes    int64
+}
+
+func TestHTTPHookAndLoggerOnRetry(t *testing.T) {
+	srv, _ := flakyServer(t, 1)
+	logger := &recordLogger{}
+	var (
+		mu        sync.Mutex
+		requests  []string
+		responses []hookResponse
+	)
+	c := NewHTTPClient(HTTPClientConfig{
+		BaseURL: srv.URL,
+		Retry:   1,
+		Logger:  logger,
+		Hook: &HTTPHook{
+			OnRequest: func(method, url string) {
+				mu.Lock()
+				defer mu.Unlock()
+				requests = append(requests, method+" "+url)
+			},
+			OnResponse: func(status int, duration time.Duration, bytes int64) {
+				mu.Lock()
+				defer mu.Unlock()
+				responses = append(responses, hookResponse{status, duration, bytes})
+			},
+		},
+	})
+
+	body, err := c.Get(t.Context(), "/markets", nil)
+	if err != nil || string(body) != `{"ok":true}` {
+		t.Fatalf("GET = %q, %v", body, err)
+	}
+
+	// 每次实际请求（含重试）各触发一次钩子
+	mu.Lock()
+	defer mu.Unlock()
+	wantURL := "GET " + srv.URL + "/markets"
+	if len(requests) != 2 || requests[0] != wantURL || requests[1] != wantURL {
+		t.Errorf("requests = %q, want 2 x %q", requests, wantURL)
+	}
+	want := []hookResponse{
+		{status: http.StatusServiceUnavailable, bytes: int64(len("unavailable\n"))},
+		{status: http.StatusOK, bytes: int64(len(`{"ok":true}`))},
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("responses = %+v, want %d", responses, len(want))
+	}
+	for i, w := range want {
+		got := responses[i]
+		if got.status != w.status || got.bytes != w.bytes || got.duration <= 0 {
+			t.Errorf("responses[%d] = %+v, want status %d, %d bytes and positive latency", i, got, w.status, w.bytes)
+		}
+	}
+
+	// 重试记为 WARN，5xx 响应记为 ERROR，成功请求记为 DEBUG
+	if warns := logger.find("WARN retry GET /markets (1/1): HTTP 503"); len(warns) != 1 {
+		t.Errorf("retry warn logs = %q, all = %q", warns, logger.lines)
+	}
+	if errs := logger.find("ERROR http response: GET " + srv.URL + "/markets -> 503"); len(errs) != 1 {
+		t.Errorf("5xx error logs = %q", errs)
+	}
+	if debugs := logger.find("DEBUG http response: GET " + srv.URL + "/markets -> 200"); len(debugs) != 1 {
+		t.Errorf("200 debug logs = %q, all = %q", debugs, logger.lines)
+	}
+	if reqs := logger.find("DEBUG http request: GET"); len(reqs) != 2 {
+		t.Errorf("request debug logs = %q, want 2", reqs)
+	}
+}
+
+func TestHTTPHookNetworkError(t *testing.T) {
+	srv, _ := flakyServer(t, 0)
+	url := srv.URL
+	srv.Close()
+
+	var statuses []int
+	logger := &recordLogger{}
+	c := NewHTTPClient(HTTPClientConfig{
+		BaseURL:        url,
+		RetryPredicate: func(string, int, []byte) bool { return false },
+		Logger:         logger,
+		Hook:           &HTTPHook{OnResponse: func(status int, _ time.Duration, _ int64) { statuses = append(statuses, status) }},
+	})
+	if _, err := c.Get(t.Context(), "/", nil); err == nil {
+		t.Fatal("GET to closed server should fail")
+	}
+	// 网络错误时 status 为 0，并记为 WARN
+	if len(statuses) != 1 || statuses[0] != 0 {
+		t.Errorf("statuses = %v, want [0]", statuses)
+	}
+	if warns := logger.find("WARN http error: GET"); len(warns) != 1 {
+		t.Errorf("network error logs = %q", logger.lines)
+	}
+}
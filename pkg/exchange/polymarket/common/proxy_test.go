@@ -0,0 +1,208 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// socks5Server 要求用户名/密码认证的最小 SOCKS5 代理，仅支持 CONNECT
+type socks5Server struct {
+	user, pass string
+	ln         net.Listener
+	wg         sync.WaitGroup
+}
+
+func newSocks5Server(t *testing.T, user, pass string) *socks5Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socks5Server{user: user, pass: pass, ln: ln}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.handle(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+// proxyString 以 host:port:user:pass:socks5 格式返回代理地址
+func (s *socks5Server) proxyString(user, pass string) string {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return host + ":" + port + ":" + user + ":" + pass + ":socks5"
+}
+
+func (s *socks5Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// 协商认证方式：只接受用户名/密码 (0x02)
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	offered := false
+	for _, m := range methods {
+		offered = offered || m == 0x02
+	}
+	if !offered {
+		conn.Write([]byte{0x05, 0xff})
+		return
+	}
+	conn.Write([]byte{0x05, 0x02})
+
+	// 用户名/密码子协商 (RFC 1929)
+	readField := func() string {
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return ""
+		}
+		b := make([]byte, n[0])
+		io.ReadFull(conn, b)
+		return string(b)
+	}
+	ver := make([]byte, 1)
+	if _, err := io.ReadFull(conn, ver); err != nil {
+		return
+	}
+	if user, pass := readField(), readField(); user != s.user || pass != s.pass {
+		conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	conn.Write([]byte{0x01, 0x00})
+
+	// CONNECT 请求
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 0x01:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 0x03:
+		host = readField()
+	default:
+		return
+	}
+	portBytes := make([]byte, 2)
+	io.ReadFull(conn, portBytes)
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))))
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+// echoServer 回显每个连接收到的前 4 字节后关闭连接
+func echoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4)
+				if _, err := io.ReadFull(conn, buf); err == nil {
+					conn.Write(buf)
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestProxyDialContextAuth(t *testing.T) {
+	proxySrv := newSocks5Server(t, "alice", "secret")
+	target := echoServer(t)
+
+	dial, err := CreateProxyDialContext(proxySrv.proxyString("alice", "secret"))
+	if err != nil || dial == nil {
+		t.Fatalf("CreateProxyDialContext = %v, %v", dial, err)
+	}
+	conn, err := dial(t.Context(), "tcp", target)
+	if err != nil {
+		t.Fatalf("dial through proxy: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("echo = %q, %v", buf, err)
+	}
+
+	// 密码错误：返回 ErrProxyAuthFailed
+	dial, err = CreateProxyDialContext(proxySrv.proxyString("alice", "wrong"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dial(t.Context(), "tcp", target); !errors.Is(err, ErrProxyAuthFailed) {
+		t.Errorf("wrong password err = %v, want ErrProxyAuthFailed", err)
+	}
+
+	// 未提供凭证而代理要求认证
+	host, port, _ := net.SplitHostPort(proxySrv.ln.Addr().String())
+	dial, err = CreateProxyDialContext(host + ":" + port + ":::socks5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dial(t.Context(), "tcp", target); !errors.Is(err, ErrProxyAuthFailed) {
+		t.Errorf("no credentials err = %v, want ErrProxyAuthFailed", err)
+	}
+
+	// 已取消的 context 直接返回，不视为认证失败
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	dial, _ = CreateProxyDialContext(proxySrv.proxyString("alice", "secret"))
+	if _, err := dial(ctx, "tcp", target); err == nil || errors.Is(err, ErrProxyAuthFailed) {
+		t.Errorf("canceled dial err = %v", err)
+	}
+}
+
+func TestProxyDialContextHTTPProxy(t *testing.T) {
+	dial, err := CreateProxyDialContext("127.0.0.1:8080:user:pass")
+	if err != nil || dial != nil {
+		t.Errorf("HTTP proxy = %v, %v, want nil dialer", dial, err)
+	}
+}
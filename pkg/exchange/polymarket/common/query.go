@@ -0,0 +1,50 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Gamma /markets、/events 的排序字段（MarketQueryParams.Order），与排行榜的 OrderBy* 区分
+// Order 可用逗号组合多个字段（如 "volume24hr,endDate"），Ascending 为 false 时降序
+// 以下为 Gamma API 实际支持且常用的字段，其他值会被 ValidateOrder 拒绝
+const (
+	MarketOrderByID          = "id"
+	MarketOrderByVolume      = "volume"
+	MarketOrderByVolume24hr  = "volume24hr"
+	MarketOrderByVolume1wk   = "volume1wk"
+	MarketOrderByVolume1mo   = "volume1mo"
+	MarketOrderByLiquidity   = "liquidity"
+	MarketOrderByStartDate   = "startDate"
+	MarketOrderByEndDate     = "endDate"
+	MarketOrderByCreatedAt   = "createdAt"
+	MarketOrderByClosedTime  = "closedTime"
+	MarketOrderByCompetitive = "competitive"
+)
+
+var validOrderFields = map[string]bool{
+	MarketOrderByID:          true,
+	MarketOrderByVolume:      true,
+	MarketOrderByVolume24hr:  true,
+	MarketOrderByVolume1wk:   true,
+	MarketOrderByVolume1mo:   true,
+	MarketOrderByLiquidity:   true,
+	MarketOrderByStartDate:   true,
+	MarketOrderByEndDate:     true,
+	MarketOrderByCreatedAt:   true,
+	MarketOrderByClosedTime:  true,
+	MarketOrderByCompetitive: true,
+}
+
+// ValidateOrder 校验排序字段（逗号分隔），空字符串表示不排序
+func ValidateOrder(order string) error {
+	if order == "" {
+		return nil
+	}
+	for _, field := range strings.Split(order, ",") {
+		if !validOrderFields[strings.TrimSpace(field)] {
+			return fmt.Errorf("unsupported order field %q", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package replay_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common/replay"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/gamma"
+)
+
+// 使用录制的 fixture 回放 Gamma 市场列表，无需网络
+func ExampleNewReplayer() {
+	client := gamma.NewClient(gamma.ClientConfig{Transport: replay.NewReplayer("../../gamma/testdata/replay")})
+	defer client.Close()
+
+	closed := false
+	markets, err := client.ListMarkets(context.Background(), &common.MarketQueryParams{Limit: 2, Closed: &closed})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, m := range markets {
+		fmt.Println(m.Slug)
+	}
+	// Output:
+	// will-bitcoin-reach-150000-by-december-31-2025
+	// fed-decreases-interest-rates-by-25-bps-after-march-2025-meeting
+}
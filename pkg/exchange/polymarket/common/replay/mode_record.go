@@ -0,0 +1,6 @@
+//go:build record
+
+package replay
+
+// Recording 是否处于录制模式（以 -tags record 构建）
+const Recording = true
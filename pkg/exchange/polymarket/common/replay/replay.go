@@ -0,0 +1,210 @@
+// Package replay 提供 HTTP 录制/回放传输层，用于基于真实响应的集成测试
+//
+// 录制：使用 Recorder 包装真实传输层请求线上 API，响应按请求保存为 JSON fixture；
+// 回放：使用 Replayer 从 fixture 目录读取响应，无需网络即可运行。
+// 通过各客户端 ClientConfig.Transport 注入，例如：
+//
+//	client := gamma.NewClient(gamma.ClientConfig{Transport: replay.NewReplayer("testdata/gamma")})
+//
+// 测试中通常使用 New：默认回放 fixture；使用 -tags record 运行测试时改为请求线上 API 并重新录制，
+//
+//	go test -tags record ./pkg/exchange/polymarket/gamma/
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fixture 录制的请求/响应
+type Fixture struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	RequestBody string `json:"requestBody,omitempty"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// FixtureKey 生成请求对应的 fixture 文件名
+// 由方法、路径和排序后的查询参数组成，请求体参与哈希以区分 POST；认证头不参与
+func FixtureKey(method string, u *url.URL, body []byte) string {
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		path = "root"
+	}
+	name := strings.ToLower(method) + "_" + unsafeChars.ReplaceAllString(path, "_")
+
+	query := u.Query()
+	if len(query) == 0 && len(body) == 0 {
+		return name + ".json"
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s&", k, strings.Join(query[k], ","))
+	}
+	h.Write(body)
+	return name + "_" + hex.EncodeToString(h.Sum(nil))[:12] + ".json"
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// New 创建测试用传输层：以 -tags record 构建时返回录制 dir 的 Recorder（请求线上 API），否则返回 dir 的 Replayer
+func New(dir string) http.RoundTripper {
+	if Recording {
+		return NewRecorder(dir, nil)
+	}
+	return NewReplayer(dir)
+}
+
+// Recorder 录制传输层：转发请求到真实传输层并保存响应
+type Recorder struct {
+	Dir       string
+	Transport http.RoundTripper // 为空时使用 http.DefaultTransport
+
+	mu sync.Mutex
+}
+
+// NewRecorder 创建录制传输层
+func NewRecorder(dir string, transport http.RoundTripper) *Recorder {
+	return &Recorder{Dir: dir, Transport: transport}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	fixture := Fixture{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: string(reqBody),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	if err := r.save(FixtureKey(req.Method, req.URL, reqBody), &fixture); err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (r *Recorder) save(name string, fixture *Fixture) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return fmt.Errorf("create fixture dir: %w", err)
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.Dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("write fixture: %w", err)
+	}
+	return nil
+}
+
+// Replayer 回放传输层：从 fixture 目录返回录制的响应
+type Replayer struct {
+	Dir string
+}
+
+// NewReplayer 创建回放传输层
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{Dir: dir}
+}
+
+// RoundTrip 实现 http.RoundTripper，fixture 不存在时返回错误
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	name := FixtureKey(req.Method, req.URL, reqBody)
+	fixture, err := LoadFixture(filepath.Join(r.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("replay %s %s: %w", req.Method, req.URL, err)
+	}
+
+	header := http.Header{}
+	if fixture.ContentType != "" {
+		header.Set("Content-Type", fixture.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+// LoadFixture 读取 fixture 文件
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
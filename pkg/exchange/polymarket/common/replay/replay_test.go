@@ -0,0 +1,104 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{"path":"` + r.URL.Path + `","query":"` + r.URL.RawQuery + `","body":"` + strings.ReplaceAll(string(body), `"`, `'`) + `"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	requests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/markets?limit=2&closed=false", "", http.StatusOK},
+		{http.MethodGet, "/markets?limit=3", "", http.StatusOK},
+		{http.MethodPost, "/books", `[{"token_id":"1"}]`, http.StatusCreated},
+	}
+	do := func(rt http.RoundTripper, method, path, body string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if body == "" {
+			req.Body = http.NoBody
+		}
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	recorded := make([]string, len(requests))
+	rec := NewRecorder(dir, nil)
+	for i, r := range requests {
+		resp, body := do(rec, r.method, r.path, r.body)
+		if resp.StatusCode != r.status {
+			t.Fatalf("record %s: status %d", r.path, resp.StatusCode)
+		}
+		recorded[i] = body
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(requests) {
+		t.Fatalf("fixtures = %d, want %d", len(entries), len(requests))
+	}
+
+	// 回放时不访问服务器
+	srv.Close()
+	rep := NewReplayer(dir)
+	for i, r := range requests {
+		resp, body := do(rep, r.method, r.path, r.body)
+		if resp.StatusCode != r.status || body != recorded[i] || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("replay %s %s = %d %q, want %d %q", r.method, r.path, resp.StatusCode, body, r.status, recorded[i])
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/markets?limit=4", nil)
+	if _, err := rep.RoundTrip(req); err == nil {
+		t.Error("expected error for missing fixture")
+	}
+}
+
+func TestFixtureKey(t *testing.T) {
+	parse := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	a := FixtureKey(http.MethodGet, parse("https://gamma-api.polymarket.com/markets?limit=2&closed=false"), nil)
+	b := FixtureKey(http.MethodGet, parse("http://127.0.0.1:8080/markets?closed=false&limit=2"), nil)
+	if a != b {
+		t.Errorf("key depends on host or query order: %s vs %s", a, b)
+	}
+	if !strings.HasPrefix(a, "get_markets_") || !strings.HasSuffix(a, ".json") {
+		t.Errorf("key = %s", a)
+	}
+	if got := FixtureKey(http.MethodGet, parse("https://clob.polymarket.com/"), nil); got != "get_root.json" {
+		t.Errorf("root key = %s", got)
+	}
+	if got := FixtureKey(http.MethodGet, parse("https://clob.polymarket.com/data/order/0xabc"), nil); got != "get_data_order_0xabc.json" {
+		t.Errorf("path key = %s", got)
+	}
+	p1 := FixtureKey(http.MethodPost, parse("https://clob.polymarket.com/books"), []byte(`["1"]`))
+	p2 := FixtureKey(http.MethodPost, parse("https://clob.polymarket.com/books"), []byte(`["2"]`))
+	if p1 == p2 {
+		t.Error("request body not part of key")
+	}
+}
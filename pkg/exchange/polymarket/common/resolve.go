@@ -0,0 +1,129 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTokenNotFound token 不属于任何市场
+var ErrTokenNotFound = errors.New("token not found in any market")
+
+// ErrMarketClosed token 所属市场已关闭
+var ErrMarketClosed = errors.New("market closed")
+
+// MarketLister 按条件查询市场（gamma.Client 实现了该接口）
+type MarketLister interface {
+	ListMarkets(ctx context.Context, params *MarketQueryParams) ([]Market, error)
+}
+
+// TokenMarket token 与所属市场的映射，字段在市场创建后不再变化
+type TokenMarket struct {
+	TokenID       string
+	ConditionID   string
+	OutcomeIndex  int    // token 在 clobTokenIds 中的下标（0=YES，1=NO）
+	Outcome       string // 对应的 outcome 名称（如 "Yes"/"Up"），无法解析时为空
+	OppositeToken string // 二元市场中另一侧的 token，多结果市场为空
+	NegRisk       bool
+}
+
+// ResolveMarketByToken 根据 CLOB token ID 查询所属市场（不缓存），返回映射和市场当前数据
+// 找不到时返回 ErrTokenNotFound；市场已关闭时同时返回结果和 ErrMarketClosed，
+// redeem 等需要已关闭市场的场景可用 errors.Is 判断后继续使用 conditionId
+func ResolveMarketByToken(ctx context.Context, lister MarketLister, tokenID string) (*TokenMarket, *Market, error) {
+	if tokenID == "" {
+		return nil, nil, fmt.Errorf("token id is required")
+	}
+
+	markets, err := lister.ListMarkets(ctx, &MarketQueryParams{ClobTokenIDs: tokenID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("list markets by token: %w", err)
+	}
+
+	for i := range markets {
+		m := &markets[i]
+		ids, err := ParseTokenIDs(m.ClobTokenIds)
+		if err != nil {
+			continue
+		}
+		for idx, id := range ids {
+			if id != tokenID {
+				continue
+			}
+			tm := &TokenMarket{
+				TokenID:      tokenID,
+				ConditionID:  m.ConditionID,
+				OutcomeIndex: idx,
+				NegRisk:      m.NegRisk,
+			}
+			if len(ids) == 2 {
+				tm.OppositeToken = ids[1-idx]
+			}
+			if outcomes, err := ParseOutcomes(m.Outcomes); err == nil && idx < len(outcomes) {
+				tm.Outcome = outcomes[idx]
+			}
+			if m.Closed {
+				return tm, m, fmt.Errorf("token %s: %w", tokenID, ErrMarketClosed)
+			}
+			return tm, m, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("token %s: %w", tokenID, ErrTokenNotFound)
+}
+
+// GetConditionIDFromToken 根据 CLOB token ID 获取所属市场的 conditionId（已关闭的市场同样返回）
+func GetConditionIDFromToken(ctx context.Context, lister MarketLister, tokenID string) (string, error) {
+	tm, _, err := ResolveMarketByToken(ctx, lister, tokenID)
+	if err != nil && !errors.Is(err, ErrMarketClosed) {
+		return "", err
+	}
+	return tm.ConditionID, nil
+}
+
+// TokenResolver 带缓存的 token → 市场映射解析器
+// 只缓存不会变化的映射（conditionId、outcome 下标、对侧 token 等），市场状态需通过 ResolveMarketByToken 实时查询
+type TokenResolver struct {
+	lister MarketLister
+	cache  sync.Map // tokenID -> *TokenMarket
+}
+
+// NewTokenResolver 创建 token 解析器
+func NewTokenResolver(lister MarketLister) *TokenResolver {
+	return &TokenResolver{lister: lister}
+}
+
+// Resolve 获取 token 所属市场的映射，首次查询后缓存；已关闭市场的 token 同样可以解析
+func (r *TokenResolver) Resolve(ctx context.Context, tokenID string) (*TokenMarket, error) {
+	if cached, ok := r.cache.Load(tokenID); ok {
+		tm := *cached.(*TokenMarket)
+		return &tm, nil
+	}
+	tm, _, err := ResolveMarketByToken(ctx, r.lister, tokenID)
+	if err != nil && !errors.Is(err, ErrMarketClosed) {
+		return nil, err
+	}
+	r.cache.Store(tokenID, tm)
+	copied := *tm
+	return &copied, nil
+}
+
+// ConditionID 获取 token 所属市场的 conditionId
+func (r *TokenResolver) ConditionID(ctx context.Context, tokenID string) (string, error) {
+	tm, err := r.Resolve(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return tm.ConditionID, nil
+}
+
+// Invalidate 清除指定 token 的缓存，tokenIDs 为空时清除全部
+func (r *TokenResolver) Invalidate(tokenIDs ...string) {
+	if len(tokenIDs) == 0 {
+		r.cache.Clear()
+		return
+	}
+	for _, id := range tokenIDs {
+		r.cache.Delete(id)
+	}
+}
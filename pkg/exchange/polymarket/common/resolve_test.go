@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// stubLister 按 clob_token_ids 过滤固定市场列表，统计查询次数
+type stubLister struct {
+	markets []Market
+	calls   atomic.Int32
+}
+
+func (s *stubLister) ListMarkets(ctx context.Context, params *MarketQueryParams) ([]Market, error) {
+	s.calls.Add(1)
+	var result []Market
+	for _, m := range s.markets {
+		if strings.Contains(m.ClobTokenIds, `"`+params.ClobTokenIDs+`"`) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func newStubLister() *stubLister {
+	return &stubLister{markets: []Market{
+		{ConditionID: "0xopen", ClobTokenIds: `["111","222"]`, Outcomes: `["Up","Down"]`},
+		{ConditionID: "0xclosed", ClobTokenIds: `["333","444"]`, Outcomes: `["Yes","No"]`, Closed: true, NegRisk: true},
+	}}
+}
+
+func TestResolveMarketByToken(t *testing.T) {
+	lister := newStubLister()
+
+	tm, market, err := ResolveMarketByToken(t.Context(), lister, "222")
+	if err != nil {
+		t.Fatalf("ResolveMarketByToken: %v", err)
+	}
+	if tm.ConditionID != "0xopen" || tm.OutcomeIndex != 1 || tm.Outcome != "Down" || tm.OppositeToken != "111" || market == nil {
+		t.Errorf("token market = %+v", tm)
+	}
+
+	// 已关闭市场：返回结果和 ErrMarketClosed
+	tm, market, err = ResolveMarketByToken(t.Context(), lister, "333")
+	if !errors.Is(err, ErrMarketClosed) {
+		t.Errorf("closed market err = %v, want ErrMarketClosed", err)
+	}
+	if tm == nil || tm.ConditionID != "0xclosed" || !tm.NegRisk || market == nil || !market.Closed {
+		t.Errorf("closed token market = %+v, %+v", tm, market)
+	}
+	if id, err := GetConditionIDFromToken(t.Context(), lister, "444"); err != nil || id != "0xclosed" {
+		t.Errorf("GetConditionIDFromToken(closed) = %q, %v", id, err)
+	}
+
+	if _, _, err := ResolveMarketByToken(t.Context(), lister, "999"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("unknown token err = %v, want ErrTokenNotFound", err)
+	}
+	if _, _, err := ResolveMarketByToken(t.Context(), lister, ""); err == nil {
+		t.Error("expected error for empty token")
+	}
+}
+
+func TestTokenResolverCache(t *testing.T) {
+	lister := newStubLister()
+	r := NewTokenResolver(lister)
+
+	for range 2 {
+		tm, err := r.Resolve(t.Context(), "111")
+		if err != nil || tm.ConditionID != "0xopen" || tm.OppositeToken != "222" {
+			t.Fatalf("Resolve = %+v, %v", tm, err)
+		}
+		tm.ConditionID = "mutated"
+	}
+	if n := lister.calls.Load(); n != 1 {
+		t.Errorf("lookups = %d, want 1 (second from cache)", n)
+	}
+
+	// 已关闭市场的映射同样可解析并缓存
+	if id, err := r.ConditionID(t.Context(), "444"); err != nil || id != "0xclosed" {
+		t.Errorf("ConditionID(closed) = %q, %v", id, err)
+	}
+	r.ConditionID(t.Context(), "444")
+	if n := lister.calls.Load(); n != 2 {
+		t.Errorf("lookups = %d, want 2", n)
+	}
+
+	// 未找到的 token 不缓存
+	for range 2 {
+		if _, err := r.Resolve(t.Context(), "999"); !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("unknown token err = %v", err)
+		}
+	}
+	if n := lister.calls.Load(); n != 4 {
+		t.Errorf("lookups after misses = %d, want 4", n)
+	}
+
+	r.Invalidate("111")
+	r.Resolve(t.Context(), "111")
+	r.Resolve(t.Context(), "444")
+	if n := lister.calls.Load(); n != 5 {
+		t.Errorf("lookups after Invalidate(111) = %d, want 5", n)
+	}
+	r.Invalidate()
+	r.Resolve(t.Context(), "444")
+	if n := lister.calls.Load(); n != 6 {
+		t.Errorf("lookups after Invalidate() = %d, want 6", n)
+	}
+
+	// 缓存属于各自的 resolver
+	other := NewTokenResolver(lister)
+	other.Resolve(t.Context(), "111")
+	if n := lister.calls.Load(); n != 7 {
+		t.Errorf("lookups from new resolver = %d, want 7", n)
+	}
+}
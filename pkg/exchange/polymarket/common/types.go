@@ -2,6 +2,10 @@ package common
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
 )
 
 // FlexString 可以从 JSON 字符串或数字解析的灵活类型
@@ -22,6 +26,12 @@ func (f *FlexString) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Float64 解析为浮点数，无效值返回 0
+func (f FlexString) Float64() float64 {
+	v, _ := strconv.ParseFloat(string(f), 64)
+	return v
+}
+
 // ========== Gamma API 类型 ==========
 
 // Event 事件
@@ -54,6 +64,17 @@ type Event struct {
 	ClobRewards      []any      `json:"clobRewards"`
 }
 
+// Validate 校验事件包含市场且 endDate 可解析（各市场需单独调用 Market.Validate）
+func (e *Event) Validate() error {
+	if len(e.Markets) == 0 {
+		return fmt.Errorf("event %s: no markets", e.Slug)
+	}
+	if _, err := ParseDate(e.EndDate); err != nil {
+		return fmt.Errorf("event %s: invalid endDate %q", e.Slug, e.EndDate)
+	}
+	return nil
+}
+
 // Market 市场
 type Market struct {
 	ID                    string     `json:"id"`
@@ -96,6 +117,95 @@ type Market struct {
 	OneDayPriceChange     FlexString `json:"oneDayPriceChange"`
 	ClobRewards           []any      `json:"clobRewards"`
 	EventSlug             string     `json:"eventSlug"`
+	Events                []Event    `json:"events,omitempty"` // 所属事件（/markets 接口返回，不含嵌套市场）
+}
+
+// ParseDate 解析 Gamma 返回的日期 (RFC3339 或 2006-01-02)
+func ParseDate(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// Validate 校验交易所需字段（conditionId、至少 2 个 token ID、可解析的 endDate）
+// Gamma 偶尔返回不完整的对象，交易前校验可避免下游越界访问
+func (m *Market) Validate() error {
+	if m.ConditionID == "" {
+		return fmt.Errorf("market %s: missing conditionId", m.Slug)
+	}
+	ids, err := m.ParsedTokenIDs()
+	if err != nil {
+		return fmt.Errorf("market %s: %w", m.Slug, err)
+	}
+	if len(ids) < 2 {
+		return fmt.Errorf("market %s: expected at least 2 token ids, got %d", m.Slug, len(ids))
+	}
+	if _, err := ParseDate(m.EndDate); err != nil {
+		return fmt.Errorf("market %s: invalid endDate %q", m.Slug, m.EndDate)
+	}
+	return nil
+}
+
+// ParsedOutcomes 解析结果名称（outcomes 字段），字段为空时返回 nil
+func (m *Market) ParsedOutcomes() ([]string, error) {
+	return ParseOutcomes(m.Outcomes)
+}
+
+// ParsedPrices 解析结果价格（outcomePrices 字段），字段为空时返回 nil
+func (m *Market) ParsedPrices() ([]float64, error) {
+	return ParseOutcomePrices(m.OutcomePrices)
+}
+
+// ParsedTokenIDs 解析 CLOB token ID（clobTokenIds 字段），字段为空时返回 nil
+func (m *Market) ParsedTokenIDs() ([]string, error) {
+	return ParseTokenIDs(m.ClobTokenIds)
+}
+
+// OutcomeToken 市场的单个结果
+type OutcomeToken struct {
+	Index   int     // 结果下标（与 clobTokenIds/outcomes 顺序一致，对应 index set 1<<Index）
+	Outcome string  // 结果名称，outcomes 缺失时为空
+	Price   float64 // Gamma 返回的结果价格，outcomePrices 缺失时为 0
+	TokenID string
+}
+
+// OutcomeTokens 将结果名称、价格和 token ID 按下标配对，支持任意数量的结果
+// outcomes/outcomePrices 与 token 数量不一致时返回错误
+func (m *Market) OutcomeTokens() ([]OutcomeToken, error) {
+	ids, err := m.ParsedTokenIDs()
+	if err != nil {
+		return nil, fmt.Errorf("market %s: %w", m.Slug, err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("market %s: no token ids", m.Slug)
+	}
+	names, err := m.ParsedOutcomes()
+	if err != nil {
+		return nil, fmt.Errorf("market %s: %w", m.Slug, err)
+	}
+	if names != nil && len(names) != len(ids) {
+		return nil, fmt.Errorf("market %s: %d outcomes for %d token ids", m.Slug, len(names), len(ids))
+	}
+	prices, err := m.ParsedPrices()
+	if err != nil {
+		return nil, fmt.Errorf("market %s: %w", m.Slug, err)
+	}
+	if prices != nil && len(prices) != len(ids) {
+		return nil, fmt.Errorf("market %s: %d outcome prices for %d token ids", m.Slug, len(prices), len(ids))
+	}
+
+	tokens := make([]OutcomeToken, len(ids))
+	for i, id := range ids {
+		tokens[i] = OutcomeToken{Index: i, TokenID: id}
+		if names != nil {
+			tokens[i].Outcome = names[i]
+		}
+		if prices != nil {
+			tokens[i].Price = prices[i]
+		}
+	}
+	return tokens, nil
 }
 
 // Tag 标签
@@ -318,6 +428,51 @@ type PositionQueryParams struct {
 	SortDirection string `url:"sortDirection,omitempty"`
 }
 
+// 持仓排序字段
+const (
+	PositionSortCurrent    = "CURRENT"
+	PositionSortInitial    = "INITIAL"
+	PositionSortTokens     = "TOKENS"
+	PositionSortCashPnl    = "CASHPNL"
+	PositionSortPercentPnl = "PERCENTPNL"
+	PositionSortTitle      = "TITLE"
+	PositionSortResolving  = "RESOLVING"
+	PositionSortPrice      = "PRICE"
+	PositionSortAvgPrice   = "AVGPRICE"
+)
+
+// 排序方向
+const (
+	SortAsc  = "ASC"
+	SortDesc = "DESC"
+)
+
+// WithMinSize 设置最小持仓数量过滤 (sizeThreshold)
+func (p *PositionQueryParams) WithMinSize(size float64) *PositionQueryParams {
+	p.SizeThreshold = strconv.FormatFloat(size, 'f', -1, 64)
+	return p
+}
+
+// Validate 校验查询参数
+func (p *PositionQueryParams) Validate() error {
+	if p.User == "" {
+		return fmt.Errorf("user is required")
+	}
+	if p.SizeThreshold != "" {
+		size, err := strconv.ParseFloat(p.SizeThreshold, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sizeThreshold: %s", p.SizeThreshold)
+		}
+		if size < 0 {
+			return fmt.Errorf("sizeThreshold must be non-negative: %s", p.SizeThreshold)
+		}
+	}
+	if p.SortDirection != "" && p.SortDirection != SortAsc && p.SortDirection != SortDesc {
+		return fmt.Errorf("invalid sortDirection: %s", p.SortDirection)
+	}
+	return nil
+}
+
 // UserStats 用户统计
 type UserStats struct {
 	TotalVolume    float64 `json:"totalVolume"`
@@ -401,6 +556,16 @@ type Activity struct {
 	ProfileImageOptimized string  `json:"profileImageOptimized"`
 }
 
+// 活动类型
+const (
+	ActivityTrade      = "TRADE"
+	ActivitySplit      = "SPLIT"
+	ActivityMerge      = "MERGE"
+	ActivityRedeem     = "REDEEM"
+	ActivityReward     = "REWARD"
+	ActivityConversion = "CONVERSION"
+)
+
 // ClosedPositionParams 已平仓持仓查询参数
 type ClosedPositionParams struct {
 	User          string `url:"user"`
@@ -503,6 +668,68 @@ type LeaderboardParams struct {
 	Offset     int    `url:"offset,omitempty"`
 }
 
+// 排行榜分类
+const (
+	CategoryOverall   = "OVERALL"
+	CategoryPolitics  = "POLITICS"
+	CategorySports    = "SPORTS"
+	CategoryCrypto    = "CRYPTO"
+	CategoryCulture   = "CULTURE"
+	CategoryMentions  = "MENTIONS"
+	CategoryWeather   = "WEATHER"
+	CategoryEconomics = "ECONOMICS"
+	CategoryTech      = "TECH"
+	CategoryFinance   = "FINANCE"
+)
+
+// 排行榜统计周期
+const (
+	TimePeriodDay   = "DAY"
+	TimePeriodWeek  = "WEEK"
+	TimePeriodMonth = "MONTH"
+	TimePeriodAll   = "ALL"
+)
+
+// 排行榜排序字段
+const (
+	OrderByPnL    = "PNL"
+	OrderByVolume = "VOL"
+)
+
+var (
+	leaderboardCategories = []string{
+		CategoryOverall, CategoryPolitics, CategorySports, CategoryCrypto, CategoryCulture,
+		CategoryMentions, CategoryWeather, CategoryEconomics, CategoryTech, CategoryFinance,
+	}
+	leaderboardTimePeriods = []string{TimePeriodDay, TimePeriodWeek, TimePeriodMonth, TimePeriodAll}
+	leaderboardOrderBys    = []string{OrderByPnL, OrderByVolume}
+)
+
+// Validate 校验排行榜查询参数（空值表示使用默认值）
+func (p *LeaderboardParams) Validate() error {
+	if p.Category != "" && !slices.Contains(leaderboardCategories, p.Category) {
+		return fmt.Errorf("invalid category: %s", p.Category)
+	}
+	if p.TimePeriod != "" && !slices.Contains(leaderboardTimePeriods, p.TimePeriod) {
+		return fmt.Errorf("invalid timePeriod: %s", p.TimePeriod)
+	}
+	if p.OrderBy != "" && !slices.Contains(leaderboardOrderBys, p.OrderBy) {
+		return fmt.Errorf("invalid orderBy: %s", p.OrderBy)
+	}
+	return validatePage(p.Limit, p.Offset)
+}
+
+// validatePage 校验分页参数
+func validatePage(limit, offset int) error {
+	if limit < 0 {
+		return fmt.Errorf("limit must be non-negative: %d", limit)
+	}
+	if offset < 0 {
+		return fmt.Errorf("offset must be non-negative: %d", offset)
+	}
+	return nil
+}
+
 // LeaderboardEntry 排行榜条目
 type LeaderboardEntry struct {
 	Rank          string  `json:"rank"`
@@ -521,6 +748,11 @@ type BuilderLeaderboardParams struct {
 	Offset int `url:"offset,omitempty"`
 }
 
+// Validate 校验 Builder 排行榜查询参数
+func (p *BuilderLeaderboardParams) Validate() error {
+	return validatePage(p.Limit, p.Offset)
+}
+
 // BuilderLeaderboardEntry Builder 排行榜条目
 type BuilderLeaderboardEntry struct {
 	Rank        string  `json:"rank"`
@@ -537,6 +769,11 @@ type BuilderVolumeParams struct {
 	Offset int `url:"offset,omitempty"`
 }
 
+// Validate 校验 Builder 交易量查询参数
+func (p *BuilderVolumeParams) Validate() error {
+	return validatePage(p.Limit, p.Offset)
+}
+
 // BuilderVolumeEntry Builder 交易量时序条目
 type BuilderVolumeEntry struct {
 	Date        string  `json:"dt"`
@@ -550,12 +787,27 @@ type BuilderVolumeEntry struct {
 
 // PnLData 盈亏数据
 type PnLData struct {
+	Timestamp     int64   `json:"timestamp"` // Unix 时间戳（秒），时间序列数据点的时间
 	TotalPnl      float64 `json:"totalPnl"`
 	RealizedPnl   float64 `json:"realizedPnl"`
 	UnrealizedPnl float64 `json:"unrealizedPnl"`
 	Timeframe     string  `json:"timeframe"`
 }
 
+// 盈亏历史时间范围
+const (
+	PnLIntervalDay   = "1d"
+	PnLIntervalWeek  = "1w"
+	PnLIntervalMonth = "1m"
+	PnLIntervalAll   = "all"
+)
+
+// PnLHistoryParams 盈亏历史查询参数
+type PnLHistoryParams struct {
+	Interval string `url:"interval,omitempty"` // 时间范围 (默认 PnLIntervalAll)
+	Fidelity string `url:"fidelity,omitempty"` // 数据点间隔，如 1h、12h、1d（默认由服务端决定）
+}
+
 // ========== WebSocket 类型 ==========
 
 // OrderBookLevel 订单簿层级
@@ -585,6 +837,38 @@ type PriceChangeEvent struct {
 	Hash    string `json:"hash"`
 	BestBid string `json:"best_bid"`
 	BestAsk string `json:"best_ask"`
+	// Timestamp 来自批量消息外层（毫秒），用于排序和丢弃乱序增量
+	Timestamp string `json:"timestamp"`
+}
+
+// PriceChangeBatch price_change 批量消息（外层携带 market 和 timestamp）
+type PriceChangeBatch struct {
+	Market    string              `json:"market"`
+	Timestamp string              `json:"timestamp"`
+	Changes   []*PriceChangeEvent `json:"price_changes"`
+}
+
+// ParsePriceChangeBatch 解析 price_change 消息，外层的 market/timestamp 会回填到每个变化中
+func ParsePriceChangeBatch(data []byte) (*PriceChangeBatch, error) {
+	var batch PriceChangeBatch
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, fmt.Errorf("unmarshal price change batch: %w", err)
+	}
+	changes := batch.Changes[:0]
+	for _, change := range batch.Changes {
+		if change == nil {
+			continue
+		}
+		if change.Market == "" {
+			change.Market = batch.Market
+		}
+		if change.Timestamp == "" {
+			change.Timestamp = batch.Timestamp
+		}
+		changes = append(changes, change)
+	}
+	batch.Changes = changes
+	return &batch, nil
 }
 
 // LastTradePrice 最新成交价
@@ -673,18 +957,20 @@ type WssAuth struct {
 
 // SplitParams Split 操作参数
 type SplitParams struct {
-	CollateralToken string
-	ConditionID     string
-	Amount          string
-	NegRisk         bool
+	CollateralToken  string
+	ConditionID      string
+	Amount           string
+	NegRisk          bool
+	OutcomeSlotCount int // 结果数量，0 表示二元市场（2）
 }
 
 // MergeParams Merge 操作参数
 type MergeParams struct {
-	CollateralToken string
-	ConditionID     string
-	Amount          string
-	NegRisk         bool
+	CollateralToken  string
+	ConditionID      string
+	Amount           string
+	NegRisk          bool
+	OutcomeSlotCount int // 结果数量，0 表示二元市场（2）
 }
 
 // ConvertParams Convert 操作参数
@@ -696,10 +982,11 @@ type ConvertParams struct {
 
 // RedeemParams Redeem 操作参数
 type RedeemParams struct {
-	CollateralToken string
-	ConditionID     string
-	NegRisk         bool
-	Amounts         []string
+	CollateralToken  string
+	ConditionID      string
+	NegRisk          bool
+	Amounts          []string
+	OutcomeSlotCount int // 结果数量（仅 CTF 赎回），0 时由 relayer 从 CTF 合约查询
 }
 
 // ApproveParams 授权参数
@@ -717,6 +1004,7 @@ type TransferParams struct {
 }
 
 // TransactionResult 交易结果
+// Relayer 交易提交后通常只返回 TransactionID，Hash 在上链前可能为空，可凭 TransactionID 轮询状态
 type TransactionResult struct {
 	Hash          string `json:"hash"`
 	TransactionID string `json:"transactionId"`
@@ -726,10 +1014,13 @@ type TransactionResult struct {
 
 // AccountStatus 账户状态
 type AccountStatus struct {
-	Address              string  `json:"address"`
-	USDCBalance          float64 `json:"usdcBalance"`
-	USDCAllowanceCTF     string  `json:"usdcAllowanceCTF"`
-	USDCAllowanceNegRisk string  `json:"usdcAllowanceNegRisk"`
-	CTFApprovedNegRisk   bool    `json:"ctfApprovedNegRisk"`
-	CTFApprovedExchange  bool    `json:"ctfApprovedExchange"`
+	Address                      string  `json:"address"`
+	USDCBalance                  float64 `json:"usdcBalance"`
+	USDCAllowanceCTF             string  `json:"usdcAllowanceCTF"`
+	USDCAllowanceNegRisk         string  `json:"usdcAllowanceNegRisk"`
+	USDCAllowanceExchange        string  `json:"usdcAllowanceExchange"`
+	USDCAllowanceNegRiskExchange string  `json:"usdcAllowanceNegRiskExchange"`
+	CTFApprovedNegRisk           bool    `json:"ctfApprovedNegRisk"`
+	CTFApprovedExchange          bool    `json:"ctfApprovedExchange"`
+	CTFApprovedNegRiskExchange   bool    `json:"ctfApprovedNegRiskExchange"`
 }
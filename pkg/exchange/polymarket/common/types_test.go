@@ -0,0 +1,317 @@
+package common
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestPositionQueryWithMinSize(t *testing.T) {
+	tests := []struct {
+		size float64
+		want string
+	}{
+		{1, "1"},
+		{0.5, "0.5"},
+		{0, "0"},
+		{1234.125, "1234.125"},
+		{1e-7, "0.0000001"},
+	}
+	for _, tt := range tests {
+		p := (&PositionQueryParams{User: "0xabc"}).WithMinSize(tt.size)
+		if p.SizeThreshold != tt.want {
+			t.Errorf("WithMinSize(%v) = %q, want %q", tt.size, p.SizeThreshold, tt.want)
+		}
+		q, err := url.ParseQuery(BuildQuery(p))
+		if err != nil {
+			t.Fatalf("parse query: %v", err)
+		}
+		if got := q.Get("sizeThreshold"); got != tt.want {
+			t.Errorf("query sizeThreshold = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPositionQueryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  PositionQueryParams
+		wantErr bool
+	}{
+		{"minimal", PositionQueryParams{User: "0xabc"}, false},
+		{"sorted", PositionQueryParams{User: "0xabc", SortBy: PositionSortCashPnl, SortDirection: SortDesc}, false},
+		{"zero size", *(&PositionQueryParams{User: "0xabc"}).WithMinSize(0), false},
+		{"missing user", PositionQueryParams{}, true},
+		{"negative size", *(&PositionQueryParams{User: "0xabc"}).WithMinSize(-1), true},
+		{"non-numeric size", PositionQueryParams{User: "0xabc", SizeThreshold: "abc"}, true},
+		{"bad direction", PositionQueryParams{User: "0xabc", SortDirection: "desc"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMarketValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{"complete", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[\"1\",\"2\"]","endDate":"2025-03-01T12:15:00Z"}`, false},
+		{"date only end", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[\"1\",\"2\"]","endDate":"2025-03-01"}`, false},
+		{"missing token ids", `{"slug":"m","conditionId":"0xc","endDate":"2025-03-01T12:15:00Z"}`, true},
+		{"single token id", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[\"1\"]","endDate":"2025-03-01T12:15:00Z"}`, true},
+		{"malformed token ids", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[1,","endDate":"2025-03-01T12:15:00Z"}`, true},
+		{"missing condition id", `{"slug":"m","clobTokenIds":"[\"1\",\"2\"]","endDate":"2025-03-01T12:15:00Z"}`, true},
+		{"empty end date", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[\"1\",\"2\"]","endDate":""}`, true},
+		{"bad end date", `{"slug":"m","conditionId":"0xc","clobTokenIds":"[\"1\",\"2\"]","endDate":"soon"}`, true},
+	}
+	for _, tt := range tests {
+		var m Market
+		if err := json.Unmarshal([]byte(tt.payload), &m); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tt.name, err)
+		}
+		if err := m.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEventValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{"complete", `{"slug":"e","endDate":"2025-03-01T12:15:00Z","markets":[{"slug":"m"}]}`, false},
+		{"no markets", `{"slug":"e","endDate":"2025-03-01T12:15:00Z","markets":[]}`, true},
+		{"null markets", `{"slug":"e","endDate":"2025-03-01T12:15:00Z","markets":null}`, true},
+		{"missing end date", `{"slug":"e","markets":[{"slug":"m"}]}`, true},
+	}
+	for _, tt := range tests {
+		var e Event
+		if err := json.Unmarshal([]byte(tt.payload), &e); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tt.name, err)
+		}
+		if err := e.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLeaderboardParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  LeaderboardParams
+		wantErr bool
+	}{
+		{"defaults", LeaderboardParams{}, false},
+		{"all valid", LeaderboardParams{Category: CategoryCrypto, TimePeriod: TimePeriodWeek, OrderBy: OrderByVolume, Limit: 50, Offset: 10}, false},
+		{"all periods", LeaderboardParams{TimePeriod: TimePeriodAll}, false},
+		{"unknown category", LeaderboardParams{Category: "MEMES"}, true},
+		{"lowercase category", LeaderboardParams{Category: "overall"}, true},
+		{"unknown period", LeaderboardParams{TimePeriod: "YEAR"}, true},
+		{"unknown order", LeaderboardParams{OrderBy: "PROFIT"}, true},
+		{"negative limit", LeaderboardParams{Limit: -1}, true},
+		{"negative offset", LeaderboardParams{Offset: -5}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	builder := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"builder leaderboard valid", (&BuilderLeaderboardParams{Limit: 10, Offset: 20}).Validate(), false},
+		{"builder leaderboard negative limit", (&BuilderLeaderboardParams{Limit: -1}).Validate(), true},
+		{"builder volume valid", (&BuilderVolumeParams{}).Validate(), false},
+		{"builder volume negative offset", (&BuilderVolumeParams{Offset: -1}).Validate(), true},
+	}
+	for _, tt := range builder {
+		if (tt.err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() err = %v, wantErr %v", tt.name, tt.err, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePriceChangeBatch(t *testing.T) {
+	// 官方文档中的 price_change 消息
+	payload := `{"market":"0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1","price_changes":[` +
+		`{"asset_id":"71321045679252212594626385532706912750332728571942532289631379312455583992563","price":"0.5","size":"200","side":"BUY","hash":"56621a121a47ed9333273e21c83b660cff37ae50","best_bid":"0.5","best_ask":"1"},` +
+		`{"asset_id":"52114319501245915516055106046884209969926127482827954674443846427813813222426","price":"0.5","size":"200","side":"SELL","hash":"1895759e4df7a796bf4f1c5a5950b748306923e2","best_bid":"0","best_ask":"0.5"}` +
+		`],"timestamp":"1757908892351","event_type":"price_change"}`
+
+	batch, err := ParsePriceChangeBatch([]byte(payload))
+	if err != nil {
+		t.Fatalf("ParsePriceChangeBatch: %v", err)
+	}
+	const market = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+	if batch.Market != market || batch.Timestamp != "1757908892351" || len(batch.Changes) != 2 {
+		t.Fatalf("batch = %+v", batch)
+	}
+	for _, c := range batch.Changes {
+		if c.Timestamp != batch.Timestamp || c.Market != market {
+			t.Errorf("change %s: timestamp %q market %q not filled from envelope", c.AssetID, c.Timestamp, c.Market)
+		}
+	}
+	if c := batch.Changes[1]; c.Side != "SELL" || c.BestAsk != "0.5" || c.Hash != "1895759e4df7a796bf4f1c5a5950b748306923e2" {
+		t.Errorf("second change = %+v", c)
+	}
+
+	// 内层自带的字段不被覆盖，null 项被丢弃
+	batch, err = ParsePriceChangeBatch([]byte(`{"market":"m","timestamp":"2","price_changes":[null,{"asset_id":"a","market":"own","timestamp":"1"}]}`))
+	if err != nil {
+		t.Fatalf("ParsePriceChangeBatch: %v", err)
+	}
+	if len(batch.Changes) != 1 || batch.Changes[0].Timestamp != "1" || batch.Changes[0].Market != "own" {
+		t.Errorf("changes = %+v", batch.Changes)
+	}
+
+	if _, err := ParsePriceChangeBatch([]byte(`{"price_changes":`)); err == nil {
+		t.Error("expected error for truncated payload")
+	}
+}
+
+func TestBuildQueryEmbeddedParams(t *testing.T) {
+	active := true
+	p := &EventQueryParams{
+		MarketQueryParams: MarketQueryParams{Limit: 50, Offset: 100, Order: MarketOrderByVolume, Active: &active},
+		TagSlug:           "crypto",
+	}
+	q, err := url.ParseQuery(BuildQuery(p))
+	if err != nil {
+		t.Fatalf("parse query: %v", err)
+	}
+	want := map[string]string{"limit": "50", "offset": "100", "order": "volume", "active": "true", "tag_slug": "crypto"}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if len(q) != len(want) {
+		t.Errorf("query = %v, want only %v", q, want)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	for _, order := range []string{"", MarketOrderByVolume, "volume24hr,endDate", "liquidity, startDate"} {
+		if err := ValidateOrder(order); err != nil {
+			t.Errorf("ValidateOrder(%q) = %v", order, err)
+		}
+	}
+	for _, order := range []string{"Volume", "volume_num", "volume,", "volume;endDate"} {
+		if err := ValidateOrder(order); err == nil {
+			t.Errorf("ValidateOrder(%q) = nil, want error", order)
+		}
+	}
+}
+
+func TestMarketParsedOutcomes(t *testing.T) {
+	tests := []struct {
+		outcomes string
+		want     []string
+		wantErr  bool
+	}{
+		{outcomes: `["Yes","No"]`, want: []string{"Yes", "No"}},
+		{outcomes: `["Up", "Down"]`, want: []string{"Up", "Down"}},
+		{outcomes: `["Trump","Harris","Other"]`, want: []string{"Trump", "Harris", "Other"}},
+		{outcomes: `["Yes, obviously","No \"way\""]`, want: []string{"Yes, obviously", `No "way"`}},
+		{outcomes: `[]`, want: []string{}},
+		{outcomes: "", want: nil},
+		{outcomes: `Yes,No`, wantErr: true},
+		{outcomes: `['Yes','No']`, wantErr: true},
+		{outcomes: `["Yes","No"`, wantErr: true},
+		{outcomes: `[1,2]`, wantErr: true},
+		{outcomes: `{"Yes":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		m := &Market{Outcomes: tt.outcomes}
+		got, err := m.ParsedOutcomes()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsedOutcomes(%q) = %q, want error", tt.outcomes, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsedOutcomes(%q): %v", tt.outcomes, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || len(got) != len(tt.want) {
+			t.Errorf("ParsedOutcomes(%q) = %#v, want %#v", tt.outcomes, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParsedOutcomes(%q)[%d] = %q, want %q", tt.outcomes, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	// Gamma 原始响应中 outcomes 是 JSON 编码的字符串
+	var m Market
+	if err := json.Unmarshal([]byte(`{"outcomes":"[\"Yes\", \"No\"]"}`), &m); err != nil {
+		t.Fatalf("unmarshal market: %v", err)
+	}
+	if got, err := m.ParsedOutcomes(); err != nil || len(got) != 2 || got[0] != "Yes" || got[1] != "No" {
+		t.Errorf("ParsedOutcomes from payload = %q, %v", got, err)
+	}
+}
+
+func TestMarketOutcomeTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		market  Market
+		want    []OutcomeToken
+		wantErr bool
+	}{
+		{
+			name:   "binary",
+			market: Market{Outcomes: `["Yes","No"]`, OutcomePrices: `["0.35","0.65"]`, ClobTokenIds: `["111","222"]`},
+			want:   []OutcomeToken{{Index: 0, Outcome: "Yes", Price: 0.35, TokenID: "111"}, {Index: 1, Outcome: "No", Price: 0.65, TokenID: "222"}},
+		},
+		{
+			name:   "multi outcome",
+			market: Market{Outcomes: `["A","B","C"]`, OutcomePrices: `[0.2,0.3,0.5]`, ClobTokenIds: `["1","2","3"]`},
+			want: []OutcomeToken{
+				{Index: 0, Outcome: "A", Price: 0.2, TokenID: "1"},
+				{Index: 1, Outcome: "B", Price: 0.3, TokenID: "2"},
+				{Index: 2, Outcome: "C", Price: 0.5, TokenID: "3"},
+			},
+		},
+		{
+			// outcomes/outcomePrices 缺失时名称为空、价格为 0
+			name:   "ids only",
+			market: Market{ClobTokenIds: `["111","222"]`},
+			want:   []OutcomeToken{{Index: 0, TokenID: "111"}, {Index: 1, TokenID: "222"}},
+		},
+		{name: "no token ids", market: Market{Outcomes: `["Yes","No"]`}, wantErr: true},
+		{name: "outcome count mismatch", market: Market{Outcomes: `["Yes"]`, ClobTokenIds: `["111","222"]`}, wantErr: true},
+		{name: "price count mismatch", market: Market{OutcomePrices: `["0.5","0.3","0.2"]`, ClobTokenIds: `["111","222"]`}, wantErr: true},
+		{name: "malformed outcomes", market: Market{Outcomes: `Yes,No`, ClobTokenIds: `["111","222"]`}, wantErr: true},
+		{name: "malformed prices", market: Market{OutcomePrices: `[0.5`, ClobTokenIds: `["111","222"]`}, wantErr: true},
+		{name: "malformed token ids", market: Market{ClobTokenIds: `["111" "222"]`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.market.OutcomeTokens()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: tokens = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: tokens[%d] = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -2,11 +2,14 @@ package common
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ParseMarketSlug 从 URL 解析市场 slug
@@ -38,18 +41,43 @@ func ParseEventSlug(eventURL string) (string, error) {
 	return "", fmt.Errorf("invalid event URL format: %s", eventURL)
 }
 
-// ParseTokenIDs 解析 clobTokenIds JSON 字符串
+// ParseTokenIDs 解析 clobTokenIds 字符串
+// 优先按 JSON 数组解析，失败时退化为宽松解析（兼容单引号、无方括号的逗号分隔格式）
 func ParseTokenIDs(clobTokenIds string) ([]string, error) {
-	if clobTokenIds == "" {
+	if strings.TrimSpace(clobTokenIds) == "" {
 		return nil, nil
 	}
 	var ids []string
-	if err := json.Unmarshal([]byte(clobTokenIds), &ids); err != nil {
-		return nil, fmt.Errorf("parse token ids: %w", err)
+	if err := json.Unmarshal([]byte(clobTokenIds), &ids); err == nil {
+		return ids, nil
+	}
+
+	trimmed := strings.TrimSpace(clobTokenIds)
+	trimmed = strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]")
+	ids = nil
+	for _, part := range strings.Split(trimmed, ",") {
+		id := strings.Trim(strings.TrimSpace(part), `"'`)
+		if id == "" {
+			continue
+		}
+		if strings.ContainsAny(id, "\"'[]{} ") {
+			return nil, fmt.Errorf("parse token ids: invalid token id %q", id)
+		}
+		ids = append(ids, id)
 	}
 	return ids, nil
 }
 
+// First 安全获取切片第一个元素，空切片 (含 nil) 返回 false
+// 用于 /value、/oi 等可能返回空数组或 null 的单值接口
+func First[T any](items []T) (T, bool) {
+	var zero T
+	if len(items) == 0 {
+		return zero, false
+	}
+	return items[0], true
+}
+
 // GetYesTokenID 获取 YES token ID
 func GetYesTokenID(market *Market) (string, error) {
 	ids, err := ParseTokenIDs(market.ClobTokenIds)
@@ -105,6 +133,7 @@ func ParseOutcomePrices(outcomePrices string) ([]float64, error) {
 		if err := json.Unmarshal([]byte(outcomePrices), &priceStrings); err != nil {
 			return nil, fmt.Errorf("parse outcome prices: %w", err)
 		}
+		prices = make([]float64, 0, len(priceStrings)) // 数字解析失败时可能已写入部分零值
 		for _, s := range priceStrings {
 			p, _ := strconv.ParseFloat(s, 64)
 			prices = append(prices, p)
@@ -137,6 +166,23 @@ func AlignPrice(price, tickSize float64, side string) float64 {
 	return float64(int(ticks+0.9999)) * tickSize
 }
 
+// ImprovePrice 在 reference 基础上向进取方向至少改善一个 tick 并对齐（BUY 向上，SELL 向下）
+// 与 AlignPrice(bestBid+tickSize, ...) 不同，结果不会因浮点误差被对齐回 reference；超出 (0, 1) 时需调用方再 ClampPrice
+func ImprovePrice(reference, tickSize float64, side string) float64 {
+	if tickSize <= 0 {
+		tickSize = 0.01
+	}
+	const eps = 1e-9
+	var ticks float64
+	if strings.ToUpper(side) == "BUY" {
+		ticks = math.Ceil(reference/tickSize-eps) + 1
+	} else {
+		ticks = math.Floor(reference/tickSize+eps) - 1
+	}
+	scale := math.Pow10(StepDecimals(tickSize))
+	return math.Round(ticks*tickSize*scale) / scale
+}
+
 // ClampPrice 限制价格范围
 func ClampPrice(price, tickSize float64) float64 {
 	min, max := tickSize, 1.0-tickSize
@@ -157,6 +203,157 @@ func AlignAmount(amount, tickSize float64) float64 {
 	return float64(int(amount/tickSize)) * tickSize
 }
 
+// SizeDecimals CLOB 下单数量的小数位数
+const SizeDecimals = 2
+
+// StepDecimals 步长（tick size / min size）的小数位数，如 0.01 -> 2、0.001 -> 3、5 -> 0
+func StepDecimals(step float64) int {
+	if step <= 0 {
+		return 0
+	}
+	str := strconv.FormatFloat(step, 'f', -1, 64)
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		return len(str) - i - 1
+	}
+	return 0
+}
+
+// FormatPrice 按 tick size 的精度格式化价格（tickSize <= 0 时按 0.01）
+func FormatPrice(p float64, tickSize float64) string {
+	if tickSize <= 0 {
+		tickSize = 0.01
+	}
+	return strconv.FormatFloat(p, 'f', StepDecimals(tickSize), 64)
+}
+
+// FormatSize 按市场数量精度格式化数量，小数位取 min size 的精度且不少于 SizeDecimals
+func FormatSize(s float64, minSize float64) string {
+	return strconv.FormatFloat(s, 'f', max(StepDecimals(minSize), SizeDecimals), 64)
+}
+
+// Pct 比例转百分比 (0.0123 -> 1.23)
+func Pct(fraction float64) float64 {
+	return fraction * 100
+}
+
+// Bps 比例转基点 (0.0123 -> 123)
+func Bps(fraction float64) float64 {
+	return fraction * 10000
+}
+
+// SpreadPct 买卖价差百分比 (ask - bid)，任一侧无报价时返回 0
+func SpreadPct(bid, ask float64) float64 {
+	if bid <= 0 || ask <= 0 {
+		return 0
+	}
+	return Pct(ask - bid)
+}
+
+// ArbMarginPct 二元市场套利空间百分比 (1 - YES ask - NO ask)
+// 正值表示同时买入两侧的成本低于 1，可锁定利润；任一侧无报价时返回 0
+func ArbMarginPct(yesAsk, noAsk float64) float64 {
+	if yesAsk <= 0 || noAsk <= 0 {
+		return 0
+	}
+	return Pct(1 - yesAsk - noAsk)
+}
+
+// CheckPairMargin 检查 YES/NO 组合成本，yesPrice + noPrice > 1 - minMargin（含双边手续费）时返回错误
+func CheckPairMargin(yesPrice, noPrice, minMargin float64) error {
+	if yesPrice <= 0 || noPrice <= 0 {
+		return fmt.Errorf("invalid pair price: yes=%.4f no=%.4f", yesPrice, noPrice)
+	}
+	if cost := yesPrice + noPrice; cost > 1-minMargin+1e-9 {
+		return fmt.Errorf("insufficient margin: yes=%.4f + no=%.4f = %.4f > %.4f", yesPrice, noPrice, cost, 1-minMargin)
+	}
+	return nil
+}
+
+// PeriodDurations Up/Down 轮次周期时长
+var PeriodDurations = map[string]time.Duration{
+	"15m":   15 * time.Minute,
+	"1h":    time.Hour,
+	"4h":    4 * time.Hour,
+	"daily": 24 * time.Hour,
+}
+
+// PeriodDuration 获取周期时长，未知周期返回 15 分钟
+func PeriodDuration(period string) time.Duration {
+	if d, ok := PeriodDurations[period]; ok {
+		return d
+	}
+	return 15 * time.Minute
+}
+
+// IsActiveRound 判断事件在 now 时是否仍在进行（未关闭、未停用且未到结束时间），并返回剩余时间
+// 结束时间无法解析时视为不活跃
+func IsActiveRound(event *Event, now time.Time) (active bool, remaining time.Duration) {
+	if event == nil || event.Closed || !event.Active {
+		return false, 0
+	}
+	endTime, err := ParseDate(event.EndDate)
+	if err != nil || !now.Before(endTime) {
+		return false, 0
+	}
+	return true, endTime.Sub(now)
+}
+
+// SelectActiveRound 从候选事件中选出当前轮次：活跃事件中结束时间最早的一个，没有活跃事件时返回 nil
+func SelectActiveRound(events []Event, now time.Time) *Event {
+	var selected *Event
+	var best time.Duration
+	for i := range events {
+		active, remaining := IsActiveRound(&events[i], now)
+		if active && (selected == nil || remaining < best) {
+			selected, best = &events[i], remaining
+		}
+	}
+	return selected
+}
+
+// RoundWindow 计算 at 所在轮次的开始/结束时间 (UTC 对齐，daily 对齐到 UTC 零点)
+func RoundWindow(period string, at time.Time) (start, end time.Time) {
+	return RoundWindowAt(period, at, 0)
+}
+
+// RoundWindowAt 计算相对 at 所在轮次偏移 offset 个轮次的开始/结束时间
+// offset 为 -1 表示上一轮，1 表示下一轮
+func RoundWindowAt(period string, at time.Time, offset int) (start, end time.Time) {
+	duration := PeriodDuration(period)
+	at = at.UTC()
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	start = day.Add(at.Sub(day).Truncate(duration)).Add(time.Duration(offset) * duration)
+	return start, start.Add(duration)
+}
+
+// VScheme 签名 v 值规范化方案
+type VScheme int
+
+const (
+	// VSchemeEthereum 标准以太坊签名，v ∈ {27, 28}（EIP-712 订单签名、CLOB L1 认证）
+	VSchemeEthereum VScheme = iota
+	// VSchemeSafeEthSign Gnosis Safe eth_sign 签名，v ∈ {31, 32}（即标准 v + 4）
+	VSchemeSafeEthSign
+)
+
+// NormalizeSignatureV 按方案规范化 65 字节签名的 v 值（返回新切片，不修改入参）
+// go-ethereum crypto.Sign 返回的 v 为 0/1，也兼容已为 27/28 的签名
+func NormalizeSignatureV(sig []byte, scheme VScheme) []byte {
+	out := append([]byte(nil), sig...)
+	if len(out) != 65 {
+		return out
+	}
+	v := out[64]
+	if v < 27 {
+		v += 27
+	}
+	if scheme == VSchemeSafeEthSign && v < 31 {
+		v += 4
+	}
+	out[64] = v
+	return out
+}
+
 // Pow10 计算 10^n
 func Pow10(n int) int64 {
 	result := int64(1)
@@ -175,6 +372,43 @@ func ParseUnits(amount string, decimals int) *big.Int {
 	return intResult
 }
 
+// ParseUnitsStrict 错误
+var (
+	// ErrInvalidAmount 金额格式错误（仅支持非负十进制数，如 "12"、"0.5"）
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrExcessPrecision 金额小数位数超过代币精度
+	ErrExcessPrecision = errors.New("amount exceeds token precision")
+)
+
+// ParseUnitsStrict 按十进制字符串精确解析金额（不经过浮点数）
+// 格式错误返回 ErrInvalidAmount，小数位数超过 decimals 返回 ErrExcessPrecision（末尾多余的 0 不计入）
+func ParseUnitsStrict(amount string, decimals int) (*big.Int, error) {
+	s := strings.TrimSpace(amount)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+			}
+		}
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrExcessPrecision, amount, decimals)
+	}
+
+	digits := whole + frac + strings.Repeat("0", decimals-len(frac))
+	result, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	return result, nil
+}
+
 // FormatUnits 格式化 BigInt 为字符串
 func FormatUnits(amount *big.Int, decimals int) string {
 	if amount == nil {
@@ -191,6 +425,18 @@ func FormatUnits(amount *big.Int, decimals int) string {
 	return fmt.Sprintf("%s.%s", whole.String(), fracStr)
 }
 
+// Partition 生成 n 个结果的完整划分 [1, 2, 4, ...]（每个结果一个 index set），n <= 0 时按二元市场处理
+func Partition(n int) []*big.Int {
+	if n <= 0 {
+		n = 2
+	}
+	partition := make([]*big.Int, n)
+	for i := range partition {
+		partition[i] = new(big.Int).Lsh(big.NewInt(1), uint(i))
+	}
+	return partition
+}
+
 // CalculateIndexSet 从 questionIDs 计算 indexSet
 func CalculateIndexSet(questionIDs []string) *big.Int {
 	indexSet := big.NewInt(0)
@@ -0,0 +1,363 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func approxEqual(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestSpreadPct(t *testing.T) {
+	tests := []struct {
+		name     string
+		bid, ask float64
+		want     float64
+	}{
+		{"one tick", 0.48, 0.49, 1},
+		{"wide", 0.30, 0.45, 15},
+		{"locked", 0.5, 0.5, 0},
+		{"crossed", 0.52, 0.50, -2},
+		{"no bid", 0, 0.49, 0},
+		{"no ask", 0.48, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := SpreadPct(tt.bid, tt.ask); !approxEqual(got, tt.want) {
+			t.Errorf("%s: SpreadPct(%v, %v) = %v, want %v", tt.name, tt.bid, tt.ask, got, tt.want)
+		}
+	}
+}
+
+func TestArbMarginPct(t *testing.T) {
+	tests := []struct {
+		name          string
+		yesAsk, noAsk float64
+		want          float64
+	}{
+		{"arbitrage", 0.47, 0.50, 3},
+		{"fair", 0.50, 0.50, 0},
+		{"overround", 0.52, 0.51, -3},
+		{"missing side", 0.47, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := ArbMarginPct(tt.yesAsk, tt.noAsk); !approxEqual(got, tt.want) {
+			t.Errorf("%s: ArbMarginPct(%v, %v) = %v, want %v", tt.name, tt.yesAsk, tt.noAsk, got, tt.want)
+		}
+	}
+}
+
+func TestPctBps(t *testing.T) {
+	if got := Pct(0.0123); !approxEqual(got, 1.23) {
+		t.Errorf("Pct(0.0123) = %v", got)
+	}
+	if got := Bps(0.0123); !approxEqual(got, 123) {
+		t.Errorf("Bps(0.0123) = %v", got)
+	}
+}
+
+func TestCheckPairMargin(t *testing.T) {
+	tests := []struct {
+		name          string
+		yes, no, marg float64
+		wantErr       bool
+	}{
+		{"within margin", 0.48, 0.50, 0.01, false},
+		{"exact boundary", 0.49, 0.50, 0.01, false},
+		{"exceeds margin", 0.50, 0.50, 0.01, true},
+		{"exceeds fee margin", 0.49, 0.49, 0.03, true},
+		{"overround", 0.55, 0.50, 0, true},
+		{"zero margin break-even", 0.50, 0.50, 0, false},
+		{"missing yes", 0, 0.50, 0.01, true},
+		{"negative no", 0.48, -0.1, 0.01, true},
+	}
+	for _, tt := range tests {
+		if err := CheckPairMargin(tt.yes, tt.no, tt.marg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckPairMargin(%v, %v, %v) err = %v, wantErr %v", tt.name, tt.yes, tt.no, tt.marg, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRoundWindowAt(t *testing.T) {
+	utc := func(s string) time.Time {
+		tm, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	est := time.FixedZone("EST", -5*3600)
+	tests := []struct {
+		name       string
+		period     string
+		at         time.Time
+		offset     int
+		start, end string
+	}{
+		{"15m", "15m", utc("2025-03-01 12:07:30"), 0, "2025-03-01 12:00:00", "2025-03-01 12:15:00"},
+		{"15m on boundary", "15m", utc("2025-03-01 12:15:00"), 0, "2025-03-01 12:15:00", "2025-03-01 12:30:00"},
+		{"15m previous across midnight", "15m", utc("2025-03-01 00:05:00"), -1, "2025-02-28 23:45:00", "2025-03-01 00:00:00"},
+		{"15m two ahead", "15m", utc("2025-03-01 23:50:00"), 2, "2025-03-02 00:15:00", "2025-03-02 00:30:00"},
+		{"1h", "1h", utc("2025-03-01 12:59:59"), 0, "2025-03-01 12:00:00", "2025-03-01 13:00:00"},
+		{"1h next across midnight", "1h", utc("2025-03-01 23:10:00"), 1, "2025-03-02 00:00:00", "2025-03-02 01:00:00"},
+		{"4h", "4h", utc("2025-03-01 05:30:00"), 0, "2025-03-01 04:00:00", "2025-03-01 08:00:00"},
+		{"4h last of day", "4h", utc("2025-03-01 23:00:00"), 0, "2025-03-01 20:00:00", "2025-03-02 00:00:00"},
+		{"daily", "daily", utc("2025-03-01 23:59:59"), 0, "2025-03-01 00:00:00", "2025-03-02 00:00:00"},
+		{"daily midnight", "daily", utc("2025-03-02 00:00:00"), 0, "2025-03-02 00:00:00", "2025-03-03 00:00:00"},
+		{"daily next across month end", "daily", utc("2025-02-28 10:00:00"), 1, "2025-03-01 00:00:00", "2025-03-02 00:00:00"},
+		{"daily previous across year end", "daily", utc("2025-01-01 03:00:00"), -1, "2024-12-31 00:00:00", "2025-01-01 00:00:00"},
+		{"daily non-UTC input", "daily", time.Date(2025, 3, 1, 21, 0, 0, 0, est), 0, "2025-03-02 00:00:00", "2025-03-03 00:00:00"},
+		{"unknown period falls back to 15m", "2m", utc("2025-03-01 12:07:30"), 0, "2025-03-01 12:00:00", "2025-03-01 12:15:00"},
+	}
+	for _, tt := range tests {
+		start, end := RoundWindowAt(tt.period, tt.at, tt.offset)
+		if !start.Equal(utc(tt.start)) || !end.Equal(utc(tt.end)) {
+			t.Errorf("%s: RoundWindowAt = [%s, %s), want [%s, %s)", tt.name, start.Format(time.DateTime), end.Format(time.DateTime), tt.start, tt.end)
+		}
+		if start.Location() != time.UTC {
+			t.Errorf("%s: start location = %v, want UTC", tt.name, start.Location())
+		}
+	}
+
+	at := utc("2025-03-01 12:07:30")
+	if s, _ := RoundWindow("1h", at); !s.Equal(utc("2025-03-01 12:00:00")) {
+		t.Errorf("RoundWindow(1h) start = %v", s)
+	}
+}
+
+func TestNormalizeSignatureV(t *testing.T) {
+	tests := []struct {
+		scheme VScheme
+		v      byte
+		want   byte
+	}{
+		{VSchemeEthereum, 0, 27},
+		{VSchemeEthereum, 1, 28},
+		{VSchemeEthereum, 27, 27},
+		{VSchemeEthereum, 28, 28},
+		{VSchemeSafeEthSign, 0, 31},
+		{VSchemeSafeEthSign, 1, 32},
+		{VSchemeSafeEthSign, 27, 31},
+		{VSchemeSafeEthSign, 28, 32},
+		{VSchemeSafeEthSign, 31, 31},
+	}
+	for _, tt := range tests {
+		sig := make([]byte, 65)
+		sig[0], sig[64] = 0xaa, tt.v
+		got := NormalizeSignatureV(sig, tt.scheme)
+		if got[64] != tt.want || got[0] != 0xaa {
+			t.Errorf("scheme %d v=%d: got v=%d, want %d", tt.scheme, tt.v, got[64], tt.want)
+		}
+		if sig[64] != tt.v {
+			t.Errorf("scheme %d v=%d: input modified", tt.scheme, tt.v)
+		}
+	}
+
+	short := []byte{1, 2, 3}
+	if got := NormalizeSignatureV(short, VSchemeEthereum); string(got) != string(short) {
+		t.Errorf("short signature changed: %v", got)
+	}
+}
+
+func TestParseTokenIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"json array", `["123","456"]`, []string{"123", "456"}, false},
+		{"json array with spaces", ` [ "123" , "456" ] `, []string{"123", "456"}, false},
+		{"single quoted", `['123', '456']`, []string{"123", "456"}, false},
+		{"bracketless", `123,456`, []string{"123", "456"}, false},
+		{"bracketless quoted", `"123", '456'`, []string{"123", "456"}, false},
+		{"single id", `123`, []string{"123"}, false},
+		{"trailing comma", `['123',]`, []string{"123"}, false},
+		{"empty", "", nil, false},
+		{"blank", "  ", nil, false},
+		{"empty array", `[]`, []string{}, false},
+		{"embedded space", `['12 3']`, nil, true},
+		{"object", `{"a":"b"}`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTokenIDs(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseTokenIDs(%q) err = %v, wantErr %v", tt.name, tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: ParseTokenIDs(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsActiveRound(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 5, 0, 0, time.UTC)
+	event := func(end string, active, closed bool) *Event {
+		return &Event{EndDate: end, Active: active, Closed: closed}
+	}
+	tests := []struct {
+		name      string
+		event     *Event
+		active    bool
+		remaining time.Duration
+	}{
+		{"active", event("2025-03-01T12:15:00Z", true, false), true, 10 * time.Minute},
+		{"expired", event("2025-03-01T12:00:00Z", true, false), false, 0},
+		{"ends now", event("2025-03-01T12:05:00Z", true, false), false, 0},
+		{"closed", event("2025-03-01T12:15:00Z", true, true), false, 0},
+		{"inactive", event("2025-03-01T12:15:00Z", false, false), false, 0},
+		{"bad end date", event("soon", true, false), false, 0},
+		{"nil", nil, false, 0},
+	}
+	for _, tt := range tests {
+		active, remaining := IsActiveRound(tt.event, now)
+		if active != tt.active || remaining != tt.remaining {
+			t.Errorf("%s: IsActiveRound = %v, %v, want %v, %v", tt.name, active, remaining, tt.active, tt.remaining)
+		}
+	}
+}
+
+func TestSelectActiveRound(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 5, 0, 0, time.UTC)
+	events := []Event{
+		{Slug: "expired", EndDate: "2025-03-01T12:00:00Z", Active: true},
+		{Slug: "next", EndDate: "2025-03-01T12:30:00Z", Active: true},
+		{Slug: "current", EndDate: "2025-03-01T12:15:00Z", Active: true},
+		{Slug: "closed", EndDate: "2025-03-01T12:10:00Z", Active: true, Closed: true},
+	}
+	if got := SelectActiveRound(events, now); got == nil || got.Slug != "current" {
+		t.Errorf("SelectActiveRound = %+v, want current", got)
+	}
+	if got := SelectActiveRound(events[:1], now); got != nil {
+		t.Errorf("SelectActiveRound with only expired = %+v, want nil", got)
+	}
+	if got := SelectActiveRound(nil, now); got != nil {
+		t.Errorf("SelectActiveRound(nil) = %+v", got)
+	}
+}
+
+func TestFormatPriceSize(t *testing.T) {
+	prices := []struct {
+		p, tick float64
+		want    string
+	}{
+		{0.5, 0.1, "0.5"},
+		{0.456, 0.01, "0.46"},
+		{0.5, 0.01, "0.50"},
+		{0.4567, 0.001, "0.457"},
+		{0.0012, 0.0001, "0.0012"},
+		{0.5, 0, "0.50"},
+	}
+	for _, tt := range prices {
+		if got := FormatPrice(tt.p, tt.tick); got != tt.want {
+			t.Errorf("FormatPrice(%v, %v) = %q, want %q", tt.p, tt.tick, got, tt.want)
+		}
+	}
+
+	sizes := []struct {
+		s, min float64
+		want   string
+	}{
+		{12.3456, 5, "12.35"},
+		{10, 1, "10.00"},
+		{1.23456, 0.001, "1.235"},
+		{7, 0, "7.00"},
+	}
+	for _, tt := range sizes {
+		if got := FormatSize(tt.s, tt.min); got != tt.want {
+			t.Errorf("FormatSize(%v, %v) = %q, want %q", tt.s, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestParseUnitsStrict(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+		wantErr  error
+	}{
+		{"1", 6, "1000000", nil},
+		{"1.5", 6, "1500000", nil},
+		{"0.000001", 6, "1", nil},
+		{"123.456789", 6, "123456789", nil},
+		{"1.100000000", 6, "1100000", nil}, // 末尾的 0 不计入小数位
+		{".5", 6, "500000", nil},
+		{"5.", 6, "5000000", nil},
+		{" 2.25 ", 6, "2250000", nil},
+		{"0", 6, "0", nil},
+		{"10", 0, "10", nil},
+		{"115792089237316195423570985008687907853269984665640564039457.584007913129639935", 18,
+			"115792089237316195423570985008687907853269984665640564039457584007913129639935", nil},
+		{"0.0000001", 6, "", ErrExcessPrecision},
+		{"1.1234567", 6, "", ErrExcessPrecision},
+		{"1.5", 0, "", ErrExcessPrecision},
+		{"-1", 6, "", ErrInvalidAmount},
+		{"-0.5", 6, "", ErrInvalidAmount},
+		{"+1", 6, "", ErrInvalidAmount},
+		{"", 6, "", ErrInvalidAmount},
+		{".", 6, "", ErrInvalidAmount},
+		{"abc", 6, "", ErrInvalidAmount},
+		{"1e6", 6, "", ErrInvalidAmount},
+		{"1,000", 6, "", ErrInvalidAmount},
+		{"1.2.3", 6, "", ErrInvalidAmount},
+		{"1 000", 6, "", ErrInvalidAmount},
+		{"0x10", 6, "", ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		got, err := ParseUnitsStrict(tt.amount, tt.decimals)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseUnitsStrict(%q, %d) = %v, %v, want %v", tt.amount, tt.decimals, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseUnitsStrict(%q, %d) = %v, %v, want %s", tt.amount, tt.decimals, got, err, tt.want)
+		}
+	}
+}
+
+func TestImprovePrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference float64
+		tickSize  float64
+		side      string
+		want      float64
+		clamped   float64
+	}{
+		{"buy one tick", 0.48, 0.01, "BUY", 0.49, 0.49},
+		{"sell one tick", 0.52, 0.01, "SELL", 0.51, 0.51},
+		{"lower-case side", 0.52, 0.01, "sell", 0.51, 0.51},
+		// 0.1+0.2 = 0.30000000000000004 不应被当作 0.31
+		{"buy float error", 0.1 + 0.2, 0.01, "BUY", 0.31, 0.31},
+		{"sell float error", 0.1 + 0.2, 0.01, "SELL", 0.29, 0.29},
+		// 不在 tick 上的参考价先对齐，再改善一个 tick
+		{"buy off tick", 0.483, 0.01, "BUY", 0.50, 0.50},
+		{"sell off tick", 0.517, 0.01, "SELL", 0.50, 0.50},
+		{"buy fine tick", 0.123, 0.001, "BUY", 0.124, 0.124},
+		{"sell fine tick", 0.7, 0.001, "SELL", 0.699, 0.699},
+		{"default tick", 0.5, 0, "BUY", 0.51, 0.51},
+		// 超出 (0, 1) 时由 ClampPrice 限制在 [tick, 1-tick]
+		{"buy at top", 0.99, 0.01, "BUY", 1, 0.99},
+		{"sell at bottom", 0.01, 0.01, "SELL", 0, 0.01},
+		{"buy at top fine tick", 0.999, 0.001, "BUY", 1, 0.999},
+		{"sell at bottom fine tick", 0.001, 0.001, "SELL", 0, 0.001},
+	}
+	for _, tt := range tests {
+		got := ImprovePrice(tt.reference, tt.tickSize, tt.side)
+		if got != tt.want {
+			t.Errorf("%s: ImprovePrice(%v, %v, %s) = %v, want %v", tt.name, tt.reference, tt.tickSize, tt.side, got, tt.want)
+		}
+		tick := tt.tickSize
+		if tick == 0 {
+			tick = 0.01
+		}
+		if c := ClampPrice(got, tick); c != tt.clamped {
+			t.Errorf("%s: ClampPrice(%v, %v) = %v, want %v", tt.name, got, tick, c, tt.clamped)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// BuilderVolumePoint Builder 单日交易量
+type BuilderVolumePoint struct {
+	Date   string
+	Time   time.Time // 由 Date 解析，无法解析时为零值
+	Volume float64
+}
+
+// builderDateLayouts dt 字段可能的格式
+var builderDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// ParseBuilderDate 解析 BuilderVolumeEntry.Date（dt 字段）
+func ParseBuilderDate(dt string) (time.Time, error) {
+	var err error
+	for _, layout := range builderDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, dt); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// SumBuilderVolume 按 Builder 汇总总交易量
+func SumBuilderVolume(entries []common.BuilderVolumeEntry) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, e := range entries {
+		totals[e.Builder] += e.Volume
+	}
+	return totals
+}
+
+// BuilderVolumeSeries 获取指定 Builder 按日期升序的交易量序列，同一日期的多条记录会合并
+func BuilderVolumeSeries(entries []common.BuilderVolumeEntry, builder string) []BuilderVolumePoint {
+	byDate := make(map[string]int)
+	var series []BuilderVolumePoint
+	for _, e := range entries {
+		if e.Builder != builder {
+			continue
+		}
+		if i, ok := byDate[e.Date]; ok {
+			series[i].Volume += e.Volume
+			continue
+		}
+		t, _ := ParseBuilderDate(e.Date)
+		byDate[e.Date] = len(series)
+		series = append(series, BuilderVolumePoint{Date: e.Date, Time: t, Volume: e.Volume})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Time.Equal(series[j].Time) {
+			return series[i].Date < series[j].Date
+		}
+		return series[i].Time.Before(series[j].Time)
+	})
+	return series
+}
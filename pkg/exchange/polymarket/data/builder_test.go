@@ -0,0 +1,76 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// builderEntries 三天、三个 Builder 的交易量数据，日期格式混用且乱序
+func builderEntries() []common.BuilderVolumeEntry {
+	return []common.BuilderVolumeEntry{
+		{Date: "2025-03-03T00:00:00Z", Builder: "alpha", Volume: 300},
+		{Date: "2025-03-01T00:00:00Z", Builder: "alpha", Volume: 100},
+		{Date: "2025-03-01T00:00:00Z", Builder: "beta", Volume: 50},
+		{Date: "2025-03-02", Builder: "alpha", Volume: 200},
+		{Date: "2025-03-02", Builder: "beta", Volume: 25},
+		{Date: "2025-03-02", Builder: "alpha", Volume: 5},
+		{Date: "2025-03-03T00:00:00Z", Builder: "gamma", Volume: 1},
+	}
+}
+
+func TestSumBuilderVolume(t *testing.T) {
+	totals := SumBuilderVolume(builderEntries())
+	want := map[string]float64{"alpha": 605, "beta": 75, "gamma": 1}
+	if len(totals) != len(want) {
+		t.Fatalf("totals = %v, want %v", totals, want)
+	}
+	for builder, v := range want {
+		if totals[builder] != v {
+			t.Errorf("%s total = %v, want %v", builder, totals[builder], v)
+		}
+	}
+	if totals := SumBuilderVolume(nil); len(totals) != 0 {
+		t.Errorf("empty totals = %v", totals)
+	}
+}
+
+func TestBuilderVolumeSeries(t *testing.T) {
+	series := BuilderVolumeSeries(builderEntries(), "alpha")
+	want := []struct {
+		date   string
+		volume float64
+	}{
+		{"2025-03-01T00:00:00Z", 100},
+		{"2025-03-02", 205},
+		{"2025-03-03T00:00:00Z", 300},
+	}
+	if len(series) != len(want) {
+		t.Fatalf("series = %+v", series)
+	}
+	for i, w := range want {
+		if series[i].Date != w.date || series[i].Volume != w.volume {
+			t.Errorf("point %d = %+v, want %s %v", i, series[i], w.date, w.volume)
+		}
+		if day := time.Date(2025, 3, i+1, 0, 0, 0, 0, time.UTC); !series[i].Time.Equal(day) {
+			t.Errorf("point %d time = %v, want %v", i, series[i].Time, day)
+		}
+	}
+
+	if series := BuilderVolumeSeries(builderEntries(), "unknown"); len(series) != 0 {
+		t.Errorf("unknown builder series = %+v", series)
+	}
+}
+
+func TestParseBuilderDate(t *testing.T) {
+	for _, dt := range []string{"2025-03-02T00:00:00Z", "2025-03-02T00:00:00", "2025-03-02"} {
+		got, err := ParseBuilderDate(dt)
+		if err != nil || !got.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("ParseBuilderDate(%q) = %v, %v", dt, got, err)
+		}
+	}
+	if _, err := ParseBuilderDate("03/02/2025"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -3,6 +3,8 @@ package data
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
 	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
@@ -13,12 +15,20 @@ type ClientConfig struct {
 	BaseURL     string
 	Timeout     time.Duration
 	ProxyString string
-	Debug       bool
+	Debug       bool              // 未设置 Logger 时将请求日志输出到 stderr
+	Transport   http.RoundTripper // 自定义传输层（可选）
+	Logger      common.Logger     // 请求/重试日志（可选）
+	Hook        *common.HTTPHook  // 请求/响应钩子（可选）
+
+	TransportOptions common.TransportOptions // 连接池/TLS 参数（可选，设置 Transport 时忽略）
+
+	PnLBaseURL string // 用户盈亏 API 地址 (默认 common.UserPnLAPIBaseURL)
 }
 
 // Client Data API 客户端
 type Client struct {
-	client *common.HTTPClient
+	client    *common.HTTPClient
+	pnlClient *common.HTTPClient // 盈亏历史使用独立的 user-pnl API
 }
 
 // NewClient 创建 Data 客户端
@@ -29,17 +39,36 @@ func NewClient(cfg ClientConfig) *Client {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
+	if cfg.PnLBaseURL == "" {
+		cfg.PnLBaseURL = common.UserPnLAPIBaseURL
+	}
+
+	httpCfg := common.HTTPClientConfig{
+		BaseURL:     cfg.BaseURL,
+		Timeout:     cfg.Timeout,
+		ProxyString: cfg.ProxyString,
+		Debug:       cfg.Debug,
+		Transport:   cfg.Transport,
+		Logger:      cfg.Logger,
+		Hook:        cfg.Hook,
+
+		TransportOptions: cfg.TransportOptions,
+	}
+	pnlCfg := httpCfg
+	pnlCfg.BaseURL = cfg.PnLBaseURL
 
 	return &Client{
-		client: common.NewHTTPClient(common.HTTPClientConfig{
-			BaseURL:     cfg.BaseURL,
-			Timeout:     cfg.Timeout,
-			ProxyString: cfg.ProxyString,
-			Debug:       cfg.Debug,
-		}),
+		client:    common.NewHTTPClient(httpCfg),
+		pnlClient: common.NewHTTPClient(pnlCfg),
 	}
 }
 
+// Close 释放空闲 HTTP 连接，客户端不再使用时调用，可重复调用
+func (c *Client) Close() {
+	c.client.Close()
+	c.pnlClient.Close()
+}
+
 // HealthCheck 健康检查
 func (c *Client) HealthCheck(ctx context.Context) (string, error) {
 	body, err := c.client.Get(ctx, "/", nil)
@@ -53,9 +82,12 @@ func (c *Client) HealthCheck(ctx context.Context) (string, error) {
 
 // GetPositions 获取用户持仓
 func (c *Client) GetPositions(ctx context.Context, params *common.PositionQueryParams) ([]common.Position, error) {
-	if params == nil || params.User == "" {
+	if params == nil {
 		return nil, fmt.Errorf("user is required")
 	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
 
 	var positions []common.Position
 	if err := c.client.GetJSON(ctx, "/positions", params, &positions); err != nil {
@@ -133,6 +165,46 @@ func (c *Client) GetPortfolioValue(ctx context.Context, user string) ([]common.P
 	return values, nil
 }
 
+// GetPnLHistory 获取用户盈亏时间序列（user-pnl API），按时间升序
+// 服务端只返回累计盈亏，结果中 TotalPnl 为该时间点的累计盈亏，RealizedPnl/UnrealizedPnl 为 0；
+// 用户没有历史时返回空切片
+func (c *Client) GetPnLHistory(ctx context.Context, user string, params *common.PnLHistoryParams) ([]common.PnLData, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+	p := common.PnLHistoryParams{}
+	if params != nil {
+		p = *params
+	}
+	if p.Interval == "" {
+		p.Interval = common.PnLIntervalAll
+	}
+
+	query := struct {
+		UserAddress string `url:"user_address"`
+		common.PnLHistoryParams
+	}{UserAddress: user, PnLHistoryParams: p}
+
+	var points []struct {
+		T int64   `json:"t"`
+		P float64 `json:"p"`
+	}
+	if err := c.pnlClient.GetJSON(ctx, "/user-pnl", &query, &points); err != nil {
+		return nil, fmt.Errorf("get pnl history: %w", err)
+	}
+
+	history := make([]common.PnLData, 0, len(points))
+	for _, pt := range points {
+		history = append(history, common.PnLData{
+			Timestamp: pt.T,
+			TotalPnl:  pt.P,
+			Timeframe: p.Interval,
+		})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp < history[j].Timestamp })
+	return history, nil
+}
+
 // GetHolders 获取市场持有者
 func (c *Client) GetHolders(ctx context.Context, params *common.HoldersParams) ([]common.MarketHolders, error) {
 	if params == nil || params.Market == "" {
@@ -195,15 +267,18 @@ func (c *Client) GetLeaderboard(ctx context.Context, params *common.LeaderboardP
 	if params == nil {
 		params = &common.LeaderboardParams{}
 	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("get leaderboard: %w", err)
+	}
 	// 设置默认值
 	if params.Category == "" {
-		params.Category = "OVERALL"
+		params.Category = common.CategoryOverall
 	}
 	if params.TimePeriod == "" {
-		params.TimePeriod = "DAY"
+		params.TimePeriod = common.TimePeriodDay
 	}
 	if params.OrderBy == "" {
-		params.OrderBy = "PNL"
+		params.OrderBy = common.OrderByPnL
 	}
 	if params.Limit == 0 {
 		params.Limit = 25
@@ -223,6 +298,9 @@ func (c *Client) GetBuilderLeaderboard(ctx context.Context, params *common.Build
 	if params == nil {
 		params = &common.BuilderLeaderboardParams{}
 	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("get builder leaderboard: %w", err)
+	}
 	if params.Limit == 0 {
 		params.Limit = 25
 	}
@@ -239,6 +317,9 @@ func (c *Client) GetBuilderVolume(ctx context.Context, params *common.BuilderVol
 	if params == nil {
 		params = &common.BuilderVolumeParams{}
 	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("get builder volume: %w", err)
+	}
 	if params.Limit == 0 {
 		params.Limit = 25
 	}
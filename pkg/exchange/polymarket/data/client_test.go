@@ -0,0 +1,190 @@
+package data
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common/replay"
+)
+
+// newTestClient 创建指向 httptest 服务器的客户端
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := NewClient(ClientConfig{BaseURL: srv.URL, PnLBaseURL: srv.URL})
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestEmptyAndNullBodies(t *testing.T) {
+	for _, body := range []string{"null", "[]", "", " null\n"} {
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+		ctx := t.Context()
+
+		values, err := c.GetPortfolioValue(ctx, "0xabc")
+		if err != nil {
+			t.Fatalf("body %q: GetPortfolioValue: %v", body, err)
+		}
+		if _, ok := common.First(values); ok {
+			t.Errorf("body %q: First(values) ok for empty response", body)
+		}
+
+		oi, err := c.GetOpenInterest(ctx)
+		if err != nil {
+			t.Fatalf("body %q: GetOpenInterest: %v", body, err)
+		}
+		if _, ok := common.First(oi); ok {
+			t.Errorf("body %q: First(oi) ok for empty response", body)
+		}
+
+		entries, err := c.GetLeaderboard(ctx, nil)
+		if err != nil || len(entries) != 0 {
+			t.Errorf("body %q: GetLeaderboard = %v, %v", body, entries, err)
+		}
+
+		volume, err := c.GetLiveVolume(ctx, 1)
+		if err != nil || volume == nil {
+			t.Errorf("body %q: GetLiveVolume = %v, %v", body, volume, err)
+		}
+
+		if body == "[]" {
+			continue
+		}
+		// 单值接口返回 null 时得到零值而不是错误
+		traded, err := c.GetMarketsTraded(ctx, "0xabc")
+		if err != nil || traded == nil || traded.Traded != 0 {
+			t.Errorf("body %q: GetMarketsTraded = %+v, %v", body, traded, err)
+		}
+	}
+}
+
+func TestFirstValue(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"user":"0xabc","value":123.45}]`))
+	}))
+	values, err := c.GetPortfolioValue(t.Context(), "0xabc")
+	if err != nil {
+		t.Fatalf("GetPortfolioValue: %v", err)
+	}
+	if v, ok := common.First(values); !ok || v.Value != 123.45 {
+		t.Errorf("First(values) = %+v, %v", v, ok)
+	}
+}
+
+// replayUser 录制 fixture 使用的公开地址
+const replayUser = "0x6af75d4e4aaf700450efbac3708cce1665810ff1"
+
+func TestGetPositionsReplay(t *testing.T) {
+	c := NewClient(ClientConfig{Transport: replay.New("testdata/replay")})
+	defer c.Close()
+
+	positions, err := c.GetPositions(t.Context(), &common.PositionQueryParams{User: replayUser, Limit: 2})
+	if err != nil {
+		t.Fatalf("GetPositions: %v", err)
+	}
+	if len(positions) == 0 || len(positions) > 2 {
+		t.Fatalf("positions = %d, want 1..2", len(positions))
+	}
+	for _, p := range positions {
+		if !strings.EqualFold(p.ProxyWallet, replayUser) {
+			t.Errorf("proxyWallet = %s, want %s", p.ProxyWallet, replayUser)
+		}
+		if p.Asset == "" || p.ConditionID == "" || p.Size <= 0 {
+			t.Errorf("incomplete position: %+v", p)
+		}
+		if p.CurrentPrice < 0 || p.CurrentPrice > 1 || p.Outcome == "" || p.OppositeAsset == "" {
+			t.Errorf("position market fields: %+v", p)
+		}
+	}
+}
+
+func TestGetLeaderboardParams(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Write([]byte("[]"))
+	}))
+
+	if _, err := c.GetLeaderboard(t.Context(), &common.LeaderboardParams{Category: "MEMES"}); err == nil {
+		t.Error("expected error for unknown category")
+	}
+	if _, err := c.GetBuilderLeaderboard(t.Context(), &common.BuilderLeaderboardParams{Limit: -1}); err == nil {
+		t.Error("expected error for negative builder limit")
+	}
+	if len(queries) != 0 {
+		t.Fatalf("invalid params sent requests: %v", queries)
+	}
+
+	if _, err := c.GetLeaderboard(t.Context(), &common.LeaderboardParams{TimePeriod: common.TimePeriodMonth}); err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+	for _, want := range []string{"category=OVERALL", "timePeriod=MONTH", "orderBy=PNL", "limit=25"} {
+		if len(queries) != 1 || !strings.Contains(queries[0], want) {
+			t.Errorf("query %v missing %s", queries, want)
+		}
+	}
+}
+
+func TestGetPnLHistory(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user-pnl", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("user_address") {
+		case "0xabc":
+			if q.Get("interval") != common.PnLIntervalWeek || q.Get("fidelity") != "1h" {
+				t.Errorf("query = %s", r.URL.RawQuery)
+			}
+			// 服务端乱序返回
+			w.Write([]byte(`[{"t":1700007200,"p":12.5},{"t":1700000000,"p":-3},{"t":1700003600,"p":4.25}]`))
+		case "0xdefault":
+			if q.Get("interval") != common.PnLIntervalAll || q.Has("fidelity") {
+				t.Errorf("default query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"t":1700000000,"p":1}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+	c := newTestClient(t, mux)
+
+	history, err := c.GetPnLHistory(t.Context(), "0xabc", &common.PnLHistoryParams{Interval: common.PnLIntervalWeek, Fidelity: "1h"})
+	if err != nil {
+		t.Fatalf("GetPnLHistory: %v", err)
+	}
+	want := []common.PnLData{
+		{Timestamp: 1700000000, TotalPnl: -3, Timeframe: common.PnLIntervalWeek},
+		{Timestamp: 1700003600, TotalPnl: 4.25, Timeframe: common.PnLIntervalWeek},
+		{Timestamp: 1700007200, TotalPnl: 12.5, Timeframe: common.PnLIntervalWeek},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history = %+v, want %d points", history, len(want))
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Errorf("history[%d] = %+v, want %+v", i, history[i], want[i])
+		}
+	}
+
+	history, err = c.GetPnLHistory(t.Context(), "0xdefault", nil)
+	if err != nil || len(history) != 1 || history[0].Timeframe != common.PnLIntervalAll {
+		t.Errorf("default interval: history = %+v, err = %v", history, err)
+	}
+
+	// 没有历史时返回空切片而不是 nil
+	history, err = c.GetPnLHistory(t.Context(), "0xempty", nil)
+	if err != nil || history == nil || len(history) != 0 {
+		t.Errorf("empty history = %#v, err = %v, want empty slice", history, err)
+	}
+
+	if _, err := c.GetPnLHistory(t.Context(), "", nil); err == nil {
+		t.Error("empty user should fail")
+	}
+}
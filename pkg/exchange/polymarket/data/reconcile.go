@@ -0,0 +1,105 @@
+package data
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// ReconcileTolerance 对账时允许的数量误差
+const ReconcileTolerance = 1e-6
+
+// Discrepancy 持仓对账差异
+type Discrepancy struct {
+	Asset        string
+	ConditionID  string
+	ActivitySize float64 // 按活动记录重建的净持仓
+	PositionSize float64 // 持仓接口返回的数量（无持仓记录时为 0）
+	Diff         float64 // ActivitySize - PositionSize
+}
+
+// ReconcilePositions 按活动记录重建每个 asset 的净持仓，并与持仓接口返回的数量比对
+// TRADE 按买卖方向增减；SPLIT/MERGE 同时增减该市场两个结果的持仓；REDEEM 清空该市场持仓。
+// SPLIT/MERGE/REDEEM 的活动不含 asset，需通过 positions 中的 Asset/OppositeAsset 映射，
+// 因此 positions 应包含全部持仓（查询时 SizeThreshold 设为 0）；CONVERSION 暂不计入。
+// activities 可按任意顺序传入，返回结果按 asset 排序
+func ReconcilePositions(activities []common.Activity, positions []common.Position) []Discrepancy {
+	reported := make(map[string]float64, len(positions))
+	conditions := make(map[string]string) // asset -> conditionId
+	outcomes := make(map[string][]string) // conditionId -> assets
+	addAsset := func(conditionID, asset string) {
+		if conditionID == "" || asset == "" {
+			return
+		}
+		if _, ok := conditions[asset]; ok {
+			return
+		}
+		conditions[asset] = conditionID
+		outcomes[conditionID] = append(outcomes[conditionID], asset)
+	}
+	for _, p := range positions {
+		reported[p.Asset] += p.Size
+		addAsset(p.ConditionID, p.Asset)
+		addAsset(p.ConditionID, p.OppositeAsset)
+	}
+	for _, a := range activities {
+		addAsset(a.ConditionID, a.Asset)
+	}
+
+	sorted := append([]common.Activity(nil), activities...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	rebuilt := make(map[string]float64)
+	for _, a := range sorted {
+		switch strings.ToUpper(a.Type) {
+		case common.ActivityTrade:
+			if strings.EqualFold(a.Side, "SELL") {
+				rebuilt[a.Asset] -= a.Size
+			} else {
+				rebuilt[a.Asset] += a.Size
+			}
+		case common.ActivitySplit:
+			for _, asset := range outcomes[a.ConditionID] {
+				rebuilt[asset] += a.Size
+			}
+		case common.ActivityMerge:
+			for _, asset := range outcomes[a.ConditionID] {
+				rebuilt[asset] -= a.Size
+			}
+		case common.ActivityRedeem:
+			for _, asset := range outcomes[a.ConditionID] {
+				rebuilt[asset] = 0
+			}
+		}
+	}
+
+	assets := make(map[string]bool, len(rebuilt)+len(reported))
+	for asset := range rebuilt {
+		assets[asset] = true
+	}
+	for asset := range reported {
+		assets[asset] = true
+	}
+
+	var result []Discrepancy
+	for asset := range assets {
+		if asset == "" {
+			continue
+		}
+		diff := rebuilt[asset] - reported[asset]
+		if math.Abs(diff) <= ReconcileTolerance {
+			continue
+		}
+		result = append(result, Discrepancy{
+			Asset:        asset,
+			ConditionID:  conditions[asset],
+			ActivitySize: rebuilt[asset],
+			PositionSize: reported[asset],
+			Diff:         diff,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Asset < result[j].Asset })
+	return result
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// trade 构造成交活动
+func trade(ts int64, asset, side string, size float64) common.Activity {
+	return common.Activity{Timestamp: ts, Type: common.ActivityTrade, ConditionID: "0xc1", Asset: asset, Side: side, Size: size}
+}
+
+func TestReconcilePositionsMatching(t *testing.T) {
+	activities := []common.Activity{
+		trade(3, "yes", "SELL", 4),
+		trade(1, "yes", "BUY", 10),
+		{Timestamp: 2, Type: common.ActivitySplit, ConditionID: "0xc1", Size: 5},
+		{Timestamp: 4, Type: common.ActivityMerge, ConditionID: "0xc1", Size: 2},
+		{Timestamp: 5, Type: common.ActivityReward, Size: 100},
+		// 已赎回的市场：赎回后持仓清零
+		{Timestamp: 1, Type: common.ActivityTrade, ConditionID: "0xc2", Asset: "old", Side: "BUY", Size: 7},
+		{Timestamp: 6, Type: common.ActivityRedeem, ConditionID: "0xc2", Size: 7},
+	}
+	positions := []common.Position{
+		{Asset: "yes", OppositeAsset: "no", ConditionID: "0xc1", Size: 9}, // 10 + 5 - 4 - 2
+		{Asset: "no", OppositeAsset: "yes", ConditionID: "0xc1", Size: 3}, // 5 - 2
+	}
+	if got := ReconcilePositions(activities, positions); len(got) != 0 {
+		t.Errorf("discrepancies = %+v, want none", got)
+	}
+}
+
+func TestReconcilePositionsMismatch(t *testing.T) {
+	activities := []common.Activity{
+		trade(1, "yes", "BUY", 10),
+		{Timestamp: 2, Type: common.ActivityTrade, ConditionID: "0xc2", Asset: "gone", Side: "BUY", Size: 3},
+		{Timestamp: 3, Type: common.ActivitySplit, ConditionID: "0xc1", Size: 5},
+	}
+	positions := []common.Position{
+		{Asset: "yes", OppositeAsset: "no", ConditionID: "0xc1", Size: 12},
+		{Asset: "no", OppositeAsset: "yes", ConditionID: "0xc1", Size: 5.0000001}, // 容差内
+		{Asset: "extra", ConditionID: "0xc3", Size: 1},
+	}
+	got := ReconcilePositions(activities, positions)
+	want := []Discrepancy{
+		{Asset: "extra", ConditionID: "0xc3", ActivitySize: 0, PositionSize: 1, Diff: -1},
+		{Asset: "gone", ConditionID: "0xc2", ActivitySize: 3, PositionSize: 0, Diff: 3},
+		{Asset: "yes", ConditionID: "0xc1", ActivitySize: 15, PositionSize: 12, Diff: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("discrepancies = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Asset != w.Asset || g.ConditionID != w.ConditionID || math.Abs(g.ActivitySize-w.ActivitySize) > 1e-9 ||
+			math.Abs(g.PositionSize-w.PositionSize) > 1e-9 || math.Abs(g.Diff-w.Diff) > 1e-9 {
+			t.Errorf("discrepancy %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// RedeemParamsFromPositions 将可赎回持仓按 condition 合并为赎回参数，每个 condition 只生成一次赎回
+// 普通市场赎回整个 condition（无需数量）；NegRisk 市场按结果索引汇总数量 [YES, NO]。
+// 非 Redeemable 或数量为 0 的持仓会被忽略，返回结果按 condition ID 排序
+func RedeemParamsFromPositions(positions []common.Position) []common.RedeemParams {
+	type group struct {
+		negRisk bool
+		amounts [2]float64
+	}
+	groups := make(map[string]*group)
+	for _, p := range positions {
+		if !p.Redeemable || p.ConditionID == "" || p.Size <= 0 {
+			continue
+		}
+		g, ok := groups[p.ConditionID]
+		if !ok {
+			g = &group{}
+			groups[p.ConditionID] = g
+		}
+		g.negRisk = g.negRisk || p.NegativeRisk
+		if p.OutcomeIndex == 0 || p.OutcomeIndex == 1 {
+			g.amounts[p.OutcomeIndex] += p.Size
+		}
+	}
+
+	result := make([]common.RedeemParams, 0, len(groups))
+	for conditionID, g := range groups {
+		params := common.RedeemParams{
+			CollateralToken: common.ContractUSDC,
+			ConditionID:     conditionID,
+			NegRisk:         g.negRisk,
+		}
+		if g.negRisk {
+			params.Amounts = []string{
+				strconv.FormatFloat(g.amounts[0], 'f', -1, 64),
+				strconv.FormatFloat(g.amounts[1], 'f', -1, 64),
+			}
+		}
+		result = append(result, params)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ConditionID < result[j].ConditionID })
+	return result
+}
@@ -0,0 +1,38 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestRedeemParamsFromPositions(t *testing.T) {
+	positions := []common.Position{
+		{ConditionID: "0xc2", OutcomeIndex: 0, Size: 3, Redeemable: true, NegativeRisk: true},
+		{ConditionID: "0xc1", OutcomeIndex: 0, Size: 10, Redeemable: true},
+		{ConditionID: "0xc1", OutcomeIndex: 1, Size: 4, Redeemable: true},
+		{ConditionID: "0xc2", OutcomeIndex: 1, Size: 5.5, Redeemable: true, NegativeRisk: true},
+		{ConditionID: "0xc2", OutcomeIndex: 1, Size: 0.5, Redeemable: true, NegativeRisk: true},
+		{ConditionID: "0xc3", OutcomeIndex: 0, Size: 7},
+		{ConditionID: "0xc4", OutcomeIndex: 0, Size: 0, Redeemable: true},
+		{ConditionID: "", OutcomeIndex: 0, Size: 1, Redeemable: true},
+	}
+
+	got := RedeemParamsFromPositions(positions)
+	if len(got) != 2 {
+		t.Fatalf("params = %+v, want one per redeemable condition", got)
+	}
+
+	ctf, negRisk := got[0], got[1]
+	if ctf.ConditionID != "0xc1" || ctf.NegRisk || ctf.Amounts != nil || ctf.CollateralToken != common.ContractUSDC {
+		t.Errorf("ctf params = %+v", ctf)
+	}
+	if negRisk.ConditionID != "0xc2" || !negRisk.NegRisk || fmt.Sprint(negRisk.Amounts) != "[3 6]" {
+		t.Errorf("neg risk params = %+v, want amounts [3 6]", negRisk)
+	}
+
+	if got := RedeemParamsFromPositions(positions[5:]); len(got) != 0 {
+		t.Errorf("params without redeemable positions = %+v", got)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
@@ -14,7 +18,12 @@ type ClientConfig struct {
 	BaseURL     string
 	Timeout     time.Duration
 	ProxyString string
-	Debug       bool
+	Debug       bool              // 未设置 Logger 时将请求日志输出到 stderr
+	Transport   http.RoundTripper // 自定义传输层（可选）
+	Logger      common.Logger     // 请求/重试日志（可选）
+	Hook        *common.HTTPHook  // 请求/响应钩子（可选）
+
+	TransportOptions common.TransportOptions // 连接池/TLS 参数（可选，设置 Transport 时忽略）
 }
 
 // Client Gamma API 客户端
@@ -37,10 +46,20 @@ func NewClient(cfg ClientConfig) *Client {
 			Timeout:     cfg.Timeout,
 			ProxyString: cfg.ProxyString,
 			Debug:       cfg.Debug,
+			Transport:   cfg.Transport,
+			Logger:      cfg.Logger,
+			Hook:        cfg.Hook,
+
+			TransportOptions: cfg.TransportOptions,
 		}),
 	}
 }
 
+// Close 释放空闲 HTTP 连接，客户端不再使用时调用，可重复调用
+func (c *Client) Close() {
+	c.client.Close()
+}
+
 // HealthCheck 健康检查
 func (c *Client) HealthCheck(ctx context.Context) (interface{}, error) {
 	body, err := c.client.Get(ctx, "/status", nil)
@@ -55,10 +74,17 @@ func (c *Client) HealthCheck(ctx context.Context) (interface{}, error) {
 	return result, nil
 }
 
-// ListEvents 查询事件列表
+// ListEvents 查询事件列表（params.Order 须为 common.MarketOrderBy* 字段）
 func (c *Client) ListEvents(ctx context.Context, params *common.EventQueryParams) ([]common.Event, error) {
+	var query url.Values
+	if params != nil {
+		var err error
+		if query, err = listQuery(params, &params.MarketQueryParams); err != nil {
+			return nil, fmt.Errorf("list events: %w", err)
+		}
+	}
 	var events []common.Event
-	if err := c.client.GetJSON(ctx, "/events", params, &events); err != nil {
+	if err := c.client.GetJSON(ctx, "/events", query, &events); err != nil {
 		return nil, fmt.Errorf("list events: %w", err)
 	}
 	return events, nil
@@ -91,15 +117,94 @@ func (c *Client) GetEventTags(ctx context.Context, eventID string) ([]common.Tag
 	return tags, nil
 }
 
-// ListMarkets 查询市场列表
+// ListMarkets 查询市场列表（params.Order 须为 common.MarketOrderBy* 字段）
 func (c *Client) ListMarkets(ctx context.Context, params *common.MarketQueryParams) ([]common.Market, error) {
+	var query url.Values
+	if params != nil {
+		var err error
+		if query, err = listQuery(params, params); err != nil {
+			return nil, fmt.Errorf("list markets: %w", err)
+		}
+	}
 	var markets []common.Market
-	if err := c.client.GetJSON(ctx, "/markets", params, &markets); err != nil {
+	if err := c.client.GetJSON(ctx, "/markets", query, &markets); err != nil {
 		return nil, fmt.Errorf("list markets: %w", err)
 	}
 	return markets, nil
 }
 
+// listQuery 校验排序字段并构建查询参数
+// 设置 Order 时总是携带 ascending（Ascending 为 false 时显式发送 ascending=false 表示降序）
+func listQuery(params interface{}, mp *common.MarketQueryParams) (url.Values, error) {
+	if err := common.ValidateOrder(mp.Order); err != nil {
+		return nil, err
+	}
+	query, err := url.ParseQuery(common.BuildQuery(params))
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+	if mp.Order != "" {
+		query.Set("ascending", strconv.FormatBool(mp.Ascending))
+	}
+	return query, nil
+}
+
+// 自动分页查询的默认参数
+const (
+	DefaultListPageSize   = 100   // params.Limit 未设置时的每页数量
+	DefaultListMaxResults = 10000 // maxResults <= 0 时的结果上限，避免无限翻页
+)
+
+// ListAllEvents 从 params.Offset 起按 params.Limit（默认 DefaultListPageSize）逐页查询事件，
+// 直到返回不足一页（含 Offset 超出数据范围时的空数组）或累计达到 maxResults（<= 0 时为 DefaultListMaxResults）
+func (c *Client) ListAllEvents(ctx context.Context, params *common.EventQueryParams, maxResults int) ([]common.Event, error) {
+	p := common.EventQueryParams{}
+	if params != nil {
+		p = *params
+	}
+	return listAll(ctx, &p.MarketQueryParams, maxResults, func(ctx context.Context) ([]common.Event, error) {
+		return c.ListEvents(ctx, &p)
+	})
+}
+
+// ListAllMarkets 逐页查询市场，分页规则同 ListAllEvents
+func (c *Client) ListAllMarkets(ctx context.Context, params *common.MarketQueryParams, maxResults int) ([]common.Market, error) {
+	p := common.MarketQueryParams{}
+	if params != nil {
+		p = *params
+	}
+	return listAll(ctx, &p, maxResults, func(ctx context.Context) ([]common.Market, error) {
+		return c.ListMarkets(ctx, &p)
+	})
+}
+
+// listAll 按 Limit/Offset 翻页调用 fetch（fetch 使用 p 的当前值），累计结果直到出现短页或达到上限
+func listAll[T any](ctx context.Context, p *common.MarketQueryParams, maxResults int, fetch func(ctx context.Context) ([]T, error)) ([]T, error) {
+	if p.Limit <= 0 {
+		p.Limit = DefaultListPageSize
+	}
+	if maxResults <= 0 {
+		maxResults = DefaultListMaxResults
+	}
+
+	var all []T
+	for len(all) < maxResults {
+		page, err := fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %w", p.Offset, err)
+		}
+		all = append(all, page...)
+		if len(page) < p.Limit {
+			break
+		}
+		p.Offset += len(page)
+	}
+	if len(all) > maxResults {
+		all = all[:maxResults]
+	}
+	return all, nil
+}
+
 // GetMarketByID 根据 ID 获取市场
 func (c *Client) GetMarketByID(ctx context.Context, id string) (*common.Market, error) {
 	var market common.Market
@@ -118,6 +223,33 @@ func (c *Client) GetMarketBySlug(ctx context.Context, slug string) (*common.Mark
 	return &market, nil
 }
 
+// GetMarketByConditionID 根据 conditionId 获取市场（持仓、relayer 等只携带 conditionId 时使用）
+// 只匹配 conditionId 完全一致（不区分大小写）的市场，没有匹配或匹配多个时返回错误
+func (c *Client) GetMarketByConditionID(ctx context.Context, conditionID string) (*common.Market, error) {
+	if conditionID == "" {
+		return nil, fmt.Errorf("condition id is required")
+	}
+	markets, err := c.ListMarkets(ctx, &common.MarketQueryParams{ConditionIDs: conditionID})
+	if err != nil {
+		return nil, fmt.Errorf("get market by condition id: %w", err)
+	}
+
+	var matched []*common.Market
+	for i := range markets {
+		if strings.EqualFold(markets[i].ConditionID, conditionID) {
+			matched = append(matched, &markets[i])
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("get market by condition id: no market for condition %s", conditionID)
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, fmt.Errorf("get market by condition id: %d markets for condition %s", len(matched), conditionID)
+	}
+}
+
 // GetMarketTagsByID 获取市场标签
 func (c *Client) GetMarketTagsByID(ctx context.Context, id string) ([]common.Tag, error) {
 	var tags []common.Tag
@@ -149,6 +281,62 @@ func (c *Client) SearchMarketsEventsAndProfiles(ctx context.Context, params *com
 	return &result, nil
 }
 
+// SearchAllPages 从 params.Page（默认 1）起逐页搜索，直到结果耗尽或达到 maxPages（<=0 不限制），
+// 合并事件/市场/用户并按 ID（用户按地址）去重
+func (c *Client) SearchAllPages(ctx context.Context, params *common.SearchParams, maxPages int) (*common.SearchResult, error) {
+	if params == nil || params.Q == "" {
+		return nil, fmt.Errorf("q parameter is required")
+	}
+
+	p := *params
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+
+	merged := &common.SearchResult{}
+	seenEvents := make(map[string]bool)
+	seenMarkets := make(map[string]bool)
+	seenProfiles := make(map[string]bool)
+
+	for pages := 0; maxPages <= 0 || pages < maxPages; pages++ {
+		page, err := c.SearchMarketsEventsAndProfiles(ctx, &p)
+		if err != nil {
+			return nil, fmt.Errorf("search page %d: %w", p.Page, err)
+		}
+
+		added := 0
+		for _, e := range page.Events {
+			if !seenEvents[e.ID] {
+				seenEvents[e.ID] = true
+				merged.Events = append(merged.Events, e)
+				added++
+			}
+		}
+		for _, m := range page.Markets {
+			if !seenMarkets[m.ID] {
+				seenMarkets[m.ID] = true
+				merged.Markets = append(merged.Markets, m)
+				added++
+			}
+		}
+		for _, pr := range page.Profiles {
+			key := strings.ToLower(pr.Address)
+			if !seenProfiles[key] {
+				seenProfiles[key] = true
+				merged.Profiles = append(merged.Profiles, pr)
+				added++
+			}
+		}
+
+		// 空页或整页重复视为结果耗尽
+		if added == 0 {
+			break
+		}
+		p.Page++
+	}
+	return merged, nil
+}
+
 // ListTeamsParams 团队列表查询参数
 type ListTeamsParams struct {
 	Limit        int    `url:"limit,omitempty"`
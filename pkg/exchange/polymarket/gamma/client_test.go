@@ -0,0 +1,101 @@
+package gamma
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common/replay"
+)
+
+// newReplayClient 创建回放 testdata/replay 的客户端（-tags record 时请求线上 API 并重新录制）
+func newReplayClient(t *testing.T) *Client {
+	t.Helper()
+	c := NewClient(ClientConfig{Transport: replay.New("testdata/replay")})
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestListMarketsReplay(t *testing.T) {
+	c := newReplayClient(t)
+	closed := false
+	markets, err := c.ListMarkets(t.Context(), &common.MarketQueryParams{Limit: 2, Closed: &closed})
+	if err != nil {
+		t.Fatalf("ListMarkets: %v", err)
+	}
+	if len(markets) != 2 {
+		t.Fatalf("markets = %d, want 2", len(markets))
+	}
+	for _, m := range markets {
+		if m.Closed {
+			t.Errorf("%s: closed market returned for closed=false", m.Slug)
+		}
+		if err := m.Validate(); err != nil {
+			t.Errorf("Validate: %v", err)
+		}
+		prices, err := m.ParsedPrices()
+		if err != nil || len(prices) != 2 {
+			t.Errorf("%s: outcome prices = %v, %v", m.Slug, prices, err)
+			continue
+		}
+		if sum := prices[0] + prices[1]; math.Abs(sum-1) > 0.05 {
+			t.Errorf("%s: outcome prices sum to %v", m.Slug, sum)
+		}
+		if len(m.Events) == 0 || m.Events[0].Slug == "" {
+			t.Errorf("%s: missing parent event", m.Slug)
+		}
+	}
+}
+
+func TestGetMarketByConditionID(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var markets []common.Market
+		switch id := r.URL.Query().Get("condition_ids"); id {
+		case "0xabc":
+			markets = []common.Market{{ID: "1", ConditionID: "0xABC"}}
+		case "0xignored":
+			// 服务端忽略过滤条件时返回无关市场
+			markets = []common.Market{{ID: "2", ConditionID: "0xother"}}
+		case "0xdup":
+			markets = []common.Market{{ID: "3", ConditionID: "0xdup"}, {ID: "4", ConditionID: "0xDUP"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(markets)
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(ClientConfig{BaseURL: srv.URL})
+	t.Cleanup(c.Close)
+
+	market, err := c.GetMarketByConditionID(t.Context(), "0xabc")
+	if err != nil || market.ID != "1" {
+		t.Fatalf("GetMarketByConditionID = %+v, %v", market, err)
+	}
+
+	tests := []struct {
+		conditionID string
+		wantErr     string
+	}{
+		{"0xmissing", "no market for condition 0xmissing"},
+		{"0xignored", "no market for condition 0xignored"},
+		{"0xdup", "2 markets for condition 0xdup"},
+	}
+	for _, tt := range tests {
+		market, err := c.GetMarketByConditionID(t.Context(), tt.conditionID)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: market = %+v, err = %v, want %q", tt.conditionID, market, err, tt.wantErr)
+		}
+	}
+
+	if _, err := c.GetMarketByConditionID(t.Context(), ""); err == nil {
+		t.Error("empty condition id should fail")
+	}
+	if requests != 4 {
+		t.Errorf("requests = %d, want 4 (no request for empty condition id)", requests)
+	}
+}
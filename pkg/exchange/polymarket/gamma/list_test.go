@@ -0,0 +1,165 @@
+package gamma
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// listStub 分页列表测试服务：共 total 条数据，按 limit/offset 切片返回，记录每次请求的查询串
+type listStub struct {
+	total int
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *listStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.Path+"?"+r.URL.RawQuery)
+	s.mu.Unlock()
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	end := min(offset+limit, s.total)
+	var ids []string
+	for i := offset; i < end; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/events" {
+		events := make([]common.Event, len(ids))
+		for i, id := range ids {
+			events[i].ID = id
+		}
+		json.NewEncoder(w).Encode(events)
+		return
+	}
+	markets := make([]common.Market, len(ids))
+	for i, id := range ids {
+		markets[i].ID = id
+	}
+	json.NewEncoder(w).Encode(markets)
+}
+
+func (s *listStub) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func newListClient(t *testing.T, stub *listStub) *Client {
+	t.Helper()
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	c := NewClient(ClientConfig{BaseURL: srv.URL})
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestListOrderQuery(t *testing.T) {
+	stub := &listStub{total: 1}
+	c := newListClient(t, stub)
+
+	// 降序时显式发送 ascending=false
+	if _, err := c.ListMarkets(t.Context(), &common.MarketQueryParams{Limit: 5, Order: common.MarketOrderByVolume}); err != nil {
+		t.Fatalf("ListMarkets: %v", err)
+	}
+	if _, err := c.ListEvents(t.Context(), &common.EventQueryParams{
+		MarketQueryParams: common.MarketQueryParams{Limit: 5, Offset: 10, Order: common.MarketOrderByEndDate, Ascending: true},
+		TagSlug:           "crypto",
+	}); err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	// 未设置 Order 时不发送 ascending
+	if _, err := c.ListMarkets(t.Context(), &common.MarketQueryParams{Limit: 5}); err != nil {
+		t.Fatalf("ListMarkets: %v", err)
+	}
+
+	want := []string{
+		"/markets?ascending=false&limit=5&order=volume",
+		"/events?ascending=true&limit=5&offset=10&order=endDate&tag_slug=crypto",
+		"/markets?limit=5",
+	}
+	got := stub.Queries()
+	if len(got) != len(want) {
+		t.Fatalf("queries = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("query[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// 未知排序字段在请求前返回错误
+	if _, err := c.ListMarkets(t.Context(), &common.MarketQueryParams{Order: "volume_num"}); err == nil {
+		t.Error("ListMarkets with unknown order should fail")
+	}
+	if _, err := c.ListEvents(t.Context(), &common.EventQueryParams{MarketQueryParams: common.MarketQueryParams{Order: "hot"}}); err == nil {
+		t.Error("ListEvents with unknown order should fail")
+	}
+	if n := len(stub.Queries()); n != len(want) {
+		t.Errorf("requests = %d, want no request for invalid order", n)
+	}
+}
+
+func TestListAllPaging(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		limit      int
+		offset     int
+		maxResults int
+		wantIDs    int
+		wantPages  int
+	}{
+		{name: "short last page", total: 25, limit: 10, wantIDs: 25, wantPages: 3},
+		{name: "empty last page", total: 20, limit: 10, wantIDs: 20, wantPages: 3},
+		{name: "offset start", total: 25, limit: 10, offset: 20, wantIDs: 5, wantPages: 1},
+		{name: "offset beyond data", total: 5, limit: 10, offset: 50, wantIDs: 0, wantPages: 1},
+		{name: "max results", total: 1000, limit: 10, maxResults: 25, wantIDs: 25, wantPages: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &listStub{total: tt.total}
+			c := newListClient(t, stub)
+			mp := common.MarketQueryParams{Limit: tt.limit, Offset: tt.offset, Order: common.MarketOrderByVolume}
+
+			events, err := c.ListAllEvents(t.Context(), &common.EventQueryParams{MarketQueryParams: mp}, tt.maxResults)
+			if err != nil {
+				t.Fatalf("ListAllEvents: %v", err)
+			}
+			markets, err := c.ListAllMarkets(t.Context(), &mp, tt.maxResults)
+			if err != nil {
+				t.Fatalf("ListAllMarkets: %v", err)
+			}
+			if len(events) != tt.wantIDs || len(markets) != tt.wantIDs {
+				t.Fatalf("events = %d, markets = %d, want %d", len(events), len(markets), tt.wantIDs)
+			}
+			for i := range events {
+				want := strconv.Itoa(tt.offset + i)
+				if events[i].ID != want || markets[i].ID != want {
+					t.Fatalf("item %d = %s/%s, want %s", i, events[i].ID, markets[i].ID, want)
+				}
+			}
+			if n := len(stub.Queries()); n != 2*tt.wantPages {
+				t.Errorf("requests = %d, want %d per lister: %q", n, tt.wantPages, stub.Queries())
+			}
+		})
+	}
+
+	// 调用方的参数不被修改
+	stub := &listStub{total: 25}
+	c := newListClient(t, stub)
+	mp := &common.MarketQueryParams{Limit: 10}
+	if _, err := c.ListAllMarkets(t.Context(), mp, 0); err != nil || mp.Offset != 0 {
+		t.Errorf("params offset = %d, err = %v, want caller params untouched", mp.Offset, err)
+	}
+}
@@ -0,0 +1,110 @@
+package gamma
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// searchStub 分页搜索测试服务：第 1、2 页有数据（含重复项），之后为空页，记录请求的页码
+type searchStub struct {
+	mu    sync.Mutex
+	pages []int
+}
+
+func (s *searchStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	s.mu.Lock()
+	s.pages = append(s.pages, page)
+	s.mu.Unlock()
+
+	var result common.SearchResult
+	switch page {
+	case 1:
+		result.Events = []common.Event{{ID: "e1"}, {ID: "e2"}}
+		result.Markets = []common.Market{{ID: "m1"}}
+		result.Profiles = []common.Profile{{Address: "0xAbC"}}
+	case 2:
+		result.Events = []common.Event{{ID: "e2"}, {ID: "e3"}}
+		result.Markets = []common.Market{{ID: "m1"}, {ID: "m2"}}
+		result.Profiles = []common.Profile{{Address: "0xabc"}, {Address: "0xdef"}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *searchStub) Pages() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.pages...)
+}
+
+func newSearchClient(t *testing.T, stub *searchStub) *Client {
+	t.Helper()
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	c := NewClient(ClientConfig{BaseURL: srv.URL})
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestSearchAllPages(t *testing.T) {
+	stub := &searchStub{}
+	c := newSearchClient(t, stub)
+
+	result, err := c.SearchAllPages(t.Context(), &common.SearchParams{Q: "btc"}, 0)
+	if err != nil {
+		t.Fatalf("SearchAllPages: %v", err)
+	}
+	ids := func(r *common.SearchResult) string {
+		var events, markets, profiles []string
+		for _, e := range r.Events {
+			events = append(events, e.ID)
+		}
+		for _, m := range r.Markets {
+			markets = append(markets, m.ID)
+		}
+		for _, p := range r.Profiles {
+			profiles = append(profiles, p.Address)
+		}
+		return fmt.Sprint(events, markets, profiles)
+	}
+	if got, want := ids(result), "[e1 e2 e3] [m1 m2] [0xAbC 0xdef]"; got != want {
+		t.Errorf("merged = %s, want %s", got, want)
+	}
+	if pages := stub.Pages(); fmt.Sprint(pages) != "[1 2 3]" {
+		t.Errorf("pages = %v, want [1 2 3]", pages)
+	}
+}
+
+func TestSearchAllPagesMaxPages(t *testing.T) {
+	stub := &searchStub{}
+	c := newSearchClient(t, stub)
+
+	result, err := c.SearchAllPages(t.Context(), &common.SearchParams{Q: "btc"}, 1)
+	if err != nil {
+		t.Fatalf("SearchAllPages: %v", err)
+	}
+	if len(result.Events) != 2 || len(stub.Pages()) != 1 {
+		t.Errorf("events = %d, pages = %v", len(result.Events), stub.Pages())
+	}
+
+	// 从指定页开始，不修改调用方的参数
+	params := &common.SearchParams{Q: "btc", Page: 2}
+	if _, err := c.SearchAllPages(t.Context(), params, 0); err != nil {
+		t.Fatalf("SearchAllPages: %v", err)
+	}
+	if pages := stub.Pages(); fmt.Sprint(pages[1:]) != "[2 3]" || params.Page != 2 {
+		t.Errorf("pages = %v, params.Page = %d", pages, params.Page)
+	}
+
+	if _, err := c.SearchAllPages(t.Context(), &common.SearchParams{}, 0); err == nil {
+		t.Error("expected error without q")
+	}
+}
@@ -2,115 +2,476 @@ package polymarket
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/shuail0/prediction-aggregator/pkg/exchange"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/clob"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/data"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/gamma"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/wss"
 )
 
-// Client Polymarket 交易所客户端
+// Config Polymarket 客户端配置
+type Config struct {
+	GammaURL      string             // Gamma API 基础 URL（默认官方地址）
+	ClobURL       string             // CLOB API 基础 URL（默认官方地址）
+	DataURL       string             // Data API 基础 URL（默认官方地址）
+	WssURL        string             // WebSocket 基础 URL（默认官方地址）
+	PrivateKey    string             // 私钥（交易及 CLOB 接口需要）
+	Funder        string             // 资金地址（Safe/Proxy 钱包），为空时使用私钥地址
+	SignatureType clob.SignatureType // 签名类型
+	ApiCreds      *clob.ApiKeyCreds  // CLOB API 凭证，为空时 Connect 自动创建或派生
+	Timeout       time.Duration      // 超时时间
+	ProxyString   string             // 代理设置
+}
+
+// Client Polymarket 交易所客户端，组合 Gamma、CLOB、Data 和 WebSocket 客户端实现 exchange.Exchange
 type Client struct {
+	gamma *gamma.Client
+	data  *data.Client
+	wss   *wss.Client
+
+	mu        sync.Mutex
+	config    Config
+	clob      *clob.Client // 未配置私钥时仅用于公开接口（订单簿等）
 	connected bool
+	conns     []*wss.Connection // 订阅创建的 WebSocket 连接，Disconnect 时关闭
+}
 
-	// TODO: 添加 CLOB Client、Gamma Client 等
+// New 创建 Polymarket 客户端，未配置私钥时仅可使用行情相关接口（含 CLOB 订单簿）
+func New(cfg Config) (*Client, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	c := &Client{
+		config: cfg,
+		gamma: gamma.NewClient(gamma.ClientConfig{
+			BaseURL:     cfg.GammaURL,
+			Timeout:     cfg.Timeout,
+			ProxyString: cfg.ProxyString,
+		}),
+		data: data.NewClient(data.ClientConfig{
+			BaseURL:     cfg.DataURL,
+			Timeout:     cfg.Timeout,
+			ProxyString: cfg.ProxyString,
+		}),
+		wss: wss.NewClient(wss.ClientConfig{
+			BaseURL:     cfg.WssURL,
+			ProxyString: cfg.ProxyString,
+		}),
+	}
+	if err := c.initClob(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// New 创建 Polymarket 客户端
-func New() exchange.Exchange {
-	return &Client{}
+// initClob 按当前配置创建 CLOB 客户端（调用方持有 c.mu 或处于构造阶段）
+func (c *Client) initClob() error {
+	clobClient, err := clob.NewClient(clob.ClientConfig{
+		BaseURL:       c.config.ClobURL,
+		PrivateKey:    c.config.PrivateKey,
+		Funder:        c.config.Funder,
+		SignatureType: c.config.SignatureType,
+		ApiCreds:      c.config.ApiCreds,
+		ProxyString:   c.config.ProxyString,
+		Timeout:       c.config.Timeout,
+	})
+	if err != nil {
+		return fmt.Errorf("create clob client: %w", err)
+	}
+	if c.clob != nil {
+		c.clob.Close()
+	}
+	c.clob = clobClient
+	return nil
+}
+
+// ========== exchange.Exchange 接口实现 ==========
+
+// clobClient 当前 CLOB 客户端（公开接口使用）
+func (c *Client) clobClient() *clob.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clob
+}
+
+// tradingClient 已配置私钥的 CLOB 客户端，未配置时返回错误
+func (c *Client) tradingClient() (*clob.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.config.PrivateKey == "" {
+		return nil, fmt.Errorf("trading not configured (missing private key)")
+	}
+	return c.clob, nil
 }
 
 // Connect 连接到 Polymarket
+// 传入的凭据覆盖配置；有私钥但没有 API 凭证时自动创建或派生 API Key
 func (c *Client) Connect(ctx context.Context, creds exchange.Credentials) error {
-	// TODO: 实现连接逻辑
-	// 1. 初始化 CLOB Client
-	// 2. 初始化 Gamma Client
-	// 3. 初始化 WebSocket Client
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reinit := false
+	if creds.PrivateKey != "" && creds.PrivateKey != c.config.PrivateKey {
+		c.config.PrivateKey = creds.PrivateKey
+		reinit = true
+	}
+	if creds.ProxyAddress != "" && creds.ProxyAddress != c.config.Funder {
+		c.config.Funder = creds.ProxyAddress
+		reinit = true
+	}
+	if creds.APIKey != "" {
+		c.config.ApiCreds = &clob.ApiKeyCreds{ApiKey: creds.APIKey, Secret: creds.APISecret, Passphrase: creds.Passphrase}
+		reinit = true
+	}
+	if reinit {
+		if err := c.initClob(); err != nil {
+			return err
+		}
+	}
+
+	if c.config.PrivateKey != "" && c.config.ApiCreds == nil {
+		apiCreds, err := c.clob.CreateOrDeriveApiKey(ctx)
+		if err != nil {
+			return fmt.Errorf("create or derive api key: %w", err)
+		}
+		c.config.ApiCreds = apiCreds
+		c.clob.SetApiCreds(apiCreds)
+	}
+
 	c.connected = true
 	return nil
 }
 
-// Disconnect 断开连接
+// Disconnect 断开连接，关闭所有订阅的 WebSocket 连接
 func (c *Client) Disconnect() error {
+	c.mu.Lock()
+	conns := c.conns
+	c.conns = nil
 	c.connected = false
+	c.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
 	return nil
 }
 
 // IsConnected 检查连接状态
 func (c *Client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.connected
 }
 
-// GetMarket 获取市场信息
+// GetMarket 获取市场信息，id 可以是 Gamma 市场 ID 或 conditionId (0x...)
 func (c *Client) GetMarket(ctx context.Context, id string) (*exchange.Market, error) {
-	// TODO: 调用 Gamma API
-	return nil, nil
+	var market *common.Market
+	var err error
+	if strings.HasPrefix(id, "0x") {
+		market, err = c.gamma.GetMarketByConditionID(ctx, id)
+	} else {
+		market, err = c.gamma.GetMarketByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convertMarket(market), nil
 }
 
-// ListMarkets 列出市场
+// ListMarkets 列出市场，filter.Query 非空时改为搜索
 func (c *Client) ListMarkets(ctx context.Context, filter exchange.MarketFilter) ([]*exchange.Market, error) {
-	// TODO: 调用 Gamma API
-	return nil, nil
+	if filter.Query != "" {
+		return c.SearchMarkets(ctx, filter.Query)
+	}
+
+	params := &common.MarketQueryParams{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if params.Limit == 0 {
+		params.Limit = 20
+	}
+	if filter.Active != nil {
+		active := *filter.Active
+		closed := !active
+		params.Active = &active
+		params.Closed = &closed
+	}
+
+	markets, err := c.gamma.ListMarkets(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return convertMarkets(markets), nil
 }
 
-// SearchMarkets 搜索市场
+// SearchMarkets 搜索市场（包含搜索结果中事件下的市场，按 ID 去重）
 func (c *Client) SearchMarkets(ctx context.Context, query string) ([]*exchange.Market, error) {
-	// TODO: 调用 Gamma API
-	return nil, nil
+	result, err := c.gamma.SearchMarketsEventsAndProfiles(ctx, &common.SearchParams{Q: query})
+	if err != nil {
+		return nil, err
+	}
+
+	markets := append([]common.Market(nil), result.Markets...)
+	for _, event := range result.Events {
+		markets = append(markets, event.Markets...)
+	}
+
+	seen := make(map[string]bool, len(markets))
+	unique := markets[:0]
+	for _, m := range markets {
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		unique = append(unique, m)
+	}
+	return convertMarkets(unique), nil
 }
 
-// SubscribeMarkets 订阅市场更新
+// SubscribeMarkets 订阅市场更新，ids 为 Gamma 市场 ID 或 conditionId
+// 每次订单簿快照或变化时推送对应市场及该结果的最新订单簿，ctx 取消后关闭连接和 Channel
 func (c *Client) SubscribeMarkets(ctx context.Context, ids []string) (<-chan exchange.MarketUpdate, error) {
-	// TODO: 实现 WebSocket 订阅
-	ch := make(chan exchange.MarketUpdate)
+	tokenMarket := make(map[string]*exchange.Market)
+	var tokenIDs []string
+	for _, id := range ids {
+		market, err := c.GetMarket(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("get market [%s]: %w", id, err)
+		}
+		for _, o := range market.Outcomes {
+			tokenMarket[o.ID] = market
+			tokenIDs = append(tokenIDs, o.ID)
+		}
+	}
+
+	ch := make(chan exchange.MarketUpdate, 100)
+	err := c.streamBooks(ctx, tokenIDs, func(book *exchange.OrderBook) {
+		select {
+		case ch <- exchange.MarketUpdate{Platform: "polymarket", Market: tokenMarket[book.OutcomeID], Book: book}:
+		default:
+		}
+	}, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
 	return ch, nil
 }
 
 // GetOrderBook 获取订单簿
 func (c *Client) GetOrderBook(ctx context.Context, outcomeID string) (*exchange.OrderBook, error) {
-	// TODO: 调用 CLOB API
-	return nil, nil
+	book, err := c.clobClient().GetOrderBook(ctx, outcomeID)
+	if err != nil {
+		return nil, err
+	}
+	return convertOrderBook(book), nil
 }
 
-// SubscribeOrderBook 订阅订单簿
+// SubscribeOrderBook 订阅订单簿，每次快照或变化时推送完整的本地订单簿，ctx 取消后关闭连接和 Channel
 func (c *Client) SubscribeOrderBook(ctx context.Context, outcomeID string) (<-chan *exchange.OrderBook, error) {
-	// TODO: 实现 WebSocket 订阅
-	ch := make(chan *exchange.OrderBook)
+	ch := make(chan *exchange.OrderBook, 100)
+	err := c.streamBooks(ctx, []string{outcomeID}, func(book *exchange.OrderBook) {
+		select {
+		case ch <- book:
+		default:
+		}
+	}, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
 	return ch, nil
 }
 
-// CreateOrder 创建订单
+// streamBooks 订阅 tokenIDs 并维护本地订单簿，每次更新调用 emit；ctx 取消或连接关闭后调用 done
+func (c *Client) streamBooks(ctx context.Context, tokenIDs []string, emit func(*exchange.OrderBook), done func()) error {
+	if len(tokenIDs) == 0 {
+		return fmt.Errorf("no outcomes to subscribe")
+	}
+	conn := c.wss.CreateMarketConnection(tokenIDs)
+	books := make(map[string]*wss.LocalBook, len(tokenIDs))
+	for _, id := range tokenIDs {
+		books[id] = conn.TrackBook(id)
+	}
+	if err := conn.Connect(); err != nil {
+		conn.Close()
+		return fmt.Errorf("connect websocket: %w", err)
+	}
+
+	c.mu.Lock()
+	c.conns = append(c.conns, conn)
+	c.mu.Unlock()
+
+	go func() {
+		defer done()
+		defer c.removeConn(conn)
+		for {
+			var assetID string
+			select {
+			case <-ctx.Done():
+				return
+			case snapshot, ok := <-conn.BookCh():
+				if !ok {
+					return
+				}
+				assetID = snapshot.AssetID
+			case event, ok := <-conn.PriceChangeCh():
+				if !ok {
+					return
+				}
+				assetID = event.AssetID
+			}
+			if book := books[assetID]; book != nil {
+				if ob := localBookToOrderBook(book); ob != nil {
+					emit(ob)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// removeConn 关闭连接并从 c.conns 中移除，避免订阅结束后连接残留到 Disconnect
+func (c *Client) removeConn(conn *wss.Connection) {
+	c.mu.Lock()
+	for i, cc := range c.conns {
+		if cc == conn {
+			c.conns = append(c.conns[:i], c.conns[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+	conn.Close()
+}
+
+// CreateOrder 创建 GTC 限价单（tick size 和 negRisk 从 CLOB 查询）
 func (c *Client) CreateOrder(ctx context.Context, req exchange.CreateOrderRequest) (*exchange.Order, error) {
-	// TODO: 调用 CLOB API
-	return nil, nil
+	clobClient, err := c.tradingClient()
+	if err != nil {
+		return nil, err
+	}
+
+	side := clob.SideBuy
+	if req.Side == exchange.SideSell {
+		side = clob.SideSell
+	}
+	resp, err := clobClient.CreateAndPostOrder(ctx, clob.UserOrder{
+		TokenID: req.OutcomeID,
+		Price:   req.Price,
+		Size:    req.Size,
+		Side:    side,
+	}, clob.CreateOrderOptions{}, clob.OrderTypeGTC)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("order rejected: %s", resp.ErrorMsg)
+	}
+
+	now := time.Now()
+	return &exchange.Order{
+		ID:        resp.OrderID,
+		OutcomeID: req.OutcomeID,
+		Side:      req.Side,
+		Price:     req.Price,
+		Size:      req.Size,
+		Status:    convertOrderStatus(strings.ToUpper(resp.Status)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
 }
 
 // CancelOrder 取消订单
 func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
-	// TODO: 调用 CLOB API
-	return nil
+	clobClient, err := c.tradingClient()
+	if err != nil {
+		return err
+	}
+	_, err = clobClient.CancelOrder(ctx, orderID)
+	return err
 }
 
 // GetOrder 查询订单
 func (c *Client) GetOrder(ctx context.Context, orderID string) (*exchange.Order, error) {
-	// TODO: 调用 CLOB API
-	return nil, nil
+	clobClient, err := c.tradingClient()
+	if err != nil {
+		return nil, err
+	}
+	order, err := clobClient.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return convertOrder(order), nil
 }
 
-// ListOrders 列出订单
+// ListOrders 列出未结订单，outcomeID 为空时返回全部
 func (c *Client) ListOrders(ctx context.Context, outcomeID string) ([]*exchange.Order, error) {
-	// TODO: 调用 CLOB API
-	return nil, nil
+	clobClient, err := c.tradingClient()
+	if err != nil {
+		return nil, err
+	}
+	orders, err := clobClient.GetOpenOrders(ctx, clob.OpenOrderParams{AssetID: outcomeID})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*exchange.Order, len(orders))
+	for i := range orders {
+		result[i] = convertOrder(&orders[i])
+	}
+	return result, nil
 }
 
-// GetBalance 获取余额
+// GetBalance 获取 USDC 余额
 func (c *Client) GetBalance(ctx context.Context) (float64, error) {
-	// TODO: 调用链上合约
-	return 0, nil
+	clobClient, err := c.tradingClient()
+	if err != nil {
+		return 0, err
+	}
+	resp, err := clobClient.GetBalanceAllowance(ctx, clob.BalanceAllowanceParams{AssetType: clob.AssetTypeCollateral})
+	if err != nil {
+		return 0, err
+	}
+	balance, err := strconv.ParseFloat(resp.Balance, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse balance %q: %w", resp.Balance, err)
+	}
+	return balance / math.Pow10(common.USDCDecimals), nil
 }
 
-// GetPositions 获取持仓
+// GetPositions 获取资金地址的持仓
 func (c *Client) GetPositions(ctx context.Context) ([]exchange.Position, error) {
-	// TODO: 调用 Data API
-	return nil, nil
+	c.mu.Lock()
+	user := c.config.Funder
+	if user == "" {
+		user = c.clob.GetAddress()
+	}
+	c.mu.Unlock()
+	if user == "" {
+		return nil, fmt.Errorf("trading not configured")
+	}
+
+	positions, err := c.data.GetPositions(ctx, &common.PositionQueryParams{User: user, Limit: 500})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Position, len(positions))
+	for i, p := range positions {
+		result[i] = exchange.Position{
+			OutcomeID: p.Asset,
+			Size:      p.Size,
+			AvgPrice:  p.AveragePrice,
+			Value:     p.CurrentValue,
+		}
+	}
+	return result, nil
 }
 
 // Name 交易所名称
@@ -122,3 +483,129 @@ func (c *Client) Name() string {
 func (c *Client) SupportedChains() []string {
 	return []string{"polygon"}
 }
+
+// ========== 扩展方法 ==========
+
+// Gamma 获取 Gamma 客户端
+func (c *Client) Gamma() *gamma.Client {
+	return c.gamma
+}
+
+// CLOB 获取 CLOB 客户端（未配置私钥时仅可调用公开接口）
+func (c *Client) CLOB() *clob.Client {
+	return c.clobClient()
+}
+
+// Data 获取 Data 客户端
+func (c *Client) Data() *data.Client {
+	return c.data
+}
+
+// WSS 获取 WebSocket 客户端
+func (c *Client) WSS() *wss.Client {
+	return c.wss
+}
+
+// ========== 转换函数 ==========
+
+func convertMarkets(markets []common.Market) []*exchange.Market {
+	result := make([]*exchange.Market, len(markets))
+	for i := range markets {
+		result[i] = convertMarket(&markets[i])
+	}
+	return result
+}
+
+func convertMarket(m *common.Market) *exchange.Market {
+	var outcomes []exchange.Outcome
+	if tokens, err := m.OutcomeTokens(); err == nil {
+		outcomes = make([]exchange.Outcome, len(tokens))
+		for i, t := range tokens {
+			outcomes[i] = exchange.Outcome{ID: t.TokenID, Name: t.Outcome, Price: t.Price}
+		}
+	}
+	endTime, _ := common.ParseDate(m.EndDate)
+
+	return &exchange.Market{
+		ID:        m.ID,
+		Platform:  "polymarket",
+		Question:  m.Question,
+		Outcomes:  outcomes,
+		EndTime:   endTime,
+		Volume:    m.Volume.Float64(),
+		Liquidity: m.Liquidity.Float64(),
+		Active:    m.Active && !m.Closed,
+	}
+}
+
+func convertOrderBook(book *clob.OrderBookSummary) *exchange.OrderBook {
+	ts, _ := strconv.ParseInt(book.Timestamp, 10, 64)
+	return &exchange.OrderBook{
+		OutcomeID: book.AssetID,
+		Bids:      convertOrderLevels(book.Bids),
+		Asks:      convertOrderLevels(book.Asks),
+		Timestamp: time.UnixMilli(ts),
+	}
+}
+
+func convertOrderLevels(levels []clob.OrderSummary) []exchange.OrderLevel {
+	result := make([]exchange.OrderLevel, len(levels))
+	for i, l := range levels {
+		result[i] = exchange.OrderLevel{Price: l.Price, Size: l.Size}
+	}
+	return result
+}
+
+// localBookToOrderBook 本地订单簿转换为 exchange.OrderBook（买卖盘均按最优价在前），未收到快照时返回 nil
+func localBookToOrderBook(book *wss.LocalBook) *exchange.OrderBook {
+	snapshot := book.Snapshot()
+	if snapshot == nil {
+		return nil
+	}
+	levels := func(in []common.OrderBookLevel) []exchange.OrderLevel {
+		out := make([]exchange.OrderLevel, len(in))
+		for i, l := range in {
+			out[i] = exchange.OrderLevel{Price: l.Price, Size: l.Size}
+		}
+		return out
+	}
+	return &exchange.OrderBook{
+		OutcomeID: book.AssetID(),
+		Bids:      levels(snapshot.Bids),
+		Asks:      levels(snapshot.Asks),
+		Timestamp: book.UpdatedAt(),
+	}
+}
+
+func convertOrder(o *clob.OpenOrder) *exchange.Order {
+	side := exchange.SideBuy
+	if strings.EqualFold(o.Side, string(clob.SideSell)) {
+		side = exchange.SideSell
+	}
+	price, _ := strconv.ParseFloat(o.Price, 64)
+	size, _ := strconv.ParseFloat(o.OriginalSize, 64)
+	filled, _ := strconv.ParseFloat(o.SizeMatched, 64)
+
+	return &exchange.Order{
+		ID:        o.ID,
+		OutcomeID: o.AssetID,
+		Side:      side,
+		Price:     price,
+		Size:      size,
+		Filled:    filled,
+		Status:    convertOrderStatus(o.Status),
+		CreatedAt: time.Unix(o.CreatedAt, 0),
+	}
+}
+
+func convertOrderStatus(status string) exchange.OrderStatus {
+	switch status {
+	case clob.OrderStatusLive:
+		return exchange.StatusOpen
+	case clob.OrderStatusMatched:
+		return exchange.StatusFilled
+	case clob.OrderStatusCanceled:
+		return exchange.StatusCancelled
+	}
+	return exchange.StatusPending
+}
@@ -0,0 +1,146 @@
+package polymarket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/clob"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestRegistryPublicMarketData(t *testing.T) {
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /markets", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("limit"); got != "5" {
+			t.Errorf("limit = %q, want 5", got)
+		}
+		writeJSON(w, []common.Market{{
+			ID:            "1",
+			Question:      "Will it rain?",
+			Active:        true,
+			Outcomes:      `["Yes","No"]`,
+			OutcomePrices: `["0.4","0.6"]`,
+			ClobTokenIds:  `["111","222"]`,
+		}})
+	})
+	mux.HandleFunc("GET /book", func(w http.ResponseWriter, r *http.Request) {
+		// 公开接口不应携带认证头
+		if r.Header.Get("POLY_API_KEY") != "" {
+			t.Error("public order book request carries auth headers")
+		}
+		writeJSON(w, clob.OrderBookSummary{
+			AssetID:   r.URL.Query().Get("token_id"),
+			Timestamp: "1700000000000",
+			Bids:      []clob.OrderSummary{{Price: "0.39", Size: "100"}},
+			Asks:      []clob.OrderSummary{{Price: "0.41", Size: "50"}},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("POLYMARKET_GAMMA_URL", srv.URL)
+	t.Setenv("POLYMARKET_CLOB_URL", srv.URL)
+	ex, err := exchange.New("polymarket")
+	if err != nil {
+		t.Fatalf("exchange.New: %v", err)
+	}
+
+	markets, err := ex.ListMarkets(t.Context(), exchange.MarketFilter{Limit: 5})
+	if err != nil {
+		t.Fatalf("ListMarkets: %v", err)
+	}
+	if len(markets) != 1 {
+		t.Fatalf("markets = %d, want 1", len(markets))
+	}
+	m := markets[0]
+	if m.Platform != "polymarket" || m.Question != "Will it rain?" || !m.Active {
+		t.Errorf("market = %+v", m)
+	}
+	if len(m.Outcomes) != 2 || m.Outcomes[0].ID != "111" || m.Outcomes[1].Name != "No" || m.Outcomes[1].Price != 0.6 {
+		t.Errorf("outcomes = %+v", m.Outcomes)
+	}
+
+	book, err := ex.GetOrderBook(t.Context(), "111")
+	if err != nil {
+		t.Fatalf("GetOrderBook without private key: %v", err)
+	}
+	if book.OutcomeID != "111" || len(book.Bids) != 1 || book.Bids[0].Price != "0.39" || book.Asks[0].Size != "50" {
+		t.Errorf("book = %+v", book)
+	}
+
+	// 交易接口仍需私钥
+	if _, err := ex.ListOrders(t.Context(), ""); err == nil {
+		t.Error("ListOrders without private key should fail")
+	}
+}
+
+func TestConvertOrderLeavesUpdatedAtZero(t *testing.T) {
+	order := convertOrder(&clob.OpenOrder{ID: "o1", Side: "SELL", Price: "0.5", OriginalSize: "10", SizeMatched: "4", CreatedAt: 1700000000})
+	if order.Side != exchange.SideSell || order.Filled != 4 || order.CreatedAt.Unix() != 1700000000 {
+		t.Errorf("order = %+v", order)
+	}
+	if !order.UpdatedAt.IsZero() {
+		t.Errorf("UpdatedAt = %v, want zero (OpenOrder carries no update time)", order.UpdatedAt)
+	}
+}
+
+func TestSubscribeOrderBookReleasesConnection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(Config{WssURL: "ws" + strings.TrimPrefix(ts.URL, "http")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	ch, err := c.SubscribeOrderBook(ctx, "111")
+	if err != nil {
+		t.Fatalf("SubscribeOrderBook: %v", err)
+	}
+	c.mu.Lock()
+	n := len(c.conns)
+	c.mu.Unlock()
+	if n != 1 {
+		t.Fatalf("conns = %d, want 1", n)
+	}
+
+	// ctx 取消后连接从客户端移除，不再留到 Disconnect
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("unexpected book after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+	c.mu.Lock()
+	n = len(c.conns)
+	c.mu.Unlock()
+	if n != 0 {
+		t.Errorf("conns after cancel = %d, want 0", n)
+	}
+}
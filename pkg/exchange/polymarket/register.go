@@ -0,0 +1,25 @@
+package polymarket
+
+import (
+	"os"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange"
+)
+
+func init() {
+	exchange.Register("polymarket", func() (exchange.Exchange, error) {
+		return New(ConfigFromEnv())
+	})
+}
+
+// ConfigFromEnv 从环境变量读取接口地址和代理（exchange.New 使用），未设置时使用官方地址；
+// 私钥等凭据不从环境变量读取，需通过 Connect 传入
+func ConfigFromEnv() Config {
+	return Config{
+		GammaURL:    os.Getenv("POLYMARKET_GAMMA_URL"),
+		ClobURL:     os.Getenv("POLYMARKET_CLOB_URL"),
+		DataURL:     os.Getenv("POLYMARKET_DATA_URL"),
+		WssURL:      os.Getenv("POLYMARKET_WSS_URL"),
+		ProxyString: os.Getenv("POLYMARKET_PROXY_STRING"),
+	}
+}
@@ -8,18 +8,23 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/data"
 )
 
 // TxType 钱包类型
@@ -42,6 +47,11 @@ const (
 	StateInvalid   TransactionState = "STATE_INVALID"   // 交易无效
 )
 
+// IsFinal 是否为终态（确认、失败或无效）
+func (s TransactionState) IsFinal() bool {
+	return s == StateConfirmed || s == StateFailed || s == StateInvalid
+}
+
 // Config Relayer 配置
 type Config struct {
 	PrivateKey        string
@@ -52,6 +62,8 @@ type Config struct {
 	BuilderSecret     string // Builder Secret (用于 HMAC 签名)
 	BuilderPassphrase string // Builder Passphrase
 	WalletType        TxType // 钱包类型 (SAFE 或 PROXY)
+	// TransactionPollInterval WaitForTransaction 轮询间隔，默认 ReceiptPollInterval
+	TransactionPollInterval time.Duration
 }
 
 // Client 免 Gas 代币操作客户端
@@ -64,6 +76,9 @@ type Client struct {
 	chainID      *big.Int
 	walletType   TxType
 	config       Config
+	closeOnce    sync.Once
+	slotCounts   sync.Map // conditionID(小写) -> outcome slot count
+	nonces       nonceManager
 }
 
 // OperationType Safe 交易操作类型
@@ -123,6 +138,8 @@ type Response struct {
 	Metadata        string `json:"metadata"`
 	CreatedAt       string `json:"createdAt"`
 	UpdatedAt       string `json:"updatedAt"`
+	// FailReason 失败原因（兼容 errorMsg/error/reason 字段），仅在失败状态下可能非空
+	FailReason string `json:"-"`
 }
 
 // DeployedResponse 部署状态响应
@@ -160,6 +177,9 @@ const (
 	DefaultBuilderPassphrase = "dcf31dda1700763e22ffb2fb858abd6c1ebb3d7aac1e87c381dfbd576950e3d2"
 )
 
+// ReceiptPollInterval WaitForReceipt 轮询交易回执的间隔
+const ReceiptPollInterval = 2 * time.Second
+
 // NewClient 创建 Relayer 操作实例
 func NewClient(cfg Config) (*Client, error) {
 	if cfg.RPCURL == "" {
@@ -171,6 +191,9 @@ func NewClient(cfg Config) (*Client, error) {
 	if cfg.WalletType == "" {
 		cfg.WalletType = TxTypeSafe // 默认使用 Safe 钱包
 	}
+	if cfg.TransactionPollInterval <= 0 {
+		cfg.TransactionPollInterval = ReceiptPollInterval
+	}
 
 	// 使用默认 Builder 凭证
 	if cfg.BuilderAPIKey == "" {
@@ -234,16 +257,26 @@ func NewClient(cfg Config) (*Client, error) {
 	}, nil
 }
 
-// calculateProxyAddress 计算 Proxy 钱包地址
+// Close 关闭 RPC 连接并释放空闲 HTTP 连接，客户端不再使用时调用，可重复调用
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		c.ethClient.Close()
+		c.httpClient.Close()
+	})
+}
+
+// calculateProxyAddress 使用 CREATE2 计算 Proxy 钱包地址
+// 与 Safe 不同，salt 为 keccak256(encodePacked(owner))，即 20 字节地址不补齐
 func calculateProxyAddress(owner ethcommon.Address) ethcommon.Address {
 	factory := ethcommon.HexToAddress(common.ContractProxyWalletFactory)
-	salt := crypto.Keccak256Hash(ethcommon.LeftPadBytes(owner.Bytes(), 32))
+	initCodeHash := ethcommon.HexToHash(common.ProxyInitCodeHash)
+
+	salt := crypto.Keccak256Hash(owner.Bytes())
 
 	data := make([]byte, 0, 1+20+32+32)
 	data = append(data, 0xff)
 	data = append(data, factory.Bytes()...)
 	data = append(data, salt.Bytes()...)
-	initCodeHash := ethcommon.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000")
 	data = append(data, initCodeHash.Bytes()...)
 
 	hash := crypto.Keccak256(data)
@@ -337,14 +370,19 @@ func (c *Client) setBuilderHeaders(req *http.Request, method, path string, body
 	req.Header.Set("POLY_BUILDER_SIGNATURE", signature)
 }
 
-// Deploy 部署代理钱包 (Safe 或 Proxy)
+// Deploy 部署 Safe 钱包
+// PROXY 钱包由 ProxyWalletFactory 在首次交易时自动部署，调用返回 ErrProxyAutoDeploy
 func (c *Client) Deploy(ctx context.Context) (*common.TransactionResult, error) {
+	if c.walletType == TxTypeProxy {
+		return nil, ErrProxyAutoDeploy
+	}
+
 	deployed, err := c.isDeployed(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("check deployed: %w", err)
 	}
 	if deployed {
-		return nil, fmt.Errorf("Safe already deployed")
+		return nil, ErrAlreadyDeployed
 	}
 
 	signature, err := c.signSafeCreate()
@@ -371,22 +409,11 @@ func (c *Client) Deploy(ctx context.Context) (*common.TransactionResult, error)
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	respBody, err := c.postWithAuth(ctx, "/submit", bodyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("submit: %w", err)
-	}
-
-	var resp Response
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("unmarshal: %w", err)
+	result, err := c.submit(ctx, bodyBytes)
+	if result != nil {
+		result.ProxyAddress = c.proxyAddress.Hex()
 	}
-
-	return &common.TransactionResult{
-		Hash:          resp.TransactionHash,
-		TransactionID: resp.TransactionID,
-		ProxyAddress:  c.proxyAddress.Hex(),
-		State:         resp.State,
-	}, nil
+	return result, err
 }
 
 // signSafeCreate 签名 Safe 创建请求 (EIP-712)
@@ -434,20 +461,8 @@ func (c *Client) signSafeCreate() (string, error) {
 		return "", fmt.Errorf("sign: %w", err)
 	}
 
-	v := sig[64]
-	switch v {
-	case 0, 1:
-		v += 31
-	case 27, 28:
-		v += 4
-	}
-
-	r_bytes := sig[0:32]
-	s_bytes := sig[32:64]
-	packed := append(r_bytes, s_bytes...)
-	packed = append(packed, v)
-
-	return "0x" + hex.EncodeToString(packed), nil
+	sig = common.NormalizeSignatureV(sig, common.VSchemeSafeEthSign)
+	return "0x" + hex.EncodeToString(sig), nil
 }
 
 // DeploySafe 部署 Safe 钱包 (兼容旧接口)
@@ -479,7 +494,7 @@ func (c *Client) getWithAuth(ctx context.Context, path string) ([]byte, error) {
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		return nil, parseError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
@@ -510,7 +525,7 @@ func (c *Client) postWithAuth(ctx context.Context, path string, body []byte) ([]
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		return nil, parseError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
@@ -537,6 +552,45 @@ func (c *Client) getNonce(ctx context.Context) (int64, error) {
 	return nonce, nil
 }
 
+// nonceManager 本地跟踪 Safe nonce，避免连续提交时 Relayer 返回尚未更新的 nonce
+// 同一客户端的提交由 mu 串行化；提交成功后本地乐观递增，失败后丢弃本地值，下次以服务端为准
+type nonceManager struct {
+	mu     sync.Mutex // 串行化同一账户的交易提交
+	next   int64      // 下一个可用 nonce
+	synced bool       // next 是否有效
+}
+
+// reserve 获取本次提交使用的 nonce：取服务端 nonce 与本地记录的较大值
+// 服务端落后（上一笔仍在处理）时使用本地值；其他进程已提交过交易时使用服务端值。调用方需持有 mu
+func (m *nonceManager) reserve(ctx context.Context, fetch func(ctx context.Context) (int64, error)) (int64, error) {
+	server, err := fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if m.synced && m.next > server {
+		return m.next, nil
+	}
+	return server, nil
+}
+
+// commit 记录提交结果：成功时下一个 nonce 为 nonce+1，失败时丢弃本地记录以便重新同步。调用方需持有 mu
+func (m *nonceManager) commit(nonce int64, err error) {
+	if err != nil {
+		m.synced = false
+		return
+	}
+	m.next = nonce + 1
+	m.synced = true
+}
+
+// ResyncNonce 丢弃本地记录的 Safe nonce，下一笔交易以 Relayer 返回的 nonce 为准
+// 在其他客户端用同一账户提交过交易、或怀疑本地 nonce 已失效时调用
+func (c *Client) ResyncNonce() {
+	c.nonces.mu.Lock()
+	defer c.nonces.mu.Unlock()
+	c.nonces.synced = false
+}
+
 // isDeployed 检查 Safe 是否已部署 (通过 API)
 func (c *Client) isDeployed(ctx context.Context) (bool, error) {
 	path := fmt.Sprintf("/deployed?address=%s", c.proxyAddress.Hex())
@@ -554,9 +608,10 @@ func (c *Client) isDeployed(ctx context.Context) (bool, error) {
 }
 
 // signSafeTransaction 签名 Safe 交易 (EIP-712)
-func (c *Client) signSafeTransaction(to, data string, nonce int64, operation OperationType) (string, error) {
+func (c *Client) signSafeTransaction(to, data string, nonce int64, operation OperationType, gas GasParams) (string, error) {
 	domainSeparator := createDomainSeparator(c.chainID.Int64(), c.proxyAddress)
-	txHash := createSafeTxHash(to, "0", data, uint8(operation), "0", "0", "0", ethcommon.Address{}, ethcommon.Address{}, nonce)
+	txHash := createSafeTxHash(to, "0", data, uint8(operation), gas.SafeTxGas, gas.BaseGas, gas.GasPrice,
+		ethcommon.HexToAddress(gas.GasToken), ethcommon.HexToAddress(gas.RefundReceiver), nonce)
 
 	eip712Hash := crypto.Keccak256(
 		[]byte("\x19\x01"),
@@ -575,20 +630,8 @@ func (c *Client) signSafeTransaction(to, data string, nonce int64, operation Ope
 		return "", fmt.Errorf("sign: %w", err)
 	}
 
-	v := sig[64]
-	switch v {
-	case 0, 1:
-		v += 31
-	case 27, 28:
-		v += 4
-	}
-
-	r_bytes := sig[0:32]
-	s_bytes := sig[32:64]
-	packed := append(r_bytes, s_bytes...)
-	packed = append(packed, v)
-
-	return "0x" + hex.EncodeToString(packed), nil
+	sig = common.NormalizeSignatureV(sig, common.VSchemeSafeEthSign)
+	return "0x" + hex.EncodeToString(sig), nil
 }
 
 // createDomainSeparator 创建 EIP-712 Domain Separator
@@ -686,8 +729,7 @@ func (c *Client) callBalanceOf(ctx context.Context, token string, account ethcom
 }
 
 // ApproveUSDCForCTF 授权 USDC 给 CTF 合约
-func (c *Client) ApproveUSDCForCTF(ctx context.Context) (*common.TransactionResult, error) {
-	maxUint256 := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+func (c *Client) ApproveUSDCForCTF(ctx context.Context, opts ...ExecuteOption) (*common.TransactionResult, error) {
 	data := encodeERC20Approve(common.ContractCTF, maxUint256)
 
 	return c.execute(ctx, []SafeTransaction{{
@@ -695,54 +737,109 @@ func (c *Client) ApproveUSDCForCTF(ctx context.Context) (*common.TransactionResu
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "approveUSDCForCTF")
+	}}, "approveUSDCForCTF", opts...)
 }
 
 // ApproveAllTokens 一次性授权所有代币
-func (c *Client) ApproveAllTokens(ctx context.Context) (*common.TransactionResult, error) {
-	maxUint256 := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+func (c *Client) ApproveAllTokens(ctx context.Context, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	approvals := RequiredApprovals()
+	txns := make([]SafeTransaction, 0, len(approvals))
+	for _, a := range approvals {
+		txns = append(txns, a.transaction())
+	}
+	return c.execute(ctx, txns, "approveAllTokens", opts...)
+}
+
+// Approval 交易所需的单项授权
+type Approval struct {
+	Name    string // 如 "USDC->CTFExchange"
+	Token   string // 授权代币合约（USDC 或 CTF）
+	Spender string
+	ERC1155 bool // true 为 CTF setApprovalForAll，false 为 USDC approve(max)
+}
 
-	usdcSpenders := []string{
-		common.ContractCTF,
-		common.ContractCTFExchange,
-		common.ContractNegRiskAdapter,
-		common.ContractNegRiskCTFExchange,
+// transaction 构造授权交易
+func (a Approval) transaction() SafeTransaction {
+	data := encodeERC20Approve(a.Spender, maxUint256)
+	if a.ERC1155 {
+		data = encodeERC1155SetApprovalForAll(a.Spender, true)
 	}
+	return SafeTransaction{To: a.Token, Value: "0", Data: data, Operation: OperationTypeCall}
+}
+
+// maxUint256 无限授权额度
+const maxUint256 = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+// RequiredApprovals 交易所需的全部授权（USDC 授权给 4 个合约，CTF 授权给 3 个合约）
+func RequiredApprovals() []Approval {
+	return []Approval{
+		{Name: "USDC->CTF", Token: common.ContractUSDC, Spender: common.ContractCTF},
+		{Name: "USDC->CTFExchange", Token: common.ContractUSDC, Spender: common.ContractCTFExchange},
+		{Name: "USDC->NegRiskAdapter", Token: common.ContractUSDC, Spender: common.ContractNegRiskAdapter},
+		{Name: "USDC->NegRiskCTFExchange", Token: common.ContractUSDC, Spender: common.ContractNegRiskCTFExchange},
+		{Name: "CTF->CTFExchange", Token: common.ContractCTF, Spender: common.ContractCTFExchange, ERC1155: true},
+		{Name: "CTF->NegRiskAdapter", Token: common.ContractCTF, Spender: common.ContractNegRiskAdapter, ERC1155: true},
+		{Name: "CTF->NegRiskCTFExchange", Token: common.ContractCTF, Spender: common.ContractNegRiskCTFExchange, ERC1155: true},
+	}
+}
 
-	ctfSpenders := []string{
-		common.ContractCTFExchange,
-		common.ContractNegRiskAdapter,
-		common.ContractNegRiskCTFExchange,
+// MissingApprovals 根据账户状态筛选尚未完成的授权
+// USDC 授权额度低于 2^255 视为未授权（无限授权不会随使用递减到该值以下）
+func MissingApprovals(status *common.AccountStatus) []Approval {
+	done := map[string]bool{
+		"USDC->CTF":                isMaxAllowance(status.USDCAllowanceCTF),
+		"USDC->CTFExchange":        isMaxAllowance(status.USDCAllowanceExchange),
+		"USDC->NegRiskAdapter":     isMaxAllowance(status.USDCAllowanceNegRisk),
+		"USDC->NegRiskCTFExchange": isMaxAllowance(status.USDCAllowanceNegRiskExchange),
+		"CTF->CTFExchange":         status.CTFApprovedExchange,
+		"CTF->NegRiskAdapter":      status.CTFApprovedNegRisk,
+		"CTF->NegRiskCTFExchange":  status.CTFApprovedNegRiskExchange,
+	}
+	var missing []Approval
+	for _, a := range RequiredApprovals() {
+		if !done[a.Name] {
+			missing = append(missing, a)
+		}
 	}
+	return missing
+}
 
-	var txns []SafeTransaction
+// isMaxAllowance 授权额度是否接近无限（>= 2^255），无法解析视为未授权
+func isMaxAllowance(allowance string) bool {
+	v, ok := new(big.Int).SetString(allowance, 10)
+	return ok && v.BitLen() >= 256
+}
 
-	for _, spender := range usdcSpenders {
-		data := encodeERC20Approve(spender, maxUint256)
-		txns = append(txns, SafeTransaction{
-			To:        common.ContractUSDC,
-			Value:     "0",
-			Data:      data,
-			Operation: OperationTypeCall,
-		})
+// EnsureApprovals 仅提交缺失的授权（通过 GetAccountStatus 检查），全部已授权时不提交交易
+// 返回实际执行的授权和交易结果（无需提交时 result 为 nil）
+func (c *Client) EnsureApprovals(ctx context.Context, opts ...ExecuteOption) ([]Approval, *common.TransactionResult, error) {
+	status, err := c.GetAccountStatus(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get account status: %w", err)
 	}
 
-	for _, spender := range ctfSpenders {
-		data := encodeERC1155SetApprovalForAll(spender, true)
-		txns = append(txns, SafeTransaction{
-			To:        common.ContractCTF,
-			Value:     "0",
-			Data:      data,
-			Operation: OperationTypeCall,
-		})
+	missing := MissingApprovals(status)
+	if len(missing) == 0 {
+		return nil, nil, nil
 	}
 
-	return c.execute(ctx, txns, "approveAllTokens")
+	txns := make([]SafeTransaction, 0, len(missing))
+	for _, a := range missing {
+		txns = append(txns, a.transaction())
+	}
+	result, err := c.execute(ctx, txns, "ensureApprovals", opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return missing, result, nil
 }
 
 // TransferUSDC 转移 USDC
-func (c *Client) TransferUSDC(ctx context.Context, params common.TransferParams) (*common.TransactionResult, error) {
-	amount := common.ParseUnits(params.Amount, common.USDCDecimals)
+func (c *Client) TransferUSDC(ctx context.Context, params common.TransferParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	amount, err := common.ParseUnitsStrict(params.Amount, common.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
 	data := encodeERC20Transfer(params.To, amount.String())
 
 	return c.execute(ctx, []SafeTransaction{{
@@ -750,12 +847,15 @@ func (c *Client) TransferUSDC(ctx context.Context, params common.TransferParams)
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "transferUSDC")
+	}}, "transferUSDC", opts...)
 }
 
 // TransferOutcomeToken 转移 Outcome Token
-func (c *Client) TransferOutcomeToken(ctx context.Context, params common.TransferParams) (*common.TransactionResult, error) {
-	amount := common.ParseUnits(params.Amount, common.CTFTokenDecimals)
+func (c *Client) TransferOutcomeToken(ctx context.Context, params common.TransferParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	amount, err := common.ParseUnitsStrict(params.Amount, common.CTFTokenDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
 	data := encodeERC1155SafeTransferFrom(c.proxyAddress.Hex(), params.To, params.TokenID, amount.String())
 
 	return c.execute(ctx, []SafeTransaction{{
@@ -763,13 +863,16 @@ func (c *Client) TransferOutcomeToken(ctx context.Context, params common.Transfe
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "transferOutcomeToken")
+	}}, "transferOutcomeToken", opts...)
 }
 
 // Split 分割 USDC
-func (c *Client) Split(ctx context.Context, params common.SplitParams) (*common.TransactionResult, error) {
-	amount := common.ParseUnits(params.Amount, common.USDCDecimals)
-	data := encodeCTFSplitPosition(params.CollateralToken, params.ConditionID, amount.String())
+func (c *Client) Split(ctx context.Context, params common.SplitParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	amount, err := common.ParseUnitsStrict(params.Amount, common.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
+	data := encodeCTFSplitPosition(params.CollateralToken, params.ConditionID, amount.String(), common.Partition(params.OutcomeSlotCount))
 
 	target := common.ContractCTF
 	if params.NegRisk {
@@ -781,13 +884,16 @@ func (c *Client) Split(ctx context.Context, params common.SplitParams) (*common.
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "split")
+	}}, "split", opts...)
 }
 
 // Merge 合并代币
-func (c *Client) Merge(ctx context.Context, params common.MergeParams) (*common.TransactionResult, error) {
-	amount := common.ParseUnits(params.Amount, common.USDCDecimals)
-	data := encodeCTFMergePositions(params.CollateralToken, params.ConditionID, amount.String())
+func (c *Client) Merge(ctx context.Context, params common.MergeParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	amount, err := common.ParseUnitsStrict(params.Amount, common.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
+	data := encodeCTFMergePositions(params.CollateralToken, params.ConditionID, amount.String(), common.Partition(params.OutcomeSlotCount))
 
 	target := common.ContractCTF
 	if params.NegRisk {
@@ -799,11 +905,139 @@ func (c *Client) Merge(ctx context.Context, params common.MergeParams) (*common.
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "merge")
+	}}, "merge", opts...)
 }
 
 // Redeem 赎回代币
-func (c *Client) Redeem(ctx context.Context, params common.RedeemParams) (*common.TransactionResult, error) {
+func (c *Client) Redeem(ctx context.Context, params common.RedeemParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	if err := c.resolveOutcomeSlotCount(ctx, &params); err != nil {
+		return nil, err
+	}
+	return c.execute(ctx, []SafeTransaction{redeemTransaction(params)}, "redeem", opts...)
+}
+
+// RedeemBatch 在一笔交易中赎回多个 condition（多笔时通过 multisend 合并）
+// 可配合 data.RedeemParamsFromPositions 按 condition 去重后调用
+func (c *Client) RedeemBatch(ctx context.Context, params []common.RedeemParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("no redeem params")
+	}
+	txns := make([]SafeTransaction, len(params))
+	for i, p := range params {
+		if err := c.resolveOutcomeSlotCount(ctx, &p); err != nil {
+			return nil, err
+		}
+		txns[i] = redeemTransaction(p)
+	}
+	return c.execute(ctx, txns, "redeem", opts...)
+}
+
+// GetOutcomeSlotCount 查询 CTF condition 的结果数量（getOutcomeSlotCount），结果按 condition 缓存
+// condition 尚未在 CTF 上 prepare 时合约返回 0，此时返回错误且不缓存
+func (c *Client) GetOutcomeSlotCount(ctx context.Context, conditionID string) (int, error) {
+	key := strings.ToLower(conditionID)
+	if n, ok := c.slotCounts.Load(key); ok {
+		return n.(int), nil
+	}
+
+	methodID := crypto.Keccak256([]byte("getOutcomeSlotCount(bytes32)"))[:4]
+	data := append(methodID, ethcommon.HexToHash(conditionID).Bytes()...)
+	ctf := ethcommon.HexToAddress(common.ContractCTF)
+	result, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &ctf, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("get outcome slot count: %w", err)
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("get outcome slot count: unexpected result length %d", len(result))
+	}
+
+	count := new(big.Int).SetBytes(result[:32])
+	if count.Sign() == 0 {
+		return 0, fmt.Errorf("condition %s not prepared", conditionID)
+	}
+	if !count.IsInt64() || count.Int64() > 256 {
+		return 0, fmt.Errorf("condition %s: invalid outcome slot count %s", conditionID, count)
+	}
+	n := int(count.Int64())
+	c.slotCounts.Store(key, n)
+	return n, nil
+}
+
+// resolveOutcomeSlotCount CTF 赎回未指定结果数量时从合约查询（NegRisk 赎回按数量进行，无需查询）
+func (c *Client) resolveOutcomeSlotCount(ctx context.Context, params *common.RedeemParams) error {
+	if params.NegRisk || params.OutcomeSlotCount > 0 {
+		return nil
+	}
+	n, err := c.GetOutcomeSlotCount(ctx, params.ConditionID)
+	if err != nil {
+		return err
+	}
+	params.OutcomeSlotCount = n
+	return nil
+}
+
+// RedeemAllBatchSize RedeemAll 每笔交易最多合并的 condition 数，避免 multisend 超出 gas 上限
+const RedeemAllBatchSize = 20
+
+// redeemPositionsPageSize RedeemAll 分页拉取持仓的每页数量
+const redeemPositionsPageSize = 500
+
+// PositionFetcher 查询用户持仓（data.Client 实现了该接口）
+type PositionFetcher interface {
+	GetPositions(ctx context.Context, params *common.PositionQueryParams) ([]common.Position, error)
+}
+
+// RedeemAll 查询 user 的全部持仓，按 condition 合并可赎回持仓并批量赎回
+// 每 RedeemAllBatchSize 个 condition 提交一笔交易（前一笔落定后再提交下一笔）；返回已提交赎回的 condition 及各笔交易结果，
+// 中途失败时返回已成功的部分和错误；没有可赎回持仓时返回空结果
+func (c *Client) RedeemAll(ctx context.Context, fetcher PositionFetcher, user string, opts ...ExecuteOption) ([]string, []*common.TransactionResult, error) {
+	if user == "" {
+		user = c.proxyAddress.Hex()
+	}
+
+	var positions []common.Position
+	for offset := 0; ; offset += redeemPositionsPageSize {
+		page, err := fetcher.GetPositions(ctx, &common.PositionQueryParams{
+			User:          user,
+			SizeThreshold: "0",
+			Limit:         redeemPositionsPageSize,
+			Offset:        offset,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("get positions: %w", err)
+		}
+		positions = append(positions, page...)
+		if len(page) < redeemPositionsPageSize {
+			break
+		}
+	}
+
+	params := data.RedeemParamsFromPositions(positions)
+	var redeemed []string
+	var results []*common.TransactionResult
+	for start := 0; start < len(params); start += RedeemAllBatchSize {
+		batch := params[start:min(start+RedeemAllBatchSize, len(params))]
+		result, err := c.RedeemBatch(ctx, batch, opts...)
+		if err != nil {
+			return redeemed, results, fmt.Errorf("redeem batch %d: %w", start/RedeemAllBatchSize, err)
+		}
+		for _, p := range batch {
+			redeemed = append(redeemed, p.ConditionID)
+		}
+		results = append(results, result)
+
+		// 后续批次需要新的 nonce，等待本笔交易落定后再提交
+		if start+RedeemAllBatchSize < len(params) {
+			if _, err := c.WaitForTransaction(ctx, result.TransactionID); err != nil {
+				return redeemed, results, fmt.Errorf("wait redeem batch %d: %w", start/RedeemAllBatchSize, err)
+			}
+		}
+	}
+	return redeemed, results, nil
+}
+
+// redeemTransaction 构建赎回交易：NegRisk 市场通过 NegRiskAdapter 按数量赎回，普通市场通过 CTF 赎回整个 condition
+func redeemTransaction(params common.RedeemParams) SafeTransaction {
 	var data string
 	var target string
 
@@ -816,22 +1050,25 @@ func (c *Client) Redeem(ctx context.Context, params common.RedeemParams) (*commo
 		data = encodeNegRiskRedeemPositions(params.ConditionID, amounts)
 		target = common.ContractNegRiskAdapter
 	} else {
-		data = encodeCTFRedeemPositions(params.CollateralToken, params.ConditionID)
+		data = encodeCTFRedeemPositions(params.CollateralToken, params.ConditionID, common.Partition(params.OutcomeSlotCount))
 		target = common.ContractCTF
 	}
 
-	return c.execute(ctx, []SafeTransaction{{
+	return SafeTransaction{
 		To:        target,
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "redeem")
+	}
 }
 
 // Convert 转换代币
-func (c *Client) Convert(ctx context.Context, params common.ConvertParams) (*common.TransactionResult, error) {
+func (c *Client) Convert(ctx context.Context, params common.ConvertParams, opts ...ExecuteOption) (*common.TransactionResult, error) {
 	indexSet := common.CalculateIndexSet(params.QuestionIDs)
-	amount := common.ParseUnits(params.Amount, common.USDCDecimals)
+	amount, err := common.ParseUnitsStrict(params.Amount, common.USDCDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("parse amount: %w", err)
+	}
 	data := encodeNegRiskConvertPositions(params.MarketID, indexSet.String(), amount.String())
 
 	return c.execute(ctx, []SafeTransaction{{
@@ -839,20 +1076,33 @@ func (c *Client) Convert(ctx context.Context, params common.ConvertParams) (*com
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "convert")
+	}}, "convert", opts...)
 }
 
 // execute 执行 Safe 交易
-func (c *Client) execute(ctx context.Context, txns []SafeTransaction, metadata string) (*common.TransactionResult, error) {
+func (c *Client) execute(ctx context.Context, txns []SafeTransaction, metadata string, opts ...ExecuteOption) (*common.TransactionResult, error) {
+	options, err := newExecuteOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一账户的交易串行提交，保证 nonce 连续
+	c.nonces.mu.Lock()
+	defer c.nonces.mu.Unlock()
+
+	if c.walletType == TxTypeProxy {
+		return c.executeProxy(ctx, txns, metadata, options)
+	}
+
 	deployed, err := c.isDeployed(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("check deployed: %w", err)
 	}
 	if !deployed {
-		return nil, fmt.Errorf("Safe not deployed, call Deploy() first")
+		return nil, ErrNotDeployed
 	}
 
-	nonce, err := c.getNonce(ctx)
+	nonce, err := c.nonces.reserve(ctx, c.getNonce)
 	if err != nil {
 		return nil, fmt.Errorf("get nonce: %w", err)
 	}
@@ -869,7 +1119,8 @@ func (c *Client) execute(ctx context.Context, txns []SafeTransaction, metadata s
 		operation = OperationTypeDelegateCall
 	}
 
-	signature, err := c.signSafeTransaction(to, data, nonce, operation)
+	gas := options.gas
+	signature, err := c.signSafeTransaction(to, data, nonce, operation, gas)
 	if err != nil {
 		return nil, fmt.Errorf("sign transaction: %w", err)
 	}
@@ -882,12 +1133,12 @@ func (c *Client) execute(ctx context.Context, txns []SafeTransaction, metadata s
 		Nonce:       fmt.Sprintf("%d", nonce),
 		Signature:   signature,
 		SignatureParams: SignatureParams{
-			GasPrice:       "0",
+			GasPrice:       gas.GasPrice,
 			Operation:      fmt.Sprintf("%d", operation),
-			SafeTxnGas:     "0",
-			BaseGas:        "0",
-			GasToken:       ethcommon.Address{}.Hex(),
-			RefundReceiver: ethcommon.Address{}.Hex(),
+			SafeTxnGas:     gas.SafeTxGas,
+			BaseGas:        gas.BaseGas,
+			GasToken:       gas.GasToken,
+			RefundReceiver: gas.RefundReceiver,
 		},
 		Type:     "SAFE",
 		Metadata: metadata,
@@ -898,21 +1149,43 @@ func (c *Client) execute(ctx context.Context, txns []SafeTransaction, metadata s
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	respBody, err := c.postWithAuth(ctx, "/submit", bodyBytes)
+	result, err := c.submit(ctx, bodyBytes)
+	c.nonces.commit(nonce, err)
+	return result, err
+}
+
+// submit 提交交易到 Relayer 并解析响应
+// 提交后通常只返回 TransactionID，Hash 在交易上链前可能为空，可通过 WaitForTransaction 轮询；
+// 响应已是 STATE_FAILED/STATE_INVALID 时同时返回结果和 *TransactionFailedError（含服务端失败原因）
+func (c *Client) submit(ctx context.Context, body []byte) (*common.TransactionResult, error) {
+	respBody, err := c.postWithAuth(ctx, "/submit", body)
 	if err != nil {
 		return nil, fmt.Errorf("submit: %w", err)
 	}
 
-	var resp Response
-	if err := json.Unmarshal(respBody, &resp); err != nil {
+	resp, err := parseTransaction(respBody)
+	if err != nil {
 		return nil, fmt.Errorf("unmarshal: %w", err)
 	}
 
-	return &common.TransactionResult{
+	result := &common.TransactionResult{
 		Hash:          resp.TransactionHash,
 		TransactionID: resp.TransactionID,
 		State:         resp.State,
-	}, nil
+	}
+	switch TransactionState(resp.State) {
+	case StateFailed, StateInvalid:
+		return result, &TransactionFailedError{
+			TransactionID: resp.TransactionID,
+			Hash:          resp.TransactionHash,
+			State:         TransactionState(resp.State),
+			Reason:        resp.FailReason,
+		}
+	}
+	if resp.TransactionID == "" && resp.TransactionHash == "" {
+		return result, fmt.Errorf("submit: response has neither transaction id nor hash")
+	}
+	return result, nil
 }
 
 // encodeMultiSendData 编码 MultiSend 数据
@@ -954,22 +1227,150 @@ func (c *Client) GetAccountStatus(ctx context.Context) (*common.AccountStatus, e
 		return nil, fmt.Errorf("get usdc balance: %w", err)
 	}
 
-	usdcAllowanceCTF, _ := c.callAllowance(ctx, common.ContractUSDC, c.proxyAddress, ethcommon.HexToAddress(common.ContractCTF))
-	usdcAllowanceNegRisk, _ := c.callAllowance(ctx, common.ContractUSDC, c.proxyAddress, ethcommon.HexToAddress(common.ContractNegRiskAdapter))
+	allowances := make([]*big.Int, 4)
+	for i, spender := range []string{common.ContractCTF, common.ContractNegRiskAdapter, common.ContractCTFExchange, common.ContractNegRiskCTFExchange} {
+		if allowances[i], err = c.callAllowance(ctx, common.ContractUSDC, c.proxyAddress, ethcommon.HexToAddress(spender)); err != nil {
+			return nil, fmt.Errorf("get usdc allowance [%s]: %w", spender, err)
+		}
+	}
+	usdcAllowanceCTF, usdcAllowanceNegRisk, usdcAllowanceExchange, usdcAllowanceNegRiskExchange := allowances[0], allowances[1], allowances[2], allowances[3]
 
-	ctfApprovedNegRisk, _ := c.callIsApprovedForAll(ctx, common.ContractCTF, c.proxyAddress, ethcommon.HexToAddress(common.ContractNegRiskAdapter))
-	ctfApprovedExchange, _ := c.callIsApprovedForAll(ctx, common.ContractCTF, c.proxyAddress, ethcommon.HexToAddress(common.ContractCTFExchange))
+	approved := make([]bool, 3)
+	for i, operator := range []string{common.ContractNegRiskAdapter, common.ContractCTFExchange, common.ContractNegRiskCTFExchange} {
+		if approved[i], err = c.callIsApprovedForAll(ctx, common.ContractCTF, c.proxyAddress, ethcommon.HexToAddress(operator)); err != nil {
+			return nil, fmt.Errorf("get ctf approval [%s]: %w", operator, err)
+		}
+	}
+	ctfApprovedNegRisk, ctfApprovedExchange, ctfApprovedNegRiskExchange := approved[0], approved[1], approved[2]
 
 	return &common.AccountStatus{
-		Address:              c.proxyAddress.Hex(),
-		USDCBalance:          usdcBalance,
-		USDCAllowanceCTF:     usdcAllowanceCTF.String(),
-		USDCAllowanceNegRisk: usdcAllowanceNegRisk.String(),
-		CTFApprovedNegRisk:   ctfApprovedNegRisk,
-		CTFApprovedExchange:  ctfApprovedExchange,
+		Address:                      c.proxyAddress.Hex(),
+		USDCBalance:                  usdcBalance,
+		USDCAllowanceCTF:             usdcAllowanceCTF.String(),
+		USDCAllowanceNegRisk:         usdcAllowanceNegRisk.String(),
+		USDCAllowanceExchange:        usdcAllowanceExchange.String(),
+		USDCAllowanceNegRiskExchange: usdcAllowanceNegRiskExchange.String(),
+		CTFApprovedNegRisk:           ctfApprovedNegRisk,
+		CTFApprovedExchange:          ctfApprovedExchange,
+		CTFApprovedNegRiskExchange:   ctfApprovedNegRiskExchange,
 	}, nil
 }
 
+// WaitForReceipt 轮询等待交易上链并返回回执，交易执行失败 (status=0) 时返回错误
+// 可用于等待 CLOB 订单撮合产生的链上交易 (clob.Client.OrderFillTxs)
+func (c *Client) WaitForReceipt(ctx context.Context, txHash string) (*types.Receipt, error) {
+	hash := ethcommon.HexToHash(txHash)
+	ticker := time.NewTicker(ReceiptPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := c.ethClient.TransactionReceipt(ctx, hash)
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return receipt, fmt.Errorf("transaction %s failed", txHash)
+			}
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("get receipt [%s]: %w", txHash, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for receipt [%s]: %w", txHash, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForReceipts 依次等待多笔交易上链，任一失败即返回
+func (c *Client) WaitForReceipts(ctx context.Context, txHashes []string) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, 0, len(txHashes))
+	for _, hash := range txHashes {
+		receipt, err := c.WaitForReceipt(ctx, hash)
+		if err != nil {
+			return receipts, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// GetTransaction 查询 Relayer 交易状态
+func (c *Client) GetTransaction(ctx context.Context, transactionID string) (*Response, error) {
+	if transactionID == "" {
+		return nil, fmt.Errorf("transaction id is required")
+	}
+	respBody, err := c.getWithAuth(ctx, "/transaction?id="+url.QueryEscape(transactionID))
+	if err != nil {
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	tx, err := parseTransaction(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("get transaction [%s]: %w", transactionID, err)
+	}
+	return tx, nil
+}
+
+// WaitForTransaction 轮询 Relayer 交易直到终态（STATE_CONFIRMED/STATE_FAILED/STATE_INVALID）或 ctx 结束
+// 失败或无效时同时返回最终状态和 *TransactionFailedError；轮询间隔由 Config.TransactionPollInterval 控制
+func (c *Client) WaitForTransaction(ctx context.Context, transactionID string) (*Response, error) {
+	ticker := time.NewTicker(c.config.TransactionPollInterval)
+	defer ticker.Stop()
+	for {
+		tx, err := c.GetTransaction(ctx, transactionID)
+		if err != nil {
+			return nil, err
+		}
+		switch TransactionState(tx.State) {
+		case StateConfirmed:
+			return tx, nil
+		case StateFailed, StateInvalid:
+			return tx, &TransactionFailedError{
+				TransactionID: transactionID,
+				Hash:          tx.TransactionHash,
+				State:         TransactionState(tx.State),
+				Reason:        tx.FailReason,
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return tx, fmt.Errorf("wait for transaction [%s] (state %s): %w", transactionID, tx.State, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseTransaction 解析 /transaction 响应（数组或单个对象）
+func parseTransaction(body []byte) (*Response, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		raws = []json.RawMessage{body}
+	}
+	if len(raws) == 0 {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	var tx Response
+	if err := json.Unmarshal(raws[0], &tx); err != nil {
+		return nil, fmt.Errorf("unmarshal transaction: %w", err)
+	}
+	var reason struct {
+		ErrorMsg string `json:"errorMsg"`
+		Error    string `json:"error"`
+		Reason   string `json:"reason"`
+	}
+	if json.Unmarshal(raws[0], &reason) == nil {
+		switch {
+		case reason.ErrorMsg != "":
+			tx.FailReason = reason.ErrorMsg
+		case reason.Error != "":
+			tx.FailReason = reason.Error
+		default:
+			tx.FailReason = reason.Reason
+		}
+	}
+	return &tx, nil
+}
+
 // ========== 通用查询方法 (与 TS 版本对齐) ==========
 
 // GetTokenBalance 查询 ERC20 代币余额
@@ -1009,18 +1410,18 @@ func (c *Client) IsApprovedForAll(ctx context.Context, operator string, address
 }
 
 // SetApprovalForAll 授权 ERC1155 给指定操作员
-func (c *Client) SetApprovalForAll(ctx context.Context, operator string, approved bool) (*common.TransactionResult, error) {
+func (c *Client) SetApprovalForAll(ctx context.Context, operator string, approved bool, opts ...ExecuteOption) (*common.TransactionResult, error) {
 	data := encodeERC1155SetApprovalForAll(operator, approved)
 	return c.execute(ctx, []SafeTransaction{{
 		To:        common.ContractCTF,
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "setApprovalForAll")
+	}}, "setApprovalForAll", opts...)
 }
 
 // ApproveToken 授权 ERC20 代币给指定地址
-func (c *Client) ApproveToken(ctx context.Context, tokenAddress, spender string, amount *big.Int) (*common.TransactionResult, error) {
+func (c *Client) ApproveToken(ctx context.Context, tokenAddress, spender string, amount *big.Int, opts ...ExecuteOption) (*common.TransactionResult, error) {
 	amountStr := "115792089237316195423570985008687907853269984665640564039457584007913129639935" // MaxUint256
 	if amount != nil {
 		amountStr = amount.String()
@@ -1031,7 +1432,7 @@ func (c *Client) ApproveToken(ctx context.Context, tokenAddress, spender string,
 		Value:     "0",
 		Data:      data,
 		Operation: OperationTypeCall,
-	}}, "approveToken")
+	}}, "approveToken", opts...)
 }
 
 // callERC1155BalanceOf 调用 ERC1155 balanceOf
@@ -1166,37 +1567,17 @@ func encodeERC1155SafeTransferFrom(from, to, tokenID, amount string) string {
 	return "0x" + hex.EncodeToString(data)
 }
 
-func encodeCTFSplitPosition(collateralToken, conditionID, amount string) string {
-	methodID := crypto.Keccak256([]byte("splitPosition(address,bytes32,bytes32,uint256[],uint256)"))[:4]
-
-	collateralPadded := ethcommon.LeftPadBytes(ethcommon.HexToAddress(collateralToken).Bytes(), 32)
-	parentCollectionID := make([]byte, 32)
-	conditionIDBytes := ethcommon.HexToHash(conditionID).Bytes()
-
-	// 头部: address(32) + bytes32(32) + bytes32(32) + offset(32) + uint256(32) = 160 bytes
-	partitionOffset := ethcommon.LeftPadBytes(big.NewInt(160).Bytes(), 32)
-
-	amountBig := new(big.Int)
-	amountBig.SetString(amount, 10)
-	amountPadded := ethcommon.LeftPadBytes(amountBig.Bytes(), 32)
-
-	partitionLength := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
-	partition1 := ethcommon.LeftPadBytes(big.NewInt(1).Bytes(), 32)
-	partition2 := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
+func encodeCTFSplitPosition(collateralToken, conditionID, amount string, partition []*big.Int) string {
+	return encodeCTFPartitionCall("splitPosition(address,bytes32,bytes32,uint256[],uint256)", collateralToken, conditionID, amount, partition)
+}
 
-	data := append(methodID, collateralPadded...)
-	data = append(data, parentCollectionID...)
-	data = append(data, conditionIDBytes...)
-	data = append(data, partitionOffset...)
-	data = append(data, amountPadded...)
-	data = append(data, partitionLength...)
-	data = append(data, partition1...)
-	data = append(data, partition2...)
-	return "0x" + hex.EncodeToString(data)
+func encodeCTFMergePositions(collateralToken, conditionID, amount string, partition []*big.Int) string {
+	return encodeCTFPartitionCall("mergePositions(address,bytes32,bytes32,uint256[],uint256)", collateralToken, conditionID, amount, partition)
 }
 
-func encodeCTFMergePositions(collateralToken, conditionID, amount string) string {
-	methodID := crypto.Keccak256([]byte("mergePositions(address,bytes32,bytes32,uint256[],uint256)"))[:4]
+// encodeCTFPartitionCall 编码 splitPosition/mergePositions (collateral, parentCollectionId=0, conditionId, partition, amount)
+func encodeCTFPartitionCall(signature, collateralToken, conditionID, amount string, partition []*big.Int) string {
+	methodID := crypto.Keccak256([]byte(signature))[:4]
 
 	collateralPadded := ethcommon.LeftPadBytes(ethcommon.HexToAddress(collateralToken).Bytes(), 32)
 	parentCollectionID := make([]byte, 32)
@@ -1209,9 +1590,7 @@ func encodeCTFMergePositions(collateralToken, conditionID, amount string) string
 	amountBig.SetString(amount, 10)
 	amountPadded := ethcommon.LeftPadBytes(amountBig.Bytes(), 32)
 
-	partitionLength := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
-	partition1 := ethcommon.LeftPadBytes(big.NewInt(1).Bytes(), 32)
-	partition2 := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
+	partitionLength := ethcommon.LeftPadBytes(big.NewInt(int64(len(partition))).Bytes(), 32)
 
 	data := append(methodID, collateralPadded...)
 	data = append(data, parentCollectionID...)
@@ -1219,12 +1598,13 @@ func encodeCTFMergePositions(collateralToken, conditionID, amount string) string
 	data = append(data, partitionOffset...)
 	data = append(data, amountPadded...)
 	data = append(data, partitionLength...)
-	data = append(data, partition1...)
-	data = append(data, partition2...)
+	for _, indexSet := range partition {
+		data = append(data, ethcommon.LeftPadBytes(indexSet.Bytes(), 32)...)
+	}
 	return "0x" + hex.EncodeToString(data)
 }
 
-func encodeCTFRedeemPositions(collateralToken, conditionID string) string {
+func encodeCTFRedeemPositions(collateralToken, conditionID string, indexSets []*big.Int) string {
 	methodID := crypto.Keccak256([]byte("redeemPositions(address,bytes32,bytes32,uint256[])"))[:4]
 
 	collateralPadded := ethcommon.LeftPadBytes(ethcommon.HexToAddress(collateralToken).Bytes(), 32)
@@ -1233,17 +1613,16 @@ func encodeCTFRedeemPositions(collateralToken, conditionID string) string {
 
 	// 头部: address(32) + bytes32(32) + bytes32(32) + offset(32) = 128 bytes
 	indexSetsOffset := ethcommon.LeftPadBytes(big.NewInt(128).Bytes(), 32)
-	indexSetsLength := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
-	indexSet1 := ethcommon.LeftPadBytes(big.NewInt(1).Bytes(), 32)
-	indexSet2 := ethcommon.LeftPadBytes(big.NewInt(2).Bytes(), 32)
+	indexSetsLength := ethcommon.LeftPadBytes(big.NewInt(int64(len(indexSets))).Bytes(), 32)
 
 	data := append(methodID, collateralPadded...)
 	data = append(data, parentCollectionID...)
 	data = append(data, conditionIDBytes...)
 	data = append(data, indexSetsOffset...)
 	data = append(data, indexSetsLength...)
-	data = append(data, indexSet1...)
-	data = append(data, indexSet2...)
+	for _, indexSet := range indexSets {
+		data = append(data, ethcommon.LeftPadBytes(indexSet.Bytes(), 32)...)
+	}
 	return "0x" + hex.EncodeToString(data)
 }
 
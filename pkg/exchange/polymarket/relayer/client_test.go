@@ -0,0 +1,822 @@
+package relayer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// testPrivateKey 测试用私钥（公开的示例密钥，不持有资产）
+const testPrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// fakeRPC JSON-RPC 测试节点：eth_chainId 返回 Polygon，eth_call 交给 call 处理（为空时返回 32 字节 0），
+// eth_getTransactionReceipt 按 receipts 返回回执状态（不存在时返回 null，即尚未上链）
+type fakeRPC struct {
+	call     func(to ethcommon.Address, data []byte) ([]byte, error)
+	receipts map[ethcommon.Hash]uint64
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_chainId":
+		resp["result"] = "0x89"
+	case "eth_estimateGas":
+		resp["result"] = "0x5208"
+	case "eth_call":
+		var arg struct {
+			To    ethcommon.Address `json:"to"`
+			Input hexutil.Bytes     `json:"input"`
+		}
+		if err := json.Unmarshal(req.Params[0], &arg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.calls++
+		f.mu.Unlock()
+		out := make([]byte, 32)
+		var err error
+		if f.call != nil {
+			out, err = f.call(arg.To, arg.Input)
+		}
+		if err != nil {
+			resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = hexutil.Bytes(out)
+		}
+	case "eth_getTransactionReceipt":
+		var hash ethcommon.Hash
+		if err := json.Unmarshal(req.Params[0], &hash); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp["result"] = nil
+		if status, ok := f.receipts[hash]; ok {
+			resp["result"] = map[string]any{
+				"transactionHash":   hash,
+				"status":            hexutil.Uint64(status),
+				"cumulativeGasUsed": "0x5208",
+				"gasUsed":           "0x5208",
+				"logsBloom":         hexutil.Bytes(make([]byte, 256)),
+				"logs":              []any{},
+			}
+		}
+	default:
+		resp["error"] = map[string]any{"code": -32601, "message": "method not found: " + req.Method}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Calls eth_call 调用次数
+func (f *fakeRPC) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// newTestClient 创建指向测试 Relayer 和测试节点的客户端，rpc 为空时使用默认 fakeRPC
+func newTestClient(t *testing.T, relayer http.Handler, rpc *fakeRPC, walletType TxType) *Client {
+	t.Helper()
+	if rpc == nil {
+		rpc = &fakeRPC{}
+	}
+	rpcSrv := httptest.NewServer(rpc)
+	t.Cleanup(rpcSrv.Close)
+	relayerSrv := httptest.NewServer(relayer)
+	t.Cleanup(relayerSrv.Close)
+
+	c, err := NewClient(Config{
+		PrivateKey:              testPrivateKey,
+		RPCURL:                  rpcSrv.URL,
+		RelayerURL:              relayerSrv.URL,
+		WalletType:              walletType,
+		TransactionPollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// writeJSON 以 JSON 写出响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// safeRelayer 已部署 Safe 的测试 Relayer：/nonce 返回 nonce()，/submit 交给 submit 处理
+func safeRelayer(nonce func() int64, submit http.HandlerFunc) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deployed", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, DeployedResponse{Deployed: true})
+	})
+	mux.HandleFunc("GET /nonce", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"nonce": fmt.Sprint(nonce())})
+	})
+	mux.HandleFunc("POST /submit", submit)
+	return mux
+}
+
+// decodeBody 解析请求体
+func decodeBody(t *testing.T, r *http.Request, v any) {
+	t.Helper()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("decode body: %v", err)
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler(), nil, TxTypeSafe)
+	if _, err := c.GetUSDCBalance(t.Context()); err != nil {
+		t.Fatalf("GetUSDCBalance before Close: %v", err)
+	}
+
+	// newTestClient 已注册 Cleanup(c.Close)，这里再调用两次，共三次；关闭后的调用不应 panic
+	c.Close()
+	c.Close()
+	c.GetUSDCBalance(t.Context())
+}
+
+func TestWaitForReceipts(t *testing.T) {
+	ok := ethcommon.HexToHash("0x01")
+	reverted := ethcommon.HexToHash("0x02")
+	pending := ethcommon.HexToHash("0x03")
+	rpc := &fakeRPC{receipts: map[ethcommon.Hash]uint64{ok: 1, reverted: 0}}
+	c := newTestClient(t, http.NotFoundHandler(), rpc, TxTypeSafe)
+
+	receipts, err := c.WaitForReceipts(t.Context(), []string{ok.Hex()})
+	if err != nil {
+		t.Fatalf("WaitForReceipts: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0].TxHash != ok {
+		t.Errorf("receipts = %+v", receipts)
+	}
+
+	// 执行失败的交易返回错误，并保留之前已确认的回执
+	receipts, err = c.WaitForReceipts(t.Context(), []string{ok.Hex(), reverted.Hex(), ok.Hex()})
+	if err == nil {
+		t.Fatal("expected error for reverted transaction")
+	}
+	if len(receipts) != 1 {
+		t.Errorf("receipts before failure = %d, want 1", len(receipts))
+	}
+
+	// 尚未上链时持续轮询直到 ctx 结束
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForReceipt(ctx, pending.Hex()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("pending receipt err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRedeemBatchSingleSubmit(t *testing.T) {
+	var got []SafeTransactionRequest
+	relayer := safeRelayer(func() int64 { return 1 }, func(w http.ResponseWriter, r *http.Request) {
+		var req SafeTransactionRequest
+		decodeBody(t, r, &req)
+		got = append(got, req)
+		writeJSON(w, http.StatusOK, Response{TransactionID: "tx", State: string(StateNew)})
+	})
+	// getOutcomeSlotCount 返回 2
+	rpc := &fakeRPC{call: func(to ethcommon.Address, data []byte) ([]byte, error) {
+		return ethcommon.LeftPadBytes([]byte{2}, 32), nil
+	}}
+	c := newTestClient(t, relayer, rpc, TxTypeSafe)
+
+	params := []common.RedeemParams{
+		{CollateralToken: common.ContractUSDC, ConditionID: "0x" + strings.Repeat("1", 64)},
+		{CollateralToken: common.ContractUSDC, ConditionID: "0x" + strings.Repeat("2", 64), NegRisk: true, Amounts: []string{"3", "6"}},
+	}
+	if _, err := c.RedeemBatch(t.Context(), params); err != nil {
+		t.Fatalf("RedeemBatch: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("submits = %d, want 1", len(got))
+	}
+	if !strings.EqualFold(got[0].To, common.ContractSafeMultisend) {
+		t.Errorf("to = %s, want multisend", got[0].To)
+	}
+	if rpc.Calls() != 1 {
+		t.Errorf("outcome slot count queries = %d, want 1 (CTF condition only)", rpc.Calls())
+	}
+
+	if _, err := c.RedeemBatch(t.Context(), nil); err == nil {
+		t.Error("expected error for empty batch")
+	}
+}
+
+// transactionRelayer /transaction 按轮询次数依次返回 states 中的状态，最后一个状态保持不变
+func transactionRelayer(t *testing.T, states []TransactionState, extra map[string]any) (*http.ServeMux, func() int) {
+	var mu sync.Mutex
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transaction", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("id"); got != "tx-1" {
+			t.Errorf("id = %q, want tx-1", got)
+		}
+		mu.Lock()
+		state := states[min(polls, len(states)-1)]
+		polls++
+		mu.Unlock()
+		tx := map[string]any{"transactionID": "tx-1", "transactionHash": "0xabc", "state": string(state)}
+		for k, v := range extra {
+			tx[k] = v
+		}
+		writeJSON(w, http.StatusOK, []any{tx})
+	})
+	return mux, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return polls
+	}
+}
+
+func TestWaitForTransaction(t *testing.T) {
+	relayer, polls := transactionRelayer(t, []TransactionState{StateNew, StateExecuted, StateMined, StateConfirmed}, nil)
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+	tx, err := c.WaitForTransaction(t.Context(), "tx-1")
+	if err != nil {
+		t.Fatalf("WaitForTransaction: %v", err)
+	}
+	if tx.State != string(StateConfirmed) || tx.TransactionHash != "0xabc" {
+		t.Errorf("tx = %+v", tx)
+	}
+	if polls() != 4 {
+		t.Errorf("polls = %d, want 4", polls())
+	}
+}
+
+func TestWaitForTransactionFailed(t *testing.T) {
+	relayer, _ := transactionRelayer(t, []TransactionState{StateNew, StateFailed}, map[string]any{"errorMsg": "execution reverted"})
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+	tx, err := c.WaitForTransaction(t.Context(), "tx-1")
+	var failed *TransactionFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("err = %v, want *TransactionFailedError", err)
+	}
+	if failed.State != StateFailed || failed.Reason != "execution reverted" || failed.Hash != "0xabc" {
+		t.Errorf("failed = %+v", failed)
+	}
+	if tx == nil || tx.State != string(StateFailed) {
+		t.Errorf("tx = %+v", tx)
+	}
+}
+
+func TestWaitForTransactionContext(t *testing.T) {
+	relayer, _ := transactionRelayer(t, []TransactionState{StateNew}, nil)
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForTransaction(ctx, "tx-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+// approvalRPC 模拟授权查询：USDC allowance 为无限额度，isApprovedForAll 返回 approved(operator)
+func approvalRPC(approved func(operator ethcommon.Address) bool) *fakeRPC {
+	isApproved := crypto.Keccak256([]byte("isApprovedForAll(address,address)"))[:4]
+	return &fakeRPC{call: func(to ethcommon.Address, data []byte) ([]byte, error) {
+		if bytes.Equal(data[:4], isApproved[:]) {
+			if approved(ethcommon.BytesToAddress(data[36:68])) {
+				return ethcommon.LeftPadBytes([]byte{1}, 32), nil
+			}
+			return make([]byte, 32), nil
+		}
+		return bytes.Repeat([]byte{0xff}, 32), nil
+	}}
+}
+
+func TestEnsureApprovals(t *testing.T) {
+	var submits []SafeTransactionRequest
+	relayer := safeRelayer(func() int64 { return 1 }, func(w http.ResponseWriter, r *http.Request) {
+		var req SafeTransactionRequest
+		decodeBody(t, r, &req)
+		submits = append(submits, req)
+		writeJSON(w, http.StatusOK, Response{TransactionID: "tx", State: string(StateNew)})
+	})
+
+	// 全部已授权：不提交交易
+	c := newTestClient(t, relayer, approvalRPC(func(ethcommon.Address) bool { return true }), TxTypeSafe)
+	executed, result, err := c.EnsureApprovals(t.Context())
+	if err != nil {
+		t.Fatalf("EnsureApprovals: %v", err)
+	}
+	if len(executed) != 0 || result != nil || len(submits) != 0 {
+		t.Errorf("fully approved: executed = %v, result = %v, submits = %d", executed, result, len(submits))
+	}
+
+	// 仅缺少 CTF->CTFExchange
+	exchange := ethcommon.HexToAddress(common.ContractCTFExchange)
+	c = newTestClient(t, relayer, approvalRPC(func(op ethcommon.Address) bool { return op != exchange }), TxTypeSafe)
+	executed, _, err = c.EnsureApprovals(t.Context())
+	if err != nil {
+		t.Fatalf("EnsureApprovals: %v", err)
+	}
+	if len(executed) != 1 || executed[0].Name != "CTF->CTFExchange" {
+		t.Errorf("executed = %+v, want CTF->CTFExchange", executed)
+	}
+	if len(submits) != 1 || !strings.EqualFold(submits[0].To, common.ContractCTF) {
+		t.Errorf("submits = %+v, want single CTF approval", submits)
+	}
+}
+
+func TestGetAccountStatusPropagatesCallErrors(t *testing.T) {
+	allowance := crypto.Keccak256([]byte("allowance(address,address)"))[:4]
+	rpc := &fakeRPC{call: func(to ethcommon.Address, data []byte) ([]byte, error) {
+		if bytes.Equal(data[:4], allowance) {
+			return nil, errors.New("rpc unavailable")
+		}
+		return make([]byte, 32), nil
+	}}
+	submitted := false
+	relayer := safeRelayer(func() int64 { return 1 }, func(w http.ResponseWriter, r *http.Request) { submitted = true })
+	c := newTestClient(t, relayer, rpc, TxTypeSafe)
+
+	if _, err := c.GetAccountStatus(t.Context()); err == nil || !strings.Contains(err.Error(), "rpc unavailable") {
+		t.Errorf("GetAccountStatus err = %v, want rpc error", err)
+	}
+	// 查询失败时不能把授权误判为缺失而重复提交
+	if _, _, err := c.EnsureApprovals(t.Context()); err == nil {
+		t.Error("EnsureApprovals should fail when status query fails")
+	}
+	if submitted {
+		t.Error("EnsureApprovals submitted despite status error")
+	}
+}
+
+func TestNonceManagerIncrementsAndResyncs(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		serverNonce int64 = 5 // Relayer 仍在处理上一笔时返回的过期 nonce
+		nonces      []string
+		failNext    bool
+	)
+	relayer := safeRelayer(func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return serverNonce
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var req SafeTransactionRequest
+		decodeBody(t, r, &req)
+		mu.Lock()
+		nonces = append(nonces, req.Nonce)
+		fail := failNext
+		failNext = false
+		mu.Unlock()
+		if fail {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid nonce"})
+			return
+		}
+		writeJSON(w, http.StatusOK, Response{TransactionID: "tx", State: string(StateNew)})
+	})
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+	transfer := func() error {
+		_, err := c.TransferUSDC(t.Context(), common.TransferParams{To: "0x0000000000000000000000000000000000000001", Amount: "1"})
+		return err
+	}
+
+	// 连续两笔：服务端 nonce 未更新，本地递增
+	for range 2 {
+		if err := transfer(); err != nil {
+			t.Fatalf("TransferUSDC: %v", err)
+		}
+	}
+
+	// 第三笔提交失败后丢弃本地记录，下一笔以服务端 nonce 为准（即使比本地值小）
+	mu.Lock()
+	failNext = true
+	serverNonce = 6
+	mu.Unlock()
+	if err := transfer(); err == nil {
+		t.Fatal("expected submit error")
+	}
+	if err := transfer(); err != nil {
+		t.Fatalf("TransferUSDC after failure: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := fmt.Sprint(nonces), "[5 6 7 6]"; got != want {
+		t.Errorf("submitted nonces = %s, want %s", got, want)
+	}
+}
+
+func TestProxyAndSafeTransferRequests(t *testing.T) {
+	const relayAddr = "0x00000000000000000000000000000000000000aa"
+	to := "0x0000000000000000000000000000000000000001"
+
+	// SAFE
+	var safeBody map[string]any
+	var safeReq SafeTransactionRequest
+	safe := safeRelayer(func() int64 { return 3 }, func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &safeBody)
+		json.Unmarshal(raw, &safeReq)
+		writeJSON(w, http.StatusOK, Response{TransactionID: "safe-tx", State: string(StateNew)})
+	})
+	sc := newTestClient(t, safe, nil, TxTypeSafe)
+	if _, err := sc.TransferUSDC(t.Context(), common.TransferParams{To: to, Amount: "1.5"}); err != nil {
+		t.Fatalf("SAFE TransferUSDC: %v", err)
+	}
+
+	// PROXY
+	var payloadQuery url.Values
+	var proxyBody map[string]any
+	var proxyReq ProxyTransactionRequest
+	proxy := http.NewServeMux()
+	proxy.HandleFunc("GET /relay-payload", func(w http.ResponseWriter, r *http.Request) {
+		payloadQuery = r.URL.Query()
+		writeJSON(w, http.StatusOK, map[string]string{"address": relayAddr, "nonce": "7"})
+	})
+	proxy.HandleFunc("POST /submit", func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &proxyBody)
+		json.Unmarshal(raw, &proxyReq)
+		writeJSON(w, http.StatusOK, Response{TransactionID: "proxy-tx", State: string(StateNew)})
+	})
+	pc := newTestClient(t, proxy, nil, TxTypeProxy)
+	result, err := pc.TransferUSDC(t.Context(), common.TransferParams{To: to, Amount: "1.5"})
+	if err != nil {
+		t.Fatalf("PROXY TransferUSDC: %v", err)
+	}
+	if result.TransactionID != "proxy-tx" {
+		t.Errorf("result = %+v", result)
+	}
+
+	// relay-payload 按 EOA 查询 PROXY nonce
+	if payloadQuery.Get("type") != "PROXY" || !strings.EqualFold(payloadQuery.Get("address"), pc.GetEOAAddress()) {
+		t.Errorf("relay-payload query = %v", payloadQuery)
+	}
+
+	// 请求体形状
+	if safeReq.Type != "SAFE" || proxyReq.Type != "PROXY" {
+		t.Errorf("types = %s / %s", safeReq.Type, proxyReq.Type)
+	}
+	if !strings.EqualFold(safeReq.To, common.ContractUSDC) || !strings.EqualFold(proxyReq.To, common.ContractProxyWalletFactory) {
+		t.Errorf("to = %s / %s", safeReq.To, proxyReq.To)
+	}
+	if safeReq.Nonce != "3" || proxyReq.Nonce != "7" {
+		t.Errorf("nonces = %s / %s, want 3 / 7", safeReq.Nonce, proxyReq.Nonce)
+	}
+	if !strings.EqualFold(proxyReq.ProxyWallet, pc.GetProxyAddress()) || strings.EqualFold(proxyReq.ProxyWallet, sc.GetProxyAddress()) {
+		t.Errorf("proxyWallet = %s (safe %s)", proxyReq.ProxyWallet, safeReq.ProxyWallet)
+	}
+	sp := proxyReq.SignatureParams
+	if sp.Relay != relayAddr || !strings.EqualFold(sp.RelayHub, common.ContractRelayHub) || sp.GasLimit != "21000" || sp.RelayerFee != "0" {
+		t.Errorf("proxy signatureParams = %+v", sp)
+	}
+	if _, ok := proxyBody["signatureParams"].(map[string]any)["relay"]; !ok {
+		t.Error("proxy body missing signatureParams.relay")
+	}
+	if _, ok := safeBody["signatureParams"].(map[string]any)["relay"]; ok {
+		t.Error("safe body must not carry relay fields")
+	}
+	// PROXY data 是 ProxyWalletFactory.proxy 调用，内含与 SAFE 相同的 USDC transfer
+	proxySelector := crypto.Keccak256([]byte("proxy((uint8,address,uint256,bytes)[])"))[:4]
+	if !strings.HasPrefix(proxyReq.Data, hexutil.Encode(proxySelector)) {
+		t.Errorf("proxy data selector = %.10s, want %x", proxyReq.Data, proxySelector)
+	}
+	if !strings.Contains(proxyReq.Data, strings.TrimPrefix(safeReq.Data, "0x")) {
+		t.Error("proxy data does not wrap the USDC transfer call")
+	}
+	if safeReq.Signature == proxyReq.Signature {
+		t.Error("SAFE and PROXY signatures should differ")
+	}
+
+	// GSN 签名：personal_sign(keccak256("rlx:" ‖ from ‖ to ‖ data ‖ relayerFee ‖ gasPrice ‖ gasLimit ‖ nonce ‖ relayHub ‖ relay))
+	u256 := func(s string) []byte {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			t.Fatalf("invalid uint256 %q", s)
+		}
+		return ethcommon.LeftPadBytes(n.Bytes(), 32)
+	}
+	structHash := crypto.Keccak256(
+		[]byte("rlx:"),
+		ethcommon.HexToAddress(proxyReq.From).Bytes(),
+		ethcommon.HexToAddress(proxyReq.To).Bytes(),
+		ethcommon.FromHex(proxyReq.Data),
+		u256(sp.RelayerFee), u256(sp.GasPrice), u256(sp.GasLimit), u256(proxyReq.Nonce),
+		ethcommon.HexToAddress(sp.RelayHub).Bytes(),
+		ethcommon.HexToAddress(sp.Relay).Bytes(),
+	)
+	digest := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), structHash)
+	sig := ethcommon.FromHex(proxyReq.Signature)
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("signature = %s, want 65 bytes with v in {27,28}", proxyReq.Signature)
+	}
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); !strings.EqualFold(signer.Hex(), pc.GetEOAAddress()) || !strings.EqualFold(proxyReq.From, signer.Hex()) {
+		t.Errorf("recovered signer = %s, want %s", signer.Hex(), pc.GetEOAAddress())
+	}
+}
+
+func TestCalculateProxyAddress(t *testing.T) {
+	// 线上账户：Polymarket RTDS 文档中 comment_created 示例的用户资料（docs/polymarketAPI/rtds/comments.md，
+	// 用户 salted.caramel），baseAddress 为 EOA，proxyWallet 为 ProxyWalletFactory 部署的 PROXY 钱包
+	onChain := struct{ owner, proxy string }{"0xce533188d53a16ed580fd5121dedf166d3482677", "0x4ca749dcfa93c87e5ee23e2d21ff4422c7a4c1ee"}
+	if got := calculateProxyAddress(ethcommon.HexToAddress(onChain.owner)); got != ethcommon.HexToAddress(onChain.proxy) {
+		t.Errorf("calculateProxyAddress(%s) = %s, want on-chain proxy %s", onChain.owner, got.Hex(), onChain.proxy)
+	}
+
+	// 以下期望地址按 CREATE2(ProxyWalletFactory, keccak256(owner), ProxyInitCodeHash) 计算后固定下来，
+	// 工厂地址、init code hash 或 salt 的编码发生变化时该测试失败
+	tests := []struct {
+		owner string
+		want  string
+	}{
+		{"0x2c7536E3605D9C16a7a3D7b1898e529396a65c23", "0x96a9892De6A11FE0B18Cf63373B9763055EcA8a6"},
+		{"0x0000000000000000000000000000000000000001", "0x7754536ecd85c00b2E0CF9c1aA679340D8550756"},
+	}
+	factory := ethcommon.HexToAddress(common.ContractProxyWalletFactory)
+	for _, tt := range tests {
+		owner := ethcommon.HexToAddress(tt.owner)
+		got := calculateProxyAddress(owner)
+		if got != ethcommon.HexToAddress(tt.want) {
+			t.Errorf("calculateProxyAddress(%s) = %s, want %s", tt.owner, got.Hex(), tt.want)
+		}
+		// 与 go-ethereum 的 CREATE2 实现交叉验证
+		if want := crypto.CreateAddress2(factory, crypto.Keccak256Hash(owner.Bytes()), ethcommon.FromHex(common.ProxyInitCodeHash)); got != want {
+			t.Errorf("calculateProxyAddress(%s) = %s, CreateAddress2 = %s", tt.owner, got.Hex(), want.Hex())
+		}
+	}
+
+	// 客户端按测试私钥对应的 EOA 计算 PROXY 钱包地址
+	c := newTestClient(t, http.NotFoundHandler(), nil, TxTypeProxy)
+	if c.GetEOAAddress() != tests[0].owner || c.GetProxyAddress() != tests[0].want {
+		t.Errorf("client EOA/proxy = %s/%s, want %s/%s", c.GetEOAAddress(), c.GetProxyAddress(), tests[0].owner, tests[0].want)
+	}
+}
+
+func TestSubmitResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       map[string]any
+		wantID     string
+		wantHash   string
+		wantFailed TransactionState
+		wantReason string
+		wantErr    bool
+	}{
+		{"accepted", map[string]any{"transactionID": "tx-1", "transactionHash": "0xabc", "state": "STATE_NEW"}, "tx-1", "0xabc", "", "", false},
+		{"id only", map[string]any{"transactionID": "tx-2", "state": "STATE_NEW"}, "tx-2", "", "", "", false},
+		{"immediately invalid", map[string]any{"transactionID": "tx-3", "state": "STATE_INVALID", "errorMsg": "invalid signature"}, "tx-3", "", StateInvalid, "invalid signature", true},
+		{"immediately failed", map[string]any{"transactionID": "tx-4", "transactionHash": "0xdef", "state": "STATE_FAILED", "reason": "execution reverted"}, "tx-4", "0xdef", StateFailed, "execution reverted", true},
+		{"neither id nor hash", map[string]any{"state": "STATE_NEW"}, "", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relayer := safeRelayer(func() int64 { return 1 }, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, tt.resp)
+			})
+			c := newTestClient(t, relayer, nil, TxTypeSafe)
+			result, err := c.TransferUSDC(t.Context(), common.TransferParams{To: "0x0000000000000000000000000000000000000001", Amount: "1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result == nil || result.TransactionID != tt.wantID || result.Hash != tt.wantHash {
+				t.Fatalf("result = %+v, want id %q hash %q", result, tt.wantID, tt.wantHash)
+			}
+
+			var failed *TransactionFailedError
+			if tt.wantFailed == "" {
+				if errors.As(err, &failed) {
+					t.Errorf("unexpected TransactionFailedError: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &failed) {
+				t.Fatalf("err = %v, want *TransactionFailedError", err)
+			}
+			if failed.State != tt.wantFailed || failed.Reason != tt.wantReason || failed.TransactionID != tt.wantID || failed.Hash != tt.wantHash {
+				t.Errorf("failed = %+v", failed)
+			}
+		})
+	}
+}
+
+func TestGetOutcomeSlotCount(t *testing.T) {
+	selector := crypto.Keccak256([]byte("getOutcomeSlotCount(bytes32)"))[:4]
+	counts := map[ethcommon.Hash]byte{
+		ethcommon.HexToHash("0x01"): 2,
+		ethcommon.HexToHash("0x02"): 3,
+		ethcommon.HexToHash("0x03"): 7,
+		// 0x04 未 prepare：合约返回 0
+	}
+	rpc := &fakeRPC{call: func(to ethcommon.Address, data []byte) ([]byte, error) {
+		if to != ethcommon.HexToAddress(common.ContractCTF) || !bytes.Equal(data[:4], selector) {
+			return nil, fmt.Errorf("unexpected call to %s: %x", to.Hex(), data)
+		}
+		return ethcommon.LeftPadBytes([]byte{counts[ethcommon.BytesToHash(data[4:36])]}, 32), nil
+	}}
+	c := newTestClient(t, http.NotFoundHandler(), rpc, TxTypeSafe)
+
+	for id, want := range counts {
+		got, err := c.GetOutcomeSlotCount(t.Context(), id.Hex())
+		if err != nil || got != int(want) {
+			t.Errorf("GetOutcomeSlotCount(%s) = %d, %v, want %d", id.Hex(), got, err, want)
+		}
+	}
+	calls := rpc.Calls()
+	if calls != len(counts) {
+		t.Errorf("rpc calls = %d, want %d", calls, len(counts))
+	}
+
+	// 已缓存（大小写不敏感）不再请求合约
+	upper := "0x" + strings.ToUpper(strings.TrimPrefix(ethcommon.HexToHash("0x03").Hex(), "0x"))
+	if got, err := c.GetOutcomeSlotCount(t.Context(), upper); err != nil || got != 7 {
+		t.Errorf("cached GetOutcomeSlotCount = %d, %v, want 7", got, err)
+	}
+	if rpc.Calls() != calls {
+		t.Errorf("rpc calls after cache hit = %d, want %d", rpc.Calls(), calls)
+	}
+
+	// 未 prepare 的 condition 返回错误且不缓存
+	notPrepared := ethcommon.HexToHash("0x04").Hex()
+	for range 2 {
+		if _, err := c.GetOutcomeSlotCount(t.Context(), notPrepared); err == nil || !strings.Contains(err.Error(), "not prepared") {
+			t.Errorf("not prepared err = %v", err)
+		}
+	}
+	if rpc.Calls() != calls+2 {
+		t.Errorf("rpc calls for unprepared condition = %d, want 2 (not cached)", rpc.Calls()-calls)
+	}
+}
+
+// positionFunc 将函数适配为 PositionFetcher
+type positionFunc func(ctx context.Context, params *common.PositionQueryParams) ([]common.Position, error)
+
+func (f positionFunc) GetPositions(ctx context.Context, params *common.PositionQueryParams) ([]common.Position, error) {
+	return f(ctx, params)
+}
+
+func TestRedeemAll(t *testing.T) {
+	cond := func(i int) string { return fmt.Sprintf("0x%064x", i) }
+	// 第一页 500 条：条件 1..21 可赎回（条件 1 含两个结果），其余不可赎回；
+	// 第二页：NegRisk 条件 22 可赎回，数量为 0 或缺少 conditionId 的持仓被忽略
+	var page1 []common.Position
+	for i := 1; i <= 21; i++ {
+		page1 = append(page1, common.Position{ConditionID: cond(i), Size: 10, Redeemable: true})
+	}
+	page1 = append(page1, common.Position{ConditionID: cond(1), Size: 5, OutcomeIndex: 1, Redeemable: true})
+	for len(page1) < redeemPositionsPageSize {
+		page1 = append(page1, common.Position{ConditionID: cond(1000 + len(page1)), Size: 10})
+	}
+	page2 := []common.Position{
+		{ConditionID: cond(22), Size: 3, Redeemable: true, NegativeRisk: true},
+		{ConditionID: cond(22), Size: 6, OutcomeIndex: 1, Redeemable: true, NegativeRisk: true},
+		{ConditionID: cond(23), Size: 0, Redeemable: true},
+		{ConditionID: "", Size: 4, Redeemable: true},
+	}
+
+	var offsets []int
+	fetcher := positionFunc(func(ctx context.Context, p *common.PositionQueryParams) ([]common.Position, error) {
+		if p.User != "0xuser" || p.SizeThreshold != "0" || p.Limit != redeemPositionsPageSize {
+			t.Errorf("position params = %+v", p)
+		}
+		offsets = append(offsets, p.Offset)
+		if p.Offset == 0 {
+			return page1, nil
+		}
+		return page2, nil
+	})
+
+	var (
+		mu      sync.Mutex
+		nonce   int64
+		submits []SafeTransactionRequest
+		polls   int
+	)
+	relayer := safeRelayer(func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return nonce
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var req SafeTransactionRequest
+		decodeBody(t, r, &req)
+		mu.Lock()
+		submits = append(submits, req)
+		nonce++
+		id := fmt.Sprintf("tx-%d", len(submits))
+		mu.Unlock()
+		writeJSON(w, http.StatusOK, Response{TransactionID: id, State: string(StateNew)})
+	})
+	relayer.HandleFunc("GET /transaction", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		polls++
+		mu.Unlock()
+		writeJSON(w, http.StatusOK, []any{map[string]any{"transactionID": r.URL.Query().Get("id"), "state": string(StateConfirmed)}})
+	})
+	rpc := &fakeRPC{call: func(to ethcommon.Address, data []byte) ([]byte, error) {
+		return ethcommon.LeftPadBytes([]byte{2}, 32), nil
+	}}
+	c := newTestClient(t, relayer, rpc, TxTypeSafe)
+
+	redeemed, results, err := c.RedeemAll(t.Context(), fetcher, "0xuser")
+	if err != nil {
+		t.Fatalf("RedeemAll: %v", err)
+	}
+	if len(offsets) != 2 || offsets[0] != 0 || offsets[1] != redeemPositionsPageSize {
+		t.Errorf("position offsets = %v, want [0 %d]", offsets, redeemPositionsPageSize)
+	}
+
+	// 只赎回可赎回的 22 个 condition，按 conditionId 排序，每 RedeemAllBatchSize 个一笔
+	if len(redeemed) != 22 {
+		t.Fatalf("redeemed = %d conditions, want 22", len(redeemed))
+	}
+	for i, id := range redeemed {
+		if id != cond(i+1) {
+			t.Errorf("redeemed[%d] = %s, want %s", i, id, cond(i+1))
+		}
+	}
+	if len(submits) != 2 || len(results) != 2 || results[0].TransactionID != "tx-1" || results[1].TransactionID != "tx-2" {
+		t.Fatalf("submits = %d, results = %+v, want 2 batches", len(submits), results)
+	}
+	if submits[0].Nonce != "0" || submits[1].Nonce != "1" {
+		t.Errorf("nonces = %s, %s, want 0, 1", submits[0].Nonce, submits[1].Nonce)
+	}
+	// 第一笔落定后才提交第二笔，最后一笔不等待
+	if polls != 1 {
+		t.Errorf("transaction polls = %d, want 1", polls)
+	}
+	data := strings.ToLower(submits[0].Data + submits[1].Data)
+	for _, id := range []string{cond(1), cond(20), cond(21), cond(22)} {
+		if !strings.Contains(data, id[2:]) {
+			t.Errorf("submitted data missing condition %s", id)
+		}
+	}
+	for _, id := range []string{cond(23), cond(1022), cond(1499)} {
+		if strings.Contains(data, id[2:]) {
+			t.Errorf("submitted data contains non-redeemable condition %s", id)
+		}
+	}
+	// 只有 CTF condition 查询 outcome slot count
+	if rpc.Calls() != 21 {
+		t.Errorf("outcome slot count queries = %d, want 21", rpc.Calls())
+	}
+}
+
+func TestRedeemAllNothingToRedeem(t *testing.T) {
+	var users []string
+	fetcher := positionFunc(func(ctx context.Context, p *common.PositionQueryParams) ([]common.Position, error) {
+		users = append(users, p.User)
+		return []common.Position{{ConditionID: "0x01", Size: 1}}, nil
+	})
+	relayer := safeRelayer(func() int64 { return 0 }, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected submit")
+	})
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+
+	// user 为空时查询代理钱包的持仓
+	redeemed, results, err := c.RedeemAll(t.Context(), fetcher, "")
+	if err != nil || len(redeemed) != 0 || len(results) != 0 {
+		t.Errorf("RedeemAll = %v, %v, %v, want empty", redeemed, results, err)
+	}
+	if len(users) != 1 || users[0] != c.proxyAddress.Hex() {
+		t.Errorf("users = %v, want proxy address %s", users, c.proxyAddress.Hex())
+	}
+
+	failing := positionFunc(func(ctx context.Context, p *common.PositionQueryParams) ([]common.Position, error) {
+		return nil, errors.New("data api down")
+	})
+	if _, _, err := c.RedeemAll(t.Context(), failing, "0xuser"); err == nil {
+		t.Error("RedeemAll should fail when positions cannot be fetched")
+	}
+}
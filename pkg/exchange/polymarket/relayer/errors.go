@@ -0,0 +1,131 @@
+package relayer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error Relayer 错误响应
+type Error struct {
+	StatusCode int    // HTTP 状态码
+	Code       string // 错误码（若响应中包含）
+	Message    string // 错误信息
+	Body       string // 原始响应体
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("relayer HTTP %d [%s]: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("relayer HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// parseError 解析 Relayer 错误响应体
+// 兼容 {"error": "..."}、{"message": "..."}、{"code": "...", "error": "..."} 及纯文本
+func parseError(statusCode int, body []byte) *Error {
+	e := &Error{StatusCode: statusCode, Body: string(body)}
+
+	var payload struct {
+		Code    json.RawMessage `json:"code"`
+		Error   string          `json:"error"`
+		Message string          `json:"message"`
+		Reason  string          `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		e.Message = strings.TrimSpace(string(body))
+		return e
+	}
+
+	if len(payload.Code) > 0 {
+		e.Code = strings.Trim(string(payload.Code), `"`)
+	}
+	switch {
+	case payload.Error != "":
+		e.Message = payload.Error
+	case payload.Message != "":
+		e.Message = payload.Message
+	case payload.Reason != "":
+		e.Message = payload.Reason
+	default:
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// AsError 从错误链中提取 *Error
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+func (e *Error) contains(keywords ...string) bool {
+	text := strings.ToLower(e.Code + " " + e.Message)
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNonceError 是否为 nonce 相关错误（nonce 过期或冲突，通常重新获取 nonce 后重试即可）
+func IsNonceError(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.contains("nonce")
+}
+
+// IsNotDeployed 是否为代理钱包未部署错误
+func IsNotDeployed(err error) bool {
+	if errors.Is(err, ErrNotDeployed) {
+		return true
+	}
+	e, ok := AsError(err)
+	return ok && e.contains("not deployed", "not_deployed", "undeployed")
+}
+
+// IsSignatureError 是否为签名错误
+func IsSignatureError(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.contains("signature", "signer")
+}
+
+// IsSponsorshipError 是否为 gas 赞助额度不足错误
+func IsSponsorshipError(err error) bool {
+	e, ok := AsError(err)
+	return ok && e.contains("sponsor", "insufficient gas", "quota", "gas limit")
+}
+
+// IsUnauthorized 是否为 Builder 认证失败
+func IsUnauthorized(err error) bool {
+	e, ok := AsError(err)
+	return ok && (e.StatusCode == 401 || e.StatusCode == 403)
+}
+
+// ErrNotDeployed 代理钱包未部署
+var ErrNotDeployed = errors.New("Safe not deployed, call Deploy() first")
+
+// ErrAlreadyDeployed 代理钱包已部署
+var ErrAlreadyDeployed = errors.New("Safe already deployed")
+
+// ErrProxyAutoDeploy PROXY 钱包无需单独部署
+var ErrProxyAutoDeploy = errors.New("PROXY wallet is deployed automatically on first transaction")
+
+// TransactionFailedError Relayer 交易最终失败（STATE_FAILED 或 STATE_INVALID）
+type TransactionFailedError struct {
+	TransactionID string
+	Hash          string
+	State         TransactionState
+	Reason        string
+}
+
+func (e *TransactionFailedError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("relayer transaction %s %s: %s", e.TransactionID, e.State, e.Reason)
+	}
+	return fmt.Sprintf("relayer transaction %s %s", e.TransactionID, e.State)
+}
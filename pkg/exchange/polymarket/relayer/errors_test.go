@@ -0,0 +1,115 @@
+package relayer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{"error field", 400, `{"error":"invalid signature"}`, "", "invalid signature"},
+		{"message field", 400, `{"message":"nonce too low"}`, "", "nonce too low"},
+		{"string code", 400, `{"code":"NONCE_EXPIRED","error":"nonce expired"}`, "NONCE_EXPIRED", "nonce expired"},
+		{"numeric code", 429, `{"code":429,"reason":"quota exceeded"}`, "429", "quota exceeded"},
+		{"plain text", 502, "bad gateway\n", "", "bad gateway"},
+		{"unknown json", 500, `{"detail":"boom"}`, "", `{"detail":"boom"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := parseError(tt.status, []byte(tt.body))
+			if e.StatusCode != tt.status || e.Code != tt.wantCode || e.Message != tt.wantMessage {
+				t.Errorf("parseError = {%d %q %q}, want {%d %q %q}", e.StatusCode, e.Code, e.Message, tt.status, tt.wantCode, tt.wantMessage)
+			}
+			if e.Body != tt.body {
+				t.Errorf("Body = %q, want raw body", e.Body)
+			}
+		})
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want map[string]bool
+	}{
+		{"nonce", parseError(400, []byte(`{"error":"invalid nonce: expected 5"}`)), map[string]bool{"nonce": true}},
+		{"not deployed body", parseError(400, []byte(`{"code":"SAFE_NOT_DEPLOYED","error":"safe not deployed"}`)), map[string]bool{"notDeployed": true}},
+		{"not deployed sentinel", fmt.Errorf("execute: %w", ErrNotDeployed), map[string]bool{"notDeployed": true}},
+		{"signature", parseError(400, []byte(`{"error":"invalid signature"}`)), map[string]bool{"signature": true}},
+		{"sponsorship", parseError(400, []byte(`{"error":"sponsor quota exhausted"}`)), map[string]bool{"sponsorship": true}},
+		{"unauthorized", parseError(401, []byte(`{"error":"invalid builder key"}`)), map[string]bool{"unauthorized": true}},
+		{"wrapped", fmt.Errorf("submit: %w", parseError(403, []byte(`{"error":"forbidden"}`))), map[string]bool{"unauthorized": true}},
+		{"plain error", errors.New("nonce"), map[string]bool{}},
+	}
+	predicates := map[string]func(error) bool{
+		"nonce":        IsNonceError,
+		"notDeployed":  IsNotDeployed,
+		"signature":    IsSignatureError,
+		"sponsorship":  IsSponsorshipError,
+		"unauthorized": IsUnauthorized,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, fn := range predicates {
+				if got := fn(tt.err); got != tt.want[name] {
+					t.Errorf("%s(%v) = %v, want %v", name, tt.err, got, tt.want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteReturnsRelayerError(t *testing.T) {
+	relayer := safeRelayer(func() int64 { return 7 }, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"code": "NONCE_EXPIRED", "error": "nonce already used"})
+	})
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+
+	_, err := c.ApproveUSDCForCTF(t.Context())
+	e, ok := AsError(err)
+	if !ok {
+		t.Fatalf("error %v is not a *relayer.Error", err)
+	}
+	if e.StatusCode != http.StatusBadRequest || e.Code != "NONCE_EXPIRED" {
+		t.Errorf("error = %+v", e)
+	}
+	if !IsNonceError(err) {
+		t.Errorf("IsNonceError(%v) = false", err)
+	}
+}
+
+func TestDeployReturnsRelayerError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deployed", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, DeployedResponse{Deployed: false})
+	})
+	mux.HandleFunc("POST /submit", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid signature for signer"})
+	})
+	c := newTestClient(t, mux, nil, TxTypeSafe)
+
+	if _, err := c.Deploy(t.Context()); !IsSignatureError(err) {
+		t.Fatalf("Deploy error = %v, want signature error", err)
+	}
+}
+
+func TestExecuteNotDeployed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deployed", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, DeployedResponse{Deployed: false})
+	})
+	c := newTestClient(t, mux, nil, TxTypeSafe)
+
+	if _, err := c.ApproveUSDCForCTF(t.Context()); !IsNotDeployed(err) {
+		t.Fatalf("error = %v, want not deployed", err)
+	}
+}
@@ -0,0 +1,113 @@
+package relayer
+
+import (
+	"fmt"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// GasParams Safe 交易 gas 参数
+// 默认全部为 0，即由 Relayer 全额赞助 gas；赞助不可用时可自行指定，参数会一并参与签名
+// PROXY 钱包仅使用 GasPrice，其余字段非零时返回错误
+type GasParams struct {
+	SafeTxGas      string // safeTxGas (十进制字符串)
+	BaseGas        string // baseGas (十进制字符串)
+	GasPrice       string // gasPrice (十进制字符串)
+	GasToken       string // 支付 gas 的代币地址，零地址表示原生代币
+	RefundReceiver string // gas 退款接收地址，零地址表示 tx.origin
+}
+
+// ExecuteOption 交易执行选项
+type ExecuteOption func(*executeOptions)
+
+type executeOptions struct {
+	gas GasParams
+}
+
+// WithGasParams 指定 gas 参数（覆盖默认的全额赞助参数）
+func WithGasParams(gas GasParams) ExecuteOption {
+	return func(o *executeOptions) { o.gas = gas }
+}
+
+// newExecuteOptions 应用选项并校验 gas 参数
+func newExecuteOptions(opts []ExecuteOption) (executeOptions, error) {
+	var o executeOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	gas, err := o.gas.withDefaults().normalize()
+	if err != nil {
+		return o, err
+	}
+	o.gas = gas
+	return o, nil
+}
+
+// withDefaults 填充未设置的字段
+func (g GasParams) withDefaults() GasParams {
+	zeroAddr := ethcommon.Address{}.Hex()
+	if g.SafeTxGas == "" {
+		g.SafeTxGas = "0"
+	}
+	if g.BaseGas == "" {
+		g.BaseGas = "0"
+	}
+	if g.GasPrice == "" {
+		g.GasPrice = "0"
+	}
+	if g.GasToken == "" {
+		g.GasToken = zeroAddr
+	}
+	if g.RefundReceiver == "" {
+		g.RefundReceiver = zeroAddr
+	}
+	return g
+}
+
+// normalize 校验各字段并转换为规范格式（十进制整数、校验和地址），
+// 避免无法解析的值在签名时被当作 0
+func (g GasParams) normalize() (GasParams, error) {
+	var err error
+	if g.SafeTxGas, err = parseGasUint("safeTxGas", g.SafeTxGas); err != nil {
+		return g, err
+	}
+	if g.BaseGas, err = parseGasUint("baseGas", g.BaseGas); err != nil {
+		return g, err
+	}
+	if g.GasPrice, err = parseGasUint("gasPrice", g.GasPrice); err != nil {
+		return g, err
+	}
+	if g.GasToken, err = parseGasAddress("gasToken", g.GasToken); err != nil {
+		return g, err
+	}
+	if g.RefundReceiver, err = parseGasAddress("refundReceiver", g.RefundReceiver); err != nil {
+		return g, err
+	}
+	return g, nil
+}
+
+// safeOnly 是否设置了仅 Safe 交易支持的字段（需先 normalize）
+func (g GasParams) safeOnly() bool {
+	zeroAddr := ethcommon.Address{}.Hex()
+	return g.SafeTxGas != "0" || g.BaseGas != "0" || g.GasToken != zeroAddr || g.RefundReceiver != zeroAddr
+}
+
+// parseGasUint 解析十进制 uint256
+func parseGasUint(name, v string) (string, error) {
+	n, ok := new(big.Int).SetString(v, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return "", fmt.Errorf("invalid gas param %s: %q (want decimal uint256)", name, v)
+	}
+	return n.String(), nil
+}
+
+// parseGasAddress 解析 hex 地址
+func parseGasAddress(name, v string) (string, error) {
+	if !ethcommon.IsHexAddress(v) {
+		return "", fmt.Errorf("invalid gas param %s: %q (want hex address)", name, v)
+	}
+	return ethcommon.HexToAddress(v).Hex(), nil
+}
@@ -0,0 +1,135 @@
+package relayer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+func TestSafeTxHashIncludesGasParams(t *testing.T) {
+	to, data := "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", "0x095ea7b3"
+	zero := ethcommon.Address{}
+	token := ethcommon.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174")
+	base := createSafeTxHash(to, "0", data, 0, "0", "0", "0", zero, zero, 5)
+
+	tests := []struct {
+		name string
+		hash []byte
+	}{
+		{"safeTxGas", createSafeTxHash(to, "0", data, 0, "100000", "0", "0", zero, zero, 5)},
+		{"baseGas", createSafeTxHash(to, "0", data, 0, "0", "21000", "0", zero, zero, 5)},
+		{"gasPrice", createSafeTxHash(to, "0", data, 0, "0", "0", "30000000000", zero, zero, 5)},
+		{"gasToken", createSafeTxHash(to, "0", data, 0, "0", "0", "0", token, zero, 5)},
+		{"refundReceiver", createSafeTxHash(to, "0", data, 0, "0", "0", "0", zero, token, 5)},
+	}
+	for _, tt := range tests {
+		if bytes.Equal(tt.hash, base) {
+			t.Errorf("%s does not change the safe tx hash", tt.name)
+		}
+	}
+}
+
+func TestWithGasParamsSignedAndSubmitted(t *testing.T) {
+	gas := GasParams{
+		SafeTxGas:      "100000",
+		BaseGas:        "21000",
+		GasPrice:       "30000000000",
+		GasToken:       "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174",
+		RefundReceiver: "0x000000000000000000000000000000000000dEaD",
+	}
+	var got []SafeTransactionRequest
+	relayer := safeRelayer(func() int64 { return 3 }, func(w http.ResponseWriter, r *http.Request) {
+		var req SafeTransactionRequest
+		decodeBody(t, r, &req)
+		got = append(got, req)
+		writeJSON(w, http.StatusOK, Response{TransactionID: "tx", State: string(StateNew)})
+	})
+	c := newTestClient(t, relayer, nil, TxTypeSafe)
+
+	if _, err := c.ApproveUSDCForCTF(t.Context(), WithGasParams(gas)); err != nil {
+		t.Fatalf("ApproveUSDCForCTF: %v", err)
+	}
+	c.ResyncNonce()
+	if _, err := c.ApproveUSDCForCTF(t.Context()); err != nil {
+		t.Fatalf("ApproveUSDCForCTF: %v", err)
+	}
+
+	withGas, sponsored := got[0], got[1]
+	p := withGas.SignatureParams
+	if p.SafeTxnGas != gas.SafeTxGas || p.BaseGas != gas.BaseGas || p.GasPrice != gas.GasPrice || p.GasToken != gas.GasToken || p.RefundReceiver != gas.RefundReceiver {
+		t.Errorf("signature params = %+v, want %+v", p, gas)
+	}
+	if sp := sponsored.SignatureParams; sp.SafeTxnGas != "0" || sp.GasPrice != "0" || sp.GasToken != (ethcommon.Address{}).Hex() {
+		t.Errorf("default signature params = %+v, want zero gas", sp)
+	}
+	if withGas.Nonce != sponsored.Nonce {
+		t.Fatalf("nonces differ: %s vs %s", withGas.Nonce, sponsored.Nonce)
+	}
+	if withGas.Signature == sponsored.Signature {
+		t.Error("signature does not depend on gas params")
+	}
+
+	want, err := c.signSafeTransaction(withGas.To, withGas.Data, 3, OperationTypeCall, gas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withGas.Signature != want {
+		t.Errorf("signature = %s, want %s", withGas.Signature, want)
+	}
+}
+
+func TestWithGasParamsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		gas  GasParams
+	}{
+		{"exponent", GasParams{GasPrice: "1e9"}},
+		{"hex number", GasParams{SafeTxGas: "0x10"}},
+		{"negative", GasParams{BaseGas: "-1"}},
+		{"overflow", GasParams{GasPrice: "115792089237316195423570985008687907853269984665640564039457584007913129639936"}},
+		{"short address", GasParams{GasToken: "0x1234"}},
+		{"not hex address", GasParams{RefundReceiver: "dead"}},
+	}
+	// 参数无效时不应发出任何请求
+	relayer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	rpc := &fakeRPC{}
+	c := newTestClient(t, relayer, rpc, TxTypeSafe)
+	for _, tt := range tests {
+		if _, err := c.ApproveUSDCForCTF(t.Context(), WithGasParams(tt.gas)); err == nil {
+			t.Errorf("%s: ApproveUSDCForCTF(%+v) should fail", tt.name, tt.gas)
+		}
+	}
+	if rpc.calls != 0 {
+		t.Errorf("rpc calls = %d, want 0", rpc.calls)
+	}
+
+	// 规范化：前导零和小写地址
+	gas, err := GasParams{GasPrice: "007", GasToken: "0x2791bca1f2de4661ed88a30c99a7a9449aa84174"}.withDefaults().normalize()
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if gas.GasPrice != "7" || gas.GasToken != "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174" {
+		t.Errorf("normalized = %+v", gas)
+	}
+}
+
+func TestProxyRejectsSafeOnlyGasParams(t *testing.T) {
+	relayer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	c := newTestClient(t, relayer, nil, TxTypeProxy)
+	for _, gas := range []GasParams{
+		{SafeTxGas: "100000"},
+		{BaseGas: "21000"},
+		{GasToken: "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"},
+		{RefundReceiver: "0x000000000000000000000000000000000000dEaD"},
+	} {
+		if _, err := c.ApproveUSDCForCTF(t.Context(), WithGasParams(gas)); err == nil {
+			t.Errorf("PROXY with %+v should fail", gas)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package relayer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// ProxyDefaultGasLimit PROXY 交易 gas 估算失败时使用的默认 gasLimit（与官方 SDK 一致）
+const ProxyDefaultGasLimit = 10_000_000
+
+// Proxy 调用类型（ProxyWalletFactory.proxy 的 typeCode）
+const (
+	proxyCallTypeCall         uint8 = 1
+	proxyCallTypeDelegateCall uint8 = 2
+)
+
+// proxyFactoryABI ProxyWalletFactory.proxy((uint8,address,uint256,bytes)[])
+const proxyFactoryABI = `[{"name":"proxy","type":"function","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"typeCode","type":"uint8"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}]}],"outputs":[{"name":"returnValues","type":"bytes[]"}]}]`
+
+// ProxySignatureParams PROXY 交易签名参数
+type ProxySignatureParams struct {
+	GasPrice   string `json:"gasPrice"`
+	GasLimit   string `json:"gasLimit"`
+	RelayerFee string `json:"relayerFee"`
+	RelayHub   string `json:"relayHub"`
+	Relay      string `json:"relay"`
+}
+
+// ProxyTransactionRequest PROXY 交易请求
+type ProxyTransactionRequest struct {
+	From            string               `json:"from"`
+	To              string               `json:"to"`
+	ProxyWallet     string               `json:"proxyWallet"`
+	Data            string               `json:"data"`
+	Nonce           string               `json:"nonce"`
+	Signature       string               `json:"signature"`
+	SignatureParams ProxySignatureParams `json:"signatureParams"`
+	Type            string               `json:"type"`
+	Metadata        string               `json:"metadata"`
+}
+
+// RelayPayload 中继地址及 PROXY nonce
+type RelayPayload struct {
+	Address string      `json:"address"`
+	Nonce   json.Number `json:"nonce"`
+}
+
+// proxyCall ProxyWalletFactory.proxy 的单个调用
+type proxyCall struct {
+	TypeCode uint8
+	To       ethcommon.Address
+	Value    *big.Int
+	Data     []byte
+}
+
+// getRelayPayload 获取中继地址和 PROXY nonce
+func (c *Client) getRelayPayload(ctx context.Context) (*RelayPayload, error) {
+	path := fmt.Sprintf("/relay-payload?address=%s&type=PROXY", c.address.Hex())
+	respBody, err := c.getWithAuth(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("get relay payload: %w", err)
+	}
+
+	var payload RelayPayload
+	if err := json.Unmarshal(respBody, &payload); err != nil {
+		return nil, fmt.Errorf("unmarshal relay payload: %w", err)
+	}
+	if payload.Address == "" {
+		return nil, fmt.Errorf("relay payload missing relay address")
+	}
+	return &payload, nil
+}
+
+// executeProxy 通过 ProxyWalletFactory 执行 PROXY 钱包交易（首次调用时工厂会自动部署钱包）
+// gas 参数仅使用 GasPrice
+func (c *Client) executeProxy(ctx context.Context, txns []SafeTransaction, metadata string, options executeOptions) (*common.TransactionResult, error) {
+	if options.gas.safeOnly() {
+		return nil, fmt.Errorf("PROXY wallet supports only GasPrice; safeTxGas, baseGas, gasToken and refundReceiver must be unset")
+	}
+
+	payload, err := c.getRelayPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encodeProxyTransactionData(txns)
+	if err != nil {
+		return nil, fmt.Errorf("encode proxy data: %w", err)
+	}
+
+	factory := ethcommon.HexToAddress(common.ContractProxyWalletFactory)
+	gasLimit := uint64(ProxyDefaultGasLimit)
+	if estimated, err := c.ethClient.EstimateGas(ctx, ethereum.CallMsg{From: c.address, To: &factory, Data: data}); err == nil && estimated > 0 {
+		gasLimit = estimated
+	}
+
+	req := buildProxyTransactionRequest(c.address, c.proxyAddress, data, payload, options.gas.GasPrice, gasLimit, metadata)
+	signature, err := signProxyTransaction(c.privateKey, req)
+	if err != nil {
+		return nil, fmt.Errorf("sign transaction: %w", err)
+	}
+	req.Signature = signature
+
+	bodyBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	return c.submit(ctx, bodyBytes)
+}
+
+// buildProxyTransactionRequest 构造未签名的 PROXY 交易请求
+func buildProxyTransactionRequest(from, proxyWallet ethcommon.Address, data []byte, payload *RelayPayload, gasPrice string, gasLimit uint64, metadata string) *ProxyTransactionRequest {
+	return &ProxyTransactionRequest{
+		From:        from.Hex(),
+		To:          common.ContractProxyWalletFactory,
+		ProxyWallet: proxyWallet.Hex(),
+		Data:        "0x" + hex.EncodeToString(data),
+		Nonce:       payload.Nonce.String(),
+		SignatureParams: ProxySignatureParams{
+			GasPrice:   gasPrice,
+			GasLimit:   fmt.Sprintf("%d", gasLimit),
+			RelayerFee: "0",
+			RelayHub:   common.ContractRelayHub,
+			Relay:      payload.Address,
+		},
+		Type:     string(TxTypeProxy),
+		Metadata: metadata,
+	}
+}
+
+// encodeProxyTransactionData 编码 ProxyWalletFactory.proxy 调用数据
+func encodeProxyTransactionData(txns []SafeTransaction) ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(proxyFactoryABI))
+	if err != nil {
+		return nil, fmt.Errorf("parse abi: %w", err)
+	}
+
+	calls := make([]proxyCall, 0, len(txns))
+	for _, txn := range txns {
+		typeCode := proxyCallTypeCall
+		if txn.Operation == OperationTypeDelegateCall {
+			typeCode = proxyCallTypeDelegateCall
+		}
+		value, ok := new(big.Int).SetString(txn.Value, 10)
+		if !ok {
+			value = new(big.Int)
+		}
+		calls = append(calls, proxyCall{
+			TypeCode: typeCode,
+			To:       ethcommon.HexToAddress(txn.To),
+			Value:    value,
+			Data:     ethcommon.FromHex(txn.Data),
+		})
+	}
+	return parsed.Pack("proxy", calls)
+}
+
+// createProxyStructHash 创建 PROXY 交易哈希（GSN 格式）
+// keccak256("rlx:" ‖ from ‖ to ‖ data ‖ relayerFee ‖ gasPrice ‖ gasLimit ‖ nonce ‖ relayHub ‖ relay)
+func createProxyStructHash(req *ProxyTransactionRequest) ([]byte, error) {
+	uint256 := func(name, v string) ([]byte, error) {
+		n, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: %q", name, v)
+		}
+		return ethcommon.LeftPadBytes(n.Bytes(), 32), nil
+	}
+
+	relayerFee, err := uint256("relayerFee", req.SignatureParams.RelayerFee)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := uint256("gasPrice", req.SignatureParams.GasPrice)
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := uint256("gasLimit", req.SignatureParams.GasLimit)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := uint256("nonce", req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256(
+		[]byte("rlx:"),
+		ethcommon.HexToAddress(req.From).Bytes(),
+		ethcommon.HexToAddress(req.To).Bytes(),
+		ethcommon.FromHex(req.Data),
+		relayerFee,
+		gasPrice,
+		gasLimit,
+		nonce,
+		ethcommon.HexToAddress(req.SignatureParams.RelayHub).Bytes(),
+		ethcommon.HexToAddress(req.SignatureParams.Relay).Bytes(),
+	), nil
+}
+
+// signProxyTransaction 签名 PROXY 交易（personal_sign，v ∈ {27, 28}）
+func signProxyTransaction(privateKey *ecdsa.PrivateKey, req *ProxyTransactionRequest) (string, error) {
+	structHash, err := createProxyStructHash(req)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(structHash))
+	messageHash := crypto.Keccak256(
+		[]byte(prefix),
+		structHash,
+	)
+
+	sig, err := crypto.Sign(messageHash, privateKey)
+	if err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+
+	sig = common.NormalizeSignatureV(sig, common.VSchemeEthereum)
+	return "0x" + hex.EncodeToString(sig), nil
+}
@@ -0,0 +1,39 @@
+package polymarket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// GammaEventSource ResolveEvent 所需的 Gamma 接口（gamma.Client 实现了该接口）
+type GammaEventSource interface {
+	ListMarkets(ctx context.Context, params *common.MarketQueryParams) ([]common.Market, error)
+	GetEventByID(ctx context.Context, id string) (*common.Event, error)
+	GetEventBySlug(ctx context.Context, slug string) (*common.Event, error)
+}
+
+// ResolveEvent 根据 CLOB condition ID 获取所属的 Gamma 事件（含标题、标签、结束时间等）
+func ResolveEvent(ctx context.Context, gammaClient GammaEventSource, conditionID string) (*common.Event, error) {
+	if conditionID == "" {
+		return nil, fmt.Errorf("condition id is required")
+	}
+
+	markets, err := gammaClient.ListMarkets(ctx, &common.MarketQueryParams{ConditionIDs: conditionID, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("resolve market [%s]: %w", conditionID, err)
+	}
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("market not found: %s", conditionID)
+	}
+	market := markets[0]
+
+	switch {
+	case len(market.Events) > 0 && market.Events[0].ID != "":
+		return gammaClient.GetEventByID(ctx, market.Events[0].ID)
+	case market.EventSlug != "":
+		return gammaClient.GetEventBySlug(ctx, market.EventSlug)
+	}
+	return nil, fmt.Errorf("no event for market: %s", conditionID)
+}
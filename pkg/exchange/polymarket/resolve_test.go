@@ -0,0 +1,87 @@
+package polymarket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/gamma"
+)
+
+// newGammaStub 创建指向测试 Gamma 服务的客户端：/markets 按 condition_ids 返回 markets 中的市场
+func newGammaStub(t *testing.T, markets map[string]common.Market, events map[string]common.Event) *gamma.Client {
+	t.Helper()
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /markets", func(w http.ResponseWriter, r *http.Request) {
+		result := []common.Market{}
+		if m, ok := markets[r.URL.Query().Get("condition_ids")]; ok {
+			result = append(result, m)
+		}
+		writeJSON(w, result)
+	})
+	mux.HandleFunc("GET /events/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ev, ok := events[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, ev)
+	})
+	mux.HandleFunc("GET /events/slug/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		for _, ev := range events {
+			if ev.Slug == r.PathValue("slug") {
+				writeJSON(w, ev)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := gamma.NewClient(gamma.ClientConfig{BaseURL: srv.URL})
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestResolveEvent(t *testing.T) {
+	events := map[string]common.Event{
+		"100": {ID: "100", Slug: "fed-decision", Title: "Fed decision", EndDate: "2025-12-10T00:00:00Z"},
+		"200": {ID: "200", Slug: "btc-150k", Title: "BTC $150k"},
+	}
+	markets := map[string]common.Market{
+		"0xbyid":   {ConditionID: "0xbyid", Events: []common.Event{{ID: "100"}}},
+		"0xbyslug": {ConditionID: "0xbyslug", EventSlug: "btc-150k"},
+		"0xorphan": {ConditionID: "0xorphan"},
+		"0xgone":   {ConditionID: "0xgone", Events: []common.Event{{ID: "999"}}},
+	}
+	c := newGammaStub(t, markets, events)
+
+	tests := []struct {
+		conditionID string
+		wantTitle   string
+		wantErr     bool
+	}{
+		{"0xbyid", "Fed decision", false},
+		{"0xbyslug", "BTC $150k", false},
+		{"0xorphan", "", true},
+		{"0xgone", "", true},
+		{"0xmissing", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		ev, err := ResolveEvent(t.Context(), c, tt.conditionID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.conditionID, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && ev.Title != tt.wantTitle {
+			t.Errorf("%q: title = %q, want %q", tt.conditionID, ev.Title, tt.wantTitle)
+		}
+	}
+}
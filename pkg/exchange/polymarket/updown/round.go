@@ -0,0 +1,210 @@
+package updown
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// Period 轮次周期
+type Period string
+
+const (
+	Period15m   Period = "15m"
+	Period1h    Period = "1h"
+	Period4h    Period = "4h"
+	PeriodDaily Period = "daily"
+)
+
+// SymbolFullNames daily 市场 slug 使用的币种全称
+var SymbolFullNames = map[string]string{
+	"btc": "bitcoin", "eth": "ethereum", "sol": "solana", "xrp": "xrp",
+}
+
+// Duration 周期时长
+func (p Period) Duration() time.Duration {
+	return common.PeriodDuration(string(p))
+}
+
+// DefaultStartGracePeriod 轮次开始后仍可订阅的默认宽限期
+const DefaultStartGracePeriod = 10 * time.Second
+
+// ShouldSkipToNextRound 轮次已开始超过 grace 时返回 true（恰好等于 grace 不跳过，尚未开始也不跳过）
+func ShouldSkipToNextRound(now, roundStart time.Time, grace time.Duration) bool {
+	return now.Sub(roundStart) > grace
+}
+
+// SubscribeRoundStart 计算 now 时应订阅的轮次开始时间
+// 当前轮次已开始超过 grace，或距结束不足 grace（周期短于两倍 grace 时可能发生）时返回下一轮
+func SubscribeRoundStart(period Period, now time.Time, grace time.Duration) time.Time {
+	start := RoundStart(period, now)
+	end := start.Add(period.Duration())
+	if ShouldSkipToNextRound(now, start, grace) || end.Sub(now) < grace {
+		return end
+	}
+	return start
+}
+
+// Round Up/Down 轮次
+type Round struct {
+	Slug        string
+	UpTokenID   string
+	DownTokenID string
+	StartTime   time.Time
+	EndTime     time.Time
+	Event       *common.Event
+}
+
+// RoundStart 计算 t 所在轮次的开始时间 (UTC 对齐)
+func RoundStart(period Period, t time.Time) time.Time {
+	start, _ := common.RoundWindow(string(period), t)
+	return start
+}
+
+// Slug 生成指定开始时间的轮次 slug（start 会先对齐到所在轮次开始）
+// 15m/1h/4h: <symbol>-updown-<period>-<unix>；daily: <全称>-up-or-down-on-<month>-<day>
+func Slug(symbol string, period Period, start time.Time) string {
+	start = RoundStart(period, start)
+	if period == PeriodDaily {
+		name := SymbolFullNames[symbol]
+		if name == "" {
+			name = symbol
+		}
+		return fmt.Sprintf("%s-up-or-down-on-%s-%d", name, strings.ToLower(start.Month().String()), start.Day())
+	}
+	return fmt.Sprintf("%s-updown-%s-%d", symbol, period, start.Unix())
+}
+
+// ParseSlug 解析轮次 slug，返回币种简称、周期和轮次开始时间 (UTC)
+// daily slug 不含年份，取距当前时间最近的年份
+func ParseSlug(slug string) (symbol string, period Period, start time.Time, err error) {
+	return parseSlugAt(slug, time.Now())
+}
+
+func parseSlugAt(slug string, now time.Time) (symbol string, period Period, start time.Time, err error) {
+	if name, date, ok := strings.Cut(slug, "-up-or-down-on-"); ok {
+		symbol = name
+		for short, full := range SymbolFullNames {
+			if full == name {
+				symbol = short
+				break
+			}
+		}
+		monthName, dayStr, ok := strings.Cut(date, "-")
+		if !ok {
+			return "", "", time.Time{}, fmt.Errorf("invalid daily slug %q", slug)
+		}
+		month, ok := parseMonth(monthName)
+		if !ok {
+			return "", "", time.Time{}, fmt.Errorf("invalid month in slug %q", slug)
+		}
+		day, convErr := strconv.Atoi(dayStr)
+		if convErr != nil || day < 1 || day > 31 {
+			return "", "", time.Time{}, fmt.Errorf("invalid day in slug %q", slug)
+		}
+		now = now.UTC()
+		start = time.Date(now.Year(), month, day, 0, 0, 0, 0, time.UTC)
+		if start.Sub(now) > 183*24*time.Hour {
+			start = start.AddDate(-1, 0, 0)
+		} else if now.Sub(start) > 183*24*time.Hour {
+			start = start.AddDate(1, 0, 0)
+		}
+		return symbol, PeriodDaily, start, nil
+	}
+
+	parts := strings.Split(slug, "-")
+	if len(parts) != 4 || parts[1] != "updown" {
+		return "", "", time.Time{}, fmt.Errorf("invalid updown slug %q", slug)
+	}
+	period = Period(parts[2])
+	if _, ok := common.PeriodDurations[parts[2]]; !ok || period == PeriodDaily {
+		return "", "", time.Time{}, fmt.Errorf("unsupported period in slug %q", slug)
+	}
+	ts, convErr := strconv.ParseInt(parts[3], 10, 64)
+	if convErr != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid timestamp in slug %q", slug)
+	}
+	return parts[0], period, time.Unix(ts, 0).UTC(), nil
+}
+
+// parseMonth 解析英文月份全称（不区分大小写）
+func parseMonth(name string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.EqualFold(m.String(), name) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// EventFetcher 按 slug 获取事件（gamma.Client 实现了该接口）
+type EventFetcher interface {
+	GetEventBySlug(ctx context.Context, slug string) (*common.Event, error)
+}
+
+// FetchRound 获取指定开始时间的轮次信息
+func FetchRound(ctx context.Context, fetcher EventFetcher, symbol string, period Period, start time.Time) (*Round, error) {
+	slug := Slug(symbol, period, start)
+	event, err := fetcher.GetEventBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("get event [%s]: %w", slug, err)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	market := &event.Markets[0]
+	if err := market.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids, _ := market.ParsedTokenIDs()
+	endTime, _ := common.ParseDate(event.EndDate)
+
+	return &Round{
+		Slug:        slug,
+		UpTokenID:   ids[0],
+		DownTokenID: ids[1],
+		StartTime:   start,
+		EndTime:     endTime,
+		Event:       event,
+	}, nil
+}
+
+// Liquidity 轮次市场的 CLOB 流动性（Gamma liquidityClob，缺失时使用 liquidity）
+func (r *Round) Liquidity() float64 {
+	if r.Event == nil || len(r.Event.Markets) == 0 {
+		return 0
+	}
+	market := &r.Event.Markets[0]
+	if liquidity := market.LiquidityClob.Float64(); liquidity > 0 {
+		return liquidity
+	}
+	return market.Liquidity.Float64()
+}
+
+// SelectRound 从 from 所在轮次开始依次检查 n 个轮次，返回第一个流动性不低于 minLiquidity 的轮次
+// 尚未创建或流动性不足的轮次会被跳过，全部不满足时返回错误
+func SelectRound(ctx context.Context, fetcher EventFetcher, symbol string, period Period, from time.Time, n int, minLiquidity float64) (*Round, error) {
+	start := RoundStart(period, from)
+	var lastErr error
+	for i := 0; i < n; i++ {
+		round, err := FetchRound(ctx, fetcher, symbol, period, start.Add(time.Duration(i)*period.Duration()))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if liquidity := round.Liquidity(); liquidity < minLiquidity {
+			lastErr = fmt.Errorf("round %s liquidity %.2f below %.2f", round.Slug, liquidity, minLiquidity)
+			continue
+		}
+		return round, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no rounds checked")
+	}
+	return nil, fmt.Errorf("select round: %w", lastErr)
+}
@@ -0,0 +1,201 @@
+package updown
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// eventFunc 将函数适配为 EventFetcher
+type eventFunc func(ctx context.Context, slug string) (*common.Event, error)
+
+func (f eventFunc) GetEventBySlug(ctx context.Context, slug string) (*common.Event, error) {
+	return f(ctx, slug)
+}
+
+func TestFetchRoundIncompletePayload(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	slug := Slug("btc", Period15m, start)
+	end := start.Add(15 * time.Minute)
+
+	tests := []struct {
+		name   string
+		mutate func(e *common.Event)
+	}{
+		{"no markets", func(e *common.Event) { e.Markets = nil }},
+		{"missing token ids", func(e *common.Event) { e.Markets[0].ClobTokenIds = "" }},
+		{"single token id", func(e *common.Event) { e.Markets[0].ClobTokenIds = `["up"]` }},
+		{"missing condition id", func(e *common.Event) { e.Markets[0].ConditionID = "" }},
+		{"empty event end date", func(e *common.Event) { e.EndDate = "" }},
+		{"empty market end date", func(e *common.Event) { e.Markets[0].EndDate = "" }},
+	}
+	for _, tt := range tests {
+		fetcher := eventFunc(func(ctx context.Context, s string) (*common.Event, error) {
+			e := testEvent(s, end, 0)
+			tt.mutate(e)
+			return e, nil
+		})
+		if round, err := FetchRound(t.Context(), fetcher, "btc", Period15m, start); err == nil {
+			t.Errorf("%s: expected error, got round %+v", tt.name, round)
+		}
+	}
+
+	round, err := FetchRound(t.Context(), eventFunc(func(ctx context.Context, s string) (*common.Event, error) {
+		return testEvent(s, end, 0), nil
+	}), "btc", Period15m, start)
+	if err != nil {
+		t.Fatalf("FetchRound: %v", err)
+	}
+	if round.UpTokenID != "up-"+slug || round.DownTokenID != "down-"+slug || !round.EndTime.Equal(end) {
+		t.Errorf("round = %+v", round)
+	}
+}
+
+func TestSelectRound(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	slug := func(i int) string { return Slug("btc", Period15m, base.Add(time.Duration(i)*15*time.Minute)) }
+	fetcher := &stubFetcher{
+		symbol: "btc", period: Period15m, from: base, until: base.Add(time.Hour),
+		liquidity: map[string]float64{slug(0): 50, slug(1): 5000, slug(2): 20000},
+	}
+	from := base.Add(7 * time.Minute)
+
+	// 当前轮次流动性不足，选中下一轮
+	round, err := SelectRound(t.Context(), fetcher, "btc", Period15m, from, 3, 1000)
+	if err != nil {
+		t.Fatalf("SelectRound: %v", err)
+	}
+	if round.Slug != slug(1) || round.Liquidity() != 5000 {
+		t.Errorf("selected %s (liquidity %v), want %s", round.Slug, round.Liquidity(), slug(1))
+	}
+
+	// 阈值为 0 时不过滤
+	if round, err := SelectRound(t.Context(), fetcher, "btc", Period15m, from, 3, 0); err != nil || round.Slug != slug(0) {
+		t.Errorf("SelectRound without threshold = %v, %v", round, err)
+	}
+
+	if _, err := SelectRound(t.Context(), fetcher, "btc", Period15m, from, 3, 50000); err == nil {
+		t.Error("expected error when no round meets the threshold")
+	}
+
+	// 获取失败的轮次被跳过
+	round, err = SelectRound(t.Context(), fetcher, "btc", Period15m, base.Add(-15*time.Minute), 2, 0)
+	if err != nil || round.Slug != slug(0) {
+		t.Errorf("SelectRound after missing round = %v, %v", round, err)
+	}
+	if _, err := SelectRound(t.Context(), fetcher, "btc", Period15m, base.Add(time.Hour), 2, 0); err == nil {
+		t.Error("expected error when no round exists")
+	}
+}
+
+func TestShouldSkipToNextRound(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	grace := 10 * time.Second
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"before start", start.Add(-time.Second), false},
+		{"at start", start, false},
+		{"within grace", start.Add(9 * time.Second), false},
+		{"exactly grace", start.Add(grace), false},
+		{"just past grace", start.Add(grace + time.Millisecond), true},
+		{"late", start.Add(5 * time.Minute), true},
+	}
+	for _, tt := range tests {
+		if got := ShouldSkipToNextRound(tt.now, start, grace); got != tt.want {
+			t.Errorf("%s: ShouldSkipToNextRound = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribeRoundStart(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	next := start.Add(15 * time.Minute)
+	grace := DefaultStartGracePeriod
+	tests := []struct {
+		name   string
+		period Period
+		now    time.Time
+		grace  time.Duration
+		want   time.Time
+	}{
+		{"on boundary", Period15m, start, grace, start},
+		{"exactly grace", Period15m, start.Add(grace), grace, start},
+		{"past grace", Period15m, start.Add(grace + time.Second), grace, next},
+		{"near end", Period15m, next.Add(-time.Second), grace, next},
+		// 宽限期超过半个周期时，开始不久的轮次也可能距结束不足 grace
+		{"ends within grace", Period15m, start.Add(time.Minute), 14*time.Minute + time.Second, next},
+		{"1h within grace", Period1h, start.Add(5 * time.Second), grace, start},
+	}
+	for _, tt := range tests {
+		if got := SubscribeRoundStart(tt.period, tt.now, tt.grace); !got.Equal(tt.want) {
+			t.Errorf("%s: SubscribeRoundStart = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseSlug(t *testing.T) {
+	now := time.Date(2025, 3, 5, 8, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		slug       string
+		now        time.Time
+		wantSymbol string
+		wantPeriod Period
+		wantStart  time.Time
+	}{
+		{"btc-updown-15m-1740830400", now, "btc", Period15m, start},
+		{"eth-updown-1h-1740830400", now, "eth", Period1h, start},
+		{"sol-updown-4h-1740830400", now, "sol", Period4h, start},
+		{"bitcoin-up-or-down-on-march-1", now, "btc", PeriodDaily, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"xrp-up-or-down-on-March-4", now, "xrp", PeriodDaily, time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"doge-up-or-down-on-may-5", now, "doge", PeriodDaily, time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)},
+		// 年份取距 now 最近的一年
+		{"ethereum-up-or-down-on-december-31", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), "eth", PeriodDaily, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"solana-up-or-down-on-january-2", time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC), "sol", PeriodDaily, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		symbol, period, got, err := parseSlugAt(tt.slug, tt.now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.slug, err)
+			continue
+		}
+		if symbol != tt.wantSymbol || period != tt.wantPeriod || !got.Equal(tt.wantStart) || got.Location() != time.UTC {
+			t.Errorf("%s = %s, %s, %v, want %s, %s, %v", tt.slug, symbol, period, got, tt.wantSymbol, tt.wantPeriod, tt.wantStart)
+		}
+	}
+
+	malformed := []string{
+		"",
+		"btc",
+		"btc-updown-15m",
+		"btc-updown-15m-1740830400-1",
+		"btc-up-15m-1740830400",
+		"btc-updown-5m-1740830400",
+		"btc-updown-daily-1740830400",
+		"btc-updown-15m-abc",
+		"bitcoin-up-or-down-on-march",
+		"bitcoin-up-or-down-on-marsh-1",
+		"bitcoin-up-or-down-on-march-0",
+		"bitcoin-up-or-down-on-march-32",
+		"bitcoin-up-or-down-on-march-1st",
+	}
+	for _, slug := range malformed {
+		if symbol, period, start, err := ParseSlug(slug); err == nil {
+			t.Errorf("ParseSlug(%q) = %s, %s, %v, want error", slug, symbol, period, start)
+		}
+	}
+
+	// 与 Slug 互逆
+	for _, period := range []Period{Period15m, Period1h, Period4h} {
+		slug := Slug("btc", period, start.Add(7*time.Minute))
+		symbol, got, gotStart, err := ParseSlug(slug)
+		if err != nil || symbol != "btc" || got != period || !gotStart.Equal(RoundStart(period, start)) {
+			t.Errorf("ParseSlug(Slug(%s)) = %s, %s, %v, %v", period, symbol, got, gotStart, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package updown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WarmerConfig 轮次预取配置
+type WarmerConfig struct {
+	Symbol   string           // btc, eth, sol, xrp
+	Period   Period           // 15m, 1h, 4h, daily
+	Ahead    int              // 后台预取的轮次数 (默认 3)
+	Interval time.Duration    // 后台预取间隔 (默认 周期/3，最长 1 分钟)
+	Now      func() time.Time // 时钟 (默认 time.Now)
+}
+
+// Warmer 轮次信息预取缓存
+// 提前若干周期获取轮次的事件和 token ID 并按 slug 缓存，使轮次切换时无需再请求 Gamma
+type Warmer struct {
+	fetcher EventFetcher
+	config  WarmerConfig
+
+	mu    sync.RWMutex
+	cache map[string]*Round
+}
+
+// NewWarmer 创建轮次预取缓存
+func NewWarmer(fetcher EventFetcher, cfg WarmerConfig) *Warmer {
+	if cfg.Period == "" {
+		cfg.Period = Period15m
+	}
+	if cfg.Ahead <= 0 {
+		cfg.Ahead = 3
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = min(cfg.Period.Duration()/3, time.Minute)
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Warmer{
+		fetcher: fetcher,
+		config:  cfg,
+		cache:   make(map[string]*Round),
+	}
+}
+
+// Prefetch 预取从当前轮次开始的 n 个轮次（已缓存的跳过），同时清理已结束的轮次
+// 尚未创建的轮次会返回错误，但不影响其他轮次的预取
+func (w *Warmer) Prefetch(ctx context.Context, n int) error {
+	start := RoundStart(w.config.Period, w.config.Now())
+	w.evict(start)
+
+	var errs []error
+	for i := 0; i < n; i++ {
+		roundStart := start.Add(time.Duration(i) * w.config.Period.Duration())
+		if _, ok := w.Get(Slug(w.config.Symbol, w.config.Period, roundStart)); ok {
+			continue
+		}
+		round, err := FetchRound(ctx, w.fetcher, w.config.Symbol, w.config.Period, roundStart)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.put(round)
+	}
+	return errors.Join(errs...)
+}
+
+// Run 后台定期预取，直到 ctx 取消
+func (w *Warmer) Run(ctx context.Context) {
+	w.Prefetch(ctx, w.config.Ahead)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Prefetch(ctx, w.config.Ahead)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Get 按 slug 获取缓存的轮次
+func (w *Warmer) Get(slug string) (*Round, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	round, ok := w.cache[slug]
+	return round, ok
+}
+
+// Round 获取指定开始时间的轮次，未命中缓存时实时获取并缓存
+func (w *Warmer) Round(ctx context.Context, start time.Time) (*Round, error) {
+	if round, ok := w.Get(Slug(w.config.Symbol, w.config.Period, start)); ok {
+		return round, nil
+	}
+	round, err := FetchRound(ctx, w.fetcher, w.config.Symbol, w.config.Period, start)
+	if err != nil {
+		return nil, err
+	}
+	w.put(round)
+	return round, nil
+}
+
+func (w *Warmer) put(round *Round) {
+	w.mu.Lock()
+	w.cache[round.Slug] = round
+	w.mu.Unlock()
+}
+
+// evict 清理在 before 之前已结束的轮次
+func (w *Warmer) evict(before time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for slug, round := range w.cache {
+		if !round.EndTime.After(before) {
+			delete(w.cache, slug)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package updown
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// stubFetcher 测试用 EventFetcher：为 [from, until) 内开始的轮次生成事件，记录请求的 slug
+type stubFetcher struct {
+	symbol    string
+	period    Period
+	from      time.Time
+	until     time.Time          // 该时间及之后开始的轮次尚未创建
+	liquidity map[string]float64 // 按 slug 指定 liquidityClob
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *stubFetcher) GetEventBySlug(ctx context.Context, slug string) (*common.Event, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, slug)
+	f.mu.Unlock()
+
+	for start := f.from; start.Before(f.until); start = start.Add(f.period.Duration()) {
+		if Slug(f.symbol, f.period, start) == slug {
+			return testEvent(slug, start.Add(f.period.Duration()), f.liquidity[slug]), nil
+		}
+	}
+	return nil, fmt.Errorf("event %s not found", slug)
+}
+
+func (f *stubFetcher) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// testEvent 构造包含一个二元市场的事件
+func testEvent(slug string, end time.Time, liquidity float64) *common.Event {
+	endDate := end.UTC().Format(time.RFC3339)
+	return &common.Event{
+		Slug:    slug,
+		EndDate: endDate,
+		Markets: []common.Market{{
+			Slug:          slug,
+			ConditionID:   "0xcond-" + slug,
+			ClobTokenIds:  fmt.Sprintf(`["up-%s","down-%s"]`, slug, slug),
+			EndDate:       endDate,
+			LiquidityClob: common.FlexString(fmt.Sprint(liquidity)),
+		}},
+	}
+}
+
+func TestWarmerPrefetchAhead(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := base.Add(5 * time.Minute)
+	fetcher := &stubFetcher{symbol: "btc", period: Period15m, from: base.Add(-time.Hour), until: base.Add(2 * time.Hour)}
+	w := NewWarmer(fetcher, WarmerConfig{Symbol: "btc", Period: Period15m, Now: func() time.Time { return now }})
+
+	if err := w.Prefetch(t.Context(), 3); err != nil {
+		t.Fatalf("Prefetch: %v", err)
+	}
+	want := []string{
+		Slug("btc", Period15m, base),
+		Slug("btc", Period15m, base.Add(15*time.Minute)),
+		Slug("btc", Period15m, base.Add(30*time.Minute)),
+	}
+	if got := fetcher.Requests(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("requests = %v, want %v", got, want)
+	}
+
+	// 轮次切换时直接命中缓存，不再请求 Gamma
+	round, err := w.Round(t.Context(), base.Add(15*time.Minute))
+	if err != nil {
+		t.Fatalf("Round: %v", err)
+	}
+	if round.UpTokenID != "up-"+want[1] || round.DownTokenID != "down-"+want[1] {
+		t.Errorf("round tokens = %s/%s", round.UpTokenID, round.DownTokenID)
+	}
+	if n := len(fetcher.Requests()); n != 3 {
+		t.Errorf("Round issued a request: %d requests", n)
+	}
+
+	// 时钟前进一个周期：已缓存的轮次跳过，只获取新进入窗口的一轮，已结束的轮次被清理
+	now = now.Add(15 * time.Minute)
+	if err := w.Prefetch(t.Context(), 3); err != nil {
+		t.Fatalf("Prefetch: %v", err)
+	}
+	requests := fetcher.Requests()
+	if len(requests) != 4 || requests[3] != Slug("btc", Period15m, base.Add(45*time.Minute)) {
+		t.Errorf("requests after advance = %v", requests)
+	}
+	if _, ok := w.Get(want[0]); ok {
+		t.Errorf("ended round %s not evicted", want[0])
+	}
+}
+
+func TestWarmerPrefetchMissingRound(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := &stubFetcher{symbol: "eth", period: Period1h, from: base, until: base.Add(time.Hour)}
+	w := NewWarmer(fetcher, WarmerConfig{Symbol: "eth", Period: Period1h, Now: func() time.Time { return base }})
+
+	if err := w.Prefetch(t.Context(), 2); err == nil {
+		t.Fatal("expected error for round that does not exist yet")
+	}
+	if _, ok := w.Get(Slug("eth", Period1h, base)); !ok {
+		t.Error("existing round not cached after partial failure")
+	}
+}
+
+func TestWarmerRunPrefetchesOnStart(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := &stubFetcher{symbol: "sol", period: Period15m, from: base, until: base.Add(time.Hour)}
+	w := NewWarmer(fetcher, WarmerConfig{Symbol: "sol", Period: Period15m, Ahead: 2, Interval: time.Hour, Now: func() time.Time { return base }})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	deadline := time.After(2 * time.Second)
+	for len(fetcher.Requests()) < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("Run did not prefetch")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+	if _, ok := w.Get(Slug("sol", Period15m, base.Add(15*time.Minute))); !ok {
+		t.Error("next round not cached by Run")
+	}
+}
@@ -0,0 +1,60 @@
+package wss
+
+import (
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// EnableTradeBatching 开启成交批量推送（仅 User 频道）
+// 开启后成交不再逐条推送到 TradeCh，而是按 window 时间窗口聚合后推送到 TradeBatchCh，去重逻辑不变
+func (c *Connection) EnableTradeBatching(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tradeBatchCh != nil {
+		return
+	}
+	c.tradeBatchCh = make(chan []*common.TradeNotification, c.config.ChannelBufferSize)
+	go c.flushTradeBatches(window)
+}
+
+// TradeBatchCh 获取成交批量推送 Channel（需先调用 EnableTradeBatching）
+func (c *Connection) TradeBatchCh() <-chan []*common.TradeNotification {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tradeBatchCh
+}
+
+// batchTrade 将成交加入当前批次，未开启批量推送时返回 false
+func (c *Connection) batchTrade(trade *common.TradeNotification) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tradeBatchCh == nil {
+		return false
+	}
+	c.pendingTrades = append(c.pendingTrades, trade)
+	return true
+}
+
+func (c *Connection) flushTradeBatches(window time.Duration) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			batch := c.pendingTrades
+			c.pendingTrades = nil
+			c.mu.Unlock()
+			if len(batch) == 0 {
+				continue
+			}
+			send(c, c.tradeBatchCh, batch)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wss
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestTradeBatching(t *testing.T) {
+	next := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		// 第一轮快速推送 4 笔成交（t2 重复一次），收到第一批后再推送第二轮
+		for _, id := range []string{"t1", "t2", "t2", "t3", "t4"} {
+			writeEvent(t, conn, map[string]any{"event_type": "trade", "id": id, "status": "MATCHED", "size": "1", "price": "0.5"})
+		}
+		<-next
+		for _, id := range []string{"t5", "t6", "t1"} {
+			writeEvent(t, conn, map[string]any{"event_type": "trade", "id": id, "status": "MATCHED", "size": "1", "price": "0.5"})
+		}
+		<-next
+	}}
+	c := newTestWSClient(t, srv)
+	conn := c.CreateUserConnection(common.WssAuth{APIKey: "key", Secret: "secret", Passphrase: "pass"}, nil)
+	conn.EnableTradeBatching(50 * time.Millisecond)
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(next)
+
+	// collect 接收批次直到累计 want 笔成交
+	collect := func(want int) [][]string {
+		var batches [][]string
+		for total := 0; total < want; {
+			select {
+			case batch := <-conn.TradeBatchCh():
+				var ids []string
+				for _, tr := range batch {
+					ids = append(ids, tr.ID)
+				}
+				batches = append(batches, ids)
+				total += len(ids)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("batches = %v, want %d trades", batches, want)
+			}
+		}
+		return batches
+	}
+
+	first := collect(4)
+	if len(first) >= 4 {
+		t.Errorf("first burst delivered one at a time: %v", first)
+	}
+	if got := fmt.Sprint(flatten(first)); got != "[t1 t2 t3 t4]" {
+		t.Errorf("first burst = %s, want [t1 t2 t3 t4]", got)
+	}
+
+	next <- struct{}{}
+	second := collect(2)
+	if got := fmt.Sprint(flatten(second)); got != "[t5 t6]" {
+		t.Errorf("second burst = %s, want [t5 t6] (t1 deduplicated)", got)
+	}
+
+	select {
+	case tr := <-conn.TradeCh():
+		t.Errorf("trade %s delivered individually while batching", tr.ID)
+	case batch := <-conn.TradeBatchCh():
+		t.Errorf("unexpected extra batch %v", batch)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func flatten(batches [][]string) []string {
+	var out []string
+	for _, b := range batches {
+		out = append(out, b...)
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package wss
+
+import (
+	"sync"
+)
+
+// BinaryQuote 二元市场组合报价
+// 合成价格基于 YES + NO = 1：买 YES 等价于卖 NO，反之亦然
+type BinaryQuote struct {
+	YesTokenID string
+	NoTokenID  string
+
+	YesBid, YesBidSize float64
+	YesAsk, YesAskSize float64
+	NoBid, NoBidSize   float64
+	NoAsk, NoAskSize   float64
+
+	// 合成价格（由对侧订单簿推导）
+	SyntheticYesBid float64 // 1 - NoAsk
+	SyntheticYesAsk float64 // 1 - NoBid
+	SyntheticNoBid  float64 // 1 - YesAsk
+	SyntheticNoAsk  float64 // 1 - YesBid
+
+	AskSum float64 // YesAsk + NoAsk，< 1 表示可同时买入两侧套利
+	BidSum float64 // YesBid + NoBid，> 1 表示可同时卖出两侧套利
+}
+
+// BestYesBid 直接与合成买价中的较优者
+func (q BinaryQuote) BestYesBid() float64 { return max(q.YesBid, q.SyntheticYesBid) }
+
+// BestYesAsk 直接与合成卖价中的较优者（0 表示无报价）
+func (q BinaryQuote) BestYesAsk() float64 { return minPositive(q.YesAsk, q.SyntheticYesAsk) }
+
+// BestNoBid 直接与合成买价中的较优者
+func (q BinaryQuote) BestNoBid() float64 { return max(q.NoBid, q.SyntheticNoBid) }
+
+// BestNoAsk 直接与合成卖价中的较优者（0 表示无报价）
+func (q BinaryQuote) BestNoAsk() float64 { return minPositive(q.NoAsk, q.SyntheticNoAsk) }
+
+// BinaryConnection 同时订阅二元市场 YES/NO 两个 token 的连接
+type BinaryConnection struct {
+	*Connection
+	yesBook *LocalBook
+	noBook  *LocalBook
+
+	mu      sync.RWMutex
+	onQuote func(BinaryQuote)
+}
+
+// CreateBinaryMarketConnection 创建二元市场连接，自动订阅 YES/NO 两侧并维护本地订单簿
+// 注意：内部会消费 BookCh/PriceChangeCh，请通过 OnQuote、BinaryQuote 或 YesBook/NoBook 获取数据
+func (c *Client) CreateBinaryMarketConnection(yesTokenID, noTokenID string) *BinaryConnection {
+	if yesTokenID == "" || noTokenID == "" {
+		return nil
+	}
+	conn := c.CreateMarketConnection([]string{yesTokenID, noTokenID})
+	bc := &BinaryConnection{
+		Connection: conn,
+		yesBook:    conn.TrackBook(yesTokenID),
+		noBook:     conn.TrackBook(noTokenID),
+	}
+	go bc.consume()
+	return bc
+}
+
+// OnQuote 设置报价更新回调（任一侧订单簿变化时触发）
+func (b *BinaryConnection) OnQuote(fn func(BinaryQuote)) {
+	b.mu.Lock()
+	b.onQuote = fn
+	b.mu.Unlock()
+}
+
+// YesBook 获取 YES 本地订单簿
+func (b *BinaryConnection) YesBook() *LocalBook { return b.yesBook }
+
+// NoBook 获取 NO 本地订单簿
+func (b *BinaryConnection) NoBook() *LocalBook { return b.noBook }
+
+// BinaryQuote 获取当前组合报价
+func (b *BinaryConnection) BinaryQuote() BinaryQuote {
+	return NewBinaryQuote(b.yesBook, b.noBook)
+}
+
+// NewBinaryQuote 由 YES/NO 两个本地订单簿计算组合报价
+func NewBinaryQuote(yesBook, noBook *LocalBook) BinaryQuote {
+	q := BinaryQuote{YesTokenID: yesBook.AssetID(), NoTokenID: noBook.AssetID()}
+	q.YesBid, q.YesBidSize = yesBook.GetBestBid()
+	q.YesAsk, q.YesAskSize = yesBook.GetBestAsk()
+	q.NoBid, q.NoBidSize = noBook.GetBestBid()
+	q.NoAsk, q.NoAskSize = noBook.GetBestAsk()
+
+	if q.NoAsk > 0 {
+		q.SyntheticYesBid = 1 - q.NoAsk
+	}
+	if q.NoBid > 0 {
+		q.SyntheticYesAsk = 1 - q.NoBid
+	}
+	if q.YesAsk > 0 {
+		q.SyntheticNoBid = 1 - q.YesAsk
+	}
+	if q.YesBid > 0 {
+		q.SyntheticNoAsk = 1 - q.YesBid
+	}
+	if q.YesAsk > 0 && q.NoAsk > 0 {
+		q.AskSum = q.YesAsk + q.NoAsk
+	}
+	if q.YesBid > 0 && q.NoBid > 0 {
+		q.BidSum = q.YesBid + q.NoBid
+	}
+	return q
+}
+
+// consume 消费订单簿推送并触发报价回调（本地订单簿已由连接读循环更新）
+func (b *BinaryConnection) consume() {
+	for {
+		select {
+		case _, ok := <-b.bookCh:
+			if !ok {
+				return
+			}
+			b.emit()
+		case _, ok := <-b.priceChangeCh:
+			if !ok {
+				return
+			}
+			b.emit()
+		case <-b.stopCh:
+			return
+		}
+	}
+}
+
+func (b *BinaryConnection) emit() {
+	b.mu.RLock()
+	fn := b.onQuote
+	b.mu.RUnlock()
+	if fn != nil {
+		fn(b.BinaryQuote())
+	}
+}
+
+func minPositive(a, b float64) float64 {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	}
+	return min(a, b)
+}
@@ -0,0 +1,80 @@
+package wss
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// snapshot 构造测试快照，levels 依次为 price, size
+func snapshot(assetID string, bids, asks []string) *common.OrderBookSnapshot {
+	s := &common.OrderBookSnapshot{AssetID: assetID}
+	for i := 0; i+1 < len(bids); i += 2 {
+		s.Bids = append(s.Bids, common.OrderBookLevel{Price: bids[i], Size: bids[i+1]})
+	}
+	for i := 0; i+1 < len(asks); i += 2 {
+		s.Asks = append(s.Asks, common.OrderBookLevel{Price: asks[i], Size: asks[i+1]})
+	}
+	return s
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestNewBinaryQuote(t *testing.T) {
+	yes := NewLocalBook("yes")
+	no := NewLocalBook("no")
+	yes.ApplySnapshot(snapshot("yes", []string{"0.40", "100", "0.39", "50"}, []string{"0.43", "80"}))
+	no.ApplySnapshot(snapshot("no", []string{"0.55", "60"}, []string{"0.58", "30", "0.60", "10"}))
+
+	q := NewBinaryQuote(yes, no)
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"YesBid", q.YesBid, 0.40},
+		{"YesBidSize", q.YesBidSize, 100},
+		{"YesAsk", q.YesAsk, 0.43},
+		{"NoBid", q.NoBid, 0.55},
+		{"NoAsk", q.NoAsk, 0.58},
+		{"SyntheticYesBid", q.SyntheticYesBid, 0.42},
+		{"SyntheticYesAsk", q.SyntheticYesAsk, 0.45},
+		{"SyntheticNoBid", q.SyntheticNoBid, 0.57},
+		{"SyntheticNoAsk", q.SyntheticNoAsk, 0.60},
+		{"AskSum", q.AskSum, 1.01},
+		{"BidSum", q.BidSum, 0.95},
+		{"BestYesBid", q.BestYesBid(), 0.42},
+		{"BestYesAsk", q.BestYesAsk(), 0.43},
+		{"BestNoBid", q.BestNoBid(), 0.57},
+		{"BestNoAsk", q.BestNoAsk(), 0.58},
+	}
+	for _, c := range checks {
+		if !approx(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// NO 侧卖单被吃掉后，合成 YES 买价随之消失，YES 买价回落到直接买单
+	no.ApplyPriceChange(&common.PriceChangeEvent{AssetID: "no", Side: "SELL", Price: "0.58", Size: "0"})
+	no.ApplyPriceChange(&common.PriceChangeEvent{AssetID: "no", Side: "SELL", Price: "0.60", Size: "0"})
+	q = NewBinaryQuote(yes, no)
+	if q.NoAsk != 0 || q.SyntheticYesBid != 0 || q.AskSum != 0 {
+		t.Errorf("after NO asks removed: NoAsk=%v SyntheticYesBid=%v AskSum=%v, want 0", q.NoAsk, q.SyntheticYesBid, q.AskSum)
+	}
+	if !approx(q.BestYesBid(), 0.40) {
+		t.Errorf("BestYesBid = %v, want 0.40", q.BestYesBid())
+	}
+	if !approx(q.SyntheticNoAsk, 0.60) {
+		t.Errorf("SyntheticNoAsk = %v, want 0.60", q.SyntheticNoAsk)
+	}
+}
+
+func TestNewBinaryQuoteEmptyBooks(t *testing.T) {
+	q := NewBinaryQuote(NewLocalBook("yes"), NewLocalBook("no"))
+	if q.BestYesAsk() != 0 || q.BestNoAsk() != 0 || q.AskSum != 0 || q.BidSum != 0 {
+		t.Errorf("empty books produced quote %+v", q)
+	}
+	if q.YesTokenID != "yes" || q.NoTokenID != "no" {
+		t.Errorf("token ids = %s/%s", q.YesTokenID, q.NoTokenID)
+	}
+}
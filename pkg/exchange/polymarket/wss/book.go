@@ -0,0 +1,360 @@
+package wss
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// maxPendingChanges 首个快照到达前最多缓存的增量数
+const maxPendingChanges = 1000
+
+// Level 订单簿价位
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// bookLevel 价位数量，raw 保留服务端原始字符串用于计算 hash
+type bookLevel struct {
+	size float64
+	raw  string
+}
+
+// LocalBook 单个 asset 的本地订单簿（由 book 快照和 price_change 增量维护）
+type LocalBook struct {
+	mu        sync.RWMutex
+	assetID   string
+	market    string
+	bids      map[string]bookLevel
+	asks      map[string]bookLevel
+	hash      string
+	timestamp string // 最近一次快照或增量的服务端 timestamp，参与 hash 计算
+	ready     bool
+	seeded    bool                       // 当前状态来自 REST 预加载，尚未收到 WebSocket 快照
+	desynced  bool                       // 最近一次校验的服务端 hash 与本地状态不一致
+	updated   time.Time                  // 最近一次快照或增量的本地时间
+	pending   []*common.PriceChangeEvent // 首个快照前到达的增量
+}
+
+// NewLocalBook 创建本地订单簿
+func NewLocalBook(assetID string) *LocalBook {
+	return &LocalBook{
+		assetID: assetID,
+		bids:    make(map[string]bookLevel),
+		asks:    make(map[string]bookLevel),
+	}
+}
+
+// AssetID 获取 asset ID
+func (b *LocalBook) AssetID() string { return b.assetID }
+
+// Ready 是否已收到快照
+func (b *LocalBook) Ready() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.ready
+}
+
+// Hash 最近一次快照或增量的订单簿 hash
+func (b *LocalBook) Hash() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.hash
+}
+
+// ApplySnapshot 应用全量快照，并按官方算法校验快照 hash（见 Desynced）
+// 快照前缓存的增量按 timestamp 对齐：不晚于快照的事件已包含在快照中，予以丢弃，晚于快照的事件继续应用；
+// 快照或增量缺少 timestamp 时无法对齐，以快照为准丢弃全部缓存
+// 预加载（Seed）的订单簿若 hash 与快照一致，说明本地状态已与快照相同，仅确认不重建
+func (b *LocalBook) ApplySnapshot(snapshot *common.OrderBookSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.seeded && snapshot.Hash != "" && snapshot.Hash == b.hash {
+		b.seeded = false
+		b.updated = time.Now()
+		return
+	}
+	b.seeded = false
+	b.desynced = !snapshot.VerifyHash()
+	b.applySnapshot(snapshot)
+}
+
+// Seed 以 REST 快照预加载订单簿，仅在尚未收到任何快照时生效，返回是否已应用
+// 预加载后增量直接应用；随后到达的首个 WebSocket 快照仍以全量覆盖（hash 一致时内容相同）
+// REST 快照的 hash 覆盖的字段与 WebSocket 不同，预加载时不做校验
+func (b *LocalBook) Seed(snapshot *common.OrderBookSnapshot) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ready {
+		return false
+	}
+	b.applySnapshot(snapshot)
+	b.seeded = true
+	return true
+}
+
+// Seeded 当前状态是否来自 REST 预加载（尚未被 WebSocket 快照确认）
+func (b *LocalBook) Seeded() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seeded
+}
+
+func (b *LocalBook) applySnapshot(snapshot *common.OrderBookSnapshot) {
+	b.bids = make(map[string]bookLevel, len(snapshot.Bids))
+	b.asks = make(map[string]bookLevel, len(snapshot.Asks))
+	for _, lvl := range snapshot.Bids {
+		setLevel(b.bids, lvl.Price, lvl.Size)
+	}
+	for _, lvl := range snapshot.Asks {
+		setLevel(b.asks, lvl.Price, lvl.Size)
+	}
+	b.market = snapshot.Market
+	b.hash = snapshot.Hash
+	b.timestamp = snapshot.Timestamp
+	b.ready = true
+	b.updated = time.Now()
+
+	pending := b.pending
+	b.pending = nil
+	snapTS, err := strconv.ParseInt(snapshot.Timestamp, 10, 64)
+	if err != nil {
+		return
+	}
+	for _, event := range pending {
+		if ts, err := strconv.ParseInt(event.Timestamp, 10, 64); err == nil && ts > snapTS {
+			b.applyChange(event)
+		}
+	}
+}
+
+// ApplyPriceChange 应用增量变化（size 为 0 表示删除该价位），首个快照前的增量会被缓存
+func (b *LocalBook) ApplyPriceChange(event *common.PriceChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.ready {
+		if len(b.pending) < maxPendingChanges {
+			b.pending = append(b.pending, event)
+		}
+		return
+	}
+	b.applyChange(event)
+}
+
+func (b *LocalBook) applyChange(event *common.PriceChangeEvent) {
+	if event.Side == "BUY" {
+		setLevel(b.bids, event.Price, event.Size)
+	} else {
+		setLevel(b.asks, event.Price, event.Size)
+	}
+	if event.Market != "" {
+		b.market = event.Market
+	}
+	if event.Timestamp != "" {
+		b.timestamp = event.Timestamp
+	}
+	if event.Hash != "" {
+		b.hash = event.Hash
+		b.desynced = b.contentHash() != event.Hash
+	}
+	b.updated = time.Now()
+}
+
+// Desynced 最近一次 book 快照或 price_change 携带的 hash 是否与本地状态不一致
+// 为 true 时说明丢失了增量或数据异常，应重新获取快照；下一次通过校验的快照会清除该状态
+func (b *LocalBook) Desynced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.desynced
+}
+
+// UpdatedAt 最近一次应用快照或增量的时间，未收到快照时为零值
+func (b *LocalBook) UpdatedAt() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.updated
+}
+
+// Snapshot 导出当前订单簿（bids 价格降序，asks 价格升序），未收到快照时返回 nil
+func (b *LocalBook) Snapshot() *common.OrderBookSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.ready {
+		return nil
+	}
+	return &common.OrderBookSnapshot{
+		AssetID: b.assetID,
+		Hash:    b.hash,
+		Bids:    toSortedBookLevels(b.bids, true),
+		Asks:    toSortedBookLevels(b.asks, false),
+	}
+}
+
+// GetBestBid 最优买价及数量，无买单时返回 0
+func (b *LocalBook) GetBestBid() (price, size float64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for p, s := range b.bids {
+		if pf, _ := strconv.ParseFloat(p, 64); pf > price {
+			price, size = pf, s.size
+		}
+	}
+	return
+}
+
+// GetBestAsk 最优卖价及数量，无卖单时返回 0
+func (b *LocalBook) GetBestAsk() (price, size float64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for p, s := range b.asks {
+		if pf, _ := strconv.ParseFloat(p, 64); price == 0 || pf < price {
+			price, size = pf, s.size
+		}
+	}
+	return
+}
+
+// ContentHash 按官方算法计算当前本地订单簿的 hash（market/timestamp 取最近一次快照或增量）
+func (b *LocalBook) ContentHash() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.contentHash()
+}
+
+func (b *LocalBook) contentHash() string {
+	return common.ComputeBookHash(b.market, b.assetID, b.timestamp, toSortedBookLevels(b.bids, false), toSortedBookLevels(b.asks, true))
+}
+
+// VerifyAgainst 校验本地订单簿与服务端 hash 是否一致，不一致说明丢失了 price_change，应重新获取快照
+func (b *LocalBook) VerifyAgainst(hash string) bool {
+	return b.ContentHash() == hash
+}
+
+// GetDepth 获取前 n 档深度（bids 价格降序，asks 价格升序），n <= 0 返回全部
+func (b *LocalBook) GetDepth(n int) (bids, asks []Level) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	bids = sortedLevels(b.bids, true, n)
+	asks = sortedLevels(b.asks, false, n)
+	return
+}
+
+func sortedLevels(levels map[string]bookLevel, desc bool, n int) []Level {
+	result := make([]Level, 0, len(levels))
+	for p, s := range levels {
+		pf, _ := strconv.ParseFloat(p, 64)
+		result = append(result, Level{Price: pf, Size: s.size})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if desc {
+			return result[i].Price > result[j].Price
+		}
+		return result[i].Price < result[j].Price
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+func toSortedBookLevels(levels map[string]bookLevel, desc bool) []common.OrderBookLevel {
+	result := toBookLevels(levels)
+	sortBookLevels(result, desc)
+	return result
+}
+
+// sortBookLevels 按价格排序（desc 为降序）
+func sortBookLevels(levels []common.OrderBookLevel, desc bool) {
+	sort.SliceStable(levels, func(i, j int) bool {
+		pi, _ := strconv.ParseFloat(levels[i].Price, 64)
+		pj, _ := strconv.ParseFloat(levels[j].Price, 64)
+		if desc {
+			return pi > pj
+		}
+		return pi < pj
+	})
+}
+
+func toBookLevels(levels map[string]bookLevel) []common.OrderBookLevel {
+	result := make([]common.OrderBookLevel, 0, len(levels))
+	for p, s := range levels {
+		result = append(result, common.OrderBookLevel{Price: p, Size: s.raw})
+	}
+	return result
+}
+
+func setLevel(levels map[string]bookLevel, price, size string) {
+	s, err := strconv.ParseFloat(size, 64)
+	if err != nil || s == 0 {
+		delete(levels, price)
+		return
+	}
+	levels[price] = bookLevel{size: s, raw: size}
+}
+
+// TrackBook 在连接内部维护指定 asset 的本地订单簿（仅 Market 频道）
+// 返回的订单簿由读循环自动更新，可在任意 goroutine 中直接读取；BookCh/PriceChangeCh 推送不受影响
+func (c *Connection) TrackBook(assetID string) *LocalBook {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.books == nil {
+		c.books = make(map[string]*LocalBook)
+	}
+	if book, ok := c.books[assetID]; ok {
+		return book
+	}
+	book := NewLocalBook(assetID)
+	c.books[assetID] = book
+	return book
+}
+
+// BooksFetcher 通过 REST 批量获取订单簿快照（clob.Client.FetchBookSnapshots 满足该签名）
+type BooksFetcher func(ctx context.Context, tokenIDs []string) ([]*common.OrderBookSnapshot, error)
+
+// Bootstrap 通过 REST 预加载所有已订阅 asset 的本地订单簿，避免首个 WebSocket 快照到达前最优价为 0
+// 已收到 WebSocket 快照的订单簿不会被覆盖；预加载的快照同样推送到 BookCh
+// 返回实际预加载的订单簿数量
+func (c *Connection) Bootstrap(ctx context.Context, fetch BooksFetcher) (int, error) {
+	assets := c.SubscribedAssets()
+	if len(assets) == 0 {
+		return 0, nil
+	}
+	for _, assetID := range assets {
+		c.TrackBook(assetID)
+	}
+
+	snapshots, err := fetch(ctx, assets)
+	if err != nil {
+		return 0, fmt.Errorf("bootstrap books: %w", err)
+	}
+
+	seeded := 0
+	for _, snapshot := range snapshots {
+		local := c.trackedBook(snapshot.AssetID)
+		if local == nil || !local.Seed(snapshot) {
+			continue
+		}
+		seeded++
+		send(c, c.bookCh, snapshot)
+	}
+	return seeded, nil
+}
+
+// UntrackBook 停止维护指定 asset 的本地订单簿
+func (c *Connection) UntrackBook(assetID string) {
+	c.mu.Lock()
+	delete(c.books, assetID)
+	c.mu.Unlock()
+}
+
+func (c *Connection) trackedBook(assetID string) *LocalBook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.books[assetID]
+}
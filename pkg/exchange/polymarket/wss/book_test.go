@@ -0,0 +1,285 @@
+package wss
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/clob"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// levels 按 price, size 成对构造订单簿价位
+func levels(ps ...string) []common.OrderBookLevel {
+	var out []common.OrderBookLevel
+	for i := 0; i+1 < len(ps); i += 2 {
+		out = append(out, common.OrderBookLevel{Price: ps[i], Size: ps[i+1]})
+	}
+	return out
+}
+
+func TestLocalBookDeletes(t *testing.T) {
+	book := NewLocalBook("a")
+	book.ApplySnapshot(&common.OrderBookSnapshot{
+		AssetID:   "a",
+		Timestamp: "1000",
+		Bids:      levels("0.48", "100", "0.47", "50", "0.45", "10"),
+		Asks:      levels("0.50", "80", "0.52", "20", "0.55", "5"),
+	})
+	if p, s := book.GetBestBid(); p != 0.48 || s != 100 {
+		t.Fatalf("best bid = %v@%v, want 0.48@100", p, s)
+	}
+	if p, s := book.GetBestAsk(); p != 0.50 || s != 80 {
+		t.Fatalf("best ask = %v@%v, want 0.50@80", p, s)
+	}
+
+	steps := []struct {
+		change           common.PriceChangeEvent
+		wantBid, wantAsk float64
+	}{
+		{common.PriceChangeEvent{Side: "BUY", Price: "0.48", Size: "0"}, 0.47, 0.50},
+		{common.PriceChangeEvent{Side: "SELL", Price: "0.50", Size: "0"}, 0.47, 0.52},
+		{common.PriceChangeEvent{Side: "BUY", Price: "0.47", Size: "0"}, 0.45, 0.52},
+		{common.PriceChangeEvent{Side: "SELL", Price: "0.52", Size: "0"}, 0.45, 0.55},
+		{common.PriceChangeEvent{Side: "SELL", Price: "0.60", Size: "0"}, 0.45, 0.55}, // 删除不存在的价位
+		{common.PriceChangeEvent{Side: "BUY", Price: "0.45", Size: "0"}, 0, 0.55},
+		{common.PriceChangeEvent{Side: "SELL", Price: "0.55", Size: "0"}, 0, 0},
+	}
+	for i, step := range steps {
+		book.ApplyPriceChange(&step.change)
+		bid, _ := book.GetBestBid()
+		ask, _ := book.GetBestAsk()
+		if bid != step.wantBid || ask != step.wantAsk {
+			t.Errorf("step %d (%s %s): best = %v/%v, want %v/%v", i, step.change.Side, step.change.Price, bid, ask, step.wantBid, step.wantAsk)
+		}
+	}
+	if bids, asks := book.GetDepth(0); len(bids) != 0 || len(asks) != 0 {
+		t.Errorf("depth after deletes = %v/%v, want empty", bids, asks)
+	}
+}
+
+func TestLocalBookDepth(t *testing.T) {
+	book := NewLocalBook("a")
+	book.ApplySnapshot(&common.OrderBookSnapshot{
+		Bids: levels("0.45", "10", "0.48", "100", "0.47", "50"),
+		Asks: levels("0.55", "5", "0.50", "80", "0.52", "20"),
+	})
+	bids, asks := book.GetDepth(2)
+	if len(bids) != 2 || bids[0] != (Level{0.48, 100}) || bids[1] != (Level{0.47, 50}) {
+		t.Errorf("bids = %v", bids)
+	}
+	if len(asks) != 2 || asks[0] != (Level{0.50, 80}) || asks[1] != (Level{0.52, 20}) {
+		t.Errorf("asks = %v", asks)
+	}
+}
+
+func TestLocalBookPendingBeforeSnapshot(t *testing.T) {
+	book := NewLocalBook("a")
+	// 快照前到达的增量：1000 已包含在快照中，1200 晚于快照需要补应用
+	book.ApplyPriceChange(&common.PriceChangeEvent{Side: "BUY", Price: "0.49", Size: "30", Timestamp: "1000"})
+	book.ApplyPriceChange(&common.PriceChangeEvent{Side: "SELL", Price: "0.50", Size: "0", Timestamp: "1200"})
+	if book.Ready() {
+		t.Fatal("book ready before snapshot")
+	}
+	if p, _ := book.GetBestBid(); p != 0 {
+		t.Fatalf("best bid before snapshot = %v, want 0", p)
+	}
+
+	book.ApplySnapshot(&common.OrderBookSnapshot{
+		Timestamp: "1100",
+		Bids:      levels("0.48", "100"),
+		Asks:      levels("0.50", "80", "0.52", "20"),
+	})
+	if p, _ := book.GetBestBid(); p != 0.48 {
+		t.Errorf("best bid = %v, want 0.48 (stale buffered change discarded)", p)
+	}
+	if p, _ := book.GetBestAsk(); p != 0.52 {
+		t.Errorf("best ask = %v, want 0.52 (later buffered delete applied)", p)
+	}
+
+	// 快照缺少 timestamp 时丢弃全部缓存
+	book = NewLocalBook("b")
+	book.ApplyPriceChange(&common.PriceChangeEvent{Side: "SELL", Price: "0.50", Size: "0", Timestamp: "1200"})
+	book.ApplySnapshot(&common.OrderBookSnapshot{Asks: levels("0.50", "80")})
+	if p, _ := book.GetBestAsk(); p != 0.50 {
+		t.Errorf("best ask = %v, want 0.50", p)
+	}
+}
+
+func TestLocalBookHashVerification(t *testing.T) {
+	snap := &common.OrderBookSnapshot{
+		Market:    "0xabc",
+		AssetID:   "a",
+		Timestamp: "1000",
+		Bids:      levels("0.47", "50", "0.48", "100.50"),
+		Asks:      levels("0.52", "20", "0.50", "80"),
+	}
+	snap.Hash = snap.ComputeHash()
+
+	book := NewLocalBook("a")
+	book.ApplySnapshot(snap)
+	if book.Desynced() {
+		t.Fatal("desynced after valid snapshot")
+	}
+	// 本地 hash 沿用服务端的价位顺序和数量字符串（"100.50" 不能被规范化为 "100.5"）
+	if got := book.ContentHash(); got != snap.Hash {
+		t.Errorf("ContentHash = %s, want %s", got, snap.Hash)
+	}
+
+	// price_change 携带变化后订单簿的 hash
+	after := common.ComputeBookHash("0xabc", "a", "1100", levels("0.47", "50", "0.48", "100.50", "0.49", "30"), levels("0.52", "20", "0.50", "80"))
+	book.ApplyPriceChange(&common.PriceChangeEvent{Market: "0xabc", AssetID: "a", Side: "BUY", Price: "0.49", Size: "30", Timestamp: "1100", Hash: after})
+	if book.Desynced() {
+		t.Error("desynced after matching price_change")
+	}
+	if !book.VerifyAgainst(after) {
+		t.Error("VerifyAgainst(after) = false")
+	}
+
+	// 丢失一条增量后 hash 不一致
+	book.ApplyPriceChange(&common.PriceChangeEvent{Market: "0xabc", AssetID: "a", Side: "SELL", Price: "0.50", Size: "0", Timestamp: "1200", Hash: "deadbeef"})
+	if !book.Desynced() {
+		t.Error("expected desynced after mismatching price_change")
+	}
+
+	// 通过校验的快照清除 desync
+	snap.Timestamp = "1300"
+	snap.Hash = snap.ComputeHash()
+	book.ApplySnapshot(snap)
+	if book.Desynced() {
+		t.Error("desynced after fresh snapshot")
+	}
+
+	// 快照自身 hash 与内容不符
+	snap.Hash = "deadbeef"
+	book.ApplySnapshot(snap)
+	if !book.Desynced() {
+		t.Error("expected desynced after corrupt snapshot")
+	}
+}
+
+// newBooksFetcher 启动返回 books 中订单簿的 /books 测试服务，fail 为 true 时返回 500；
+// 返回使用公开 clob 客户端的 BooksFetcher 和最近一次请求的 token ID
+func newBooksFetcher(t *testing.T, books map[string]clob.OrderBookSummary, fail *atomic.Bool) (BooksFetcher, *[]string) {
+	t.Helper()
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /books", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TokenIDs []string `json:"token_ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requested = body.TokenIDs
+		if fail.Load() {
+			http.Error(w, `{"error":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		var out []clob.OrderBookSummary
+		for _, id := range body.TokenIDs {
+			if book, ok := books[id]; ok {
+				book.AssetID = id
+				out = append(out, book)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	client, err := clob.NewClient(clob.ClientConfig{BaseURL: srv.URL, MaxRetries: -1, TimeSyncInterval: -1})
+	if err != nil {
+		t.Fatalf("clob.NewClient: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client.FetchBookSnapshots, &requested
+}
+
+func TestConnectionBootstrap(t *testing.T) {
+	var fail atomic.Bool
+	fetch, requested := newBooksFetcher(t, map[string]clob.OrderBookSummary{
+		"a": {Market: "m", Timestamp: "1000", Hash: "rest-a",
+			Bids: []clob.OrderSummary{{Price: "0.48", Size: "10"}}, Asks: []clob.OrderSummary{{Price: "0.52", Size: "5"}}},
+		"b": {Market: "m", Timestamp: "1000", Hash: "rest-b",
+			Bids: []clob.OrderSummary{{Price: "0.40", Size: "10"}}},
+		// c 没有订单簿
+	}, &fail)
+
+	conn := NewClient(ClientConfig{}).CreateMarketConnection([]string{"a", "b", "c"})
+	// b 已收到 WebSocket 快照，不应被 REST 快照覆盖
+	conn.TrackBook("b").ApplySnapshot(&common.OrderBookSnapshot{AssetID: "b", Hash: "ws-b", Bids: levels("0.30", "1")})
+
+	n, err := conn.Bootstrap(t.Context(), fetch)
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("seeded = %d, want 1", n)
+	}
+	if !slices.Equal(*requested, []string{"a", "b", "c"}) {
+		t.Errorf("requested = %v, want all subscribed assets", *requested)
+	}
+
+	a := conn.trackedBook("a")
+	if bid, _ := a.GetBestBid(); bid != 0.48 || !a.Seeded() || a.Hash() != "rest-a" {
+		t.Errorf("book a: best bid %v, seeded %v, hash %q", bid, a.Seeded(), a.Hash())
+	}
+	if ask, size := a.GetBestAsk(); ask != 0.52 || size != 5 {
+		t.Errorf("book a best ask = %v/%v, want 0.52/5", ask, size)
+	}
+	b := conn.trackedBook("b")
+	if bid, _ := b.GetBestBid(); bid != 0.30 || b.Seeded() || b.Hash() != "ws-b" {
+		t.Errorf("book b overwritten: best bid %v, seeded %v, hash %q", bid, b.Seeded(), b.Hash())
+	}
+	if c := conn.trackedBook("c"); c == nil || c.Ready() {
+		t.Errorf("book c = %v, want tracked but not ready", c)
+	}
+
+	// 预加载的快照推送到 BookCh
+	select {
+	case snap := <-conn.BookCh():
+		if snap.AssetID != "a" || snap.Hash != "rest-a" {
+			t.Errorf("BookCh snapshot = %+v, want a", snap)
+		}
+	default:
+		t.Error("no snapshot on BookCh")
+	}
+	select {
+	case snap := <-conn.BookCh():
+		t.Errorf("unexpected snapshot %+v", snap)
+	default:
+	}
+}
+
+func TestConnectionBootstrapError(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	fetch, _ := newBooksFetcher(t, map[string]clob.OrderBookSummary{"a": {Bids: []clob.OrderSummary{{Price: "0.48", Size: "10"}}}}, &fail)
+
+	conn := NewClient(ClientConfig{}).CreateMarketConnection([]string{"a"})
+	n, err := conn.Bootstrap(t.Context(), fetch)
+	if err == nil || n != 0 {
+		t.Fatalf("Bootstrap = %d, %v, want error", n, err)
+	}
+	var apiErr *clob.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("err = %v, want wrapped 503 APIError", err)
+	}
+	if book := conn.trackedBook("a"); book == nil || book.Ready() {
+		t.Errorf("book after failed bootstrap = %v, want tracked but not ready", book)
+	}
+	select {
+	case snap := <-conn.BookCh():
+		t.Errorf("unexpected snapshot %+v", snap)
+	default:
+	}
+
+	// 恢复后再次预加载成功
+	fail.Store(false)
+	if n, err := conn.Bootstrap(t.Context(), fetch); err != nil || n != 1 {
+		t.Errorf("retry Bootstrap = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,110 @@
+package wss
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// DefaultBookMaxAge BookCache 本地订单簿的默认最大时效
+const DefaultBookMaxAge = 30 * time.Second
+
+// BookFetcher 通过 REST 获取订单簿快照（clob.Client.FetchBookSnapshot 满足该签名）
+type BookFetcher func(ctx context.Context, tokenID string) (*common.OrderBookSnapshot, error)
+
+// BookCache 由 WebSocket 实时维护的订单簿缓存，缓存未命中或过期时回退到 REST
+// 可在多个 goroutine 中并发调用
+type BookCache struct {
+	conn   *Connection
+	fetch  BookFetcher
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	tracked map[string]*LocalBook
+}
+
+// NewBookCache 创建订单簿缓存，conn 须为 Market 频道连接；maxAge <= 0 时使用 DefaultBookMaxAge
+// 最近一次更新早于 maxAge 的本地订单簿视为过期（如断线期间），GetBook 会改用 REST
+func NewBookCache(conn *Connection, fetch BookFetcher, maxAge time.Duration) *BookCache {
+	if maxAge <= 0 {
+		maxAge = DefaultBookMaxAge
+	}
+	return &BookCache{
+		conn:    conn,
+		fetch:   fetch,
+		maxAge:  maxAge,
+		tracked: make(map[string]*LocalBook),
+	}
+}
+
+// Track 开始通过 WebSocket 维护 token 的订单簿，尚未订阅的 token 会自动订阅
+func (bc *BookCache) Track(tokenIDs ...string) error {
+	var subscribe []string
+	bc.mu.Lock()
+	for _, id := range tokenIDs {
+		if _, ok := bc.tracked[id]; ok {
+			continue
+		}
+		bc.tracked[id] = bc.conn.TrackBook(id)
+		subscribe = append(subscribe, id)
+	}
+	bc.mu.Unlock()
+
+	if len(subscribe) == 0 {
+		return nil
+	}
+	// 未连接时 Subscribe 仅记录 asset，连接建立后统一订阅
+	if err := bc.conn.Subscribe(subscribe); err != nil && bc.conn.IsConnected() {
+		return fmt.Errorf("subscribe books: %w", err)
+	}
+	return nil
+}
+
+// Untrack 停止维护 token 的订单簿并取消订阅
+func (bc *BookCache) Untrack(tokenIDs ...string) error {
+	bc.mu.Lock()
+	for _, id := range tokenIDs {
+		delete(bc.tracked, id)
+		bc.conn.UntrackBook(id)
+	}
+	bc.mu.Unlock()
+
+	if err := bc.conn.Unsubscribe(tokenIDs); err != nil && bc.conn.IsConnected() {
+		return fmt.Errorf("unsubscribe books: %w", err)
+	}
+	return nil
+}
+
+// Cached 获取未过期的本地订单簿快照，未跟踪、未收到快照、已过期或 hash 校验失败时返回 false
+func (bc *BookCache) Cached(tokenID string) (*common.OrderBookSnapshot, bool) {
+	bc.mu.Lock()
+	book := bc.tracked[tokenID]
+	bc.mu.Unlock()
+	if book == nil || book.Desynced() || time.Since(book.UpdatedAt()) > bc.maxAge {
+		return nil, false
+	}
+	snapshot := book.Snapshot()
+	return snapshot, snapshot != nil
+}
+
+// GetBook 获取订单簿：优先返回未过期的本地订单簿，否则通过 REST 获取
+// 两种来源的价位均按最优在前排序（bids 降序，asks 升序）
+func (bc *BookCache) GetBook(ctx context.Context, tokenID string) (*common.OrderBookSnapshot, error) {
+	if snapshot, ok := bc.Cached(tokenID); ok {
+		return snapshot, nil
+	}
+	if bc.fetch == nil {
+		return nil, fmt.Errorf("book %s not cached and no REST fetcher", tokenID)
+	}
+
+	snapshot, err := bc.fetch(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch book %s: %w", tokenID, err)
+	}
+	sortBookLevels(snapshot.Bids, true)
+	sortBookLevels(snapshot.Asks, false)
+	return snapshot, nil
+}
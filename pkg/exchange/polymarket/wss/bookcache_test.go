@@ -0,0 +1,162 @@
+package wss
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// countingFetcher 返回 books 中订单簿副本并记录调用次数的 BookFetcher，未知 token 返回错误
+func countingFetcher(books map[string]*common.OrderBookSnapshot) (BookFetcher, *int) {
+	calls := 0
+	return func(ctx context.Context, tokenID string) (*common.OrderBookSnapshot, error) {
+		calls++
+		book, ok := books[tokenID]
+		if !ok {
+			return nil, errors.New("no orderbook")
+		}
+		out := *book
+		out.Bids = append([]common.OrderBookLevel(nil), book.Bids...)
+		out.Asks = append([]common.OrderBookLevel(nil), book.Asks...)
+		return &out, nil
+	}, &calls
+}
+
+func TestBookCacheApplyAndDelta(t *testing.T) {
+	fetch, calls := countingFetcher(map[string]*common.OrderBookSnapshot{
+		"a": {AssetID: "a", Hash: "rest", Bids: levels("0.30", "1")},
+	})
+	conn := NewClient(ClientConfig{}).CreateMarketConnection([]string{"a"})
+	bc := NewBookCache(conn, fetch, 0)
+	// 未连接时 Track 仅记录订阅，不返回错误
+	if err := bc.Track("a"); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	book := conn.trackedBook("a")
+	if book == nil {
+		t.Fatal("Track did not register a local book")
+	}
+
+	// 未收到快照时回退到 REST
+	if snap, err := bc.GetBook(t.Context(), "a"); err != nil || snap.Hash != "rest" || *calls != 1 {
+		t.Fatalf("before snapshot: snap = %+v, err = %v, calls = %d", snap, err, *calls)
+	}
+
+	snap := &common.OrderBookSnapshot{
+		Market:    "0xabc",
+		AssetID:   "a",
+		Timestamp: "1000",
+		Bids:      levels("0.47", "50", "0.48", "100"),
+		Asks:      levels("0.52", "20", "0.50", "80"),
+	}
+	snap.Hash = snap.ComputeHash()
+	book.ApplySnapshot(snap)
+
+	got, err := bc.GetBook(t.Context(), "a")
+	if err != nil || *calls != 1 {
+		t.Fatalf("after snapshot: err = %v, calls = %d, want served from cache", err, *calls)
+	}
+	if got.Hash != snap.Hash || got.Bids[0].Price != "0.48" || got.Asks[0].Price != "0.50" {
+		t.Errorf("cached book = %+v, want best levels first", got)
+	}
+
+	// 增量更新直接反映在缓存中
+	after := common.ComputeBookHash("0xabc", "a", "1100", levels("0.47", "50", "0.48", "100", "0.49", "30"), levels("0.52", "20", "0.50", "80"))
+	book.ApplyPriceChange(&common.PriceChangeEvent{Market: "0xabc", AssetID: "a", Side: "BUY", Price: "0.49", Size: "30", Timestamp: "1100", Hash: after})
+	book.ApplyPriceChange(&common.PriceChangeEvent{AssetID: "a", Side: "SELL", Price: "0.50", Size: "0"})
+	got, err = bc.GetBook(t.Context(), "a")
+	if err != nil || *calls != 1 {
+		t.Fatalf("after delta: err = %v, calls = %d, want served from cache", err, *calls)
+	}
+	if got.Bids[0].Price != "0.49" || got.Bids[0].Size != "30" || len(got.Asks) != 1 || got.Asks[0].Price != "0.52" {
+		t.Errorf("book after delta = %+v", got)
+	}
+
+	// Untrack 后不再命中缓存
+	if err := bc.Untrack("a"); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+	if _, ok := bc.Cached("a"); ok {
+		t.Error("Cached after Untrack = true")
+	}
+	if conn.trackedBook("a") != nil {
+		t.Error("Untrack left the local book on the connection")
+	}
+}
+
+func TestBookCacheStaleHash(t *testing.T) {
+	fetch, calls := countingFetcher(map[string]*common.OrderBookSnapshot{
+		"a": {AssetID: "a", Hash: "rest", Bids: levels("0.40", "1", "0.45", "2"), Asks: levels("0.60", "1", "0.55", "2")},
+	})
+	conn := NewClient(ClientConfig{}).CreateMarketConnection([]string{"a"})
+	bc := NewBookCache(conn, fetch, 0)
+	bc.Track("a")
+	book := conn.trackedBook("a")
+
+	snap := &common.OrderBookSnapshot{Market: "0xabc", AssetID: "a", Timestamp: "1000", Bids: levels("0.48", "100"), Asks: levels("0.50", "80")}
+	snap.Hash = snap.ComputeHash()
+	book.ApplySnapshot(snap)
+	if _, ok := bc.Cached("a"); !ok {
+		t.Fatal("valid snapshot not cached")
+	}
+
+	// 增量 hash 不一致说明丢失了增量，改用 REST
+	book.ApplyPriceChange(&common.PriceChangeEvent{Market: "0xabc", AssetID: "a", Side: "BUY", Price: "0.49", Size: "30", Timestamp: "1100", Hash: "deadbeef"})
+	if _, ok := bc.Cached("a"); ok {
+		t.Error("Cached with desynced book = true")
+	}
+	got, err := bc.GetBook(t.Context(), "a")
+	if err != nil || got.Hash != "rest" || *calls != 1 {
+		t.Fatalf("desynced: snap = %+v, err = %v, calls = %d, want REST book", got, err, *calls)
+	}
+	// REST 价位同样按最优在前排序
+	if got.Bids[0].Price != "0.45" || got.Asks[0].Price != "0.55" {
+		t.Errorf("REST book = %+v, want best levels first", got)
+	}
+
+	// 通过校验的快照恢复缓存
+	snap.Timestamp = "1200"
+	snap.Hash = snap.ComputeHash()
+	book.ApplySnapshot(snap)
+	if got, ok := bc.Cached("a"); !ok || got.Hash != snap.Hash {
+		t.Errorf("after fresh snapshot: Cached = %+v, %v", got, ok)
+	}
+
+	// 快照自身 hash 与内容不符
+	snap.Hash = "deadbeef"
+	book.ApplySnapshot(snap)
+	if _, ok := bc.Cached("a"); ok {
+		t.Error("Cached with corrupt snapshot = true")
+	}
+}
+
+func TestBookCacheStaleAge(t *testing.T) {
+	fetch, calls := countingFetcher(map[string]*common.OrderBookSnapshot{"a": {AssetID: "a", Hash: "rest"}})
+	conn := NewClient(ClientConfig{}).CreateMarketConnection([]string{"a"})
+	bc := NewBookCache(conn, fetch, 10*time.Millisecond)
+	bc.Track("a", "b")
+	conn.trackedBook("a").ApplySnapshot(&common.OrderBookSnapshot{AssetID: "a", Bids: levels("0.48", "100")})
+
+	if _, ok := bc.Cached("a"); !ok {
+		t.Fatal("fresh book not cached")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := bc.Cached("a"); ok {
+		t.Error("Cached after maxAge = true")
+	}
+	if got, err := bc.GetBook(t.Context(), "a"); err != nil || got.Hash != "rest" || *calls != 1 {
+		t.Errorf("stale: snap = %+v, err = %v, calls = %d, want REST book", got, err, *calls)
+	}
+
+	// REST 失败时返回错误
+	if _, err := bc.GetBook(t.Context(), "b"); err == nil {
+		t.Error("GetBook with failing fetcher should fail")
+	}
+	// 没有 REST 回退
+	if _, err := NewBookCache(conn, nil, 0).GetBook(t.Context(), "a"); err == nil {
+		t.Error("GetBook without fetcher should fail")
+	}
+}
@@ -3,7 +3,10 @@ package wss
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,12 +18,27 @@ import (
 type ClientConfig struct {
 	BaseURL              string
 	PingInterval         time.Duration
+	ReadTimeout          time.Duration // 读超时，超时未收到任何消息或 pong 视为连接失效并触发重连 (默认 PingInterval*2)
 	ReconnectDelay       time.Duration
 	MaxReconnectAttempts int
-	ChannelBufferSize    int
+	ChannelBufferSize    int // 推送 Channel 缓冲大小 (默认 100)，缓冲满时丢弃最新事件，读循环不会阻塞
 	ProxyString          string
+
+	// 重连退避：第 n 次重连延迟 = min(BackoffBase * BackoffMultiplier^(n-1), MaxReconnectDelay)，再叠加 ±JitterFraction 的随机抖动，
+	// 抖动后仍不超过 MaxReconnectDelay
+	BackoffBase       time.Duration  // 首次重连延迟 (默认 ReconnectDelay)
+	BackoffMultiplier float64        // 退避倍数 (默认 2)
+	MaxReconnectDelay time.Duration  // 最大重连延迟 (默认 60 秒)
+	JitterFraction    float64        // 抖动比例 (默认 0.2，负数表示关闭抖动)
+	JitterSource      func() float64 // 抖动随机源，返回 [0,1) (默认 math/rand)
+
+	// DialFunc 自定义拨号函数（可选），设置后忽略 ProxyString，可用于连接测试用的本地 WebSocket 服务
+	DialFunc DialFunc
 }
 
+// DialFunc WebSocket 拨号函数，签名与 websocket.Dialer.Dial 一致
+type DialFunc func(url string, header http.Header) (*websocket.Conn, *http.Response, error)
+
 // ChannelType 频道类型
 type ChannelType string
 
@@ -42,9 +60,27 @@ func NewClient(cfg ClientConfig) *Client {
 	if cfg.PingInterval == 0 {
 		cfg.PingInterval = 10 * time.Second
 	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = cfg.PingInterval * 2
+	}
 	if cfg.ReconnectDelay == 0 {
 		cfg.ReconnectDelay = 5 * time.Second
 	}
+	if cfg.BackoffBase == 0 {
+		cfg.BackoffBase = cfg.ReconnectDelay
+	}
+	if cfg.BackoffMultiplier < 1 {
+		cfg.BackoffMultiplier = 2
+	}
+	if cfg.MaxReconnectDelay == 0 {
+		cfg.MaxReconnectDelay = 60 * time.Second
+	}
+	if cfg.JitterFraction == 0 {
+		cfg.JitterFraction = 0.2
+	}
+	if cfg.JitterSource == nil {
+		cfg.JitterSource = rand.Float64
+	}
 	if cfg.MaxReconnectAttempts == 0 {
 		cfg.MaxReconnectAttempts = 10
 	}
@@ -54,8 +90,8 @@ func NewClient(cfg ClientConfig) *Client {
 	return &Client{config: cfg}
 }
 
-// CreateMarketConnection 创建市场频道连接
-func (c *Client) CreateMarketConnection(assetIDs []string) *Connection {
+// CreateMarketConnection 创建市场频道连接，opts 可向订阅消息添加额外选项
+func (c *Client) CreateMarketConnection(assetIDs []string, opts ...SubscribeOption) *Connection {
 	if len(assetIDs) == 0 {
 		return nil
 	}
@@ -63,11 +99,12 @@ func (c *Client) CreateMarketConnection(assetIDs []string) *Connection {
 		"assets_ids": assetIDs,
 		"type":       "market",
 	}
+	applySubscribeOptions(payload, opts)
 	return NewConnection(ChannelMarket, c.config, payload)
 }
 
-// CreateUserConnection 创建用户频道连接
-func (c *Client) CreateUserConnection(auth common.WssAuth, markets []string) *Connection {
+// CreateUserConnection 创建用户频道连接，opts 可向订阅消息添加额外选项
+func (c *Client) CreateUserConnection(auth common.WssAuth, markets []string, opts ...SubscribeOption) *Connection {
 	payload := map[string]interface{}{
 		"type": "user",
 		"auth": map[string]string{
@@ -79,6 +116,7 @@ func (c *Client) CreateUserConnection(auth common.WssAuth, markets []string) *Co
 	if len(markets) > 0 {
 		payload["markets"] = markets
 	}
+	applySubscribeOptions(payload, opts)
 	return NewConnection(ChannelUser, c.config, payload)
 }
 
@@ -92,10 +130,17 @@ type Connection struct {
 	isConnected        bool
 	isIntentionalClose bool
 	reconnectAttempts  int
+	timerMu            sync.Mutex // 保护 pingTimer/reconnectTimer（读循环重连与 Close 可能并发操作）
 	pingTimer          *time.Ticker
 	reconnectTimer     *time.Timer
 	stopCh             chan struct{}
 	processedTrades    sync.Map
+	reconciler         UserStateFetcher
+	disconnectedAt     time.Time
+	pendingTrades      []*common.TradeNotification
+	books              map[string]*LocalBook
+	userState          *UserState
+	assets             []string // 当前订阅的 asset（Market 频道），重连时整体重新订阅
 
 	// 生命周期回调
 	onConnected     func()
@@ -104,19 +149,28 @@ type Connection struct {
 	onReconnecting  func(attempt int, delay time.Duration)
 	onReconnectFail func(attempts int)
 
-	// Channel 推送
+	// 消息回调
+	onPriceChangeBatch func(batch *common.PriceChangeBatch)
+	onUnknownEvent     func(eventType string, raw []byte)
+
+	// Channel 推送（Close 后关闭，chClosed 由 chMu 保护）
+	chMu             sync.RWMutex
+	chClosed         bool
 	bookCh           chan *common.OrderBookSnapshot
 	priceChangeCh    chan *common.PriceChangeEvent
 	lastTradePriceCh chan *common.LastTradePrice
 	tickSizeChangeCh chan *common.TickSizeChange
 	orderCh          chan *common.OrderUpdate
 	tradeCh          chan *common.TradeNotification
+	tradeBatchCh     chan []*common.TradeNotification
 }
 
 // NewConnection 创建 WebSocket 连接
 func NewConnection(channel ChannelType, config ClientConfig, payload map[string]interface{}) *Connection {
 	bufSize := config.ChannelBufferSize
+	assets, _ := payload["assets_ids"].([]string)
 	return &Connection{
+		assets:           append([]string(nil), assets...),
 		channel:          channel,
 		config:           config,
 		subscribePayload: payload,
@@ -137,6 +191,16 @@ func (c *Connection) OnError(fn func(err error))                             { c
 func (c *Connection) OnReconnecting(fn func(attempt int, delay time.Duration)) { c.onReconnecting = fn }
 func (c *Connection) OnReconnectFail(fn func(attempts int))                  { c.onReconnectFail = fn }
 
+// OnPriceChangeBatch 设置 price_change 批量回调（含外层 timestamp），在读循环中同步调用，逐条推送的 PriceChangeCh 不受影响
+func (c *Connection) OnPriceChangeBatch(fn func(batch *common.PriceChangeBatch)) {
+	c.onPriceChangeBatch = fn
+}
+
+// OnUnknownEvent 设置未识别 event_type 的回调（raw 为该条消息的 JSON），用于兼容新增事件类型和调试
+func (c *Connection) OnUnknownEvent(fn func(eventType string, raw []byte)) {
+	c.onUnknownEvent = fn
+}
+
 // Channel 获取方法
 func (c *Connection) BookCh() <-chan *common.OrderBookSnapshot     { return c.bookCh }
 func (c *Connection) PriceChangeCh() <-chan *common.PriceChangeEvent { return c.priceChangeCh }
@@ -157,21 +221,7 @@ func (c *Connection) Connect() error {
 
 	wsURL := fmt.Sprintf("%s/ws/%s", c.config.BaseURL, c.channel)
 
-	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
-
-	if c.config.ProxyString != "" {
-		if proxyCfg := common.ParseProxyString(c.config.ProxyString); proxyCfg != nil {
-			if proxyCfg.IsSocks() {
-				if proxyDialer, err := common.CreateProxyDialer(c.config.ProxyString); err == nil && proxyDialer != nil {
-					dialer.NetDial = proxyDialer.Dial
-				}
-			} else {
-				dialer.Proxy = http.ProxyURL(proxyCfg.GetProxyURL())
-			}
-		}
-	}
-
-	conn, _, err := dialer.Dial(wsURL, http.Header{})
+	conn, _, err := c.dial(wsURL)
 	if err != nil {
 		return fmt.Errorf("dial: %w", err)
 	}
@@ -180,6 +230,8 @@ func (c *Connection) Connect() error {
 	c.conn = conn
 	c.isConnected = true
 	c.reconnectAttempts = 0
+	disconnectedAt := c.disconnectedAt
+	c.disconnectedAt = time.Time{}
 	c.mu.Unlock()
 
 	if err := c.subscribe(); err != nil {
@@ -187,16 +239,54 @@ func (c *Connection) Connect() error {
 		return fmt.Errorf("subscribe: %w", err)
 	}
 
+	// 收到控制帧 pong 时延长读超时（应用层 "PONG" 文本消息在读循环中同样会延长）
+	conn.SetPongHandler(func(string) error {
+		c.extendReadDeadline(conn)
+		return nil
+	})
+
 	c.startPing()
 	go c.readLoop()
 
+	// 重连后通过 REST 补齐断线期间的成交
+	if !disconnectedAt.IsZero() {
+		go c.reconcile(disconnectedAt)
+	}
+
 	if c.onConnected != nil {
 		c.onConnected()
 	}
 	return nil
 }
 
-// Close 关闭连接
+// dial 建立 WebSocket 连接，优先使用配置的 DialFunc
+func (c *Connection) dial(wsURL string) (*websocket.Conn, *http.Response, error) {
+	if c.config.DialFunc != nil {
+		return c.config.DialFunc(wsURL, http.Header{})
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+
+	if c.config.ProxyString != "" {
+		if proxyCfg := common.ParseProxyString(c.config.ProxyString); proxyCfg != nil {
+			if proxyCfg.IsSocks() {
+				dialContext, err := common.CreateProxyDialContext(c.config.ProxyString)
+				if err != nil {
+					return nil, nil, fmt.Errorf("create proxy dialer: %w", err)
+				}
+				// NetDialContext 受 HandshakeTimeout 控制，TLS 由 websocket 在代理连接之上完成
+				dialer.NetDialContext = dialContext
+			} else {
+				dialer.Proxy = http.ProxyURL(proxyCfg.GetProxyURL())
+			}
+		}
+	}
+
+	return dialer.Dial(wsURL, http.Header{})
+}
+
+// Close 关闭连接，同时关闭所有推送 Channel（range 消费者会正常退出）
+// 关闭后连接不可再次使用，重复调用安全
 func (c *Connection) Close() {
 	c.mu.Lock()
 	c.isIntentionalClose = true
@@ -218,6 +308,41 @@ func (c *Connection) Close() {
 	default:
 		close(c.stopCh)
 	}
+	c.closeChannels()
+}
+
+// closeChannels 关闭推送 Channel，持有写锁保证不会与 send 并发
+func (c *Connection) closeChannels() {
+	c.chMu.Lock()
+	defer c.chMu.Unlock()
+	if c.chClosed {
+		return
+	}
+	c.chClosed = true
+	close(c.bookCh)
+	close(c.priceChangeCh)
+	close(c.lastTradePriceCh)
+	close(c.tickSizeChangeCh)
+	close(c.orderCh)
+	close(c.tradeCh)
+	c.mu.RLock()
+	if c.tradeBatchCh != nil {
+		close(c.tradeBatchCh)
+	}
+	c.mu.RUnlock()
+}
+
+// send 非阻塞推送到 Channel：缓冲满时丢弃该事件 (drop-newest)，Channel 已关闭时忽略
+func send[T any](c *Connection, ch chan T, v T) {
+	c.chMu.RLock()
+	defer c.chMu.RUnlock()
+	if c.chClosed {
+		return
+	}
+	select {
+	case ch <- v:
+	default:
+	}
 }
 
 // IsConnected 检查连接状态
@@ -257,6 +382,13 @@ func (c *Connection) Subscribe(assetIDs []string) error {
 	if c.channel != ChannelMarket {
 		return fmt.Errorf("subscribe only supported for market channel")
 	}
+	c.mu.Lock()
+	for _, id := range assetIDs {
+		if !slices.Contains(c.assets, id) {
+			c.assets = append(c.assets, id)
+		}
+	}
+	c.mu.Unlock()
 	return c.Send(map[string]interface{}{"assets_ids": assetIDs, "operation": "subscribe"})
 }
 
@@ -265,6 +397,9 @@ func (c *Connection) Unsubscribe(assetIDs []string) error {
 	if c.channel != ChannelMarket {
 		return fmt.Errorf("unsubscribe only supported for market channel")
 	}
+	c.mu.Lock()
+	c.assets = slices.DeleteFunc(c.assets, func(id string) bool { return slices.Contains(assetIDs, id) })
+	c.mu.Unlock()
 	return c.Send(map[string]interface{}{"assets_ids": assetIDs, "operation": "unsubscribe"})
 }
 
@@ -273,19 +408,40 @@ func (c *Connection) ClearProcessedTrades() {
 	c.processedTrades = sync.Map{}
 }
 
+// SubscribedAssets 获取当前订阅的 asset ID（Market 频道）
+func (c *Connection) SubscribedAssets() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.assets...)
+}
+
+// subscribe 发送初始订阅，Market 频道使用当前完整的订阅集合（包含后续 Subscribe 添加的 asset）
 func (c *Connection) subscribe() error {
-	return c.Send(c.subscribePayload)
+	if c.channel != ChannelMarket {
+		return c.Send(c.subscribePayload)
+	}
+	payload := make(map[string]interface{}, len(c.subscribePayload))
+	for k, v := range c.subscribePayload {
+		payload[k] = v
+	}
+	payload["assets_ids"] = c.SubscribedAssets()
+	return c.Send(payload)
 }
 
 func (c *Connection) startPing() {
 	c.stopPing()
-	c.pingTimer = time.NewTicker(c.config.PingInterval)
+	ticker := time.NewTicker(c.config.PingInterval)
+	c.timerMu.Lock()
+	c.pingTimer = ticker
+	c.timerMu.Unlock()
 	go func() {
 		for {
 			select {
-			case <-c.pingTimer.C:
+			case <-ticker.C:
 				if c.IsConnected() {
+					// 应用层 PING 兼容服务端协议，控制帧 ping 用于探测半开连接
 					c.Send("PING")
+					c.sendControlPing()
 				}
 			case <-c.stopCh:
 				return
@@ -294,7 +450,34 @@ func (c *Connection) startPing() {
 	}()
 }
 
+// sendControlPing 发送 WebSocket 控制帧 ping（WriteControl 可与其他写操作并发调用）
+func (c *Connection) sendControlPing() {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn != nil {
+		conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.config.PingInterval))
+	}
+}
+
+// readTimeout 读超时，未配置时为 PingInterval*2，均未配置时不设置超时
+func (c *Connection) readTimeout() time.Duration {
+	if c.config.ReadTimeout > 0 {
+		return c.config.ReadTimeout
+	}
+	return c.config.PingInterval * 2
+}
+
+// extendReadDeadline 从当前时间起延长读超时
+func (c *Connection) extendReadDeadline(conn *websocket.Conn) {
+	if timeout := c.readTimeout(); timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+}
+
 func (c *Connection) stopPing() {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
 	if c.pingTimer != nil {
 		c.pingTimer.Stop()
 		c.pingTimer = nil
@@ -302,6 +485,8 @@ func (c *Connection) stopPing() {
 }
 
 func (c *Connection) stopReconnect() {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
 	if c.reconnectTimer != nil {
 		c.reconnectTimer.Stop()
 		c.reconnectTimer = nil
@@ -318,9 +503,11 @@ func (c *Connection) readLoop() {
 			return
 		}
 
+		// 每条消息后刷新读超时：对端静默（半开连接）时 ReadMessage 超时返回错误并触发重连
+		c.extendReadDeadline(conn)
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
-			c.handleClose(websocket.CloseAbnormalClosure, err.Error())
+			c.handleClose(conn, websocket.CloseAbnormalClosure, err.Error())
 			return
 		}
 		c.handleMessage(msg)
@@ -370,41 +557,38 @@ func (c *Connection) handleMarketMessage(data interface{}) {
 		case "book":
 			var book common.OrderBookSnapshot
 			if b, _ := json.Marshal(msg); json.Unmarshal(b, &book) == nil {
-				select {
-				case c.bookCh <- &book:
-				default:
+				if local := c.trackedBook(book.AssetID); local != nil {
+					local.ApplySnapshot(&book)
 				}
+				send(c, c.bookCh, &book)
 			}
 		case "price_change":
-			if changes, ok := msg["price_changes"].([]interface{}); ok {
-				for _, change := range changes {
-					if m, ok := change.(map[string]interface{}); ok {
-						var event common.PriceChangeEvent
-						if b, _ := json.Marshal(m); json.Unmarshal(b, &event) == nil {
-							select {
-							case c.priceChangeCh <- &event:
-							default:
-							}
-						}
-					}
+			b, _ := json.Marshal(msg)
+			batch, err := common.ParsePriceChangeBatch(b)
+			if err != nil {
+				continue
+			}
+			for _, event := range batch.Changes {
+				if local := c.trackedBook(event.AssetID); local != nil {
+					local.ApplyPriceChange(event)
 				}
+				send(c, c.priceChangeCh, event)
+			}
+			if c.onPriceChangeBatch != nil {
+				c.onPriceChangeBatch(batch)
 			}
 		case "last_trade_price":
 			var event common.LastTradePrice
 			if b, _ := json.Marshal(msg); json.Unmarshal(b, &event) == nil {
-				select {
-				case c.lastTradePriceCh <- &event:
-				default:
-				}
+				send(c, c.lastTradePriceCh, &event)
 			}
 		case "tick_size_change":
 			var event common.TickSizeChange
 			if b, _ := json.Marshal(msg); json.Unmarshal(b, &event) == nil {
-				select {
-				case c.tickSizeChangeCh <- &event:
-				default:
-				}
+				send(c, c.tickSizeChangeCh, &event)
 			}
+		default:
+			c.emitUnknown(eventType, msg)
 		}
 	}
 }
@@ -420,36 +604,65 @@ func (c *Connection) handleUserMessage(data interface{}) {
 	case "order":
 		var order common.OrderUpdate
 		if b, _ := json.Marshal(msg); json.Unmarshal(b, &order) == nil {
-			select {
-			case c.orderCh <- &order:
-			default:
+			if state := c.trackedUserState(); state != nil {
+				state.ApplyOrder(&order)
 			}
+			send(c, c.orderCh, &order)
 		}
 	case "trade":
 		var trade common.TradeNotification
 		if b, _ := json.Marshal(msg); json.Unmarshal(b, &trade) == nil {
-			tradeID := trade.ID
-			if tradeID == "" {
-				tradeID = trade.TradeID
-			}
-			if tradeID != "" {
-				if _, loaded := c.processedTrades.LoadOrStore(tradeID, true); loaded {
-					return
-				}
-			}
-			select {
-			case c.tradeCh <- &trade:
-			default:
+			if state := c.trackedUserState(); state != nil {
+				state.ApplyTrade(&trade)
 			}
+			c.emitTrade(&trade)
 		}
+	default:
+		c.emitUnknown(eventType, msg)
 	}
 }
 
-func (c *Connection) handleClose(code int, reason string) {
+// emitUnknown 触发未识别事件回调
+func (c *Connection) emitUnknown(eventType string, msg map[string]interface{}) {
+	if c.onUnknownEvent == nil {
+		return
+	}
+	if raw, err := json.Marshal(msg); err == nil {
+		c.onUnknownEvent(eventType, raw)
+	}
+}
+
+// emitTrade 推送成交（按成交 ID 去重）
+func (c *Connection) emitTrade(trade *common.TradeNotification) {
+	tradeID := trade.ID
+	if tradeID == "" {
+		tradeID = trade.TradeID
+	}
+	if tradeID != "" {
+		if _, loaded := c.processedTrades.LoadOrStore(tradeID, true); loaded {
+			return
+		}
+	}
+	if c.batchTrade(trade) {
+		return
+	}
+	send(c, c.tradeCh, trade)
+}
+
+// handleClose 读循环出错后关闭失效连接（读超时时 socket 仍未关闭，不关闭会在每次重连时泄漏），非主动关闭时触发重连
+func (c *Connection) handleClose(conn *websocket.Conn, code int, reason string) {
+	conn.Close()
+
 	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
 	c.isConnected = false
 	c.stopPing()
 	intentional := c.isIntentionalClose
+	if !intentional && c.disconnectedAt.IsZero() {
+		c.disconnectedAt = time.Now()
+	}
 	c.mu.Unlock()
 
 	if c.onDisconnected != nil {
@@ -472,21 +685,52 @@ func (c *Connection) tryReconnect() {
 	}
 	c.reconnectAttempts++
 	attempt := c.reconnectAttempts
-	delay := c.config.ReconnectDelay * time.Duration(attempt)
+	delay := c.config.reconnectDelay(attempt)
 	c.mu.Unlock()
 
 	if c.onReconnecting != nil {
 		c.onReconnecting(attempt, delay)
 	}
 
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
 	c.reconnectTimer = time.AfterFunc(delay, func() {
-		c.mu.RLock()
-		intentional := c.isIntentionalClose
-		c.mu.RUnlock()
-		if !intentional {
-			if err := c.Connect(); err != nil && c.onError != nil {
+		if c.intentionalClose() {
+			return
+		}
+		if err := c.Connect(); err != nil {
+			if c.onError != nil {
 				c.onError(err)
 			}
+			// 拨号失败不会触发 handleClose，需在这里继续下一次退避重连
+			if !c.intentionalClose() {
+				c.tryReconnect()
+			}
 		}
 	})
 }
+
+func (c *Connection) intentionalClose() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isIntentionalClose
+}
+
+// reconnectDelay 计算第 attempt 次重连的延迟（指数退避 + 随机抖动）
+func (cfg ClientConfig) reconnectDelay(attempt int) time.Duration {
+	base := cfg.BackoffBase
+	if base <= 0 {
+		base = cfg.ReconnectDelay
+	}
+	delay := float64(base) * math.Pow(max(cfg.BackoffMultiplier, 1), float64(attempt-1))
+	if cfg.MaxReconnectDelay > 0 {
+		delay = min(delay, float64(cfg.MaxReconnectDelay))
+	}
+	if cfg.JitterFraction > 0 && cfg.JitterSource != nil {
+		delay *= 1 + cfg.JitterFraction*(2*cfg.JitterSource()-1)
+	}
+	if cfg.MaxReconnectDelay > 0 {
+		delay = min(delay, float64(cfg.MaxReconnectDelay))
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,555 @@
+package wss
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// wsServer 测试用 WebSocket 服务：记录每次连接收到的订阅消息，并把连接交给 serve 处理
+type wsServer struct {
+	serve func(n int, conn *websocket.Conn) // n 为第几次连接（从 1 开始），返回后关闭连接
+
+	mu         sync.Mutex
+	subscribes []map[string]any
+	conns      int
+}
+
+func (s *wsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var sub map[string]any
+	if err := conn.ReadJSON(&sub); err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.conns++
+	n := s.conns
+	s.subscribes = append(s.subscribes, sub)
+	s.mu.Unlock()
+
+	if s.serve != nil {
+		s.serve(n, conn)
+	}
+}
+
+// Subscribes 已收到的订阅消息
+func (s *wsServer) Subscribes() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any(nil), s.subscribes...)
+}
+
+// newTestWSClient 启动测试服务并返回指向它的客户端：重连延迟 1ms、无抖动
+func newTestWSClient(t *testing.T, srv *wsServer, opts ...func(*ClientConfig)) *Client {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	cfg := ClientConfig{
+		BaseURL:              "ws" + strings.TrimPrefix(ts.URL, "http"),
+		PingInterval:         time.Hour,
+		ReconnectDelay:       time.Millisecond,
+		MaxReconnectAttempts: 3,
+		JitterFraction:       -1,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return NewClient(cfg)
+}
+
+// writeEvent 以 JSON 写出一条推送
+func writeEvent(t *testing.T, conn *websocket.Conn, v any) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		t.Errorf("write event: %v", err)
+	}
+}
+
+// waitFor 轮询等待条件成立，超时则失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMarketChannelsInOrder(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		writeEvent(t, conn, map[string]any{"event_type": "book", "asset_id": "a", "hash": "h1",
+			"bids": []map[string]string{{"price": "0.48", "size": "10"}}, "asks": []map[string]string{{"price": "0.52", "size": "10"}}})
+		writeEvent(t, conn, map[string]any{"event_type": "price_change", "market": "m", "timestamp": "1000", "price_changes": []map[string]string{
+			{"asset_id": "a", "price": "0.49", "size": "5", "side": "BUY"},
+			{"asset_id": "a", "price": "0.51", "size": "5", "side": "SELL"},
+		}})
+		writeEvent(t, conn, map[string]any{"event_type": "last_trade_price", "asset_id": "a", "price": "0.50"})
+		writeEvent(t, conn, map[string]any{"event_type": "tick_size_change", "asset_id": "a", "old_tick_size": "0.01", "new_tick_size": "0.001"})
+		// 数组形式的消息按顺序展开
+		writeEvent(t, conn, []map[string]any{
+			{"event_type": "book", "asset_id": "a", "hash": "h2"},
+			{"event_type": "last_trade_price", "asset_id": "a", "price": "0.51"},
+		})
+		<-done
+	}}
+	c := newTestWSClient(t, srv)
+	conn := c.CreateMarketConnection([]string{"a"})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer close(done)
+
+	recv := func(name string, ch any) any {
+		t.Helper()
+		timeout := time.After(2 * time.Second)
+		switch ch := ch.(type) {
+		case <-chan *common.OrderBookSnapshot:
+			select {
+			case v := <-ch:
+				return v.Hash
+			case <-timeout:
+			}
+		case <-chan *common.PriceChangeEvent:
+			select {
+			case v := <-ch:
+				return v.Price + "/" + v.Timestamp
+			case <-timeout:
+			}
+		case <-chan *common.LastTradePrice:
+			select {
+			case v := <-ch:
+				return v.Price
+			case <-timeout:
+			}
+		case <-chan *common.TickSizeChange:
+			select {
+			case v := <-ch:
+				return v.NewTickSize
+			case <-timeout:
+			}
+		}
+		t.Fatalf("timed out waiting for %s", name)
+		return nil
+	}
+	for _, step := range []struct {
+		name string
+		ch   any
+		want any
+	}{
+		{"book 1", conn.BookCh(), "h1"},
+		{"book 2", conn.BookCh(), "h2"},
+		{"price change 1", conn.PriceChangeCh(), "0.49/1000"},
+		{"price change 2", conn.PriceChangeCh(), "0.51/1000"},
+		{"last trade 1", conn.LastTradePriceCh(), "0.50"},
+		{"last trade 2", conn.LastTradePriceCh(), "0.51"},
+		{"tick size", conn.TickSizeChangeCh(), "0.001"},
+	} {
+		if got := recv(step.name, step.ch); got != step.want {
+			t.Errorf("%s = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// Close 后所有 Channel 关闭，range 的消费者可以退出
+	conn.Close()
+	for name, closed := range map[string]func() bool{
+		"BookCh":           func() bool { _, ok := <-conn.BookCh(); return !ok },
+		"PriceChangeCh":    func() bool { _, ok := <-conn.PriceChangeCh(); return !ok },
+		"LastTradePriceCh": func() bool { _, ok := <-conn.LastTradePriceCh(); return !ok },
+		"TickSizeChangeCh": func() bool { _, ok := <-conn.TickSizeChangeCh(); return !ok },
+	} {
+		if !closed() {
+			t.Errorf("%s not closed after Close", name)
+		}
+	}
+}
+
+func TestChannelDropsNewestWhenFull(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		for _, price := range []string{"0.1", "0.2", "0.3", "0.4"} {
+			writeEvent(t, conn, map[string]any{"event_type": "last_trade_price", "asset_id": "a", "price": price})
+		}
+		writeEvent(t, conn, map[string]any{"event_type": "tick_size_change", "asset_id": "a", "new_tick_size": "0.001"})
+		<-done
+	}}
+	c := newTestWSClient(t, srv, func(cfg *ClientConfig) { cfg.ChannelBufferSize = 2 })
+	conn := c.CreateMarketConnection([]string{"a"})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	// tick_size_change 在成交价之后发送，收到它说明前面的事件都已处理
+	select {
+	case <-conn.TickSizeChangeCh():
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for tick size change")
+	}
+	var prices []string
+	for len(conn.LastTradePriceCh()) > 0 {
+		prices = append(prices, (<-conn.LastTradePriceCh()).Price)
+	}
+	if fmt.Sprint(prices) != "[0.1 0.2]" {
+		t.Errorf("buffered prices = %v, want [0.1 0.2] (newest dropped)", prices)
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name   string
+		jitter float64 // JitterSource 返回值
+		want   []time.Duration
+	}{
+		// factor = 1 + 0.2*(2*0.75-1) = 1.1，第 4 次 80ms 先封顶到 50ms，抖动后 55ms 再次封顶
+		{"jitter up", 0.75, []time.Duration{11 * ms, 22 * ms, 44 * ms, 50 * ms, 50 * ms}},
+		// factor = 0.8
+		{"jitter down", 0, []time.Duration{8 * ms, 16 * ms, 32 * ms, 40 * ms, 40 * ms}},
+		{"centered jitter", 0.5, []time.Duration{10 * ms, 20 * ms, 40 * ms, 50 * ms, 50 * ms}},
+	}
+	for _, tt := range tests {
+		cfg := NewClient(ClientConfig{
+			BackoffBase:       10 * ms,
+			MaxReconnectDelay: 50 * ms,
+			JitterSource:      func() float64 { return tt.jitter },
+		}).config
+		for i, want := range tt.want {
+			got := cfg.reconnectDelay(i + 1)
+			if got != want {
+				t.Errorf("%s: attempt %d delay = %v, want %v", tt.name, i+1, got, want)
+			}
+			if got > cfg.MaxReconnectDelay {
+				t.Errorf("%s: attempt %d delay %v exceeds cap", tt.name, i+1, got)
+			}
+		}
+	}
+}
+
+func TestReconnectBackoffSequence(t *testing.T) {
+	srv := &wsServer{} // 订阅后立即断开
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var (
+		mu     sync.Mutex
+		dials  int
+		delays []time.Duration
+	)
+	failed := make(chan int, 1)
+	c := NewClient(ClientConfig{
+		BaseURL:              "ws" + strings.TrimPrefix(ts.URL, "http"),
+		PingInterval:         time.Hour,
+		BackoffBase:          time.Millisecond,
+		MaxReconnectDelay:    4 * time.Millisecond,
+		MaxReconnectAttempts: 4,
+		JitterFraction:       -1,
+		// 仅首次拨号成功，之后的重连全部失败
+		DialFunc: func(url string, header http.Header) (*websocket.Conn, *http.Response, error) {
+			mu.Lock()
+			dials++
+			n := dials
+			mu.Unlock()
+			if n > 1 {
+				return nil, nil, fmt.Errorf("dial refused")
+			}
+			return websocket.DefaultDialer.Dial(url, header)
+		},
+	})
+	conn := c.CreateMarketConnection([]string{"a"})
+	conn.OnReconnecting(func(attempt int, delay time.Duration) {
+		mu.Lock()
+		delays = append(delays, delay)
+		mu.Unlock()
+	})
+	conn.OnReconnectFail(func(attempts int) { failed <- attempts })
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case attempts := <-failed:
+		if attempts != 4 {
+			t.Errorf("gave up after %d attempts, want 4", attempts)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reconnect did not give up")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	ms := time.Millisecond
+	if want := []time.Duration{ms, 2 * ms, 4 * ms, 4 * ms}; fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Errorf("reported delays = %v, want %v", delays, want)
+	}
+	if dials != 5 {
+		t.Errorf("dials = %d, want 5 (initial + 4 reconnects)", dials)
+	}
+}
+
+func TestReconnectResubscribesTrackedAssets(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		if n == 1 {
+			// 读取连接期间的动态订阅/取消订阅后断开
+			for range 2 {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+			return
+		}
+		<-done
+	}}
+	c := newTestWSClient(t, srv)
+	conn := c.CreateMarketConnection([]string{"a", "b"}, WithCustomFeatures(true))
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	if err := conn.Subscribe([]string{"c", "b"}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := conn.Unsubscribe([]string{"a"}); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	waitFor(t, "resubscribe", func() bool { return len(srv.Subscribes()) == 2 })
+	subs := srv.Subscribes()
+	if got := fmt.Sprint(subs[0]["assets_ids"]); got != "[a b]" {
+		t.Errorf("initial assets = %s, want [a b]", got)
+	}
+	if got := fmt.Sprint(subs[1]["assets_ids"]); got != "[b c]" {
+		t.Errorf("reconnect assets = %s, want [b c]", got)
+	}
+	if subs[1]["type"] != "market" || subs[1]["custom_feature_enabled"] != true {
+		t.Errorf("reconnect payload lost options: %v", subs[1])
+	}
+	if got := fmt.Sprint(conn.SubscribedAssets()); got != "[b c]" {
+		t.Errorf("SubscribedAssets = %s, want [b c]", got)
+	}
+}
+
+func TestDialFuncInjected(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		writeEvent(t, conn, map[string]any{"event_type": "last_trade_price", "asset_id": "a", "price": "0.50"})
+		<-done
+	}}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var dialed []string
+	c := NewClient(ClientConfig{
+		BaseURL:      "wss://polymarket.invalid",
+		ProxyString:  "127.0.0.1:1:user:pass",
+		PingInterval: time.Hour,
+		// 无论目标地址如何都连到本地测试服务，同时记录请求的地址
+		DialFunc: func(url string, header http.Header) (*websocket.Conn, *http.Response, error) {
+			dialed = append(dialed, url)
+			return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), header)
+		},
+	})
+	conn := c.CreateMarketConnection([]string{"a"})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	select {
+	case e := <-conn.LastTradePriceCh():
+		if e.Price != "0.50" {
+			t.Errorf("price = %s", e.Price)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event through injected dialer")
+	}
+	if len(dialed) != 1 || dialed[0] != "wss://polymarket.invalid/ws/market" {
+		t.Errorf("dialed = %v", dialed)
+	}
+	if subs := srv.Subscribes(); len(subs) != 1 {
+		t.Errorf("subscribes = %v", subs)
+	}
+}
+
+func TestPriceChangeBatchCallback(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		writeEvent(t, conn, map[string]any{"event_type": "price_change", "market": "m", "timestamp": "1757908892351", "price_changes": []map[string]string{
+			{"asset_id": "a", "price": "0.49", "size": "5", "side": "BUY"},
+			{"asset_id": "b", "price": "0.51", "size": "5", "side": "SELL"},
+		}})
+		<-done
+	}}
+	c := newTestWSClient(t, srv)
+	conn := c.CreateMarketConnection([]string{"a", "b"})
+	batches := make(chan *common.PriceChangeBatch, 1)
+	conn.OnPriceChangeBatch(func(batch *common.PriceChangeBatch) { batches <- batch })
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	select {
+	case batch := <-batches:
+		if batch.Market != "m" || batch.Timestamp != "1757908892351" || len(batch.Changes) != 2 {
+			t.Errorf("batch = %+v", batch)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no batch callback")
+	}
+	// 逐条推送同样携带外层 timestamp
+	for range 2 {
+		select {
+		case e := <-conn.PriceChangeCh():
+			if e.Timestamp != "1757908892351" || e.Market != "m" {
+				t.Errorf("event = %+v", e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("missing price change event")
+		}
+	}
+}
+
+func TestUnknownEventCallback(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		writeEvent(t, conn, map[string]any{"event_type": "market_resolved", "market": "m", "winner": "a"})
+		<-done
+	}}
+	c := newTestWSClient(t, srv)
+	conn := c.CreateMarketConnection([]string{"a"})
+	type unknown struct {
+		eventType string
+		raw       []byte
+	}
+	got := make(chan unknown, 1)
+	conn.OnUnknownEvent(func(eventType string, raw []byte) { got <- unknown{eventType, raw} })
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	select {
+	case u := <-got:
+		if u.eventType != "market_resolved" {
+			t.Errorf("event type = %q", u.eventType)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(u.raw, &payload); err != nil {
+			t.Fatalf("raw payload: %v", err)
+		}
+		if payload["winner"] != "a" || payload["market"] != "m" {
+			t.Errorf("raw payload = %s", u.raw)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no unknown event callback")
+	}
+}
+
+func TestSilentPeerTriggersReconnect(t *testing.T) {
+	done := make(chan struct{})
+	firstClosed := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		if n == 1 {
+			// 半开连接：吞掉控制帧 ping 不回 pong，也不推送任何消息；读到错误说明客户端关闭了旧连接
+			conn.SetPingHandler(func(string) error { return nil })
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					close(firstClosed)
+					return
+				}
+			}
+		}
+		// 正常连接：读循环自动回复控制帧 pong
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		writeEvent(t, conn, map[string]any{"event_type": "last_trade_price", "asset_id": "a", "price": "0.42"})
+		<-done
+	}}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var (
+		mu       sync.Mutex
+		dials    int
+		attempts []int
+	)
+	c := NewClient(ClientConfig{
+		BaseURL:              "wss://polymarket.invalid",
+		PingInterval:         20 * time.Millisecond,
+		ReconnectDelay:       time.Millisecond,
+		MaxReconnectAttempts: 3,
+		JitterFraction:       -1,
+		DialFunc: func(url string, header http.Header) (*websocket.Conn, *http.Response, error) {
+			mu.Lock()
+			dials++
+			mu.Unlock()
+			return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), header)
+		},
+	})
+	conn := c.CreateMarketConnection([]string{"a"})
+	conn.OnReconnecting(func(attempt int, delay time.Duration) {
+		mu.Lock()
+		attempts = append(attempts, attempt)
+		mu.Unlock()
+	})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	defer close(done)
+
+	select {
+	case e := <-conn.LastTradePriceCh():
+		if e.Price != "0.42" {
+			t.Errorf("price = %s", e.Price)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event after read deadline reconnect")
+	}
+	select {
+	case <-firstClosed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stale connection was not closed")
+	}
+	// 正常连接在数个读超时周期内不应再重连
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dials != 2 || fmt.Sprint(attempts) != "[1]" {
+		t.Errorf("dials = %d, reconnect attempts = %v, want 2 dials and [1]", dials, attempts)
+	}
+}
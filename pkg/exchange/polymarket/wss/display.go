@@ -0,0 +1,92 @@
+package wss
+
+// BookState 订单簿两侧挂单状态
+type BookState string
+
+const (
+	BookStateTwoSided BookState = "TWO_SIDED" // 买卖两侧均有挂单
+	BookStateBidOnly  BookState = "BID_ONLY"  // 仅有买单
+	BookStateAskOnly  BookState = "ASK_ONLY"  // 仅有卖单
+	BookStateEmpty    BookState = "EMPTY"     // 两侧均无挂单
+)
+
+// PriceSource 展示价格来源
+type PriceSource string
+
+const (
+	PriceSourceAsk  PriceSource = "ask"  // 最优卖价（买入成本）
+	PriceSourceBid  PriceSource = "bid"  // 最优买价（卖出所得）
+	PriceSourceMid  PriceSource = "mid"  // 中间价，仅两侧均有挂单时可用
+	PriceSourceLast PriceSource = "last" // 最新成交价
+)
+
+// DefaultPriceSources 默认价格来源优先级：卖价 → 中间价 → 最新成交价
+var DefaultPriceSources = []PriceSource{PriceSourceAsk, PriceSourceMid, PriceSourceLast}
+
+// BookView 单个 asset 的展示视图（容忍单边或空订单簿）
+type BookView struct {
+	State   BookState
+	Bid     float64
+	BidSize float64
+	Ask     float64
+	AskSize float64
+	Mid     float64 // 两侧均有挂单时为 (Bid+Ask)/2，否则为 0
+	Last    float64 // 调用方提供的最新成交价，未知时为 0
+
+	Price  float64     // 按优先级选出的展示价格，无可用来源时为 0
+	Source PriceSource // Price 的来源，无可用来源时为空
+}
+
+// HasPrice 是否有可用的展示价格
+func (v BookView) HasPrice() bool { return v.Source != "" }
+
+// ViewBook 根据本地订单簿和最新成交价生成展示视图
+// prefer 指定价格来源优先级，依次选取第一个可用的来源；为空时使用 DefaultPriceSources
+func ViewBook(book *LocalBook, last float64, prefer ...PriceSource) BookView {
+	var v BookView
+	if book != nil {
+		v.Bid, v.BidSize = book.GetBestBid()
+		v.Ask, v.AskSize = book.GetBestAsk()
+	}
+	if last > 0 {
+		v.Last = last
+	}
+
+	switch {
+	case v.Bid > 0 && v.Ask > 0:
+		v.State = BookStateTwoSided
+		v.Mid = (v.Bid + v.Ask) / 2
+	case v.Bid > 0:
+		v.State = BookStateBidOnly
+	case v.Ask > 0:
+		v.State = BookStateAskOnly
+	default:
+		v.State = BookStateEmpty
+	}
+
+	if len(prefer) == 0 {
+		prefer = DefaultPriceSources
+	}
+	for _, src := range prefer {
+		if p := v.price(src); p > 0 {
+			v.Price, v.Source = p, src
+			break
+		}
+	}
+	return v
+}
+
+// price 指定来源的价格，不可用时返回 0
+func (v BookView) price(src PriceSource) float64 {
+	switch src {
+	case PriceSourceAsk:
+		return v.Ask
+	case PriceSourceBid:
+		return v.Bid
+	case PriceSourceMid:
+		return v.Mid
+	case PriceSourceLast:
+		return v.Last
+	}
+	return 0
+}
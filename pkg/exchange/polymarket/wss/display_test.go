@@ -0,0 +1,71 @@
+package wss
+
+import (
+	"testing"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestViewBook(t *testing.T) {
+	// book 以快照构造本地订单簿，bids/asks 均为 nil 时返回空订单簿
+	book := func(bids, asks []common.OrderBookLevel) *LocalBook {
+		b := NewLocalBook("a")
+		b.ApplySnapshot(&common.OrderBookSnapshot{AssetID: "a", Bids: bids, Asks: asks})
+		return b
+	}
+	twoSided := book(levels("0.40", "100", "0.45", "20"), levels("0.55", "30", "0.60", "5"))
+	bidOnly := book(levels("0.45", "20"), nil)
+	askOnly := book(nil, levels("0.55", "30"))
+	empty := book(nil, nil)
+
+	tests := []struct {
+		name   string
+		book   *LocalBook
+		last   float64
+		prefer []PriceSource
+		want   BookView
+	}{
+		{
+			name: "two sided default", book: twoSided, last: 0.5,
+			want: BookView{State: BookStateTwoSided, Bid: 0.45, BidSize: 20, Ask: 0.55, AskSize: 30, Mid: 0.5, Last: 0.5, Price: 0.55, Source: PriceSourceAsk},
+		},
+		{
+			name: "two sided prefer mid", book: twoSided, prefer: []PriceSource{PriceSourceMid, PriceSourceAsk},
+			want: BookView{State: BookStateTwoSided, Bid: 0.45, BidSize: 20, Ask: 0.55, AskSize: 30, Mid: 0.5, Price: 0.5, Source: PriceSourceMid},
+		},
+		{
+			// 默认优先级下仅有买单时卖价和中间价都不可用，回退到最新成交价
+			name: "bid only falls back to last", book: bidOnly, last: 0.47,
+			want: BookView{State: BookStateBidOnly, Bid: 0.45, BidSize: 20, Last: 0.47, Price: 0.47, Source: PriceSourceLast},
+		},
+		{
+			name: "bid only prefer bid", book: bidOnly, prefer: []PriceSource{PriceSourceBid},
+			want: BookView{State: BookStateBidOnly, Bid: 0.45, BidSize: 20, Price: 0.45, Source: PriceSourceBid},
+		},
+		{
+			name: "ask only", book: askOnly,
+			want: BookView{State: BookStateAskOnly, Ask: 0.55, AskSize: 30, Price: 0.55, Source: PriceSourceAsk},
+		},
+		{
+			name: "empty with last", book: empty, last: 0.3,
+			want: BookView{State: BookStateEmpty, Last: 0.3, Price: 0.3, Source: PriceSourceLast},
+		},
+		{
+			name: "empty without price", book: empty, last: -1,
+			want: BookView{State: BookStateEmpty},
+		},
+		{
+			name: "nil book", book: nil, last: 0.2, prefer: []PriceSource{PriceSourceMid},
+			want: BookView{State: BookStateEmpty, Last: 0.2},
+		},
+	}
+	for _, tt := range tests {
+		got := ViewBook(tt.book, tt.last, tt.prefer...)
+		if got != tt.want {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+		if got.HasPrice() != (tt.want.Source != "") {
+			t.Errorf("%s: HasPrice = %v", tt.name, got.HasPrice())
+		}
+	}
+}
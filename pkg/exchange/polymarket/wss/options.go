@@ -0,0 +1,31 @@
+package wss
+
+// SubscribeOption 订阅消息选项，合并到连接建立时发送的订阅消息中
+type SubscribeOption func(payload map[string]interface{})
+
+// WithSubscribeField 在订阅消息中添加自定义字段（assets_ids/type 等默认字段不会被覆盖）
+func WithSubscribeField(key string, value interface{}) SubscribeOption {
+	return func(payload map[string]interface{}) {
+		if _, ok := payload[key]; !ok {
+			payload[key] = value
+		}
+	}
+}
+
+// WithInitialDump 是否在订阅后推送初始订单簿快照 (initial_dump)
+func WithInitialDump(enabled bool) SubscribeOption {
+	return WithSubscribeField("initial_dump", enabled)
+}
+
+// WithCustomFeatures 是否启用扩展推送事件 (custom_feature_enabled)
+func WithCustomFeatures(enabled bool) SubscribeOption {
+	return WithSubscribeField("custom_feature_enabled", enabled)
+}
+
+func applySubscribeOptions(payload map[string]interface{}, opts []SubscribeOption) {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(payload)
+		}
+	}
+}
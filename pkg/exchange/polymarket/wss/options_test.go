@@ -0,0 +1,56 @@
+package wss
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestSubscribeOptionsInPayload(t *testing.T) {
+	done := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) { <-done }}
+	c := newTestWSClient(t, srv)
+	defer close(done)
+
+	market := c.CreateMarketConnection([]string{"a"},
+		WithInitialDump(false),
+		WithCustomFeatures(true),
+		WithSubscribeField("level", 1),
+		WithSubscribeField("type", "user"), // 默认字段不会被覆盖
+		nil,
+	)
+	user := c.CreateUserConnection(common.WssAuth{APIKey: "key"}, []string{"m"}, WithSubscribeField("markets", []string{"x"}))
+	for _, conn := range []*Connection{market, user} {
+		if err := conn.Connect(); err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		defer conn.Close()
+	}
+	waitFor(t, "subscribes", func() bool { return len(srv.Subscribes()) == 2 })
+
+	byType := map[string]map[string]any{}
+	for _, sub := range srv.Subscribes() {
+		byType[fmt.Sprint(sub["type"])] = sub
+	}
+	m := byType["market"]
+	if m == nil {
+		t.Fatalf("no market subscribe in %v", srv.Subscribes())
+	}
+	if m["initial_dump"] != false || m["custom_feature_enabled"] != true || m["level"] != float64(1) {
+		t.Errorf("market payload missing options: %v", m)
+	}
+	if fmt.Sprint(m["assets_ids"]) != "[a]" {
+		t.Errorf("market assets = %v", m["assets_ids"])
+	}
+	if u := byType["user"]; u == nil || fmt.Sprint(u["markets"]) != "[m]" {
+		t.Errorf("user payload = %v, want markets [m] kept", u)
+	}
+
+	// 不带选项时保持默认订阅消息
+	plain := c.CreateMarketConnection([]string{"b"}).subscribePayload
+	if len(plain) != 2 || plain["type"] != "market" {
+		t.Errorf("default payload = %v", plain)
+	}
+}
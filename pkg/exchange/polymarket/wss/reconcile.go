@@ -0,0 +1,61 @@
+package wss
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// reconcileTimeout 重连后 REST 补齐的超时时间
+const reconcileTimeout = 30 * time.Second
+
+// reconcileLookback 补齐成交时向前多查询的时间，覆盖断线检测延迟和时钟偏差
+const reconcileLookback = time.Minute
+
+// UserStateFetcher 通过 REST 获取 since 之后的成交及当前未结订单（clob.Client.FetchUserState 满足该签名）
+type UserStateFetcher func(ctx context.Context, since time.Time) ([]*common.TradeNotification, []*common.OrderUpdate, error)
+
+// SetReconciler 设置用户频道重连后的状态补齐函数（仅 User 频道）
+// 重连成功后会调用 fetcher 拉取断线期间的成交与未结订单，未处理过的成交推送到 TradeCh，订单状态推送到 OrderCh
+func (c *Connection) SetReconciler(fetcher UserStateFetcher) {
+	c.mu.Lock()
+	c.reconciler = fetcher
+	c.mu.Unlock()
+}
+
+// reconcile 补齐断线期间遗漏的成交和订单状态
+func (c *Connection) reconcile(since time.Time) {
+	c.mu.RLock()
+	fetcher := c.reconciler
+	c.mu.RUnlock()
+	if fetcher == nil || c.channel != ChannelUser {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer cancel()
+
+	trades, orders, err := fetcher(ctx, since.Add(-reconcileLookback))
+	if err != nil {
+		if c.onError != nil {
+			c.onError(fmt.Errorf("reconcile: %w", err))
+		}
+		return
+	}
+
+	state := c.trackedUserState()
+	for _, order := range orders {
+		if state != nil {
+			state.ApplyOrder(order)
+		}
+		send(c, c.orderCh, order)
+	}
+	for _, trade := range trades {
+		if state != nil {
+			state.ApplyTrade(trade)
+		}
+		c.emitTrade(trade)
+	}
+}
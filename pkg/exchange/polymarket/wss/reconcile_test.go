@@ -0,0 +1,89 @@
+package wss
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestReconcileFillDuringDisconnect(t *testing.T) {
+	release := make(chan struct{})
+	srv := &wsServer{serve: func(n int, conn *websocket.Conn) {
+		if n == 1 {
+			// 第一次连接推送一笔成交后断开
+			writeEvent(t, conn, map[string]any{"event_type": "trade", "id": "t1", "taker_order_id": "o1", "status": "MATCHED", "size": "5", "price": "0.5"})
+			return
+		}
+		<-release
+	}}
+	defer close(release)
+	c := newTestWSClient(t, srv)
+
+	var (
+		mu    sync.Mutex
+		since []time.Time
+	)
+	disconnected := time.Now()
+	conn := c.CreateUserConnection(common.WssAuth{APIKey: "key", Secret: "secret", Passphrase: "pass"}, nil)
+	state := conn.TrackUserState("key")
+	// 断线期间 o1 再次成交 (t2)，REST 同时返回已推送过的 t1
+	conn.SetReconciler(func(ctx context.Context, s time.Time) ([]*common.TradeNotification, []*common.OrderUpdate, error) {
+		mu.Lock()
+		since = append(since, s)
+		mu.Unlock()
+		return []*common.TradeNotification{
+			{ID: "t1", TakerOrderID: "o1", Status: "MATCHED", Size: "5", Price: "0.5"},
+			{ID: "t2", TakerOrderID: "o1", Status: "MATCHED", Size: "3", Price: "0.5"},
+		}, []*common.OrderUpdate{
+			{ID: "o1", Side: "BUY", Price: "0.5", OriginalSize: "10", SizeMatched: "8"},
+		}, nil
+	})
+	if err := conn.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	var trades []string
+	for len(trades) < 2 {
+		select {
+		case tr := <-conn.TradeCh():
+			trades = append(trades, tr.ID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("trades = %v, want t1 then reconciled t2", trades)
+		}
+	}
+	if trades[0] != "t1" || trades[1] != "t2" {
+		t.Errorf("trades = %v, want [t1 t2]", trades)
+	}
+	select {
+	case tr := <-conn.TradeCh():
+		t.Errorf("duplicate trade %s emitted", tr.ID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// 重连时重新发送鉴权
+	subs := srv.Subscribes()
+	if len(subs) != 2 {
+		t.Fatalf("subscribes = %d, want 2", len(subs))
+	}
+	if auth, _ := subs[1]["auth"].(map[string]any); auth["apiKey"] != "key" {
+		t.Errorf("reconnect subscribe missing auth: %v", subs[1])
+	}
+
+	mu.Lock()
+	if len(since) != 1 || !since[0].Before(disconnected) {
+		t.Errorf("reconcile since = %v, want one call before %v", since, disconnected)
+	}
+	mu.Unlock()
+	waitFor(t, "reconciled order", func() bool {
+		o, ok := state.Order("o1")
+		return ok && o.SizeMatched == "8"
+	})
+	if fills := state.FillsForOrder("o1"); len(fills) != 2 {
+		t.Errorf("fills for o1 = %+v, want t1 and t2", fills)
+	}
+}
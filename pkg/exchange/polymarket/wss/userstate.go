@@ -0,0 +1,322 @@
+package wss
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+// 订单状态（由用户频道推送推导）
+const (
+	OrderStateLive     = "LIVE"
+	OrderStateMatched  = "MATCHED"
+	OrderStateCanceled = "CANCELED"
+)
+
+// DefaultUserStateRetention UserState 默认保留时长：已成交/已取消订单和成交记录超过该时长后被清理
+const DefaultUserStateRetention = time.Hour
+
+// Fill 自己订单的一笔成交
+type Fill struct {
+	TradeID string
+	OrderID string
+	Market  string
+	AssetID string
+	Side    string
+	Price   float64
+	Size    float64
+	Status  string // MATCHED, MINED, CONFIRMED, RETRYING, FAILED
+	Time    time.Time
+}
+
+// UserState 用户频道状态跟踪：维护订单最新状态和成交记录，可按市场过滤
+// 通过 Connection.TrackUserState 获取，由读循环自动更新，可在任意 goroutine 中读取
+// 未结订单始终保留；已结束的订单和成交在 retention 后自动清理，避免长时间运行时无限增长
+type UserState struct {
+	owner string
+	now   func() time.Time
+
+	mu        sync.RWMutex
+	orders    map[string]*common.OrderUpdate
+	updatedAt map[string]time.Time // orderID -> 最近一次收到订单推送的时间
+	trades    map[string][]string  // orderID -> 关联成交 ID (associate_trades + 成交推送)
+	fills     map[string]*Fill     // tradeID + ":" + orderID -> 成交
+	retention time.Duration
+	lastPrune time.Time
+}
+
+// NewUserState 创建用户状态跟踪器
+// owner 为 API Key，用于在作为 maker 成交时识别属于自己的订单（为空时仅识别已跟踪的订单）
+func NewUserState(owner string) *UserState {
+	return &UserState{
+		owner:     owner,
+		now:       time.Now,
+		orders:    make(map[string]*common.OrderUpdate),
+		updatedAt: make(map[string]time.Time),
+		trades:    make(map[string][]string),
+		fills:     make(map[string]*Fill),
+		retention: DefaultUserStateRetention,
+	}
+}
+
+// SetRetention 设置已结束订单和成交的保留时长（<= 0 表示不自动清理）
+func (s *UserState) SetRetention(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = d
+}
+
+// Prune 清理 before 之前结束的订单（已成交或已取消）和 before 之前的成交，未结订单及其成交不受影响
+func (s *UserState) Prune(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(before)
+}
+
+func (s *UserState) prune(before time.Time) {
+	for id, order := range s.orders {
+		if orderState(order) != OrderStateLive && s.updatedAt[id].Before(before) {
+			delete(s.orders, id)
+			delete(s.updatedAt, id)
+		}
+	}
+	filled := make(map[string]bool)
+	for key, fill := range s.fills {
+		if _, open := s.orders[fill.OrderID]; !open && fill.Time.Before(before) {
+			delete(s.fills, key)
+			continue
+		}
+		filled[fill.OrderID] = true
+	}
+	for orderID := range s.trades {
+		if _, ok := s.orders[orderID]; !ok && !filled[orderID] {
+			delete(s.trades, orderID)
+		}
+	}
+}
+
+// maybePrune 推送处理后按保留时长清理，每 retention/10 最多执行一次（调用方持有写锁）
+func (s *UserState) maybePrune() {
+	if s.retention <= 0 {
+		return
+	}
+	now := s.now()
+	if now.Sub(s.lastPrune) < s.retention/10 {
+		return
+	}
+	s.lastPrune = now
+	s.prune(now.Add(-s.retention))
+}
+
+// ApplyOrder 应用订单推送
+func (s *UserState) ApplyOrder(order *common.OrderUpdate) {
+	if order == nil || order.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := *order
+	if prev, ok := s.orders[order.ID]; ok {
+		// 更新推送可能缺少部分字段，沿用之前的值
+		if updated.OriginalSize == "" {
+			updated.OriginalSize = prev.OriginalSize
+		}
+		if updated.Market == "" {
+			updated.Market = prev.Market
+		}
+		if updated.AssetID == "" {
+			updated.AssetID = prev.AssetID
+		}
+		if prev.Type == "CANCELLATION" {
+			updated.Type = prev.Type
+		}
+	}
+	s.orders[order.ID] = &updated
+	s.updatedAt[order.ID] = s.now()
+	for _, tradeID := range order.AssociateTrades {
+		s.linkTrade(order.ID, tradeID)
+	}
+	s.maybePrune()
+}
+
+// ApplyTrade 应用成交推送（同一成交的状态更新会覆盖之前的状态）
+func (s *UserState) ApplyTrade(trade *common.TradeNotification) {
+	if trade == nil {
+		return
+	}
+	tradeID := trade.ID
+	if tradeID == "" {
+		tradeID = trade.TradeID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.maybePrune()
+
+	at := parseUnixTime(trade.MatchTime, s.now)
+
+	if strings.EqualFold(trade.TraderSide, "MAKER") {
+		for _, m := range trade.MakerOrders {
+			_, tracked := s.orders[m.OrderID]
+			if !tracked && (s.owner == "" || m.Owner != s.owner) {
+				continue
+			}
+			s.addFill(&Fill{
+				TradeID: tradeID, OrderID: m.OrderID, Market: trade.Market, AssetID: m.AssetID, Side: m.Side,
+				Price: parseFloat(m.Price), Size: parseFloat(m.MatchedAmount), Status: trade.Status, Time: at,
+			})
+		}
+		return
+	}
+	s.addFill(&Fill{
+		TradeID: tradeID, OrderID: trade.TakerOrderID, Market: trade.Market, AssetID: trade.AssetID, Side: trade.Side,
+		Price: parseFloat(trade.Price), Size: parseFloat(trade.Size), Status: trade.Status, Time: at,
+	})
+}
+
+func (s *UserState) addFill(fill *Fill) {
+	key := fill.TradeID + ":" + fill.OrderID
+	if prev, ok := s.fills[key]; ok {
+		prev.Status = fill.Status
+		return
+	}
+	s.fills[key] = fill
+	s.linkTrade(fill.OrderID, fill.TradeID)
+}
+
+func (s *UserState) linkTrade(orderID, tradeID string) {
+	if orderID == "" || tradeID == "" {
+		return
+	}
+	for _, id := range s.trades[orderID] {
+		if id == tradeID {
+			return
+		}
+	}
+	s.trades[orderID] = append(s.trades[orderID], tradeID)
+}
+
+// OrderState 订单当前状态 (OrderStateLive/Matched/Canceled)，未跟踪的订单返回 false
+func (s *UserState) OrderState(orderID string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return "", false
+	}
+	return orderState(order), true
+}
+
+// Order 获取订单最新推送
+func (s *UserState) Order(orderID string) (common.OrderUpdate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return common.OrderUpdate{}, false
+	}
+	return *order, true
+}
+
+// OpenOrders 获取所有未完全成交且未取消的订单
+func (s *UserState) OpenOrders() []common.OrderUpdate {
+	return s.OpenOrdersByMarket("")
+}
+
+// OpenOrdersByMarket 获取指定市场 (condition ID) 的未结订单，market 为空时返回全部
+func (s *UserState) OpenOrdersByMarket(market string) []common.OrderUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []common.OrderUpdate
+	for _, order := range s.orders {
+		if orderState(order) == OrderStateLive && (market == "" || order.Market == market) {
+			result = append(result, *order)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp < result[j].Timestamp })
+	return result
+}
+
+// FillsSince 获取 t 之后的成交（按时间升序，FAILED 成交除外）
+func (s *UserState) FillsSince(t time.Time) []Fill {
+	return s.filterFills(func(f *Fill) bool { return !f.Time.Before(t) })
+}
+
+// FillsByMarket 获取指定市场的成交
+func (s *UserState) FillsByMarket(market string) []Fill {
+	return s.filterFills(func(f *Fill) bool { return f.Market == market })
+}
+
+// FillsForOrder 获取指定订单的成交
+func (s *UserState) FillsForOrder(orderID string) []Fill {
+	return s.filterFills(func(f *Fill) bool { return f.OrderID == orderID })
+}
+
+// TradeIDs 获取订单关联的成交 ID（来自订单推送的 associate_trades 及成交推送）
+func (s *UserState) TradeIDs(orderID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.trades[orderID]...)
+}
+
+func (s *UserState) filterFills(match func(*Fill) bool) []Fill {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []Fill
+	for _, f := range s.fills {
+		if !strings.EqualFold(f.Status, "FAILED") && match(f) {
+			result = append(result, *f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result
+}
+
+func orderState(order *common.OrderUpdate) string {
+	if order.Type == "CANCELLATION" {
+		return OrderStateCanceled
+	}
+	original, matched := parseFloat(order.OriginalSize), parseFloat(order.SizeMatched)
+	if original > 0 && matched >= original-1e-9 {
+		return OrderStateMatched
+	}
+	return OrderStateLive
+}
+
+// parseUnixTime 解析秒或毫秒时间戳，无效时返回 now()
+func parseUnixTime(s string, now func() time.Time) time.Time {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return now()
+	}
+	if n > 1e12 {
+		return time.UnixMilli(n)
+	}
+	return time.Unix(n, 0)
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// TrackUserState 在连接内部维护用户订单和成交状态（仅 User 频道）
+// 返回的状态由读循环自动更新；OrderCh/TradeCh 推送不受影响
+func (c *Connection) TrackUserState(owner string) *UserState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.userState == nil {
+		c.userState = NewUserState(owner)
+	}
+	return c.userState
+}
+
+func (c *Connection) trackedUserState() *UserState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.userState
+}
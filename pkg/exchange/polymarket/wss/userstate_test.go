@@ -0,0 +1,121 @@
+package wss
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shuail0/prediction-aggregator/pkg/exchange/polymarket/common"
+)
+
+func TestUserStateLiveToMatched(t *testing.T) {
+	s := NewUserState("key")
+
+	s.ApplyOrder(&common.OrderUpdate{ID: "o1", Market: "m1", AssetID: "a", Type: "PLACEMENT", OriginalSize: "10", SizeMatched: "0", Timestamp: "1"})
+	s.ApplyOrder(&common.OrderUpdate{ID: "o2", Market: "m2", AssetID: "b", Type: "PLACEMENT", OriginalSize: "5", SizeMatched: "0", Timestamp: "2"})
+	if state, _ := s.OrderState("o1"); state != OrderStateLive {
+		t.Fatalf("o1 state = %s, want LIVE", state)
+	}
+	if open := s.OpenOrdersByMarket("m1"); len(open) != 1 || open[0].ID != "o1" {
+		t.Errorf("open orders in m1 = %+v", open)
+	}
+
+	// 作为 taker 部分成交，更新推送缺少 original_size 时沿用之前的值
+	s.ApplyTrade(&common.TradeNotification{ID: "t1", Market: "m1", AssetID: "a", TakerOrderID: "o1", Side: "BUY", Price: "0.5", Size: "4", Status: "MATCHED", MatchTime: "1000"})
+	s.ApplyOrder(&common.OrderUpdate{ID: "o1", Type: "UPDATE", SizeMatched: "4", AssociateTrades: []string{"t1"}})
+	if state, _ := s.OrderState("o1"); state != OrderStateLive {
+		t.Errorf("partially filled o1 state = %s, want LIVE", state)
+	}
+
+	// 作为 maker 成交剩余部分，只记录属于自己的 maker 订单
+	s.ApplyTrade(&common.TradeNotification{ID: "t2", Market: "m1", TraderSide: "MAKER", Status: "MATCHED", MatchTime: "1001", MakerOrders: []common.MakerOrder{
+		{OrderID: "o1", Owner: "key", AssetID: "a", Side: "BUY", Price: "0.5", MatchedAmount: "6"},
+		{OrderID: "other", Owner: "someone", AssetID: "a", Side: "BUY", Price: "0.5", MatchedAmount: "3"},
+	}})
+	s.ApplyOrder(&common.OrderUpdate{ID: "o1", Type: "UPDATE", SizeMatched: "10", AssociateTrades: []string{"t1", "t2"}})
+
+	if state, _ := s.OrderState("o1"); state != OrderStateMatched {
+		t.Errorf("o1 state = %s, want MATCHED", state)
+	}
+	if open := s.OpenOrders(); len(open) != 1 || open[0].ID != "o2" {
+		t.Errorf("open orders = %+v", open)
+	}
+	fills := s.FillsForOrder("o1")
+	if len(fills) != 2 || fills[0].TradeID != "t1" || fills[1].TradeID != "t2" || fills[0].Size+fills[1].Size != 10 {
+		t.Errorf("fills = %+v", fills)
+	}
+	if ids := s.TradeIDs("o1"); fmt.Sprint(ids) != "[t1 t2]" {
+		t.Errorf("trade ids = %v", ids)
+	}
+	if fills := s.FillsByMarket("m2"); len(fills) != 0 {
+		t.Errorf("fills in m2 = %+v", fills)
+	}
+
+	// 成交状态更新覆盖原记录，FAILED 成交不返回
+	s.ApplyTrade(&common.TradeNotification{ID: "t1", Market: "m1", TakerOrderID: "o1", Status: "FAILED", MatchTime: "1000"})
+	if fills := s.FillsSince(time.Unix(0, 0)); len(fills) != 1 || fills[0].TradeID != "t2" {
+		t.Errorf("fills after failure = %+v", fills)
+	}
+
+	s.ApplyOrder(&common.OrderUpdate{ID: "o2", Type: "CANCELLATION", SizeMatched: "0"})
+	if state, _ := s.OrderState("o2"); state != OrderStateCanceled {
+		t.Errorf("o2 state = %s, want CANCELED", state)
+	}
+}
+
+func TestUserStatePrune(t *testing.T) {
+	now := time.Unix(10_000, 0)
+	s := NewUserState("key")
+	s.now = func() time.Time { return now }
+	s.SetRetention(time.Hour)
+
+	s.ApplyOrder(&common.OrderUpdate{ID: "done", Market: "m", OriginalSize: "1", SizeMatched: "1", AssociateTrades: []string{"t1"}})
+	s.ApplyOrder(&common.OrderUpdate{ID: "live", Market: "m", OriginalSize: "5", SizeMatched: "1", AssociateTrades: []string{"t2"}})
+	s.ApplyTrade(&common.TradeNotification{ID: "t1", TakerOrderID: "done", Size: "1", Status: "MATCHED", MatchTime: fmt.Sprint(now.Unix())})
+	s.ApplyTrade(&common.TradeNotification{ID: "t2", TakerOrderID: "live", Size: "1", Status: "MATCHED", MatchTime: fmt.Sprint(now.Unix())})
+	s.ApplyTrade(&common.TradeNotification{ID: "t3", TakerOrderID: "untracked", Size: "1", Status: "MATCHED", MatchTime: fmt.Sprint(now.Unix())})
+
+	// 保留期内不清理
+	now = now.Add(30 * time.Minute)
+	s.ApplyOrder(&common.OrderUpdate{ID: "new", Market: "m", OriginalSize: "2", SizeMatched: "0"})
+	if _, ok := s.Order("done"); !ok {
+		t.Fatal("finished order pruned within retention")
+	}
+
+	// 超过保留期后，下一次推送触发清理：已结束订单及其成交被删除，未结订单及其成交保留
+	now = now.Add(time.Hour)
+	s.ApplyOrder(&common.OrderUpdate{ID: "new", Market: "m", SizeMatched: "0"})
+	if _, ok := s.Order("done"); ok {
+		t.Error("finished order not pruned")
+	}
+	if len(s.FillsForOrder("done")) != 0 || len(s.TradeIDs("done")) != 0 {
+		t.Error("fills of finished order not pruned")
+	}
+	if len(s.FillsForOrder("untracked")) != 0 || len(s.TradeIDs("untracked")) != 0 {
+		t.Error("fills of untracked order not pruned")
+	}
+	if _, ok := s.Order("live"); !ok || len(s.FillsForOrder("live")) != 1 || len(s.TradeIDs("live")) != 1 {
+		t.Error("live order or its fills pruned")
+	}
+	if open := s.OpenOrders(); len(open) != 2 {
+		t.Errorf("open orders = %+v", open)
+	}
+
+	// 关闭自动清理后只能手动 Prune
+	s.SetRetention(0)
+	s.ApplyOrder(&common.OrderUpdate{ID: "new", SizeMatched: "2"})
+	finishedAt := now
+	now = now.Add(24 * time.Hour)
+	s.ApplyOrder(&common.OrderUpdate{ID: "x", OriginalSize: "1", SizeMatched: "1"})
+	if _, ok := s.Order("new"); !ok {
+		t.Error("order pruned with retention disabled")
+	}
+	s.Prune(finishedAt)
+	if _, ok := s.Order("new"); !ok {
+		t.Error("order finished at cutoff pruned")
+	}
+	s.Prune(finishedAt.Add(time.Second))
+	if _, ok := s.Order("new"); ok {
+		t.Error("Prune did not remove finished order")
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"math/rand"
+	"time"
+)
+
+// NewRand 创建独立的随机数源，固定 seed 可复现结果，seed 为 0 时使用当前时间
+func NewRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// Shuffle 使用指定随机数源返回打乱顺序后的副本（不修改入参），r 为 nil 时使用时间种子
+func Shuffle[T any](r *rand.Rand, items []T) []T {
+	if r == nil {
+		r = NewRand(0)
+	}
+	out := append([]T(nil), items...)
+	r.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out
+}
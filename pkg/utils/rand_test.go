@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShuffleFixedSeed(t *testing.T) {
+	markets := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	first := Shuffle(NewRand(42), markets)
+	second := Shuffle(NewRand(42), markets)
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Fatalf("same seed produced different orderings: %v vs %v", first, second)
+	}
+	// 固定 seed 的顺序与 Go 版本的 math/rand 实现绑定，变化时需同步更新
+	if got, want := fmt.Sprint(first), "[f h e g b d a c]"; got != want {
+		t.Errorf("seed 42 ordering = %s, want %s", got, want)
+	}
+	if fmt.Sprint(markets) != "[a b c d e f g h]" {
+		t.Errorf("input modified: %v", markets)
+	}
+
+	differs := false
+	for seed := int64(1); seed <= 5 && !differs; seed++ {
+		differs = fmt.Sprint(Shuffle(NewRand(seed), markets)) != fmt.Sprint(first)
+	}
+	if !differs {
+		t.Error("different seeds produced the same ordering")
+	}
+
+	// 同一随机数源的连续调用继续推进，而不是重复相同顺序
+	r := NewRand(7)
+	if a, b := Shuffle(r, markets), Shuffle(r, markets); fmt.Sprint(a) == fmt.Sprint(b) {
+		t.Errorf("consecutive shuffles from one source are identical: %v", a)
+	}
+}